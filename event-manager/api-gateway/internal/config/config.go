@@ -21,6 +21,7 @@ type Config struct {
 	PasswordResetExpiration time.Duration
 	RabbitMQURL            string
 	CommentQueueName       string
+	MaxRequestBodySize     int64
 }
 
 func New() (*Config, error) {
@@ -101,6 +102,15 @@ func New() (*Config, error) {
 		commentQueueName = "comments"
 	}
 
+	// Лимит тела проксируемых запросов к расходам в мегабайтах, включая загрузку чеков
+	maxRequestBodySize := int64(11) << 20
+	if sizeStr := os.Getenv("MAX_REQUEST_BODY_MB"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err == nil && size > 0 {
+			maxRequestBodySize = int64(size) << 20
+		}
+	}
+
 	return &Config{
 		Port:                    port,
 		DatabaseURL:             dbURL,
@@ -115,5 +125,6 @@ func New() (*Config, error) {
 		PasswordResetExpiration: pwResetExp,
 		RabbitMQURL:             rabbitMQURL,
 		CommentQueueName:        commentQueueName,
+		MaxRequestBodySize:      maxRequestBodySize,
 	}, nil
 }
@@ -45,8 +45,11 @@ func (h *Proxy) ProxyToEventService(c *gin.Context) {
 		return
 	}
 
+	// На публичных маршрутах пользователя нет, и заголовок клиента не должен дойти до сервиса событий
 	if userId, err := getUserIdFromContext(c); err == nil {
 		c.Request.Header.Set("X-User-Id", strconv.Itoa(userId))
+	} else {
+		c.Request.Header.Del("X-User-Id")
 	}
 
 	proxy.ServeHTTP(c.Writer, c.Request)
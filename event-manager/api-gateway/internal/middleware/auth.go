@@ -24,51 +24,24 @@ func NewAuthMiddleware(authService AuthService) *AuthMiddleware {
 
 func (m *AuthMiddleware) Authenticate() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
-			return
-		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
-			c.Abort()
-			return
-		}
-
-		tokenString := parts[1]
-
-		claims, err := m.authService.ValidateToken(c, tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		if _, ok := m.authorize(c); !ok {
 			return
 		}
 
-		if claims != nil {
-			c.Set("user_id", claims.UserId)
-			c.Set("user_role", claims.Role)
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
-		}
+		c.Next()
 	}
 }
 
+// AuthenticateAdmin пропускает дальше только администраторов. Роль проверяется до вызова
+// следующих обработчиков, поэтому запрос без прав не доходит до проксирования
 func (m *AuthMiddleware) AuthenticateAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		m.Authenticate()(c)
-
-		if c.IsAborted() {
+		claims, ok := m.authorize(c)
+		if !ok {
 			return
 		}
 
-		role, exists := c.Get("user_role")
-		if !exists || role.(domain.UserRole) != domain.RoleAdmin {
+		if domain.UserRole(claims.Role) != domain.RoleAdmin {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Admin privileges required"})
 			c.Abort()
 			return
@@ -77,3 +50,41 @@ func (m *AuthMiddleware) AuthenticateAdmin() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// authorize проверяет токен запроса и сохраняет пользователя в контексте. При ошибке
+// отвечает 401 и прерывает цепочку обработчиков
+func (m *AuthMiddleware) authorize(c *gin.Context) (*domain.JWTClaims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+		c.Abort()
+		return nil, false
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+		c.Abort()
+		return nil, false
+	}
+
+	tokenString := parts[1]
+
+	claims, err := m.authService.ValidateToken(c, tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return nil, false
+	}
+
+	if claims == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		c.Abort()
+		return nil, false
+	}
+
+	c.Set("user_id", claims.UserId)
+	c.Set("user_role", claims.Role)
+
+	return claims, true
+}
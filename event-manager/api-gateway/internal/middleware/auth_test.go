@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PabloPerdolie/event-manager/api-gateway/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Токены вида "<role>" принадлежат пользователю 1 с этой ролью
+type tokenRoles struct{}
+
+func (tokenRoles) ValidateToken(ctx context.Context, tokenString string) (*domain.JWTClaims, error) {
+	if tokenString == "expired" {
+		return nil, errors.New("token expired")
+	}
+	return &domain.JWTClaims{UserId: 1, Role: tokenString}, nil
+}
+
+// Администраторский маршрут не доходит до проксирования без роли администратора
+func TestAuthenticateAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name    string
+		header  string
+		status  int
+		proxied bool
+	}{
+		{name: "admin", header: "Bearer admin", status: http.StatusOK, proxied: true},
+		{name: "user", header: "Bearer user", status: http.StatusForbidden},
+		{name: "expired token", header: "Bearer expired", status: http.StatusUnauthorized},
+		{name: "no token", status: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxied := false
+			router := gin.New()
+			router.PUT("/exchange-rates", NewAuthMiddleware(tokenRoles{}).AuthenticateAdmin(), func(c *gin.Context) {
+				proxied = true
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPut, "/exchange-rates", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Errorf("status = %d, want %d", rec.Code, tc.status)
+			}
+			if proxied != tc.proxied {
+				t.Errorf("proxied = %v, want %v", proxied, tc.proxied)
+			}
+		})
+	}
+}
@@ -35,7 +35,7 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config, c *Controllers, authMid
 	{
 		setupPublicRoutes(api, c)
 
-		setupProtectedRoutes(api, c, authMiddleware)
+		setupProtectedRoutes(api, cfg, c, authMiddleware)
 
 		//setupAdminRoutes(api, c, authMiddleware)
 	}
@@ -52,9 +52,20 @@ func setupPublicRoutes(api *gin.RouterGroup, c *Controllers) {
 		//auth.POST("/forgot-password", c.AuthCtrl.ForgotPassword)
 		//auth.POST("/reset-password", c.AuthCtrl.ResetPassword)
 	}
+
+	// Лента календаря открывается календарными приложениями без заголовка Authorization,
+	// доступ к ней дает секретный токен в пути
+	api.GET("/calendar/:token", c.ProxyCtrl.ProxyToEventService)
+
+	// Ссылки из письма-приглашения открывают в браузере без авторизации страницу подтверждения,
+	// ответ с нее отправляется POST-запросом. Приглашение подтверждает подписанный токен в пути
+	api.GET("/invitations/:token/accept", c.ProxyCtrl.ProxyToEventService)
+	api.GET("/invitations/:token/decline", c.ProxyCtrl.ProxyToEventService)
+	api.POST("/invitations/:token/accept", c.ProxyCtrl.ProxyToEventService)
+	api.POST("/invitations/:token/decline", c.ProxyCtrl.ProxyToEventService)
 }
 
-func setupProtectedRoutes(api *gin.RouterGroup, c *Controllers, authMiddleware *middleware.AuthMiddleware) {
+func setupProtectedRoutes(api *gin.RouterGroup, cfg *config.Config, c *Controllers, authMiddleware *middleware.AuthMiddleware) {
 	// User management
 	//users := api.Group("/users", authMiddleware.Authenticate())
 	//{
@@ -67,7 +78,7 @@ func setupProtectedRoutes(api *gin.RouterGroup, c *Controllers, authMiddleware *
 	//	users.GET("/me/comments", h.GetUserComments)
 	//}
 
-	setupServiceProxies(api, c, authMiddleware)
+	setupServiceProxies(api, cfg, c, authMiddleware)
 }
 
 //
@@ -81,7 +92,7 @@ func setupProtectedRoutes(api *gin.RouterGroup, c *Controllers, authMiddleware *
 //	}
 //}
 
-func setupServiceProxies(api *gin.RouterGroup, c *Controllers, authMiddleware *middleware.AuthMiddleware) {
+func setupServiceProxies(api *gin.RouterGroup, cfg *config.Config, c *Controllers, authMiddleware *middleware.AuthMiddleware) {
 	eventsProxy := api.Group("/events", authMiddleware.Authenticate())
 	{
 		eventsProxy.Any("", c.ProxyCtrl.ProxyToEventService)      // /api/v1/events
@@ -94,12 +105,34 @@ func setupServiceProxies(api *gin.RouterGroup, c *Controllers, authMiddleware *m
 		tasksProxy.Any("/*any", c.ProxyCtrl.ProxyToEventService) // всё остальное
 	}
 
-	expensesProxy := api.Group("/expenses", authMiddleware.Authenticate())
+	templatesProxy := api.Group("/templates", authMiddleware.Authenticate())
+	{
+		templatesProxy.Any("", c.ProxyCtrl.ProxyToEventService)
+		templatesProxy.Any("/*any", c.ProxyCtrl.ProxyToEventService)
+	}
+
+	// Чеки загружаются multipart-запросом через прокси, поэтому размер тела ограничивается до проксирования
+	expensesProxy := api.Group("/expenses", authMiddleware.Authenticate(), middleware.LimitBody(cfg.MaxRequestBodySize))
 	{
 		expensesProxy.Any("", c.ProxyCtrl.ProxyToEventService)      // /api/v1/tasks
 		expensesProxy.Any("/*any", c.ProxyCtrl.ProxyToEventService) // всё остальное
 	}
 
+	// Сводные данные текущего пользователя по всем его событиям
+	usersProxy := api.Group("/users/me", authMiddleware.Authenticate())
+	{
+		usersProxy.GET("/*any", c.ProxyCtrl.ProxyToEventService)
+		usersProxy.POST("/calendar/token", c.ProxyCtrl.ProxyToEventService)
+		usersProxy.POST("/invitations/claim", c.ProxyCtrl.ProxyToEventService)
+	}
+
+	// Курсы валют читают все пользователи, а загружают только администраторы
+	exchangeRatesProxy := api.Group("/exchange-rates")
+	{
+		exchangeRatesProxy.GET("", authMiddleware.Authenticate(), c.ProxyCtrl.ProxyToEventService)
+		exchangeRatesProxy.PUT("", authMiddleware.AuthenticateAdmin(), c.ProxyCtrl.ProxyToEventService)
+	}
+
 	comments := api.Group("/comments", authMiddleware.Authenticate())
 	{
 		comments.POST("/create", c.CommentCtrl.Create)
@@ -111,7 +144,7 @@ func configureCORS(router *gin.Engine, allowedOrigin string) {
 	corsConfig.AllowOrigins = []string{allowedOrigin}
 	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	corsConfig.ExposeHeaders = []string{"Content-Length"}
+	corsConfig.ExposeHeaders = []string{"Content-Length", "Content-Disposition"}
 	corsConfig.AllowCredentials = true
 	router.Use(cors.New(corsConfig))
 }
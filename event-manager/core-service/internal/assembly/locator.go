@@ -1,17 +1,28 @@
 package assembly
 
 import (
+	"context"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/config"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/handler"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/repository"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/routes"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/service"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/access"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/attachment"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/budget"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/calendar"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/currency"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/service/event"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/service/expense"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/export"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/recurring"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/settlement"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/service/task"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/service/template"
 	"github.com/PabloPerdolie/event-manager/core-service/pkg/http/client"
 	"github.com/PabloPerdolie/event-manager/core-service/pkg/postgres"
 	rabbitmq "github.com/PabloPerdolie/event-manager/core-service/pkg/rabbitmq/publisher"
+	"github.com/PabloPerdolie/event-manager/core-service/pkg/storage"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/pkg/errors"
@@ -21,6 +32,9 @@ import (
 type ServiceLocator struct {
 	Controllers routes.Controllers
 	DB          *sqlx.DB
+	recurring   recurring.Service
+	events      event.Service
+	cfg         *config.Config
 	logger      *zap.SugaredLogger
 }
 
@@ -40,34 +54,83 @@ func NewLocator(cfg *config.Config, logger *zap.SugaredLogger) (*ServiceLocator,
 	pblRepo := repository.NewPublisher(publisher)
 
 	eventRepo := repository.NewEvent(db)
+	eventExceptionRepo := repository.NewEventException(db)
 	taskRepo := repository.NewTask(db)
 	assignmentRepo := repository.NewTaskAssignment(db)
 	userRepo := repository.NewUser(db)
 	participantRepo := repository.NewParticipant(db)
+	calendarTokenRepo := repository.NewCalendarToken(db)
+	templateRepo := repository.NewEventTemplate(db)
+	invitationRepo := repository.NewEventInvitation(db)
+	inviteCodeRepo := repository.NewEventInviteCode(db)
+	emailInvitationRepo := repository.NewEventEmailInvitation(db)
 	commentsServiceRepo := repository.NewCommunicationService(&commCli)
 
 	// Репозитории для расходов
 	expenseRepo := repository.NewExpense(db)
 	expenseShareRepo := repository.NewExpenseShare(db)
+	expenseHistoryRepo := repository.NewExpenseHistory(db)
+	attachmentRepo := repository.NewAttachment(db)
+	settlementRepo := repository.NewSettlement(db)
+	exchangeRateRepo := repository.NewExchangeRate(db)
+	budgetRepo := repository.NewBudget(db)
+	recurringRepo := repository.NewRecurringExpense(db)
+
+	transactor := repository.NewTransactor(db)
 
 	healthService := service.NewHealthService(db, logger)
 
-	eventService := event.NewService(eventRepo, participantRepo, pblRepo, logger)
-	eventParticipantService := event.NewParticipantService(participantRepo, userRepo, pblRepo, logger)
-	taskService := task.NewService(taskRepo, assignmentRepo, logger)
+	eventService := event.NewService(eventRepo, participantRepo, eventExceptionRepo, pblRepo, transactor, logger)
+	taskService := task.NewService(taskRepo, assignmentRepo, transactor, logger)
+	calendarService := calendar.NewService(eventRepo, eventExceptionRepo, taskRepo, calendarTokenRepo, cfg.CalendarFeedURL, logger)
+
+	// Сервис курсов валют
+	currencyService := currency.NewService(exchangeRateRepo, eventRepo, transactor, logger)
+	if cfg.ExchangeRatesFile != "" {
+		if err := currencyService.LoadFile(context.Background(), cfg.ExchangeRatesFile); err != nil {
+			return nil, errors.WithMessage(err, "load exchange rates")
+		}
+	}
 
-	// Сервис для работы с расходами
-	expenseService := expense.NewService(expenseRepo, expenseShareRepo, logger)
+	// Хранилище и сервис чеков расходов
+	fileStorage, err := storage.NewLocal(cfg.AttachmentsDir)
+	if err != nil {
+		return nil, errors.WithMessage(err, "init attachment storage")
+	}
+	attachmentService := attachment.NewService(attachmentRepo, fileStorage, cfg.MaxAttachmentSize, logger)
 
-	commonService := service.NewService(taskService, eventParticipantService, eventService, commentsServiceRepo, expenseService)
+	// Сервисы бюджета и расходов
+	budgetService := budget.NewService(budgetRepo, expenseRepo, eventRepo, participantRepo, pblRepo, logger)
+	expenseService := expense.NewService(expenseRepo, expenseShareRepo, expenseHistoryRepo, attachmentService, budgetService, currencyService, eventRepo, transactor, logger)
+	recurringService := recurring.NewService(recurringRepo, expenseService, budgetService, eventRepo, transactor, logger)
+	eventParticipantService := event.NewParticipantService(participantRepo, userRepo, eventRepo, invitationRepo, inviteCodeRepo,
+		emailInvitationRepo, taskService, expenseService, recurringService, event.NewInvitationLinks(cfg.InvitationSecret, cfg.InvitationURL, cfg.InvitationTTL),
+		pblRepo, transactor, logger)
+	exportService := export.NewService(expenseService, budgetService, logger)
+	templateService := template.NewService(templateRepo, eventRepo, eventService, participantRepo, eventParticipantService, taskRepo, budgetRepo, transactor, logger)
+	settlementService := settlement.NewService(settlementRepo, expenseShareRepo, participantRepo, eventRepo, currencyService, transactor, logger)
+
+	// Сервис проверки прав участников событий
+	accessService := access.NewService(participantRepo, taskRepo, expenseRepo, expenseShareRepo, logger)
+
+	commonService := service.NewService(taskService, eventParticipantService, eventService, commentsServiceRepo, expenseService, settlementService, budgetService)
 
 	healthCtrl := handler.NewHealthController(healthService, logger)
-	eventCtrl := handler.NewEvent(commonService, eventService, logger)
-	eventParticipantCtrl := handler.NewParticipantHandler(eventParticipantService, logger)
-	taskCtrl := handler.NewTask(taskService, logger)
+	eventCtrl := handler.NewEvent(commonService, eventService, accessService, logger)
+	eventParticipantCtrl := handler.NewParticipantHandler(eventParticipantService, accessService, logger)
+	taskCtrl := handler.NewTask(taskService, accessService, logger)
 
 	// Контроллер для работы с расходами
-	expenseCtrl := handler.NewExpenseController(expenseService)
+	expenseCtrl := handler.NewExpenseController(expenseService, accessService)
+	exportCtrl := handler.NewExport(exportService, accessService, logger)
+	attachmentCtrl := handler.NewAttachment(attachmentService, accessService, expenseService, cfg.MaxAttachmentSize, logger)
+	settlementCtrl := handler.NewSettlement(settlementService, accessService, logger)
+	budgetCtrl := handler.NewBudget(budgetService, recurringService, accessService, logger)
+	exchangeRateCtrl := handler.NewExchangeRate(currencyService, logger)
+	calendarCtrl := handler.NewCalendar(calendarService, accessService, logger)
+	invitationCtrl := handler.NewInvitation(eventParticipantService, accessService, logger)
+	inviteCodeCtrl := handler.NewInviteCode(eventParticipantService, accessService, logger)
+	templateCtrl := handler.NewTemplate(templateService, accessService, logger)
 
 	controllers := routes.Controllers{
 		HealthCtrl:           healthCtrl,
@@ -75,15 +138,33 @@ func NewLocator(cfg *config.Config, logger *zap.SugaredLogger) (*ServiceLocator,
 		EventParticipantCtrl: eventParticipantCtrl,
 		TaskCtrl:             taskCtrl,
 		ExpenseCtrl:          expenseCtrl,
+		ExportCtrl:           exportCtrl,
+		AttachmentCtrl:       attachmentCtrl,
+		SettlementCtrl:       settlementCtrl,
+		BudgetCtrl:           budgetCtrl,
+		ExchangeRateCtrl:     exchangeRateCtrl,
+		CalendarCtrl:         calendarCtrl,
+		TemplateCtrl:         templateCtrl,
+		InvitationCtrl:       invitationCtrl,
+		InviteCodeCtrl:       inviteCodeCtrl,
 	}
 
 	return &ServiceLocator{
 		Controllers: controllers,
 		DB:          db,
+		recurring:   recurringService,
+		events:      eventService,
+		cfg:         cfg,
 		logger:      logger,
 	}, nil
 }
 
+// StartJobs запускает фоновые задачи сервиса, которые работают до отмены ctx
+func (l *ServiceLocator) StartJobs(ctx context.Context) {
+	go l.recurring.Run(ctx, l.cfg.RecurringInterval)
+	go l.events.RunStatusUpdates(ctx, l.cfg.EventStatusInterval)
+}
+
 func (l *ServiceLocator) Close() {
 	l.logger.Info("Cleaning up resources...")
 	if l.DB != nil {
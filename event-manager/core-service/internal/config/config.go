@@ -3,6 +3,9 @@ package config
 import (
 	"github.com/pkg/errors"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +14,15 @@ type Config struct {
 	RabbitMQURL           string
 	CommentServiceUrl     string
 	NotificationQueueName string
+	ExchangeRatesFile     string
+	AttachmentsDir        string
+	MaxAttachmentSize     int64
+	RecurringInterval     time.Duration
+	EventStatusInterval   time.Duration
+	CalendarFeedURL       string
+	InvitationSecret      string
+	InvitationURL         string
+	InvitationTTL         time.Duration
 }
 
 func New() (*Config, error) {
@@ -39,11 +51,86 @@ func New() (*Config, error) {
 		commentServiceUrl = "http://communication-service:8083"
 	}
 
+	// Необязательный JSON-файл с курсами валют, загружаемый при старте
+	exchangeRatesFile := os.Getenv("EXCHANGE_RATES_FILE")
+
+	attachmentsDir := os.Getenv("ATTACHMENTS_DIR")
+	if attachmentsDir == "" {
+		attachmentsDir = "data/attachments"
+	}
+
+	// Максимальный размер файла чека в мегабайтах
+	maxAttachmentSize := int64(10) << 20
+	if sizeStr := os.Getenv("ATTACHMENT_MAX_SIZE_MB"); sizeStr != "" {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil || size <= 0 {
+			return nil, errors.Errorf("invalid ATTACHMENT_MAX_SIZE_MB: %s", sizeStr)
+		}
+		maxAttachmentSize = int64(size) << 20
+	}
+
+	// Период запуска планировщика повторяющихся расходов
+	recurringInterval := time.Hour
+	if intervalStr := os.Getenv("RECURRING_EXPENSES_INTERVAL"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil || interval <= 0 {
+			return nil, errors.Errorf("invalid RECURRING_EXPENSES_INTERVAL: %s", intervalStr)
+		}
+		recurringInterval = interval
+	}
+
+	// Период обновления статусов событий по датам начала и окончания
+	eventStatusInterval := 5 * time.Minute
+	if intervalStr := os.Getenv("EVENT_STATUS_INTERVAL"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil || interval <= 0 {
+			return nil, errors.Errorf("invalid EVENT_STATUS_INTERVAL: %s", intervalStr)
+		}
+		eventStatusInterval = interval
+	}
+
+	// Публичный адрес ленты календаря в api-gateway, к нему добавляется токен пользователя
+	calendarFeedURL := os.Getenv("CALENDAR_FEED_URL")
+	if calendarFeedURL == "" {
+		calendarFeedURL = "http://localhost:8081/api/v1/calendar"
+	}
+
+	// Ключ подписи ссылок приглашений: без него ссылку можно подобрать
+	invitationSecret := os.Getenv("INVITATION_SECRET")
+	if invitationSecret == "" {
+		return nil, errors.New("INVITATION_SECRET environment variable is required")
+	}
+
+	// Публичный адрес ответа на приглашение в api-gateway, к нему добавляется подписанный токен
+	invitationURL := os.Getenv("INVITATION_URL")
+	if invitationURL == "" {
+		invitationURL = "http://localhost:8081/api/v1/invitations"
+	}
+
+	// Срок действия ссылки приглашения
+	invitationTTL := 7 * 24 * time.Hour
+	if ttlStr := os.Getenv("INVITATION_TTL"); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl <= 0 {
+			return nil, errors.Errorf("invalid INVITATION_TTL: %s", ttlStr)
+		}
+		invitationTTL = ttl
+	}
+
 	return &Config{
 		Port:                  port,
 		DatabaseURL:           dbURL,
 		RabbitMQURL:           rabbitMQURL,
 		CommentServiceUrl:     commentServiceUrl,
 		NotificationQueueName: notificationsQueueName,
+		ExchangeRatesFile:     exchangeRatesFile,
+		AttachmentsDir:        attachmentsDir,
+		MaxAttachmentSize:     maxAttachmentSize,
+		RecurringInterval:     recurringInterval,
+		EventStatusInterval:   eventStatusInterval,
+		CalendarFeedURL:       strings.TrimSuffix(calendarFeedURL, "/"),
+		InvitationSecret:      invitationSecret,
+		InvitationURL:         strings.TrimSuffix(invitationURL, "/"),
+		InvitationTTL:         invitationTTL,
 	}, nil
 }
@@ -0,0 +1,31 @@
+package domain
+
+// Permission описывает действие над событием или его ресурсами
+type Permission string
+
+const (
+	PermissionViewEvent          Permission = "event.view"
+	PermissionUpdateEvent        Permission = "event.update"
+	PermissionDeleteEvent        Permission = "event.delete"
+	PermissionManageParticipants Permission = "participant.manage"
+	PermissionCreateTask         Permission = "task.create"
+	PermissionUpdateTask         Permission = "task.update"
+	PermissionDeleteTask         Permission = "task.delete"
+	PermissionCreateExpense      Permission = "expense.create"
+	PermissionUpdateExpense      Permission = "expense.update"
+	PermissionDeleteExpense      Permission = "expense.delete"
+	PermissionUpdateExpenseShare Permission = "expense_share.update"
+	PermissionUploadAttachment   Permission = "attachment.upload"
+	PermissionRecordSettlement   Permission = "settlement.record"
+	PermissionManageBudget       Permission = "budget.manage"
+	PermissionAnswerInvitation   Permission = "invitation.answer"
+	PermissionChangeRole         Permission = "participant.role"
+	PermissionTransferEvent      Permission = "event.transfer"
+)
+
+// ErrorResponse ответ сервера при возникновении ошибки
+type ErrorResponse struct {
+	Error      string     `json:"error"`
+	Message    string     `json:"message"`
+	Permission Permission `json:"permission,omitempty"`
+}
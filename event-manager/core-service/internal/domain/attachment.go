@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// AttachmentResponse информация о прикрепленном к расходу файле чека
+type AttachmentResponse struct {
+	AttachmentID int       `json:"attachment_id"`
+	ExpenseID    int       `json:"expense_id"`
+	UploadedBy   int       `json:"uploaded_by"`
+	FileName     string    `json:"file_name"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AttachmentsResponse список файлов расхода
+type AttachmentsResponse struct {
+	Items []AttachmentResponse `json:"items"`
+}
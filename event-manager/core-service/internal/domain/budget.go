@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"time"
+)
+
+// CategoryRequest запрос на создание или изменение категории расходов. Лимит указывается
+// в базовой валюте события, без лимита расходы категории не ограничиваются
+type CategoryRequest struct {
+	Name   string       `json:"name" binding:"required,max=100"`
+	Budget *model.Money `json:"budget" binding:"omitempty,gt=0"`
+}
+
+// CategoryResponse категория расходов события
+type CategoryResponse struct {
+	CategoryID int          `json:"category_id"`
+	EventID    int          `json:"event_id"`
+	Name       string       `json:"name"`
+	Budget     *model.Money `json:"budget,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// CategoriesResponse список категорий расходов события
+type CategoriesResponse struct {
+	Items []CategoryResponse `json:"items"`
+}
+
+// BudgetUpdateRequest запрос на изменение общего лимита расходов события. Пустой total_budget снимает лимит
+type BudgetUpdateRequest struct {
+	TotalBudget *model.Money `json:"total_budget" binding:"omitempty,gt=0"`
+}
+
+// BudgetUsage сравнение лимита с фактическими расходами. Без лимита budget и remaining не заполняются
+type BudgetUsage struct {
+	Budget    *model.Money `json:"budget,omitempty"`
+	Spent     model.Money  `json:"spent"`
+	Remaining *model.Money `json:"remaining,omitempty"` // Отрицательный остаток означает превышение лимита
+	Exceeded  bool         `json:"exceeded"`
+}
+
+// CategoryBudgetResponse лимит и расходы одной категории
+type CategoryBudgetResponse struct {
+	CategoryID int    `json:"category_id"`
+	Name       string `json:"name"`
+	BudgetUsage
+}
+
+// BudgetReportResponse бюджет события в сравнении с расходами в базовой валюте события
+type BudgetReportResponse struct {
+	EventID       int                      `json:"event_id"`
+	Currency      string                   `json:"currency"`
+	Uncategorized model.Money              `json:"uncategorized"` // Расходы без категории
+	Categories    []CategoryBudgetResponse `json:"categories"`
+	BudgetUsage
+}
+
+// RecurringExpenseCreateRequest запрос на создание повторяющегося расхода. Расходы создаются каждый день
+// или неделю, начиная с start_date (по умолчанию — дата начала события) до end_date (по умолчанию — дата
+// окончания события). Участники задаются так же, как при создании расхода
+type RecurringExpenseCreateRequest struct {
+	Description string                `json:"description" binding:"required"`
+	Amount      model.Money           `json:"amount" binding:"required,gt=0"`
+	Currency    string                `json:"currency" binding:"required,len=3"`
+	SplitMethod string                `json:"split_method" binding:"required,oneof=equal percent exact shares"`
+	UserIDs     []int                 `json:"user_ids"`
+	Shares      []ExpenseShareRequest `json:"shares" binding:"omitempty,dive"`
+	CategoryID  *int                  `json:"category_id"`
+	Frequency   string                `json:"frequency" binding:"required,oneof=daily weekly"`
+	StartDate   *time.Time            `json:"start_date"`
+	EndDate     *time.Time            `json:"end_date"`
+}
+
+// RecurringExpenseResponse правило повторяющегося расхода
+type RecurringExpenseResponse struct {
+	RecurringID int                   `json:"recurring_id"`
+	EventID     int                   `json:"event_id"`
+	CreatedBy   int                   `json:"created_by"`
+	CategoryID  *int                  `json:"category_id,omitempty"`
+	Description string                `json:"description"`
+	Amount      model.Money           `json:"amount"`
+	Currency    string                `json:"currency"`
+	SplitMethod string                `json:"split_method"`
+	UserIDs     []int                 `json:"user_ids,omitempty"`
+	Shares      []ExpenseShareRequest `json:"shares,omitempty"`
+	Frequency   string                `json:"frequency"`
+	NextDate    time.Time             `json:"next_date"` // Дата следующего расхода
+	EndDate     *time.Time            `json:"end_date,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// RecurringExpensesResponse список повторяющихся расходов события
+type RecurringExpensesResponse struct {
+	Items []RecurringExpenseResponse `json:"items"`
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// CalendarFeedResponse ссылка для подписки на календарь пользователя. Ссылка открывается без авторизации,
+// поэтому при утечке ее нужно перевыпустить
+type CalendarFeedResponse struct {
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -3,34 +3,122 @@ package domain
 import "time"
 
 type EventCreateRequest struct {
-	Title       string    `json:"title" binding:"required"`
-	Description string    `json:"description"`
-	StartDate   time.Time `json:"start_date" binding:"required"`
-	EndDate     time.Time `json:"end_date" binding:"required"`
-	Location    string    `json:"location"`
+	Title        string    `json:"title" binding:"required"`
+	Description  string    `json:"description"`
+	StartDate    time.Time `json:"start_date" binding:"required"`
+	EndDate      time.Time `json:"end_date" binding:"required"`
+	Location     string    `json:"location"`
+	BaseCurrency string    `json:"base_currency" binding:"omitempty,len=3"`        // Валюта балансов события, по умолчанию RUB
+	Status       string    `json:"status" binding:"omitempty,oneof=draft planned"` // Начальный статус, по умолчанию planned
+	// RecurrenceRule правило повторения RFC 5545, например FREQ=WEEKLY;BYDAY=TH;COUNT=10.
+	// Даты события задают первое повторение
+	RecurrenceRule string `json:"recurrence_rule"`
+	// MaxParticipants наибольшее число участников, сверх него участники попадают в лист ожидания.
+	// По умолчанию без ограничения
+	MaxParticipants *int `json:"max_participants" binding:"omitempty,min=1"`
 }
 
+// EventUpdateRequest запрос на изменение события. Незаданные поля не меняются. Version — версия события,
+// которую видел клиент: если событие с тех пор изменили, запрос отклоняется
 type EventUpdateRequest struct {
-	Title       *string    `json:"title"`
+	Version     int        `json:"version" binding:"required,min=1"`
+	Title       *string    `json:"title" binding:"omitempty,min=1"`
 	Description *string    `json:"description"`
 	StartDate   *time.Time `json:"start_date"`
 	EndDate     *time.Time `json:"end_date"`
 	Location    *string    `json:"location"`
+	// MaxParticipants новое ограничение числа участников, 0 снимает ограничение. При увеличении
+	// ограничения освободившиеся места занимают участники из листа ожидания
+	MaxParticipants *int `json:"max_participants" binding:"omitempty,min=0"`
+}
+
+// EventStatusRequest запрос на перевод события в другой статус
+type EventStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=draft planned active completed cancelled archived"`
 }
 
 type EventResponse struct {
-	Id          int       `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	StartDate   time.Time `json:"start_date"`
-	EndDate     time.Time `json:"end_date"`
-	Location    string    `json:"location"`
-	Status      string    `json:"status"`
-	CreatedBy   int       `json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
+	Id           int       `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	Location     string    `json:"location"`
+	Status       string    `json:"status"` // draft, planned, active, completed, cancelled или archived
+	BaseCurrency string    `json:"base_currency"`
+	CreatedBy    int       `json:"created_by"`
+	Version      int       `json:"version"` // Передается в запросе на изменение события
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	// RecurrenceRule правило повторения серии, пусто у обычного события
+	RecurrenceRule string `json:"recurrence_rule,omitempty"`
+	// MaxParticipants ограничение числа участников, не задано у события без ограничения
+	MaxParticipants *int `json:"max_participants,omitempty"`
+	// OccurrenceDate начало повторения по правилу серии. Заполнено, если ответ — одно повторение,
+	// а StartDate и EndDate — его фактическое время
+	OccurrenceDate *time.Time `json:"occurrence_date,omitempty"`
+	Modified       bool       `json:"modified,omitempty"` // Повторение изменено отдельно от серии
+}
+
+// Области изменения повторения серии
+const (
+	OccurrenceScopeThis      = "this"      // Только это повторение
+	OccurrenceScopeFollowing = "following" // Это и следующие: серия разделяется на две
+	OccurrenceScopeAll       = "all"       // Вся серия
+)
+
+// OccurrencesRequest окно, в котором разворачиваются повторения серии. Даты включаются в окно
+type OccurrencesRequest struct {
+	From time.Time `form:"from" binding:"required" time_format:"2006-01-02"`
+	To   time.Time `form:"to" binding:"required" time_format:"2006-01-02"`
+}
+
+// OccurrenceUpdateRequest изменение повторения серии. OccurrenceDate — начало повторения по правилу,
+// StartDate и EndDate — его новое время. При scope following и all сдвиг времени применяется ко всем
+// затронутым повторениям, а RecurrenceRule заменяет правило
+type OccurrenceUpdateRequest struct {
+	OccurrenceDate time.Time  `json:"occurrence_date" binding:"required"`
+	Scope          string     `json:"scope" binding:"required,oneof=this following all"`
+	Version        int        `json:"version" binding:"required,min=1"` // Версия серии
+	Title          *string    `json:"title" binding:"omitempty,min=1"`
+	Description    *string    `json:"description"`
+	StartDate      *time.Time `json:"start_date"`
+	EndDate        *time.Time `json:"end_date"`
+	Location       *string    `json:"location"`
+	RecurrenceRule *string    `json:"recurrence_rule" binding:"omitempty,min=1"`
+}
+
+// OccurrenceCancelRequest отмена повторения серии или всех повторений начиная с него
+type OccurrenceCancelRequest struct {
+	OccurrenceDate time.Time `json:"occurrence_date" binding:"required"`
+	Scope          string    `json:"scope" binding:"required,oneof=this following"`
+	Version        int       `json:"version" binding:"required,min=1"` // Версия серии
+}
+
+// OccurrencesResponse повторения серии в окне по возрастанию времени начала
+type OccurrencesResponse struct {
+	Occurrences []EventResponse `json:"occurrences"`
+}
+
+// EventListRequest поиск, фильтры, сортировка и пагинация списка событий.
+// Даты задаются в формате YYYY-MM-DD и включаются в диапазон. Если заданы обе даты, серии
+// разворачиваются в повторения внутри диапазона, а пагинация по-прежнему считает события
+type EventListRequest struct {
+	Query         string     `form:"q"`                                  // Поиск по названию, описанию и месту проведения
+	DateFrom      *time.Time `form:"date_from" time_format:"2006-01-02"` // События, идущие в этот день или позже
+	DateTo        *time.Time `form:"date_to" time_format:"2006-01-02"`   // События, идущие в этот день или раньше
+	Status        []string   `form:"status" binding:"omitempty,dive,oneof=draft planned active completed cancelled archived"`
+	OrganizerID   *int       `form:"organizer_id"`
+	ParticipantID *int       `form:"participant_id"`
+	Sort          string     `form:"sort" binding:"omitempty,oneof=start_date_desc start_date_asc created_at_desc created_at_asc title_asc relevance"`
+	Cursor        string     `form:"cursor"`
+	Limit         int        `form:"limit" binding:"omitempty,min=1,max=100"`
+	Page          int        `form:"page" binding:"omitempty,min=1"`         // Номер страницы для навигации без курсора
+	Size          int        `form:"size" binding:"omitempty,min=1,max=100"` // Устаревший синоним limit
 }
 
 type EventsResponse struct {
-	Events []EventResponse `json:"events"`
-	Total  int             `json:"total"`
+	Events     []EventResponse `json:"events"`
+	Total      int             `json:"total"`                 // Количество всех событий, подходящих под фильтры
+	NextCursor string          `json:"next_cursor,omitempty"` // Курсор следующей страницы, пуст на последней
 }
@@ -1,29 +1,94 @@
 package domain
 
-import "time"
+import (
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"time"
+)
 
 type EventParticipantCreateRequest struct {
 	EventTitle string `json:"event_title" binding:"required"`
 	UserID     int    `json:"user_id"`
 	Username   string `json:"username"`
-	Email      string `json:"email"`
 }
 
+// EventParticipantUpdateRequest смена роли участника. Роль организатора передается только вместе
+// с событием, подтверждает участие сам участник
 type EventParticipantUpdateRequest struct {
-	Role        *string `json:"role"`
-	IsConfirmed *bool   `json:"is_confirmed"`
+	Role string `json:"role" binding:"required,oneof=participant admin"`
+}
+
+// OwnershipTransferRequest передача события другому участнику
+type OwnershipTransferRequest struct {
+	ParticipantID int `json:"participant_id" binding:"required,min=1"` // ID участия нового организатора
 }
 
 type EventParticipantResponse struct {
 	Id          int          `json:"id"`
 	EventID     int          `json:"event_id"`
+	EventTitle  string       `json:"event_title,omitempty"`
 	User        UserResponse `json:"user"`
 	Role        string       `json:"role"`
 	JoinedAt    *time.Time   `json:"joined_at,omitempty"`
 	IsConfirmed *bool        `json:"is_confirmed,omitempty"`
+	// WaitlistPosition место в листе ожидания начиная с 1, не задано у участника, занявшего место
+	WaitlistPosition *int `json:"waitlist_position,omitempty"`
 }
 
 type EventParticipantsResponse struct {
 	Participants []EventParticipantResponse `json:"participants"`
-	Total        int                        `json:"total"`
+	Total        int                        `json:"total"` // Количество всех участий, подходящих под фильтры
+}
+
+// ParticipantListRequest фильтры и пагинация списка участников
+type ParticipantListRequest struct {
+	Role   []string `form:"role" binding:"omitempty,dive,oneof=organizer admin participant"`
+	Status string   `form:"status" binding:"omitempty,oneof=confirmed pending declined waitlisted"`
+	Query  string   `form:"q"` // Поиск по части имени пользователя
+	Page   int      `form:"page" binding:"omitempty,min=1"`
+	Size   int      `form:"size" binding:"omitempty,min=1,max=100"`
+}
+
+// ParticipantRemovalRequest что делать с задачами и долями удаляемого участника. По умолчанию задачи
+// остаются без исполнителя, а долги участника сохраняются
+type ParticipantRemovalRequest struct {
+	Tasks      string `form:"tasks" binding:"omitempty,oneof=unassign reassign"`
+	ReassignTo *int   `form:"reassign_to" binding:"omitempty,min=1"` // ID пользователя, обязателен для reassign
+	Shares     string `form:"shares" binding:"omitempty,oneof=keep redistribute"`
+}
+
+// Политики удаления участника
+const (
+	RemovalTasksUnassign      = "unassign"
+	RemovalTasksReassign      = "reassign"
+	RemovalSharesKeep         = "keep"
+	RemovalSharesRedistribute = "redistribute"
+)
+
+// ParticipantRemovalResponse что изменилось при удалении участника
+type ParticipantRemovalResponse struct {
+	Id      int                  `json:"id"`
+	EventID int                  `json:"event_id"`
+	UserID  int                  `json:"user_id"`
+	Tasks   RemovedTasksSummary  `json:"tasks"`
+	Shares  RemovedSharesSummary `json:"shares"`
+}
+
+// RemovedTasksSummary незавершенные задачи события, с которых снят удаленный участник
+type RemovedTasksSummary struct {
+	Policy       string `json:"policy"`
+	ReassignedTo *int   `json:"reassigned_to,omitempty"`
+	Reassigned   []int  `json:"reassigned"` // ID задач, переданных другому участнику
+	Unassigned   []int  `json:"unassigned"` // ID задач, оставшихся без удаленного участника
+}
+
+// RemovedSharesSummary неоплаченные доли удаленного участника в чужих расходах. Суммы указаны
+// в базовой валюте события
+type RemovedSharesSummary struct {
+	Policy     string      `json:"policy"`
+	Currency   string      `json:"currency"`
+	Kept       []int       `json:"kept"` // ID долей, которые участник по-прежнему должен
+	KeptAmount model.Money `json:"kept_amount"`
+	// Redistributed ID расходов, доля в которых разделена между остальными участниками расхода
+	Redistributed       []int       `json:"redistributed"`
+	RedistributedAmount model.Money `json:"redistributed_amount"`
 }
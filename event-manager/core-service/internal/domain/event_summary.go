@@ -9,4 +9,6 @@ type EventData struct {
 	Comments          model.CommunicationServiceResponse
 	Expenses          ExpensesResponse
 	BalanceReport     BalanceReportResponse
+	Settlements       SettlementPlanResponse
+	Budget            BudgetReportResponse
 }
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// ExchangeRateRequest курс пересчета: 1 единица from_currency стоит rate единиц to_currency
+type ExchangeRateRequest struct {
+	FromCurrency string  `json:"from_currency" binding:"required,len=3"`
+	ToCurrency   string  `json:"to_currency" binding:"required,len=3"`
+	Rate         float64 `json:"rate" binding:"required,gt=0"`
+}
+
+// ExchangeRatesUpdateRequest запрос на загрузку курсов валют. Тот же формат используется в файле курсов
+type ExchangeRatesUpdateRequest struct {
+	Rates []ExchangeRateRequest `json:"rates" binding:"required,min=1,dive"`
+}
+
+type ExchangeRateResponse struct {
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         float64   `json:"rate"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type ExchangeRatesResponse struct {
+	Rates []ExchangeRateResponse `json:"rates"`
+}
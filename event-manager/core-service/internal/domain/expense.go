@@ -7,51 +7,97 @@ import (
 
 // UserBalance представляет баланс пользователя в событии
 type UserBalance struct {
-	UserID       int     `json:"user_id"`
-	Username     string  `json:"username"`
-	Balance      float64 `json:"balance"`
-	PaidAmount   float64 `json:"paid_amount,omitempty"`   // Сумма оплаченных долей
-	UnpaidAmount float64 `json:"unpaid_amount,omitempty"` // Сумма неоплаченных долей
-	TotalDue     float64 `json:"total_due,omitempty"`     // Общая сумма к оплате
+	UserID       int         `json:"user_id"`
+	Username     string      `json:"username"`
+	Balance      model.Money `json:"balance"`
+	PaidAmount   model.Money `json:"paid_amount,omitempty"`   // Сумма оплаченных долей
+	UnpaidAmount model.Money `json:"unpaid_amount,omitempty"` // Сумма неоплаченных долей
+	TotalDue     model.Money `json:"total_due,omitempty"`     // Общая сумма к оплате
+}
+
+// ExpenseShareRequest вес доли участника при разделении расхода
+type ExpenseShareRequest struct {
+	UserID int     `json:"user_id" binding:"required"`
+	Value  float64 `json:"value" binding:"gt=0"` // Процент, точная сумма или количество долей в зависимости от split_method
 }
 
 // ExpenseCreateRequest запрос на создание расхода
 type ExpenseCreateRequest struct {
-	EventID     int     `json:"event_id" binding:"required"`
-	Description string  `json:"description" binding:"required"`
-	Amount      float64 `json:"amount" binding:"required,gt=0"`
-	Currency    string  `json:"currency" binding:"required"`
-	SplitMethod string  `json:"split_method" binding:"required"`
-	CreatedBy   int     `json:"created_by" binding:"required"`
-	UserIDs     []int   `json:"user_ids" binding:"required"`
+	EventID     int                   `json:"event_id" binding:"required"`
+	Description string                `json:"description" binding:"required"`
+	Amount      model.Money           `json:"amount" binding:"required,gt=0"`
+	Currency    string                `json:"currency" binding:"required,len=3"`
+	SplitMethod string                `json:"split_method" binding:"required,oneof=equal percent exact shares"`
+	CreatedBy   int                   `json:"created_by" binding:"required"`
+	CategoryID  *int                  `json:"category_id"`
+	UserIDs     []int                 `json:"user_ids"`                        // Участники для равного разделения
+	Shares      []ExpenseShareRequest `json:"shares" binding:"omitempty,dive"` // Веса участников для percent, exact и shares
 }
 
-// ExpenseUpdateRequest запрос на обновление расхода
+// ExpenseUpdateRequest запрос на обновление расхода. Незаданные поля не меняются;
+// если не переданы ни user_ids, ни shares, доли пересчитываются по текущим участникам и весам
 type ExpenseUpdateRequest struct {
-	Description *string  `json:"description"`
-	Amount      *float64 `json:"amount" binding:"omitempty,gt=0"`
-	Currency    *string  `json:"currency"`
-	SplitMethod *string  `json:"split_method"`
-	UserIDs     *[]int   `json:"user_ids"`
+	Description *string                `json:"description" binding:"omitempty,min=1"`
+	Amount      *model.Money           `json:"amount" binding:"omitempty,gt=0"`
+	Currency    *string                `json:"currency" binding:"omitempty,len=3"`
+	SplitMethod *string                `json:"split_method" binding:"omitempty,oneof=equal percent exact shares"`
+	CategoryID  *int                   `json:"category_id"` // 0 убирает расход из категории
+	UserIDs     *[]int                 `json:"user_ids"`
+	Shares      *[]ExpenseShareRequest `json:"shares" binding:"omitempty,dive"`
 }
 
 // ExpenseResponse ответ с информацией о расходе
 type ExpenseResponse struct {
-	ExpenseID   int                  `json:"expense_id"`
-	EventID     int                  `json:"event_id"`
-	CreatedBy   int                  `json:"created_by"`
-	Description string               `json:"description"`
-	Amount      float64              `json:"amount"`
-	Currency    string               `json:"currency"`
-	SplitMethod string               `json:"split_method"`
-	CreatedAt   time.Time            `json:"created_at"`
-	Shares      []model.ExpenseShare `json:"shares,omitempty"`
+	ExpenseID    int                  `json:"expense_id"`
+	EventID      int                  `json:"event_id"`
+	CreatedBy    int                  `json:"created_by"`
+	Description  string               `json:"description"`
+	Amount       model.Money          `json:"amount"`
+	Currency     string               `json:"currency"`
+	ExchangeRate float64              `json:"exchange_rate"` // Курс к базовой валюте события на момент создания
+	BaseAmount   model.Money          `json:"base_amount"`   // Сумма в базовой валюте события
+	SplitMethod  string               `json:"split_method"`
+	CategoryID   *int                 `json:"category_id,omitempty"`
+	RecurringID  *int                 `json:"recurring_id,omitempty"` // Повторяющийся расход, по которому создан расход
+	CreatedAt    time.Time            `json:"created_at"`
+	Shares       []model.ExpenseShare `json:"shares,omitempty"`
+}
+
+// ExpenseListRequest фильтры, сортировка и пагинация списка расходов события.
+// Даты задаются в формате YYYY-MM-DD и включаются в диапазон
+type ExpenseListRequest struct {
+	EventID       int        `form:"event_id" binding:"required"`
+	CreatedBy     *int       `form:"created_by"`
+	ParticipantID *int       `form:"participant_id"` // Участник, у которого есть доля в расходе
+	CategoryID    *int       `form:"category_id"`
+	Currency      string     `form:"currency" binding:"omitempty,len=3"`
+	DateFrom      *time.Time `form:"date_from" time_format:"2006-01-02"`
+	DateTo        *time.Time `form:"date_to" time_format:"2006-01-02"`
+	Status        string     `form:"status" binding:"omitempty,oneof=paid unpaid"` // Статус доли participant_id, а без него — всех долей расхода
+	Sort          string     `form:"sort" binding:"omitempty,oneof=created_at_desc created_at_asc amount_desc amount_asc"`
+	Cursor        string     `form:"cursor"`
+	Limit         int        `form:"limit" binding:"omitempty,min=1,max=100"`
 }
 
 // ExpensesResponse список расходов с пагинацией
 type ExpensesResponse struct {
 	Items      []ExpenseResponse `json:"items"`
 	TotalCount int               `json:"total_count"`
+	NextCursor string            `json:"next_cursor,omitempty"` // Курсор следующей страницы, пуст на последней
+}
+
+// ExpenseHistoryEntry одно редактирование расхода
+type ExpenseHistoryEntry struct {
+	HistoryID int                   `json:"history_id"`
+	ChangedBy int                   `json:"changed_by"`
+	ChangedAt time.Time             `json:"changed_at"`
+	Changes   []model.ExpenseChange `json:"changes"`
+}
+
+// ExpenseHistoryResponse журнал изменений расхода, от новых записей к старым
+type ExpenseHistoryResponse struct {
+	ExpenseID int                   `json:"expense_id"`
+	Items     []ExpenseHistoryEntry `json:"items"`
 }
 
 // ExpenseShareUpdateRequest запрос на обновление статуса доли расхода
@@ -59,9 +105,51 @@ type ExpenseShareUpdateRequest struct {
 	IsPaid bool `json:"is_paid" binding:"required"`
 }
 
-// BalanceReportResponse ответ с отчетом о балансах пользователей
+// BalanceReportResponse ответ с отчетом о балансах пользователей.
+// Все суммы указаны в базовой валюте события
 type BalanceReportResponse struct {
 	EventID      int           `json:"event_id"`
-	TotalAmount  float64       `json:"total_amount"`
+	Currency     string        `json:"currency"`
+	TotalAmount  model.Money   `json:"total_amount"`
 	UserBalances []UserBalance `json:"user_balances"`
 }
+
+// BalanceTotals суммы долгов пользователя. Balance — сколько пользователю должны в итоге,
+// отрицательное значение означает, что должен он сам
+type BalanceTotals struct {
+	OwedToUser model.Money `json:"owed_to_user"` // Неоплаченные доли других участников в расходах пользователя
+	UserOwes   model.Money `json:"user_owes"`    // Неоплаченные доли пользователя в чужих расходах
+	PaidToUser model.Money `json:"paid_to_user"` // Оплаченные доли других участников в расходах пользователя
+	PaidByUser model.Money `json:"paid_by_user"` // Оплаченные доли пользователя в чужих расходах
+	Balance    model.Money `json:"balance"`
+}
+
+// CounterpartyBalanceResponse долги пользователя с одним участником события
+type CounterpartyBalanceResponse struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	BalanceTotals
+}
+
+// EventBalanceResponse долги пользователя в одном событии в базовой валюте события
+type EventBalanceResponse struct {
+	EventID        int                           `json:"event_id"`
+	EventTitle     string                        `json:"event_title"`
+	Currency       string                        `json:"currency"`
+	Counterparties []CounterpartyBalanceResponse `json:"counterparties"`
+	BalanceTotals
+}
+
+// CurrencyBalanceResponse итог по всем событиям с одной базовой валютой
+type CurrencyBalanceResponse struct {
+	Currency string `json:"currency"`
+	BalanceTotals
+}
+
+// UserBalancesResponse сводка долгов пользователя по всем его событиям.
+// Суммы в разных валютах не складываются, итоги приводятся отдельно по каждой валюте
+type UserBalancesResponse struct {
+	UserID int                       `json:"user_id"`
+	Totals []CurrencyBalanceResponse `json:"totals"`
+	Events []EventBalanceResponse    `json:"events"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+// Форматы выгрузки расходов
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// Языки заголовков выгрузки
+const (
+	ExportLangRu = "ru"
+	ExportLangEn = "en"
+)
+
+// ExportRequest параметры выгрузки расходов события. Без lang язык выбирается по заголовку Accept-Language
+type ExportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+	Lang   string `form:"lang" binding:"omitempty,oneof=ru en"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// InvitationResponse приглашение участника, ожидающее ответа
+type InvitationResponse struct {
+	ParticipantID int       `json:"participant_id"`
+	UserID        int       `json:"user_id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	SentAt        time.Time `json:"sent_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Expired       bool      `json:"expired"` // Ссылка больше не действует, приглашение можно отправить повторно
+	// WaitlistPosition место приглашенного в листе ожидания, не задано, если место за ним закреплено
+	WaitlistPosition *int `json:"waitlist_position,omitempty"`
+}
+
+type InvitationsResponse struct {
+	Invitations []InvitationResponse `json:"invitations"`
+	Total       int                  `json:"total"`
+}
+
+// InvitationClaimRequest токен из письма с приглашением по адресу
+type InvitationClaimRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// InvitationPreviewResponse событие приглашения для страницы подтверждения ответа
+type InvitationPreviewResponse struct {
+	EventID          int       `json:"event_id"`
+	EventName        string    `json:"event_name"`
+	StartDate        time.Time `json:"start_date"`
+	WaitlistPosition *int      `json:"waitlist_position,omitempty"`
+}
+
+// InvitationAnswerResponse результат ответа на приглашение по ссылке
+type InvitationAnswerResponse struct {
+	EventID          int    `json:"event_id"`
+	EventName        string `json:"event_name"`
+	Status           string `json:"status"` // confirmed или declined
+	WaitlistPosition *int   `json:"waitlist_position,omitempty"`
+}
+
+// Ответы на приглашение
+const (
+	InvitationConfirmed = "confirmed"
+	InvitationDeclined  = "declined"
+)
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// InviteCodeCreateRequest параметры кода приглашения. Без max_uses и expires_at код действует
+// без ограничений, пока его не отзовут
+type InviteCodeCreateRequest struct {
+	Role      string     `json:"role" binding:"omitempty,oneof=participant admin"` // По умолчанию participant
+	MaxUses   *int       `json:"max_uses" binding:"omitempty,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Permission возвращает право, необходимое для выпуска кода. Код с ролью admin назначает роль
+// так же, как ее смена, поэтому выпустить его может только тот, кто вправе менять роли
+func (r InviteCodeCreateRequest) Permission() Permission {
+	if r.Role == "admin" {
+		return PermissionChangeRole
+	}
+	return PermissionManageParticipants
+}
+
+// InviteCodeResponse код приглашения со статистикой использования
+type InviteCodeResponse struct {
+	Id        int    `json:"id"`
+	EventID   int    `json:"event_id"`
+	Code      string `json:"code"`
+	Role      string `json:"role"`
+	Status    string `json:"status"` // active, expired, exhausted или revoked
+	MaxUses   *int   `json:"max_uses,omitempty"`
+	UsesCount int    `json:"uses_count"`
+	// RemainingUses сколько пользователей еще могут вступить по коду, не задано для кода без ограничения
+	RemainingUses *int       `json:"remaining_uses,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CreatedBy     int        `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+type InviteCodesResponse struct {
+	InviteCodes []InviteCodeResponse `json:"invite_codes"`
+	Total       int                  `json:"total"`
+}
+
+// Статусы кода приглашения
+const (
+	InviteCodeActive    = "active"
+	InviteCodeExpired   = "expired"
+	InviteCodeExhausted = "exhausted"
+	InviteCodeRevoked   = "revoked"
+)
@@ -0,0 +1,40 @@
+package domain
+
+// ParticipantImportRequest список пользователей для добавления в событие. Строка задает пользователя
+// именем или адресом; если заданы оба, сначала ищется имя
+type ParticipantImportRequest struct {
+	Rows []ParticipantImportRow `json:"rows" binding:"required,min=1,max=500"`
+}
+
+type ParticipantImportRow struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// ParticipantImportResult результат обработки одной строки списка
+type ParticipantImportResult struct {
+	Row      int    `json:"row"` // Номер строки начиная с 1
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Status   string `json:"status"` // added, already_participant, invited_by_email или invalid
+	Reason   string `json:"reason,omitempty"`
+	// ParticipantID ID участия добавленного пользователя
+	ParticipantID    *int `json:"participant_id,omitempty"`
+	WaitlistPosition *int `json:"waitlist_position,omitempty"`
+}
+
+type ParticipantImportResponse struct {
+	Results            []ParticipantImportResult `json:"results"`
+	Added              int                       `json:"added"`
+	AlreadyParticipant int                       `json:"already_participant"`
+	InvitedByEmail     int                       `json:"invited_by_email"`
+	Invalid            int                       `json:"invalid"`
+}
+
+// Статусы строки списка участников
+const (
+	ImportAdded              = "added"
+	ImportAlreadyParticipant = "already_participant"
+	ImportInvitedByEmail     = "invited_by_email"
+	ImportInvalid            = "invalid"
+)
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"time"
+)
+
+// SettlementTransfer перевод из плана взаиморасчетов: кто, кому и сколько должен заплатить
+type SettlementTransfer struct {
+	FromUserID   int         `json:"from_user_id"`
+	FromUsername string      `json:"from_username"`
+	ToUserID     int         `json:"to_user_id"`
+	ToUsername   string      `json:"to_username"`
+	Amount       model.Money `json:"amount"`
+}
+
+// SettlementCreateRequest запрос на регистрацию платежа между участниками. Сумма указывается в базовой валюте события
+type SettlementCreateRequest struct {
+	FromUserID int         `json:"from_user_id" binding:"required"`
+	ToUserID   int         `json:"to_user_id" binding:"required"`
+	Amount     model.Money `json:"amount" binding:"required,gt=0"`
+}
+
+// SettlementResponse зарегистрированный платеж
+type SettlementResponse struct {
+	Id           int         `json:"id"`
+	EventID      int         `json:"event_id"`
+	FromUserID   int         `json:"from_user_id"`
+	ToUserID     int         `json:"to_user_id"`
+	Amount       model.Money `json:"amount"`
+	CreatedBy    int         `json:"created_by"`
+	CreatedAt    time.Time   `json:"created_at"`
+	PaidShareIDs []int       `json:"paid_share_ids,omitempty"` // Доли, отмеченные оплаченными этим платежом
+}
+
+// SettlementPlanResponse минимальный набор переводов для погашения всех долгов события
+type SettlementPlanResponse struct {
+	EventID   int                  `json:"event_id"`
+	Currency  string               `json:"currency"`
+	Transfers []SettlementTransfer `json:"transfers"`
+	Payments  []SettlementResponse `json:"payments"`
+}
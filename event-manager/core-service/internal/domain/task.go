@@ -12,23 +12,26 @@ const (
 )
 
 type TaskCreateRequest struct {
-	EventId     int     `json:"event_id" binding:"required"`
-	Title       string  `json:"title" binding:"required"`
-	Description string  `json:"description"`
-	ParentId    *int    `json:"parent_id"`
-	StoryPoints *int    `json:"story_points"`
-	Priority    *string `json:"priority"`
-	AssignedTo  *int    `json:"assigned_to"`
+	EventId     int        `json:"event_id" binding:"required"`
+	Title       string     `json:"title" binding:"required"`
+	Description string     `json:"description"`
+	ParentId    *int       `json:"parent_id"`
+	StoryPoints *int       `json:"story_points"`
+	Priority    *string    `json:"priority"`
+	DueDate     *time.Time `json:"due_date"`
+	AssignedTo  *int       `json:"assigned_to"`
 }
 
 type TaskUpdateRequest struct {
-	Title       *string     `json:"title"`
-	Description *string     `json:"description"`
-	ParentId    *int        `json:"parent_id"`
-	StoryPoints *int        `json:"story_points"`
-	Priority    *string     `json:"priority"`
-	Status      *TaskStatus `json:"status"`
-	AssignedTo  *int        `json:"assigned_to"`
+	Title        *string     `json:"title"`
+	Description  *string     `json:"description"`
+	ParentId     *int        `json:"parent_id"`
+	StoryPoints  *int        `json:"story_points"`
+	Priority     *string     `json:"priority"`
+	Status       *TaskStatus `json:"status"`
+	DueDate      *time.Time  `json:"due_date"`
+	ClearDueDate bool        `json:"clear_due_date" binding:"excluded_with=DueDate"` // Снять срок задачи
+	AssignedTo   *int        `json:"assigned_to"`
 }
 
 type TaskResponse struct {
@@ -40,6 +43,7 @@ type TaskResponse struct {
 	StoryPoints *int       `json:"story_points,omitempty"`
 	Priority    *string    `json:"priority,omitempty"`
 	Status      TaskStatus `json:"status"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	AssignedTo  *int       `json:"assigned_to,omitempty"`
 }
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+)
+
+// TemplateCreateRequest запрос на сохранение события как шаблона
+type TemplateCreateRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// EventCopyRequest запрос на создание события из шаблона или копии события. Даты события и сроки задач
+// сдвигаются так, чтобы событие начиналось в start_date. Пустое название берется из шаблона
+type EventCopyRequest struct {
+	StartDate time.Time `json:"start_date" binding:"required"`
+	Title     string    `json:"title"`
+}
+
+type TemplateResponse struct {
+	Id                int          `json:"id"`
+	Name              string       `json:"name"`
+	SourceEventId     *int         `json:"source_event_id,omitempty"`
+	Title             string       `json:"title"`
+	Duration          string       `json:"duration"` // Длительность события, например 3h0m0s
+	RecurrenceRule    *string      `json:"recurrence_rule,omitempty"`
+	TasksCount        int          `json:"tasks_count"`
+	ParticipantsCount int          `json:"participants_count"`
+	CategoriesCount   int          `json:"categories_count"`
+	TotalBudget       *model.Money `json:"total_budget,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+}
+
+type TemplatesResponse struct {
+	Templates []TemplateResponse `json:"templates"`
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+type AccessService interface {
+	CheckEvent(ctx context.Context, userId, eventId int, perm domain.Permission) error
+	CheckTask(ctx context.Context, userId, taskId int, perm domain.Permission) error
+	CheckExpense(ctx context.Context, userId, expenseId int, perm domain.Permission) error
+	CheckExpenseShare(ctx context.Context, userId, shareId int, perm domain.Permission) error
+	CheckParticipant(ctx context.Context, userId, eventId, eventPartId int, perm domain.Permission) error
+	CheckSettlement(ctx context.Context, userId, eventId, fromUserId, toUserId int) error
+}
+
+func getUserId(c *gin.Context) (int, error) {
+	return strconv.Atoi(c.GetHeader("X-User-Id"))
+}
+
+// handleAccessError отвечает клиенту по ошибке проверки прав на действие perm
+func handleAccessError(c *gin.Context, perm domain.Permission, err error) {
+	switch {
+	case errors.Is(err, model.ErrNotEventParticipant), errors.Is(err, model.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, domain.ErrorResponse{
+			Error:      "forbidden",
+			Message:    err.Error(),
+			Permission: perm,
+		})
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, model.ErrExpenseNotFound), errors.Is(err, model.ErrExpenseShareNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AttachmentService interface {
+	Upload(ctx context.Context, expenseId, userId int, fileName string, size int64, r io.Reader) (*domain.AttachmentResponse, error)
+	List(ctx context.Context, expenseId int) (domain.AttachmentsResponse, error)
+	Open(ctx context.Context, expenseId, attachmentId int) (model.Attachment, io.ReadCloser, error)
+	Delete(ctx context.Context, expenseId, attachmentId int) error
+}
+
+// ExpenseWriteChecker проверяет, что расход можно изменять
+type ExpenseWriteChecker interface {
+	CheckExpenseWritable(ctx context.Context, expenseId int) error
+}
+
+type AttachmentController struct {
+	service  AttachmentService
+	access   AccessService
+	expenses ExpenseWriteChecker
+	maxSize  int64
+	logger   *zap.SugaredLogger
+}
+
+func NewAttachment(service AttachmentService, access AccessService, expenses ExpenseWriteChecker, maxSize int64, logger *zap.SugaredLogger) AttachmentController {
+	return AttachmentController{
+		service:  service,
+		access:   access,
+		expenses: expenses,
+		maxSize:  maxSize,
+		logger:   logger,
+	}
+}
+
+// multipartOverhead запас на заголовки multipart-запроса сверх размера файла
+const multipartOverhead = 1 << 20
+
+// Upload godoc
+// @Summary Загрузить чек расхода
+// @Description Прикрепляет к расходу фото или PDF чека из поля file multipart-запроса.
+// @Description Допустимые типы: JPEG, PNG, GIF, WebP и PDF, тип определяется по содержимому файла.
+// @Description Размер ограничен настройкой ATTACHMENT_MAX_SIZE_MB. Доступно всем участникам события.
+// @Description Чеки расходов архивного события не изменяются
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param id path int true "ID расхода"
+// @Param file formData file true "Файл чека"
+// @Success 201 {object} domain.AttachmentResponse
+// @Failure 400 {object} map[string]string "Файл не передан или его тип не поддерживается"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Расход не найден"
+// @Failure 409 {object} map[string]string "Событие в архиве"
+// @Failure 413 {object} map[string]string "Файл слишком большой"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /expenses/{id}/attachments [post]
+func (h AttachmentController) Upload(c *gin.Context) {
+	expenseId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckExpense(c.Request.Context(), userId, expenseId, domain.PermissionUploadAttachment); err != nil {
+		handleAccessError(c, domain.PermissionUploadAttachment, err)
+		return
+	}
+
+	if !h.checkWritable(c, expenseId) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": model.ErrAttachmentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.logger.Errorw("Failed to open uploaded file", "error", err, "expenseId", expenseId)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.Upload(c.Request.Context(), expenseId, userId, header.Filename, header.Size, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrAttachmentTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, model.ErrInvalidAttachment):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// List godoc
+// @Summary Получить чеки расхода
+// @Description Возвращает список файлов, прикрепленных к расходу. Доступно всем участникам события
+// @Tags attachments
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param id path int true "ID расхода"
+// @Success 200 {object} domain.AttachmentsResponse
+// @Failure 400 {object} map[string]string "Некорректный ID расхода"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Расход не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /expenses/{id}/attachments [get]
+func (h AttachmentController) List(c *gin.Context) {
+	expenseId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckExpense(c.Request.Context(), userId, expenseId, domain.PermissionViewEvent); err != nil {
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
+
+	attachments, err := h.service.List(c.Request.Context(), expenseId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// Download godoc
+// @Summary Скачать чек расхода
+// @Description Возвращает содержимое прикрепленного к расходу файла. Доступно всем участникам события
+// @Tags attachments
+// @Produce application/octet-stream
+// @Param X-User-Id header string true "ID пользователя"
+// @Param id path int true "ID расхода"
+// @Param attachment_id path int true "ID файла"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Файл не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /expenses/{id}/attachments/{attachment_id} [get]
+func (h AttachmentController) Download(c *gin.Context) {
+	expenseId, attachmentId, userId, ok := h.parseAttachmentRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.access.CheckExpense(c.Request.Context(), userId, expenseId, domain.PermissionViewEvent); err != nil {
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
+
+	attachment, file, err := h.service.Open(c.Request.Context(), expenseId, attachmentId)
+	if err != nil {
+		handleAttachmentError(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, file, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// Delete godoc
+// @Summary Удалить чек расхода
+// @Description Удаляет прикрепленный к расходу файл. Доступно автору расхода, организатору и администраторам события
+// @Tags attachments
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param id path int true "ID расхода"
+// @Param attachment_id path int true "ID файла"
+// @Success 204 "Файл удален"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Файл не найден"
+// @Failure 409 {object} map[string]string "Событие в архиве"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /expenses/{id}/attachments/{attachment_id} [delete]
+func (h AttachmentController) Delete(c *gin.Context) {
+	expenseId, attachmentId, userId, ok := h.parseAttachmentRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.access.CheckExpense(c.Request.Context(), userId, expenseId, domain.PermissionUpdateExpense); err != nil {
+		handleAccessError(c, domain.PermissionUpdateExpense, err)
+		return
+	}
+
+	if !h.checkWritable(c, expenseId) {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), expenseId, attachmentId); err != nil {
+		handleAttachmentError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// checkWritable отвечает 409, если событие расхода в архиве и его чеки доступны только для чтения
+func (h AttachmentController) checkWritable(c *gin.Context, expenseId int) bool {
+	if err := h.expenses.CheckExpenseWritable(c.Request.Context(), expenseId); err != nil {
+		if errors.Is(err, model.ErrEventArchived) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return false
+		}
+		h.logger.Errorw("Failed to check expense", "error", err, "expenseId", expenseId)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	return true
+}
+
+func (h AttachmentController) parseAttachmentRequest(c *gin.Context) (int, int, int, bool) {
+	expenseId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return 0, 0, 0, false
+	}
+
+	attachmentId, err := strconv.Atoi(c.Param("attachment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment id"})
+		return 0, 0, 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, 0, false
+	}
+
+	return expenseId, attachmentId, userId, true
+}
+
+func handleAttachmentError(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrAttachmentNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "attachment not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,395 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type BudgetService interface {
+	GetReport(ctx context.Context, eventId int) (domain.BudgetReportResponse, error)
+	SetBudget(ctx context.Context, eventId int, req domain.BudgetUpdateRequest) (domain.BudgetReportResponse, error)
+	ListCategories(ctx context.Context, eventId int) (domain.CategoriesResponse, error)
+	CreateCategory(ctx context.Context, eventId int, req domain.CategoryRequest) (*domain.CategoryResponse, error)
+	UpdateCategory(ctx context.Context, eventId, categoryId int, req domain.CategoryRequest) (*domain.CategoryResponse, error)
+	DeleteCategory(ctx context.Context, eventId, categoryId int) error
+}
+
+type RecurringExpenseService interface {
+	Create(ctx context.Context, eventId, userId int, req domain.RecurringExpenseCreateRequest) (*domain.RecurringExpenseResponse, error)
+	List(ctx context.Context, eventId int) (domain.RecurringExpensesResponse, error)
+	Delete(ctx context.Context, eventId, recurringId int) error
+}
+
+type BudgetController struct {
+	service   BudgetService
+	recurring RecurringExpenseService
+	access    AccessService
+	logger    *zap.SugaredLogger
+}
+
+func NewBudget(service BudgetService, recurring RecurringExpenseService, access AccessService, logger *zap.SugaredLogger) BudgetController {
+	return BudgetController{
+		service:   service,
+		recurring: recurring,
+		access:    access,
+		logger:    logger,
+	}
+}
+
+// GetBudget godoc
+// @Summary Получить бюджет события
+// @Description Возвращает общий лимит и лимиты категорий в сравнении с фактическими расходами в базовой валюте события
+// @Tags budget
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Success 200 {object} domain.BudgetReportResponse
+// @Failure 400 {object} map[string]string "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/budget [get]
+func (h BudgetController) GetBudget(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionViewEvent)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetReport(c.Request.Context(), eventId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// UpdateBudget godoc
+// @Summary Изменить общий лимит расходов события
+// @Description Задает общий лимит расходов в базовой валюте события, пустой total_budget снимает лимит.
+// @Description Если расходы уже превышают новый лимит, организатор и администраторы получают уведомление.
+// @Description Доступно организатору и администраторам события
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.BudgetUpdateRequest true "Лимит расходов"
+// @Success 200 {object} domain.BudgetReportResponse
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/budget [put]
+func (h BudgetController) UpdateBudget(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionManageBudget)
+	if !ok {
+		return
+	}
+
+	var req domain.BudgetUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.SetBudget(c.Request.Context(), eventId, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListCategories godoc
+// @Summary Получить категории расходов события
+// @Tags budget
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Success 200 {object} domain.CategoriesResponse
+// @Failure 400 {object} map[string]string "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/categories [get]
+func (h BudgetController) ListCategories(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionViewEvent)
+	if !ok {
+		return
+	}
+
+	categories, err := h.service.ListCategories(c.Request.Context(), eventId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, categories)
+}
+
+// CreateCategory godoc
+// @Summary Создать категорию расходов
+// @Description Создает категорию расходов события с необязательным лимитом в базовой валюте события.
+// @Description Доступно организатору и администраторам события
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.CategoryRequest true "Данные категории"
+// @Success 201 {object} domain.CategoryResponse
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} map[string]string "Категория с таким названием уже есть"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/categories [post]
+func (h BudgetController) CreateCategory(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionManageBudget)
+	if !ok {
+		return
+	}
+
+	var req domain.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.CreateCategory(c.Request.Context(), eventId, req)
+	if err != nil {
+		handleBudgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory godoc
+// @Summary Изменить категорию расходов
+// @Description Меняет название и лимит категории, пустой budget снимает лимит.
+// @Description Доступно организатору и администраторам события
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param category_id path int true "ID категории"
+// @Param request body domain.CategoryRequest true "Данные категории"
+// @Success 200 {object} domain.CategoryResponse
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Категория не найдена"
+// @Failure 409 {object} map[string]string "Категория с таким названием уже есть"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/categories/{category_id} [put]
+func (h BudgetController) UpdateCategory(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionManageBudget)
+	if !ok {
+		return
+	}
+
+	categoryId, err := strconv.Atoi(c.Param("category_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	var req domain.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.UpdateCategory(c.Request.Context(), eventId, categoryId, req)
+	if err != nil {
+		handleBudgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary Удалить категорию расходов
+// @Description Удаляет категорию, ее расходы остаются без категории. Доступно организатору и администраторам события
+// @Tags budget
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param category_id path int true "ID категории"
+// @Success 204 "Категория удалена"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Категория не найдена"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/categories/{category_id} [delete]
+func (h BudgetController) DeleteCategory(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionManageBudget)
+	if !ok {
+		return
+	}
+
+	categoryId, err := strconv.Atoi(c.Param("category_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid category id"})
+		return
+	}
+
+	if err := h.service.DeleteCategory(c.Request.Context(), eventId, categoryId); err != nil {
+		handleBudgetError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListRecurring godoc
+// @Summary Получить повторяющиеся расходы события
+// @Tags budget
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Success 200 {object} domain.RecurringExpensesResponse
+// @Failure 400 {object} map[string]string "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/recurring-expenses [get]
+func (h BudgetController) ListRecurring(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionViewEvent)
+	if !ok {
+		return
+	}
+
+	recurring, err := h.recurring.List(c.Request.Context(), eventId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recurring)
+}
+
+// CreateRecurring godoc
+// @Summary Создать повторяющийся расход
+// @Description Создает правило, по которому расход автоматически добавляется каждый день или неделю в пределах
+// @Description дат события. Расходы за уже наступившие даты создаются сразу, остальные — планировщиком в свой день.
+// @Description Автором расходов считается создатель правила. Доступно организатору и администраторам события
+// @Tags budget
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.RecurringExpenseCreateRequest true "Данные повторяющегося расхода"
+// @Success 201 {object} domain.RecurringExpenseResponse
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} map[string]string "Событие в архиве"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/recurring-expenses [post]
+func (h BudgetController) CreateRecurring(c *gin.Context) {
+	eventId, userId, ok := h.authorize(c, domain.PermissionManageBudget)
+	if !ok {
+		return
+	}
+
+	var req domain.RecurringExpenseCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recurring, err := h.recurring.Create(c.Request.Context(), eventId, userId, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidRecurrence) || errors.Is(err, model.ErrInvalidExpenseSplit) ||
+			errors.Is(err, model.ErrExchangeRateNotFound) || errors.Is(err, model.ErrCategoryNotFound):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, model.ErrEventArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, recurring)
+}
+
+// DeleteRecurring godoc
+// @Summary Остановить повторяющийся расход
+// @Description Удаляет правило повторяющегося расхода, уже созданные по нему расходы сохраняются.
+// @Description Доступно организатору и администраторам события
+// @Tags budget
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param recurring_id path int true "ID повторяющегося расхода"
+// @Success 204 "Повторяющийся расход удален"
+// @Failure 400 {object} map[string]string "Некорректный ID"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Повторяющийся расход не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/recurring-expenses/{recurring_id} [delete]
+func (h BudgetController) DeleteRecurring(c *gin.Context) {
+	eventId, _, ok := h.authorize(c, domain.PermissionManageBudget)
+	if !ok {
+		return
+	}
+
+	recurringId, err := strconv.Atoi(c.Param("recurring_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid recurring expense id"})
+		return
+	}
+
+	if err := h.recurring.Delete(c.Request.Context(), eventId, recurringId); err != nil {
+		if errors.Is(err, model.ErrRecurringExpenseNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "recurring expense not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// authorize разбирает ID события и пользователя и проверяет право пользователя в событии
+func (h BudgetController) authorize(c *gin.Context, perm domain.Permission) (int, int, bool) {
+	eventId, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return 0, 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, perm); err != nil {
+		h.logger.Warnw("Budget action not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, perm, err)
+		return 0, 0, false
+	}
+
+	return eventId, userId, true
+}
+
+func handleBudgetError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "expense category not found"})
+	case errors.Is(err, model.ErrCategoryExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CalendarService interface {
+	ExportEvent(ctx context.Context, eventId int, w io.Writer) error
+	Feed(ctx context.Context, token string, w io.Writer) error
+	FeedLink(ctx context.Context, userId int) (*domain.CalendarFeedResponse, error)
+	RotateFeedLink(ctx context.Context, userId int) (*domain.CalendarFeedResponse, error)
+}
+
+type CalendarController struct {
+	service CalendarService
+	access  AccessService
+	logger  *zap.SugaredLogger
+}
+
+func NewCalendar(service CalendarService, access AccessService, logger *zap.SugaredLogger) CalendarController {
+	return CalendarController{
+		service: service,
+		access:  access,
+		logger:  logger,
+	}
+}
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// ExportEvent godoc
+// @Summary Выгрузить событие в календарь
+// @Description Возвращает событие в формате iCalendar (.ics) для импорта в календарь. Серия выгружается
+// @Description правилом повторения: отмененные повторения исключаются, измененные записываются отдельно.
+// @Description Задачи события со сроком выгружаются как VTODO. UID событий и задач постоянны, поэтому
+// @Description повторный импорт обновляет записи. Доступно всем участникам события
+// @Tags events
+// @Produce text/calendar
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
+// @Failure 404 {object} map[string]string "Событие не найдено"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/ics [get]
+func (h CalendarController) ExportEvent(c *gin.Context) {
+	eventId, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionViewEvent); err != nil {
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if err := h.service.ExportEvent(c.Request.Context(), eventId, buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileName := fmt.Sprintf("event-%d.ics", eventId)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	c.Data(http.StatusOK, calendarContentType, buf.Bytes())
+}
+
+// Feed godoc
+// @Summary Лента календаря пользователя
+// @Description Возвращает в формате iCalendar все события, в которых владелец токена подтвердил участие
+// @Description и занимает место, и сроки задач этих событий. Приглашения без ответа, отказы и лист ожидания
+// @Description в ленту не попадают. Ссылка открывается без авторизации и предназначена для подписки
+// @Description в календарных приложениях; окончание .ics в токене необязательно
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Токен календаря"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string "Токен не найден или перевыпущен"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /calendar/{token} [get]
+func (h CalendarController) Feed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	buf := &bytes.Buffer{}
+	if err := h.service.Feed(c.Request.Context(), token, buf); err != nil {
+		if errors.Is(err, model.ErrCalendarTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": model.ErrCalendarTokenNotFound.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Токен в ссылке заменяет авторизацию, поэтому ответ не должен оседать в общих кэшах
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, calendarContentType, buf.Bytes())
+}
+
+// FeedLink godoc
+// @Summary Ссылка на календарь пользователя
+// @Description Возвращает ссылку для подписки на календарь текущего пользователя. При первом запросе
+// @Description выпускается токен, дальше возвращается та же ссылка до перевыпуска
+// @Tags calendar
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Success 200 {object} domain.CalendarFeedResponse
+// @Failure 400 {object} map[string]string "Некорректный ID пользователя"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /users/me/calendar [get]
+func (h CalendarController) FeedLink(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	resp, err := h.service.FeedLink(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// RotateFeedLink godoc
+// @Summary Перевыпустить ссылку на календарь
+// @Description Выпускает новый токен календаря текущего пользователя. Прежняя ссылка перестает открываться,
+// @Description подписки на нее нужно заменить
+// @Tags calendar
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Success 200 {object} domain.CalendarFeedResponse
+// @Failure 400 {object} map[string]string "Некорректный ID пользователя"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /users/me/calendar/token [post]
+func (h CalendarController) RotateFeedLink(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	resp, err := h.service.RotateFeedLink(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -2,44 +2,58 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"go.uber.org/zap"
 )
 
+// immutableEventFields поля события, которые нельзя изменить запросом на обновление.
+// Статус меняется отдельными переходами, базовая валюта — основа уже пересчитанных расходов
+var immutableEventFields = []string{"id", "created_by", "organizer_id", "base_currency", "status", "created_at", "updated_at"}
+
 type EventCommonService interface {
 	GetEventSummary(ctx context.Context, eventId int) (*domain.EventData, error)
 }
 
 type EventService interface {
 	Create(ctx context.Context, userId int, req domain.EventCreateRequest) (int, error)
-	Update(ctx context.Context, id int, req domain.EventUpdateRequest) error
+	Update(ctx context.Context, id, userId int, req domain.EventUpdateRequest) (*domain.EventResponse, error)
+	ChangeStatus(ctx context.Context, id int, req domain.EventStatusRequest) (*domain.EventResponse, error)
 	Delete(ctx context.Context, id int) error
-	List(ctx context.Context, page, size int) (*domain.EventsResponse, error)
-	ListByOrganizer(ctx context.Context, organizerId int, page, size int) (*domain.EventsResponse, error)
-	ListByParticipant(ctx context.Context, participantId int, page, size int) (*domain.EventsResponse, error)
+	List(ctx context.Context, viewerId *int, req domain.EventListRequest) (*domain.EventsResponse, error)
+	ListOccurrences(ctx context.Context, id int, req domain.OccurrencesRequest) (domain.OccurrencesResponse, error)
+	UpdateOccurrence(ctx context.Context, id, userId int, req domain.OccurrenceUpdateRequest) (*domain.EventResponse, error)
+	CancelOccurrence(ctx context.Context, id, userId int, req domain.OccurrenceCancelRequest) (*domain.EventResponse, error)
 }
 
 type EventController struct {
 	commonService EventCommonService
 	service       EventService
+	access        AccessService
 	logger        *zap.SugaredLogger
 }
 
-func NewEvent(commonService EventCommonService, service EventService, logger *zap.SugaredLogger) EventController {
+func NewEvent(commonService EventCommonService, service EventService, access AccessService, logger *zap.SugaredLogger) EventController {
 	return EventController{
 		commonService: commonService,
 		service:       service,
+		access:        access,
 		logger:        logger,
 	}
 }
 
 // Create godoc
 // @Summary Создать новое событие
-// @Description Создает новое событие с указанным пользователем в качестве организатора
+// @Description Создает новое событие с указанным пользователем в качестве организатора.
+// @Description Если передано правило recurrence_rule (RRULE RFC 5545), создается серия повторяющихся событий,
+// @Description а даты события задают первое повторение
 // @Tags events
 // @Accept json
 // @Produce json
@@ -67,6 +81,10 @@ func (h *EventController) Create(c *gin.Context) {
 
 	eventId, err := h.service.Create(c.Request.Context(), id, req)
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidEventRecurrence) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		h.logger.Errorw("Failed to create event", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -75,14 +93,156 @@ func (h *EventController) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"id": eventId})
 }
 
+// Update godoc
+// @Summary Изменить событие
+// @Description Изменяет переданные поля события. В запросе передается version из ответа на получение события:
+// @Description если событие с тех пор изменили, возвращается 409 и событие нужно получить заново.
+// @Description Дата окончания должна быть позже даты начала. Поля id, created_by, organizer_id, base_currency,
+// @Description status, created_at и updated_at не изменяются. Участники события получают уведомление
+// @Description со списком измененных полей. У серии изменяется первое повторение, а сдвиг дат переносится
+// @Description на все повторения. Доступно организатору и администраторам события
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.EventUpdateRequest true "Изменяемые поля события"
+// @Success 200 {object} domain.EventResponse "Событие с новой версией"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Событие не найдено"
+// @Failure 409 {object} map[string]interface{} "Событие изменено другим запросом или находится в архиве"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id} [put]
+func (h *EventController) Update(c *gin.Context) {
+	idStr := c.Param("event_id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event ID", "error", err, "id", idStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event ID"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, id, domain.PermissionUpdateEvent); err != nil {
+		h.logger.Warnw("Event update not permitted", "error", err, "id", id, "user_id", userId)
+		handleAccessError(c, domain.PermissionUpdateEvent, err)
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := c.ShouldBindBodyWith(&fields, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for _, field := range immutableEventFields {
+		if _, ok := fields[field]; ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "field " + field + " cannot be changed"})
+			return
+		}
+	}
+
+	var req domain.EventUpdateRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.service.Update(c.Request.Context(), id, userId, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrEventVersionConflict), errors.Is(err, model.ErrEventArchived):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, model.ErrInvalidEventDates), errors.Is(err, model.ErrInvalidEventRecurrence):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Errorw("Failed to update event", "error", err, "id", id)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// ChangeStatus godoc
+// @Summary Изменить статус события
+// @Description Переводит событие в другой статус. Допустимые переходы: draft → planned, cancelled;
+// @Description planned → draft, active, cancelled; active → completed, cancelled; completed → archived;
+// @Description cancelled → archived. Запланированные события становятся активными в дату начала,
+// @Description а активные — прошедшими в дату окончания автоматически. Архивное событие доступно только для чтения:
+// @Description его нельзя изменить и в него нельзя добавить расходы. Доступно организатору и администраторам события
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.EventStatusRequest true "Новый статус"
+// @Success 200 {object} domain.EventResponse "Событие в новом статусе"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Событие не найдено"
+// @Failure 409 {object} map[string]interface{} "Переход недопустим или статус уже изменен другим запросом"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/status [post]
+func (h *EventController) ChangeStatus(c *gin.Context) {
+	idStr := c.Param("event_id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event ID", "error", err, "id", idStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event ID"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, id, domain.PermissionUpdateEvent); err != nil {
+		h.logger.Warnw("Event status change not permitted", "error", err, "id", id, "user_id", userId)
+		handleAccessError(c, domain.PermissionUpdateEvent, err)
+		return
+	}
+
+	var req domain.EventStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.service.ChangeStatus(c.Request.Context(), id, req)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidEventTransition) || errors.Is(err, model.ErrEventVersionConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Errorw("Failed to change event status", "error", err, "id", id)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
 // Delete godoc
 // @Summary Удалить событие
-// @Description Удаляет событие по ID
+// @Description Удаляет событие по ID. Доступно только организатору
 // @Tags events
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
 // @Param event_id path int true "ID события"
 // @Success 200 "Операция успешна"
 // @Failure 400 {object} map[string]interface{} "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /events/{event_id} [delete]
 func (h *EventController) Delete(c *gin.Context) {
@@ -94,6 +254,19 @@ func (h *EventController) Delete(c *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, id, domain.PermissionDeleteEvent); err != nil {
+		h.logger.Warnw("Event delete not permitted", "error", err, "id", id, "user_id", userId)
+		handleAccessError(c, domain.PermissionDeleteEvent, err)
+		return
+	}
+
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
 		h.logger.Errorw("Failed to delete event", "error", err, "id", id)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -105,18 +278,38 @@ func (h *EventController) Delete(c *gin.Context) {
 
 // List godoc
 // @Summary Получить список событий
-// @Description Возвращает список событий с пагинацией, может фильтровать по участию пользователя
+// @Description Ищет события по словам из названия, описания и места проведения с учетом словоформ
+// @Description русского и английского языков. Фильтр по датам выбирает события, которые хотя бы частично идут
+// @Description в заданном диапазоне. Статусов можно передать несколько. Если передан X-User-Id, выбираются
+// @Description только события, в которых участвует пользователь. По умолчанию события с поисковой фразой
+// @Description упорядочены по релевантности, без нее — по дате начала от поздних к ранним. Если заданы обе даты,
+// @Description серии повторяющихся событий разворачиваются в повторения внутри диапазона, пагинация считает серии.
+// @Description Следующая страница запрашивается по next_cursor с той же сортировкой, курсор другой сортировки отклоняется
+// @Description с ошибкой 400; page и size оставлены для постраничной навигации без курсора
 // @Tags events
 // @Produce json
-// @Param page query int false "Номер страницы (по умолчанию: 1)"
-// @Param size query int false "Размер страницы (по умолчанию: 10)"
 // @Param X-User-Id header string false "ID пользователя для фильтрации по участию"
+// @Param q query string false "Поисковая фраза"
+// @Param date_from query string false "События, идущие в этот день или позже (YYYY-MM-DD)"
+// @Param date_to query string false "События, идущие в этот день или раньше (YYYY-MM-DD)"
+// @Param status query []string false "Статусы события" collectionFormat(multi) Enums(draft, planned, active, completed, cancelled, archived)
+// @Param organizer_id query int false "ID организатора"
+// @Param participant_id query int false "ID участника"
+// @Param sort query string false "Сортировка" Enums(relevance, start_date_desc, start_date_asc, created_at_desc, created_at_asc, title_asc)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param limit query int false "Размер страницы (по умолчанию: 10, максимум: 100)"
+// @Param page query int false "Номер страницы при навигации без курсора"
+// @Param size query int false "Устаревший синоним limit"
 // @Success 200 {object} domain.EventsResponse "Список событий"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации или некорректный курсор"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /events [get]
 func (h *EventController) List(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	var req domain.EventListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	userIDStr := c.GetHeader("X-User-Id")
 	var userID *int
@@ -129,29 +322,190 @@ func (h *EventController) List(c *gin.Context) {
 		}
 	}
 
-	if page < 1 {
-		page = 1
+	events, err := h.service.List(c.Request.Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Errorw("Failed to list events", "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-	if size < 1 {
-		size = 10
+
+	c.JSON(http.StatusOK, events)
+}
+
+// ListOccurrences godoc
+// @Summary Получить повторения серии
+// @Description Разворачивает серию повторяющихся событий в повторения, которые идут в заданном окне хотя бы частично.
+// @Description Измененные повторения возвращаются с изменениями и признаком modified, отмененные пропускаются.
+// @Description Возвращается не больше 500 повторений. Обычное событие возвращается как единственное повторение
+// @Tags events
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param from query string true "Начало окна (YYYY-MM-DD)"
+// @Param to query string true "Окончание окна включительно (YYYY-MM-DD)"
+// @Success 200 {object} domain.OccurrencesResponse "Повторения серии"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/occurrences [get]
+func (h *EventController) ListOccurrences(c *gin.Context) {
+	id, userId, ok := h.parseEventRequest(c)
+	if !ok {
+		return
 	}
 
-	var events *domain.EventsResponse
-	var err error
+	if err := h.access.CheckEvent(c.Request.Context(), userId, id, domain.PermissionViewEvent); err != nil {
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
 
-	if userID != nil {
-		events, err = h.service.ListByParticipant(c.Request.Context(), *userID, page, size)
-	} else {
-		events, err = h.service.List(c.Request.Context(), page, size)
+	var req domain.OccurrencesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.To.Before(req.From) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
 	}
 
+	occurrences, err := h.service.ListOccurrences(c.Request.Context(), id, req)
 	if err != nil {
-		h.logger.Errorw("Failed to list events", "error", err, "page", page, "size", size, "user_id", userID)
+		h.logger.Errorw("Failed to list event occurrences", "error", err, "id", id)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	c.JSON(http.StatusOK, occurrences)
+}
+
+// UpdateOccurrence godoc
+// @Summary Изменить повторение серии
+// @Description Изменяет повторение серии, начинающееся по правилу в occurrence_date. scope this изменяет только
+// @Description это повторение, all — всю серию, following — это и следующие повторения: серия заканчивается перед
+// @Description повторением, а начиная с него создается новая серия с теми же участниками, расходы и задачи остаются
+// @Description в прежней. При following и all новое время повторения сдвигает все затронутые повторения, а
+// @Description recurrence_rule заменяет правило. Передается version серии, при расхождении возвращается 409.
+// @Description Доступно организатору и администраторам события
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID серии"
+// @Param request body domain.OccurrenceUpdateRequest true "Изменения повторения"
+// @Success 200 {object} domain.EventResponse "Измененное повторение, серия или новая серия"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации или событие не повторяется"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Повторение не найдено"
+// @Failure 409 {object} map[string]interface{} "Серия изменена другим запросом или находится в архиве"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/occurrences [put]
+func (h *EventController) UpdateOccurrence(c *gin.Context) {
+	id, userId, ok := h.parseEventRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, id, domain.PermissionUpdateEvent); err != nil {
+		handleAccessError(c, domain.PermissionUpdateEvent, err)
+		return
+	}
+
+	var req domain.OccurrenceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.service.UpdateOccurrence(c.Request.Context(), id, userId, req)
+	if err != nil {
+		handleOccurrenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// CancelOccurrence godoc
+// @Summary Отменить повторение серии
+// @Description Отменяет повторение серии, начинающееся по правилу в occurrence_date (scope this), или все повторения
+// @Description начиная с него (scope following): тогда серия заканчивается перед этим повторением. Отменить так
+// @Description все повторения нельзя — для этого меняется статус события. Передается version серии.
+// @Description Доступно организатору и администраторам события
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID серии"
+// @Param request body domain.OccurrenceCancelRequest true "Отменяемое повторение"
+// @Success 200 {object} domain.EventResponse "Серия с новой версией"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации или событие не повторяется"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Повторение не найдено"
+// @Failure 409 {object} map[string]interface{} "Серия изменена другим запросом или находится в архиве"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/occurrences/cancel [post]
+func (h *EventController) CancelOccurrence(c *gin.Context) {
+	id, userId, ok := h.parseEventRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, id, domain.PermissionUpdateEvent); err != nil {
+		handleAccessError(c, domain.PermissionUpdateEvent, err)
+		return
+	}
+
+	var req domain.OccurrenceCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.service.CancelOccurrence(c.Request.Context(), id, userId, req)
+	if err != nil {
+		handleOccurrenceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+func (h *EventController) parseEventRequest(c *gin.Context) (int, int, bool) {
+	idStr := c.Param("event_id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event ID", "error", err, "id", idStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event ID"})
+		return 0, 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	return id, userId, true
+}
+
+func handleOccurrenceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrEventVersionConflict), errors.Is(err, model.ErrEventArchived):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrOccurrenceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrInvalidEventDates), errors.Is(err, model.ErrInvalidEventRecurrence),
+		errors.Is(err, model.ErrEventNotRecurring):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 // EventSummary godoc
@@ -159,9 +513,11 @@ func (h *EventController) List(c *gin.Context) {
 // @Description Возвращает детальную информацию о событии, включая список участников и задач
 // @Tags events
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
 // @Param event_id path int true "ID события"
 // @Success 200 {object} domain.EventData "Детальная информация о событии"
 // @Failure 400 {object} map[string]interface{} "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /events/{event_id} [get]
 func (h *EventController) EventSummary(c *gin.Context) {
@@ -173,6 +529,19 @@ func (h *EventController) EventSummary(c *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionViewEvent); err != nil {
+		h.logger.Warnw("Event view not permitted", "error", err, "event_id", eventId, "user_id", userId)
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
+
 	summary, err := h.commonService.GetEventSummary(c.Request.Context(), eventId)
 	if err != nil {
 		h.logger.Errorw("Failed to get event summary", "error", err, "event_id", eventId)
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ExchangeRateService interface {
+	List(ctx context.Context) (domain.ExchangeRatesResponse, error)
+	Update(ctx context.Context, req domain.ExchangeRatesUpdateRequest) error
+}
+
+type ExchangeRateController struct {
+	service ExchangeRateService
+	logger  *zap.SugaredLogger
+}
+
+func NewExchangeRate(service ExchangeRateService, logger *zap.SugaredLogger) ExchangeRateController {
+	return ExchangeRateController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List godoc
+// @Summary Получить курсы валют
+// @Description Возвращает курсы, по которым расходы пересчитываются в базовую валюту события
+// @Tags exchange-rates
+// @Produce json
+// @Success 200 {object} domain.ExchangeRatesResponse "Курсы валют"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /exchange-rates [get]
+func (h *ExchangeRateController) List(c *gin.Context) {
+	rates, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.logger.Errorw("Failed to list exchange rates", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rates)
+}
+
+// Update godoc
+// @Summary Загрузить курсы валют
+// @Description Добавляет или перезаписывает курсы валют. Доступ ограничивается администраторами на уровне api-gateway.
+// @Description Курс не влияет на уже созданные расходы: у них сохранен курс на момент создания
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param request body domain.ExchangeRatesUpdateRequest true "Курсы валют"
+// @Success 200 {object} map[string]interface{} "Курсы обновлены"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /exchange-rates [put]
+func (h *ExchangeRateController) Update(c *gin.Context) {
+	var req domain.ExchangeRatesUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Errorw("Failed to bind request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Update(c.Request.Context(), req); err != nil {
+		h.logger.Errorw("Failed to update exchange rates", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "exchange rates updated"})
+}
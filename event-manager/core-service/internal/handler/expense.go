@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -11,29 +12,42 @@ import (
 
 type ExpenseService interface {
 	CreateExpense(ctx context.Context, req domain.ExpenseCreateRequest) (int, error)
+	ListExpenses(ctx context.Context, req domain.ExpenseListRequest) (domain.ExpensesResponse, error)
+	UpdateExpense(ctx context.Context, id, userId int, req domain.ExpenseUpdateRequest) (*domain.ExpenseResponse, error)
+	GetExpenseHistory(ctx context.Context, expenseId int) (domain.ExpenseHistoryResponse, error)
 	UpdateExpenseSharePaidStatus(ctx context.Context, shareId int, isPaid bool) error
 	DeleteExpense(ctx context.Context, id int) error
+	GetUserBalances(ctx context.Context, userId int) (domain.UserBalancesResponse, error)
 }
 
 type ExpenseController struct {
 	service ExpenseService
+	access  AccessService
 }
 
-func NewExpenseController(service ExpenseService) ExpenseController {
+func NewExpenseController(service ExpenseService, access AccessService) ExpenseController {
 	return ExpenseController{
 		service: service,
+		access:  access,
 	}
 }
 
 // Create godoc
 // @Summary Create a new expense
-// @Description Create a new expense with participants
+// @Description Create a new expense with participants. split_method equal splits evenly between user_ids,
+// @Description percent, exact and shares take per-user weights from shares (percentages summing to 100,
+// @Description exact amounts summing to the expense amount, or share units). The amount is converted to the
+// @Description event base currency using the stored exchange rate, which is saved on the expense.
+// @Description Archived events do not accept new expenses
 // @Tags expenses
 // @Accept json
 // @Produce json
+// @Param X-User-Id header string true "User ID"
 // @Param expense body domain.ExpenseCreateRequest true "Expense creation data"
 // @Success 201 {object} map[string]int
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} domain.ErrorResponse
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /expenses [post]
 func (c ExpenseController) Create(ctx *gin.Context) {
@@ -50,11 +64,25 @@ func (c ExpenseController) Create(ctx *gin.Context) {
 		return
 	}
 
+	if err := c.access.CheckEvent(ctx, id, req.EventID, domain.PermissionCreateExpense); err != nil {
+		handleAccessError(ctx, domain.PermissionCreateExpense, err)
+		return
+	}
+
 	// Устанавливаем создателя расхода
 	req.CreatedBy = id
 
 	expenseId, err := c.service.CreateExpense(ctx, req)
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidExpenseSplit) || errors.Is(err, model.ErrExchangeRateNotFound) ||
+			errors.Is(err, model.ErrCategoryNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, model.ErrEventArchived) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -62,15 +90,208 @@ func (c ExpenseController) Create(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, gin.H{"id": expenseId})
 }
 
+// List godoc
+// @Summary List event expenses
+// @Description List expenses of an event with shares. Filters: author, participant with a share, currency, creation
+// @Description date range (inclusive, YYYY-MM-DD) and paid status — of the participant's share when participant_id is set,
+// @Description otherwise of all expense shares. Pagination is cursor based: pass next_cursor from the previous page
+// @Description with the same sort, a cursor issued for another sort is rejected with 400.
+// @Description total_count counts all expenses matching the filters. Available to all event participants
+// @Tags expenses
+// @Produce json
+// @Param X-User-Id header string true "User ID"
+// @Param event_id query int true "Event ID"
+// @Param created_by query int false "Expense author"
+// @Param participant_id query int false "User with a share in the expense"
+// @Param category_id query int false "Expense category"
+// @Param currency query string false "Expense currency"
+// @Param date_from query string false "Created on or after date (YYYY-MM-DD)"
+// @Param date_to query string false "Created on or before date (YYYY-MM-DD)"
+// @Param status query string false "Paid status" Enums(paid, unpaid)
+// @Param sort query string false "Sort order (default created_at_desc)" Enums(created_at_desc, created_at_asc, amount_desc, amount_asc)
+// @Param cursor query string false "Cursor of the next page"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Success 200 {object} domain.ExpensesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} domain.ErrorResponse
+// @Failure 500 {object} map[string]string
+// @Router /expenses [get]
+func (c ExpenseController) List(ctx *gin.Context) {
+	var req domain.ExpenseListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := getUserId(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := c.access.CheckEvent(ctx, userId, req.EventID, domain.PermissionViewEvent); err != nil {
+		handleAccessError(ctx, domain.PermissionViewEvent, err)
+		return
+	}
+
+	resp, err := c.service.ListExpenses(ctx, req)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// Update godoc
+// @Summary Update expense
+// @Description Update description, amount, currency, split method or participants of an expense. Shares are recalculated
+// @Description when the amount, currency, split method, user_ids or shares change; without user_ids and shares the current
+// @Description participants and weights are reused. A paid share keeps its status while its base amount is unchanged,
+// @Description an edit that reduces or removes a paid share is rejected with 409. The exchange rate stored on the expense
+// @Description is kept unless the currency changes. Every change is recorded in the expense history.
+// @Description Allowed to the expense author, event organizer and admins
+// @Tags expenses
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "User ID"
+// @Param id path int true "Expense ID"
+// @Param expense body domain.ExpenseUpdateRequest true "Expense update data"
+// @Success 200 {object} domain.ExpenseResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} domain.ErrorResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /expenses/{id} [put]
+func (c ExpenseController) Update(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	var req domain.ExpenseUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userId, err := getUserId(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := c.access.CheckExpense(ctx, userId, id, domain.PermissionUpdateExpense); err != nil {
+		handleAccessError(ctx, domain.PermissionUpdateExpense, err)
+		return
+	}
+
+	resp, err := c.service.UpdateExpense(ctx, id, userId, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrExpenseNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "expense not found"})
+		case errors.Is(err, model.ErrPaidShareReduced), errors.Is(err, model.ErrEventArchived):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, model.ErrInvalidExpenseSplit) || errors.Is(err, model.ErrExchangeRateNotFound) ||
+			errors.Is(err, model.ErrCategoryNotFound):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// History godoc
+// @Summary Get expense history
+// @Description Get the change log of an expense, newest first. Available to all event participants
+// @Tags expenses
+// @Produce json
+// @Param X-User-Id header string true "User ID"
+// @Param id path int true "Expense ID"
+// @Success 200 {object} domain.ExpenseHistoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} domain.ErrorResponse
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /expenses/{id}/history [get]
+func (c ExpenseController) History(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid expense id"})
+		return
+	}
+
+	userId, err := getUserId(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := c.access.CheckExpense(ctx, userId, id, domain.PermissionViewEvent); err != nil {
+		handleAccessError(ctx, domain.PermissionViewEvent, err)
+		return
+	}
+
+	resp, err := c.service.GetExpenseHistory(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UserBalances godoc
+// @Summary Get current user balances
+// @Description Get how much the current user owes and is owed across all events they participate in,
+// @Description grouped by event and by counterparty. Amounts of each event are in its base currency and totals
+// @Description are given per currency. balance is positive when the user is owed money and includes settlements
+// @Description that are not matched to any share
+// @Tags expenses
+// @Produce json
+// @Param X-User-Id header string true "User ID"
+// @Success 200 {object} domain.UserBalancesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/me/balances [get]
+func (c ExpenseController) UserBalances(ctx *gin.Context) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	resp, err := c.service.GetUserBalances(ctx, userId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
 // Delete godoc
 // @Summary Delete expense
-// @Description Delete an existing expense
+// @Description Delete an existing expense. Allowed to the expense author, event organizer and admins
 // @Tags expenses
 // @Produce json
+// @Param X-User-Id header string true "User ID"
 // @Param id path int true "Expense ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} domain.ErrorResponse
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /expenses/{id} [delete]
 func (c ExpenseController) Delete(ctx *gin.Context) {
@@ -80,11 +301,26 @@ func (c ExpenseController) Delete(ctx *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := c.access.CheckExpense(ctx, userId, id, domain.PermissionDeleteExpense); err != nil {
+		handleAccessError(ctx, domain.PermissionDeleteExpense, err)
+		return
+	}
+
 	if err := c.service.DeleteExpense(ctx, id); err != nil {
-		if errors.Is(err, errors.New("expense not found")) {
+		if errors.Is(err, model.ErrExpenseNotFound) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "expense not found"})
 			return
 		}
+		if errors.Is(err, model.ErrEventArchived) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -94,14 +330,18 @@ func (c ExpenseController) Delete(ctx *gin.Context) {
 
 // UpdateExpenseSharePaidStatus godoc
 // @Summary Update expense share paid status
-// @Description Update the paid status of an expense share
+// @Description Update the paid status of an expense share. Allowed to the expense author, event organizer and admins
 // @Tags expenses
 // @Accept json
 // @Produce json
+// @Param X-User-Id header string true "User ID"
 // @Param id path int true "Share ID"
 // @Param request body domain.ExpenseShareUpdateRequest true "Paid status update data"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} domain.ErrorResponse
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /expenses/shares/{id}/paid-status [put]
 func (c ExpenseController) UpdateExpenseSharePaidStatus(ctx *gin.Context) {
@@ -117,7 +357,22 @@ func (c ExpenseController) UpdateExpenseSharePaidStatus(ctx *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := c.access.CheckExpenseShare(ctx, userId, id, domain.PermissionUpdateExpenseShare); err != nil {
+		handleAccessError(ctx, domain.PermissionUpdateExpenseShare, err)
+		return
+	}
+
 	if err := c.service.UpdateExpenseSharePaidStatus(ctx, id, req.IsPaid); err != nil {
+		if errors.Is(err, model.ErrEventArchived) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
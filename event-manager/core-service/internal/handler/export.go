@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ExportService interface {
+	Export(ctx context.Context, eventId int, format, lang string, w io.Writer) error
+}
+
+type ExportController struct {
+	service ExportService
+	access  AccessService
+	logger  *zap.SugaredLogger
+}
+
+func NewExport(service ExportService, access AccessService, logger *zap.SugaredLogger) ExportController {
+	return ExportController{
+		service: service,
+		access:  access,
+		logger:  logger,
+	}
+}
+
+// exportContentTypes типы содержимого форматов выгрузки
+var exportContentTypes = map[string]string{
+	domain.ExportFormatCSV:  "text/csv; charset=utf-8",
+	domain.ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportExpenses godoc
+// @Summary Выгрузить расходы события
+// @Description Выгружает расходы события, их доли и балансы участников в CSV или XLSX. Файл формируется потоком
+// @Description по мере чтения расходов. В XLSX разделы записываются на отдельные листы, в CSV — друг за другом
+// @Description через пустую строку. Суммы записываются с точностью валюты; в русской выгрузке CSV поля разделяются
+// @Description точкой с запятой, а дробная часть — запятой. Язык заголовков задается параметром lang, без него —
+// @Description заголовком Accept-Language. Доступно всем участникам события
+// @Tags expenses
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param format query string false "Формат файла: csv (по умолчанию) или xlsx"
+// @Param lang query string false "Язык заголовков: ru или en"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
+// @Failure 404 {object} map[string]string "Событие не найдено"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/expenses/export [get]
+func (h ExportController) ExportExpenses(c *gin.Context) {
+	eventId, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req domain.ExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = domain.ExportFormatCSV
+	}
+	if req.Lang == "" {
+		req.Lang = exportLang(c.GetHeader("Accept-Language"))
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionViewEvent); err != nil {
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
+
+	w := &exportWriter{
+		c:           c,
+		contentType: exportContentTypes[req.Format],
+		fileName:    fmt.Sprintf("event-%d-expenses.%s", eventId, req.Format),
+	}
+	if err := h.service.Export(c.Request.Context(), eventId, req.Format, req.Lang, w); err != nil {
+		if !w.started {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// Часть файла уже отправлена, статус изменить нельзя: клиент получит оборванный файл
+		h.logger.Errorw("Expense export interrupted", "error", err, "eventId", eventId)
+	}
+}
+
+// exportLang выбирает язык выгрузки по заголовку Accept-Language: первый из русского и английского в списке,
+// по умолчанию русский
+func exportLang(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch {
+		case strings.HasPrefix(strings.ToLower(tag), domain.ExportLangRu):
+			return domain.ExportLangRu
+		case strings.HasPrefix(strings.ToLower(tag), domain.ExportLangEn):
+			return domain.ExportLangEn
+		}
+	}
+
+	return domain.ExportLangRu
+}
+
+// exportWriter отправляет заголовки файла при первой записи, чтобы до нее можно было ответить ошибкой в JSON
+type exportWriter struct {
+	c           *gin.Context
+	contentType string
+	fileName    string
+	started     bool
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": w.fileName}))
+		w.c.Header("X-Content-Type-Options", "nosniff")
+		w.c.Status(http.StatusOK)
+	}
+
+	return w.c.Writer.Write(p)
+}
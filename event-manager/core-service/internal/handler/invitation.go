@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type InvitationService interface {
+	GetInvitation(ctx context.Context, token string) (*domain.InvitationPreviewResponse, error)
+	AcceptInvitation(ctx context.Context, token string) (*domain.InvitationAnswerResponse, error)
+	DeclineInvitation(ctx context.Context, token string) (*domain.InvitationAnswerResponse, error)
+	ListInvitations(ctx context.Context, eventId int) (*domain.InvitationsResponse, error)
+	ResendInvitation(ctx context.Context, eventId, id int) (*domain.InvitationResponse, error)
+	RevokeInvitation(ctx context.Context, eventId, id int) error
+	ClaimEmailInvitations(ctx context.Context, userId int, token string) (*domain.EventParticipantsResponse, error)
+}
+
+type InvitationController struct {
+	service InvitationService
+	access  AccessService
+	logger  *zap.SugaredLogger
+}
+
+func NewInvitation(service InvitationService, access AccessService, logger *zap.SugaredLogger) InvitationController {
+	return InvitationController{
+		service: service,
+		access:  access,
+		logger:  logger,
+	}
+}
+
+// answerPage страница подтверждения ответа на приглашение. Форма отправляет POST на адрес страницы
+var answerPage = template.Must(template.New("answer").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head><meta charset="utf-8"><title>Приглашение в событие</title></head>
+<body>
+<h1>{{.Invitation.EventName}}</h1>
+<p>Начало: {{.Invitation.StartDate.Format "02.01.2006 15:04"}} UTC</p>
+{{if .Invitation.WaitlistPosition}}<p>Место в листе ожидания: {{.Invitation.WaitlistPosition}}</p>{{end}}
+<form method="post"><button type="submit">{{.Action}}</button></form>
+</body>
+</html>
+`))
+
+// AcceptPage godoc
+// @Summary Страница принятия приглашения
+// @Description Показывает событие приглашения и кнопку подтверждения. Ссылка из письма ничего не меняет:
+// @Description ее могут открыть почтовые сканеры, участие подтверждается POST-запросом с этой страницы
+// @Tags invitations
+// @Produce html
+// @Param token path string true "Подписанный токен приглашения"
+// @Success 200 {string} string "Страница подтверждения"
+// @Failure 404 {object} map[string]string "Приглашение не найдено, уже принято или отклонено"
+// @Failure 410 {object} map[string]string "Срок действия приглашения истек"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /invitations/{token}/accept [get]
+func (h InvitationController) AcceptPage(c *gin.Context) {
+	h.renderAnswerPage(c, "Принять приглашение")
+}
+
+// DeclinePage godoc
+// @Summary Страница отказа от приглашения
+// @Description Показывает событие приглашения и кнопку отказа. Ссылка из письма ничего не меняет:
+// @Description отказ отправляется POST-запросом с этой страницы
+// @Tags invitations
+// @Produce html
+// @Param token path string true "Подписанный токен приглашения"
+// @Success 200 {string} string "Страница подтверждения"
+// @Failure 404 {object} map[string]string "Приглашение не найдено, уже принято или отклонено"
+// @Failure 410 {object} map[string]string "Срок действия приглашения истек"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /invitations/{token}/decline [get]
+func (h InvitationController) DeclinePage(c *gin.Context) {
+	h.renderAnswerPage(c, "Отказаться от участия")
+}
+
+func (h InvitationController) renderAnswerPage(c *gin.Context, action string) {
+	invitation, err := h.service.GetInvitation(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		handleInvitationError(c, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = answerPage.Execute(&buf, map[string]any{"Invitation": invitation, "Action": action})
+	if err != nil {
+		h.logger.Errorw("Failed to render invitation page", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// Accept godoc
+// @Summary Принять приглашение по ссылке
+// @Description Подтверждает участие по подписанному токену из письма с приглашением. Запрос отправляет
+// @Description страница подтверждения без авторизации. Токен действует до первого ответа, истечения срока
+// @Description или повторной отправки приглашения. Если мест нет, участник остается в листе ожидания
+// @Tags invitations
+// @Produce json
+// @Param token path string true "Подписанный токен приглашения"
+// @Success 200 {object} domain.InvitationAnswerResponse
+// @Failure 404 {object} map[string]string "Приглашение не найдено, уже принято или отклонено"
+// @Failure 410 {object} map[string]string "Срок действия приглашения истек"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /invitations/{token}/accept [post]
+func (h InvitationController) Accept(c *gin.Context) {
+	resp, err := h.service.AcceptInvitation(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		handleInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Decline godoc
+// @Summary Отклонить приглашение по ссылке
+// @Description Отклоняет участие по подписанному токену из письма с приглашением. Запрос отправляет
+// @Description страница подтверждения без авторизации. Освободившееся место занимает первый участник
+// @Description из листа ожидания
+// @Tags invitations
+// @Produce json
+// @Param token path string true "Подписанный токен приглашения"
+// @Success 200 {object} domain.InvitationAnswerResponse
+// @Failure 404 {object} map[string]string "Приглашение не найдено, уже принято или отклонено"
+// @Failure 410 {object} map[string]string "Срок действия приглашения истек"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /invitations/{token}/decline [post]
+func (h InvitationController) Decline(c *gin.Context) {
+	resp, err := h.service.DeclineInvitation(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		handleInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// List godoc
+// @Summary Приглашения события
+// @Description Возвращает приглашения события, ожидающие ответа, в порядке отправки. Приглашения
+// @Description с истекшим сроком отмечаются expired и могут быть отправлены повторно. Доступно организатору
+// @Description и администраторам события
+// @Tags invitations
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Success 200 {object} domain.InvitationsResponse
+// @Failure 400 {object} map[string]string "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/invitations [get]
+func (h InvitationController) List(c *gin.Context) {
+	eventId, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionManageParticipants); err != nil {
+		handleAccessError(c, domain.PermissionManageParticipants, err)
+		return
+	}
+
+	resp, err := h.service.ListInvitations(c.Request.Context(), eventId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Resend godoc
+// @Summary Отправить приглашение повторно
+// @Description Отправляет приглашение, ожидающее ответа, повторно с новым сроком действия. Ссылки
+// @Description из прежних писем перестают действовать. Доступно организатору и администраторам события
+// @Tags invitations
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param event_part_id path int true "ID участия в событии"
+// @Success 200 {object} domain.InvitationResponse
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Приглашение не найдено или на него уже ответили"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/invitations/{event_part_id}/resend [post]
+func (h InvitationController) Resend(c *gin.Context) {
+	eventId, id, ok := h.parseManageRequest(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ResendInvitation(c.Request.Context(), eventId, id)
+	if err != nil {
+		handleInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Revoke godoc
+// @Summary Отозвать приглашение
+// @Description Отзывает приглашение, ожидающее ответа: приглашенный удаляется из события, а закрепленное
+// @Description за ним место занимает первый участник из листа ожидания. Доступно организатору
+// @Description и администраторам события
+// @Tags invitations
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param event_part_id path int true "ID участия в событии"
+// @Success 204 "Приглашение отозвано"
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Приглашение не найдено или на него уже ответили"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/invitations/{event_part_id} [delete]
+func (h InvitationController) Revoke(c *gin.Context) {
+	eventId, id, ok := h.parseManageRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RevokeInvitation(c.Request.Context(), eventId, id); err != nil {
+		handleInvitationError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Claim godoc
+// @Summary Получить приглашения, отправленные на адрес пользователя
+// @Description Превращает приглашения, отправленные на адрес до регистрации пользователя, в обычные
+// @Description приглашения участника: на них отвечают по ссылкам из писем. Адрес подтверждается токеном
+// @Description из письма с приглашением. Приглашения в завершенные события удаляются без добавления
+// @Tags invitations
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param request body domain.InvitationClaimRequest true "Токен из письма"
+// @Success 200 {object} domain.EventParticipantsResponse "Участия, ожидающие ответа на приглашение"
+// @Failure 400 {object} map[string]string "Некорректный ID пользователя или запрос"
+// @Failure 404 {object} map[string]string "Недействительный токен"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /users/me/invitations/claim [post]
+func (h InvitationController) Claim(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var req domain.InvitationClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.ClaimEmailInvitations(c.Request.Context(), userId, req.Token)
+	if err != nil {
+		handleInvitationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseManageRequest разбирает ID события и участия и проверяет право управлять участниками события
+func (h InvitationController) parseManageRequest(c *gin.Context) (int, int, bool) {
+	eventId, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return 0, 0, false
+	}
+
+	id, err := strconv.Atoi(c.Param("event_part_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event participant id"})
+		return 0, 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	// Приглашенный сам не может отозвать или переотправить свое приглашение, поэтому права
+	// проверяются на событие, а не на участие
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionManageParticipants); err != nil {
+		handleAccessError(c, domain.PermissionManageParticipants, err)
+		return 0, 0, false
+	}
+
+	return eventId, id, true
+}
+
+func handleInvitationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": model.ErrInvitationNotFound.Error()})
+	case errors.Is(err, model.ErrInvitationExpired):
+		c.JSON(http.StatusGone, gin.H{"error": model.ErrInvitationExpired.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
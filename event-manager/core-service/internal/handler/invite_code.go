@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type InviteCodeService interface {
+	CreateInviteCode(ctx context.Context, eventId, userId int, req domain.InviteCodeCreateRequest) (*domain.InviteCodeResponse, error)
+	ListInviteCodes(ctx context.Context, eventId int) (*domain.InviteCodesResponse, error)
+	RevokeInviteCode(ctx context.Context, eventId, id int) error
+	JoinByCode(ctx context.Context, userId int, code string) (*domain.EventParticipantResponse, error)
+}
+
+type InviteCodeController struct {
+	service InviteCodeService
+	access  AccessService
+	logger  *zap.SugaredLogger
+}
+
+func NewInviteCode(service InviteCodeService, access AccessService, logger *zap.SugaredLogger) InviteCodeController {
+	return InviteCodeController{
+		service: service,
+		access:  access,
+		logger:  logger,
+	}
+}
+
+// Create godoc
+// @Summary Создать код приглашения
+// @Description Выпускает код, по которому в событие может вступить любой зарегистрированный пользователь
+// @Description с указанной ролью. Код можно ограничить сроком действия и числом вступлений. Доступно
+// @Description организатору и администраторам события, код с ролью admin — только организатору
+// @Tags invite-codes
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.InviteCodeCreateRequest true "Роль, ограничение числа вступлений и срок действия"
+// @Success 201 {object} domain.InviteCodeResponse
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/invite-codes [post]
+func (h InviteCodeController) Create(c *gin.Context) {
+	var req domain.InviteCodeCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	eventId, userId, ok := h.parseEventRequest(c, req.Permission())
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.CreateInviteCode(c.Request.Context(), eventId, userId, req)
+	if err != nil {
+		handleInviteCodeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// List godoc
+// @Summary Коды приглашения события
+// @Description Возвращает коды приглашения события, новые первыми, вместе с отозванными, истекшими
+// @Description и исчерпанными. Для каждого кода возвращается число вступлений, оставшиеся вступления
+// @Description и время последнего использования. Доступно организатору и администраторам события
+// @Tags invite-codes
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Success 200 {object} domain.InviteCodesResponse
+// @Failure 400 {object} map[string]string "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/invite-codes [get]
+func (h InviteCodeController) List(c *gin.Context) {
+	eventId, _, ok := h.parseEventRequest(c, domain.PermissionManageParticipants)
+	if !ok {
+		return
+	}
+
+	resp, err := h.service.ListInviteCodes(c.Request.Context(), eventId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Revoke godoc
+// @Summary Отозвать код приглашения
+// @Description Отзывает код приглашения: по нему больше нельзя вступить в событие. Вступившие по коду
+// @Description остаются участниками, статистика кода сохраняется. Доступно организатору и администраторам
+// @Description события
+// @Tags invite-codes
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param code_id path int true "ID кода приглашения"
+// @Success 204 "Код отозван"
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Код не найден или уже отозван"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/invite-codes/{code_id} [delete]
+func (h InviteCodeController) Revoke(c *gin.Context) {
+	eventId, _, ok := h.parseEventRequest(c, domain.PermissionManageParticipants)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("code_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite code id"})
+		return
+	}
+
+	if err := h.service.RevokeInviteCode(c.Request.Context(), eventId, id); err != nil {
+		handleInviteCodeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Join godoc
+// @Summary Вступить в событие по коду
+// @Description Добавляет текущего пользователя в событие по коду приглашения с ролью, заданной в коде.
+// @Description Участие сразу подтверждено. Если в событии нет свободных мест, пользователь попадает в лист
+// @Description ожидания, а в ответе возвращается его место в очереди. Код не учитывает регистр
+// @Tags invite-codes
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param code path string true "Код приглашения"
+// @Success 201 {object} domain.EventParticipantResponse "Информация о созданном участии"
+// @Failure 400 {object} map[string]string "Некорректный ID пользователя"
+// @Failure 404 {object} map[string]string "Код не найден или отозван"
+// @Failure 409 {object} map[string]string "Пользователь уже участник события"
+// @Failure 410 {object} map[string]string "Срок действия кода истек, лимит вступлений исчерпан или событие завершено"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/join/{code} [post]
+func (h InviteCodeController) Join(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	resp, err := h.service.JoinByCode(c.Request.Context(), userId, c.Param("code"))
+	if err != nil {
+		handleInviteCodeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// parseEventRequest разбирает ID события и пользователя и проверяет право perm на событие
+func (h InviteCodeController) parseEventRequest(c *gin.Context, perm domain.Permission) (int, int, bool) {
+	eventId, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return 0, 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, perm); err != nil {
+		handleAccessError(c, perm, err)
+		return 0, 0, false
+	}
+
+	return eventId, userId, true
+}
+
+func handleInviteCodeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidInviteCode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrInviteCodeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": model.ErrInviteCodeNotFound.Error()})
+	case errors.Is(err, model.ErrUserAlreadyAnParticipant):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrInviteCodeExpired), errors.Is(err, model.ErrInviteCodeExhausted):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
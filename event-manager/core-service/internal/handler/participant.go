@@ -2,7 +2,9 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"net/http"
 	"strconv"
 
@@ -11,35 +13,49 @@ import (
 )
 
 type ParticipantService interface {
-	ListByUser(ctx context.Context, userId int, page, size int) (*domain.EventParticipantsResponse, error)
+	ListByEvent(ctx context.Context, eventId int, req domain.ParticipantListRequest) (*domain.EventParticipantsResponse, error)
+	ListByUser(ctx context.Context, userId int, req domain.ParticipantListRequest) (*domain.EventParticipantsResponse, error)
 	Create(ctx context.Context, eventID int, req domain.EventParticipantCreateRequest) (*domain.EventParticipantResponse, error)
-	Delete(ctx context.Context, id int) error
+	Delete(ctx context.Context, eventId, id, userId int, req domain.ParticipantRemovalRequest) (*domain.ParticipantRemovalResponse, error)
 	ConfirmParticipation(ctx context.Context, id int) error
 	DeclineParticipation(ctx context.Context, id int) error
+	ChangeRole(ctx context.Context, eventId, id int, req domain.EventParticipantUpdateRequest) (*domain.EventParticipantResponse, error)
+	TransferOwnership(ctx context.Context, eventId int, req domain.OwnershipTransferRequest) error
+	ImportParticipants(ctx context.Context, eventId, invitedBy int, req domain.ParticipantImportRequest) (*domain.ParticipantImportResponse, error)
 }
 
 type ParticipantController struct {
 	service ParticipantService
+	access  AccessService
 	logger  *zap.SugaredLogger
 }
 
-func NewParticipantHandler(service ParticipantService, logger *zap.SugaredLogger) ParticipantController {
+func NewParticipantHandler(service ParticipantService, access AccessService, logger *zap.SugaredLogger) ParticipantController {
 	return ParticipantController{
 		service: service,
+		access:  access,
 		logger:  logger,
 	}
 }
 
 // Create godoc
 // @Summary Добавить участника в событие
-// @Description Добавляет нового участника в событие
+// @Description Приглашает пользователя в событие: участник создается неподтвержденным, а на его почту
+// @Description отправляется письмо со ссылками для принятия и отклонения приглашения. Пока приглашение
+// @Description не отклонено, место за участником сохраняется. Если в событии задано ограничение числа
+// @Description участников и свободных мест нет, участник попадает в лист ожидания, а в ответе возвращается
+// @Description его место в очереди
 // @Tags participants
 // @Accept json
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
 // @Param event_id path int true "ID события"
 // @Param request body domain.EventParticipantCreateRequest true "Данные для добавления участника (необходимо указать либо user_id, либо username)"
 // @Success 201 {object} domain.EventParticipantResponse "Информация о созданном участии"
 // @Failure 400 {object} map[string]interface{} "Ошибка валидации или некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Пользователь не найден"
+// @Failure 409 {object} map[string]interface{} "Пользователь уже участвует в событии или событие в архиве"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /events/{event_id}/participants [post]
 func (h *ParticipantController) Create(c *gin.Context) {
@@ -51,6 +67,19 @@ func (h *ParticipantController) Create(c *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionManageParticipants); err != nil {
+		h.logger.Warnw("Participant creation not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, domain.PermissionManageParticipants, err)
+		return
+	}
+
 	var req domain.EventParticipantCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Errorw("Failed to bind request", "error", err)
@@ -68,7 +97,7 @@ func (h *ParticipantController) Create(c *gin.Context) {
 	participant, err := h.service.Create(c.Request.Context(), eventId, req)
 	if err != nil {
 		h.logger.Errorw("Failed to create event participant", "error", err, "eventId", eventId)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleParticipantError(c, err)
 		return
 	}
 
@@ -77,66 +106,178 @@ func (h *ParticipantController) Create(c *gin.Context) {
 
 // Delete godoc
 // @Summary Удалить участника из события
-// @Description Удаляет участника из события
+// @Description Удаляет участника из события. Участник может покинуть событие сам. Освободившееся место
+// @Description занимает первый участник из листа ожидания. Организатора удалить нельзя: сначала он должен
+// @Description передать событие другому участнику.
+// @Description Незавершенные задачи участника остаются без исполнителя (tasks=unassign) или переходят
+// @Description к участнику reassign_to (tasks=reassign). Неоплаченные доли участника в чужих расходах остаются
+// @Description за ним (shares=keep) или делятся между неоплаченными долями остальных участников расхода
+// @Description (shares=redistribute). Передавать задачи и делить долги может только управляющий участниками
+// @Description Участник исключается из повторяющихся расходов события, а созданные им правила останавливаются
 // @Tags participants
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
 // @Param event_part_id path int true "ID участия в событии"
-// @Success 204 "Участник успешно удален"
-// @Failure 400 {object} map[string]interface{} "Некорректный ID участия"
+// @Param tasks query string false "Что сделать с задачами: unassign (по умолчанию) или reassign"
+// @Param reassign_to query int false "ID пользователя, которому передаются задачи"
+// @Param shares query string false "Что сделать с долгами: keep (по умолчанию) или redistribute"
+// @Success 200 {object} domain.ParticipantRemovalResponse "Участник удален, изменения задач и долей"
+// @Failure 400 {object} map[string]interface{} "Некорректный ID участия или политика удаления"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Участие не найдено"
+// @Failure 409 {object} map[string]interface{} "Организатор не может покинуть событие"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
-// @Router /events/participants/{event_part_id} [delete]
+// @Router /events/{event_id}/participants/{event_part_id} [delete]
 func (h *ParticipantController) Delete(c *gin.Context) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return
+	}
+
 	eventPartIdStr := c.Param("event_part_id")
 	eventPartId, err := strconv.Atoi(eventPartIdStr)
 	if err != nil {
-		h.logger.Errorw("Invalid event Id", "error", err, "id", eventPartId)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		h.logger.Errorw("Invalid event participant Id", "error", err, "id", eventPartIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event participant Id"})
 		return
 	}
 
-	if err := h.service.Delete(c.Request.Context(), eventPartId); err != nil {
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckParticipant(c.Request.Context(), userId, eventId, eventPartId, domain.PermissionManageParticipants); err != nil {
+		h.logger.Warnw("Participant deletion not permitted", "error", err, "eventPartId", eventPartId, "userId", userId)
+		handleAccessError(c, domain.PermissionManageParticipants, err)
+		return
+	}
+
+	var req domain.ParticipantRemovalRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Покидая событие сам, участник не может переложить на других свои задачи и долги
+	if req.Tasks == domain.RemovalTasksReassign || req.ReassignTo != nil || req.Shares == domain.RemovalSharesRedistribute {
+		if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionManageParticipants); err != nil {
+			h.logger.Warnw("Participant removal policy not permitted", "error", err, "eventPartId", eventPartId, "userId", userId)
+			handleAccessError(c, domain.PermissionManageParticipants, err)
+			return
+		}
+	}
+
+	resp, err := h.service.Delete(c.Request.Context(), eventId, eventPartId, userId, req)
+	if err != nil {
 		h.logger.Errorw("Failed to delete event participant", "error", err, "eventPartId", eventPartId)
+		handleParticipantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListByEvent godoc
+// @Summary Получить участников события
+// @Description Возвращает участников события с данными пользователей. Участников можно отфильтровать по ролям,
+// @Description состоянию участия (confirmed — подтвердил и занимает место, pending — не ответил на приглашение,
+// @Description declined — отказался, waitlisted — в листе ожидания) и части имени пользователя
+// @Tags participants
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param role query []string false "Роли участников" collectionFormat(multi) Enums(organizer, admin, participant)
+// @Param status query string false "Состояние участия" Enums(confirmed, pending, declined, waitlisted)
+// @Param q query string false "Часть имени пользователя"
+// @Param page query int false "Номер страницы (по умолчанию: 1)"
+// @Param size query int false "Размер страницы (по умолчанию: 10, не больше 100)"
+// @Success 200 {object} domain.EventParticipantsResponse "Страница участников и их общее количество"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} domain.ErrorResponse "Событие не найдено"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/participants [get]
+func (h *ParticipantController) ListByEvent(c *gin.Context) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	var req domain.ParticipantListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Errorw("Failed to bind participant list request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionViewEvent); err != nil {
+		h.logger.Warnw("Participant list not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
+
+	participants, err := h.service.ListByEvent(c.Request.Context(), eventId, req)
+	if err != nil {
+		h.logger.Errorw("Failed to list event participants", "error", err, "eventId", eventId)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, participants)
 }
 
 // ListByUser godoc
-// @Summary Получить список событий пользователя
-// @Description Возвращает список событий, в которых пользователь является участником
+// @Summary Получить участия текущего пользователя
+// @Description Возвращает события, в которых участвует текущий пользователь: роль, состояние участия
+// @Description и название события. Фильтры те же, что у списка участников события, кроме поиска по имени
 // @Tags participants
 // @Produce json
 // @Param X-User-Id header string true "ID пользователя"
+// @Param role query []string false "Роли пользователя в событиях" collectionFormat(multi) Enums(organizer, admin, participant)
+// @Param status query string false "Состояние участия" Enums(confirmed, pending, declined, waitlisted)
 // @Param page query int false "Номер страницы (по умолчанию: 1)"
-// @Param size query int false "Размер страницы (по умолчанию: 10)"
-// @Success 200 {object} domain.EventParticipantsResponse "Список участий в событиях"
-// @Failure 400 {object} map[string]interface{} "Некорректный ID пользователя"
+// @Param size query int false "Размер страницы (по умолчанию: 10, не больше 100)"
+// @Success 200 {object} domain.EventParticipantsResponse "Страница участий и их общее количество"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
-// @Router /participants/user [get]
+// @Router /users/me/participations [get]
 func (h *ParticipantController) ListByUser(c *gin.Context) {
-	userIdStr := c.GetHeader("X-User-Id")
-	userId, err := strconv.Atoi(userIdStr)
+	userId, err := getUserId(c)
 	if err != nil {
-		h.logger.Errorw("Invalid user Id", "error", err, "id", userId)
+		h.logger.Errorw("Invalid user Id", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if size < 1 {
-		size = 10
+	var req domain.ParticipantListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Errorw("Failed to bind participation list request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
+	// Все участия принадлежат одному пользователю, искать по имени незачем
+	req.Query = ""
 
-	participants, err := h.service.ListByUser(c.Request.Context(), userId, page, size)
+	participants, err := h.service.ListByUser(c.Request.Context(), userId, req)
 	if err != nil {
-		h.logger.Errorw("Failed to list event participants", "error", err, "userId", userId, "page", page, "size", size)
+		h.logger.Errorw("Failed to list user participations", "error", err, "userId", userId)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -146,26 +287,28 @@ func (h *ParticipantController) ListByUser(c *gin.Context) {
 
 // ConfirmParticipation godoc
 // @Summary Подтвердить участие в событии
-// @Description Подтверждает участие пользователя в событии
+// @Description Принимает приглашение текущего пользователя в событие. Отказавшийся ранее участник, если
+// @Description свободных мест нет, попадает в конец листа ожидания. Доступно только самому участнику
 // @Tags participants
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
 // @Param event_part_id path int true "ID участия в событии"
 // @Success 204 "Участие успешно подтверждено"
 // @Failure 400 {object} map[string]interface{} "Некорректный ID участия"
+// @Failure 403 {object} domain.ErrorResponse "Участие принадлежит другому пользователю"
+// @Failure 404 {object} map[string]interface{} "Участие не найдено"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
-// @Router /events/participants/{event_part_id}/confirm [put]
+// @Router /events/{event_id}/participants/{event_part_id}/confirm [put]
 func (h *ParticipantController) ConfirmParticipation(c *gin.Context) {
-	eventPartIdStr := c.Param("event_part_id")
-	eventPartId, err := strconv.Atoi(eventPartIdStr)
-	if err != nil {
-		h.logger.Errorw("Invalid event participant Id", "error", err, "id", eventPartIdStr)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event participant Id"})
+	eventPartId, ok := h.parseAnswerRequest(c)
+	if !ok {
 		return
 	}
 
 	if err := h.service.ConfirmParticipation(c.Request.Context(), eventPartId); err != nil {
 		h.logger.Errorw("Failed to confirm participation", "error", err, "eventPartId", eventPartId)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleParticipantError(c, err)
 		return
 	}
 
@@ -174,15 +317,64 @@ func (h *ParticipantController) ConfirmParticipation(c *gin.Context) {
 
 // DeclineParticipation godoc
 // @Summary Отклонить участие в событии
-// @Description Отклоняет участие пользователя в событии
+// @Description Отклоняет приглашение текущего пользователя в событие. Освободившееся место занимает первый
+// @Description участник из листа ожидания. Доступно только самому участнику, организатор отказаться
+// @Description не может
 // @Tags participants
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
 // @Param event_part_id path int true "ID участия в событии"
 // @Success 204 "Участие успешно отклонено"
 // @Failure 400 {object} map[string]interface{} "Некорректный ID участия"
+// @Failure 403 {object} domain.ErrorResponse "Участие принадлежит другому пользователю"
+// @Failure 404 {object} map[string]interface{} "Участие не найдено"
+// @Failure 409 {object} map[string]interface{} "Организатор не может отказаться от участия"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
-// @Router /events/participants/{event_part_id}/decline [put]
+// @Router /events/{event_id}/participants/{event_part_id}/decline [put]
 func (h *ParticipantController) DeclineParticipation(c *gin.Context) {
+	eventPartId, ok := h.parseAnswerRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeclineParticipation(c.Request.Context(), eventPartId); err != nil {
+		h.logger.Errorw("Failed to decline participation", "error", err, "eventPartId", eventPartId)
+		handleParticipantError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangeRole godoc
+// @Summary Изменить роль участника
+// @Description Назначает участника администратором события или возвращает ему роль участника. Роль
+// @Description организатора передается только вместе с событием. Участник получает уведомление о новой
+// @Description роли. Доступно только организатору события
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param event_part_id path int true "ID участия в событии"
+// @Param request body domain.EventParticipantUpdateRequest true "Новая роль: participant или admin"
+// @Success 200 {object} domain.EventParticipantResponse "Участие с новой ролью"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Участие не найдено"
+// @Failure 409 {object} map[string]interface{} "Роль организатора меняется только передачей события или событие в архиве"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/participants/{event_part_id}/role [put]
+func (h *ParticipantController) ChangeRole(c *gin.Context) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return
+	}
+
 	eventPartIdStr := c.Param("event_part_id")
 	eventPartId, err := strconv.Atoi(eventPartIdStr)
 	if err != nil {
@@ -191,11 +383,134 @@ func (h *ParticipantController) DeclineParticipation(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.DeclineParticipation(c.Request.Context(), eventPartId); err != nil {
-		h.logger.Errorw("Failed to decline participation", "error", err, "eventPartId", eventPartId)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionChangeRole); err != nil {
+		h.logger.Warnw("Participant role change not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, domain.PermissionChangeRole, err)
+		return
+	}
+
+	var req domain.EventParticipantUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Errorw("Failed to bind request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	participant, err := h.service.ChangeRole(c.Request.Context(), eventId, eventPartId, req)
+	if err != nil {
+		handleParticipantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, participant)
+}
+
+// TransferOwnership godoc
+// @Summary Передать событие другому участнику
+// @Description Делает подтвердившего участие участника организатором события, а текущего организатора —
+// @Description администратором. Роли и организатор события меняются в одной транзакции, оба участника
+// @Description получают уведомление о новой роли. Доступно только организатору события
+// @Tags participants
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.OwnershipTransferRequest true "ID участия нового организатора"
+// @Success 204 "Событие передано"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Участие не найдено"
+// @Failure 409 {object} map[string]interface{} "Участник не подтвердил участие, находится в листе ожидания или событие в архиве"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/transfer [post]
+func (h *ParticipantController) TransferOwnership(c *gin.Context) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionTransferEvent); err != nil {
+		h.logger.Warnw("Event transfer not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, domain.PermissionTransferEvent, err)
+		return
+	}
+
+	var req domain.OwnershipTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Errorw("Failed to bind request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.TransferOwnership(c.Request.Context(), eventId, req); err != nil {
+		handleParticipantError(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// parseAnswerRequest разбирает ID участия и проверяет, что оно принадлежит текущему пользователю
+func (h *ParticipantController) parseAnswerRequest(c *gin.Context) (int, bool) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return 0, false
+	}
+
+	eventPartIdStr := c.Param("event_part_id")
+	eventPartId, err := strconv.Atoi(eventPartIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event participant Id", "error", err, "id", eventPartIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event participant Id"})
+		return 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return 0, false
+	}
+
+	if err := h.access.CheckParticipant(c.Request.Context(), userId, eventId, eventPartId, domain.PermissionAnswerInvitation); err != nil {
+		handleAccessError(c, domain.PermissionAnswerInvitation, err)
+		return 0, false
+	}
+
+	return eventPartId, true
+}
+
+func handleParticipantError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrNotEventParticipant), errors.Is(err, model.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrInvalidRemovalPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrOrganizerRoleChange), errors.Is(err, model.ErrOrganizerCannotLeave),
+		errors.Is(err, model.ErrInvalidOwnershipTransfer), errors.Is(err, model.ErrEventArchived),
+		errors.Is(err, model.ErrUserAlreadyAnParticipant):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxImportSize ограничение размера списка участников
+const maxImportSize = 1 << 20
+
+// Import godoc
+// @Summary Добавить участников списком
+// @Description Добавляет в событие пользователей из списка в одной транзакции. Список передается в JSON
+// @Description или в CSV (Content-Type: text/csv): с заголовком, содержащим колонки username и email,
+// @Description или по одному имени или адресу в первой колонке каждой строки. Найденные пользователи получают
+// @Description приглашение, как при добавлении по одному. Для адресов без пользователя сохраняется приглашение
+// @Description по адресу: зарегистрировавшись, пользователь получит обычное приглашение. Для каждой строки
+// @Description возвращается результат: added, already_participant, invited_by_email или invalid
+// @Tags participants
+// @Accept json
+// @Accept text/csv
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.ParticipantImportRequest true "Список пользователей, не больше 500 строк"
+// @Success 200 {object} domain.ParticipantImportResponse "Результаты по строкам"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации или некорректный CSV"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} map[string]interface{} "Событие в архиве"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/participants/import [post]
+func (h *ParticipantController) Import(c *gin.Context) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionManageParticipants); err != nil {
+		h.logger.Warnw("Participant import not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, domain.PermissionManageParticipants, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var req domain.ParticipantImportRequest
+	if c.ContentType() == "text/csv" {
+		req.Rows, err = parseParticipantCSV(c.Request.Body)
+		if err == nil {
+			err = binding.Validator.ValidateStruct(req)
+		}
+	} else {
+		err = c.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		h.logger.Errorw("Failed to bind participant import", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.ImportParticipants(c.Request.Context(), eventId, userId, req)
+	if err != nil {
+		handleParticipantError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseParticipantCSV разбирает список участников в CSV. Если первая строка — заголовок с колонками
+// username и email, значения берутся из них, иначе первая колонка каждой строки содержит имя или адрес
+func parseParticipantCSV(r io.Reader) ([]domain.ParticipantImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	// Таблицы, сохраненные в Excel, начинаются с BOM
+	records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+
+	usernameCol, emailCol := -1, -1
+	for i, cell := range records[0] {
+		switch strings.ToLower(strings.TrimSpace(cell)) {
+		case "username":
+			usernameCol = i
+		case "email":
+			emailCol = i
+		}
+	}
+
+	rows := make([]domain.ParticipantImportRow, 0, len(records))
+	if usernameCol < 0 && emailCol < 0 {
+		for _, record := range records {
+			value := strings.TrimSpace(record[0])
+			if strings.Contains(value, "@") {
+				rows = append(rows, domain.ParticipantImportRow{Email: value})
+			} else {
+				rows = append(rows, domain.ParticipantImportRow{Username: value})
+			}
+		}
+		return rows, nil
+	}
+
+	column := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	for _, record := range records[1:] {
+		rows = append(rows, domain.ParticipantImportRow{
+			Username: column(record, usernameCol),
+			Email:    column(record, emailCol),
+		})
+	}
+
+	return rows, nil
+}
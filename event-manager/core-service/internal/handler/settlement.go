@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SettlementService interface {
+	GetPlan(ctx context.Context, eventId int) (domain.SettlementPlanResponse, error)
+	Record(ctx context.Context, eventId, userId int, req domain.SettlementCreateRequest) (*domain.SettlementResponse, error)
+}
+
+type SettlementController struct {
+	service SettlementService
+	access  AccessService
+	logger  *zap.SugaredLogger
+}
+
+func NewSettlement(service SettlementService, access AccessService, logger *zap.SugaredLogger) SettlementController {
+	return SettlementController{
+		service: service,
+		access:  access,
+		logger:  logger,
+	}
+}
+
+// GetPlan godoc
+// @Summary Получить план взаиморасчетов
+// @Description Возвращает минимальный набор переводов, погашающий все долги участников события, и проведенные платежи
+// @Tags settlements
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Success 200 {object} domain.SettlementPlanResponse "План взаиморасчетов"
+// @Failure 400 {object} map[string]interface{} "Некорректный ID события"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/settlements [get]
+func (h *SettlementController) GetPlan(c *gin.Context) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, domain.PermissionViewEvent); err != nil {
+		h.logger.Warnw("Settlement plan not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, domain.PermissionViewEvent, err)
+		return
+	}
+
+	plan, err := h.service.GetPlan(c.Request.Context(), eventId)
+	if err != nil {
+		h.logger.Errorw("Failed to get settlement plan", "error", err, "eventId", eventId)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// Create godoc
+// @Summary Зарегистрировать платеж между участниками
+// @Description Регистрирует перевод от одного участника другому и отмечает оплаченными погашенные им доли расходов.
+// @Description Сторонами платежа могут быть и бывшие участники, за которыми остались долги или которым еще должны.
+// @Description Провести платеж могут его стороны, организатор или администратор события
+// @Tags settlements
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.SettlementCreateRequest true "Данные платежа"
+// @Success 201 {object} domain.SettlementResponse "Зарегистрированный платеж"
+// @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 409 {object} map[string]interface{} "Событие в архиве"
+// @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/settlements [post]
+func (h *SettlementController) Create(c *gin.Context) {
+	eventIdStr := c.Param("event_id")
+	eventId, err := strconv.Atoi(eventIdStr)
+	if err != nil {
+		h.logger.Errorw("Invalid event Id", "error", err, "id", eventIdStr)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event Id"})
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	var req domain.SettlementCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Errorw("Failed to bind request", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.access.CheckSettlement(c.Request.Context(), userId, eventId, req.FromUserID, req.ToUserID); err != nil {
+		h.logger.Warnw("Settlement not permitted", "error", err, "eventId", eventId, "userId", userId)
+		handleAccessError(c, domain.PermissionRecordSettlement, err)
+		return
+	}
+
+	settlement, err := h.service.Record(c.Request.Context(), eventId, userId, req)
+	if err != nil {
+		h.logger.Errorw("Failed to record settlement", "error", err, "eventId", eventId)
+		if errors.Is(err, model.ErrInvalidSettlement) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, model.ErrEventArchived) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, settlement)
+}
@@ -20,12 +20,14 @@ type TaskService interface {
 }
 type TaskController struct {
 	service TaskService
+	access  AccessService
 	logger  *zap.SugaredLogger
 }
 
-func NewTask(service TaskService, logger *zap.SugaredLogger) TaskController {
+func NewTask(service TaskService, access AccessService, logger *zap.SugaredLogger) TaskController {
 	return TaskController{
 		service: service,
+		access:  access,
 		logger:  logger,
 	}
 }
@@ -36,9 +38,11 @@ func NewTask(service TaskService, logger *zap.SugaredLogger) TaskController {
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
 // @Param request body domain.TaskCreateRequest true "Данные для создания задачи"
 // @Success 201 {object} map[string]interface{} "Возвращает ID созданной задачи"
 // @Failure 400 {object} map[string]interface{} "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /tasks [post]
 func (h *TaskController) Create(c *gin.Context) {
@@ -49,6 +53,19 @@ func (h *TaskController) Create(c *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, req.EventId, domain.PermissionCreateTask); err != nil {
+		h.logger.Warnw("Task creation not permitted", "error", err, "eventId", req.EventId, "userId", userId)
+		handleAccessError(c, domain.PermissionCreateTask, err)
+		return
+	}
+
 	task, err := h.service.Create(c.Request.Context(), req)
 	if err != nil {
 		h.logger.Errorw("Failed to create task", "error", err)
@@ -61,14 +78,18 @@ func (h *TaskController) Create(c *gin.Context) {
 
 // Update godoc
 // @Summary Обновить задачу
-// @Description Обновляет существующую задачу по ID
+// @Description Обновляет существующую задачу по ID. Срок задачи снимается флагом clear_due_date,
+// @Description который нельзя передавать вместе с due_date
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
 // @Param task_id path int true "ID задачи"
 // @Param request body domain.TaskUpdateRequest true "Данные для обновления задачи"
 // @Success 204 "Задача успешно обновлена"
 // @Failure 400 {object} map[string]interface{} "Ошибка валидации или некорректный ID задачи"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Задача не найдена"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /tasks/{task_id} [put]
 func (h *TaskController) Update(c *gin.Context) {
@@ -87,6 +108,19 @@ func (h *TaskController) Update(c *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckTask(c.Request.Context(), userId, id, domain.PermissionUpdateTask); err != nil {
+		h.logger.Warnw("Task update not permitted", "error", err, "id", id, "userId", userId)
+		handleAccessError(c, domain.PermissionUpdateTask, err)
+		return
+	}
+
 	if err := h.service.Update(c.Request.Context(), id, req); err != nil {
 		h.logger.Errorw("Failed to update task", "error", err, "id", id)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -101,9 +135,12 @@ func (h *TaskController) Update(c *gin.Context) {
 // @Description Удаляет задачу по ID
 // @Tags tasks
 // @Produce json
+// @Param X-User-Id header string true "ID пользователя"
 // @Param task_id path int true "ID задачи"
 // @Success 204 "Задача успешно удалена"
 // @Failure 400 {object} map[string]interface{} "Некорректный ID задачи"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]interface{} "Задача не найдена"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /tasks/{task_id} [delete]
 func (h *TaskController) Delete(c *gin.Context) {
@@ -115,6 +152,19 @@ func (h *TaskController) Delete(c *gin.Context) {
 		return
 	}
 
+	userId, err := getUserId(c)
+	if err != nil {
+		h.logger.Errorw("Invalid user Id", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+		return
+	}
+
+	if err := h.access.CheckTask(c.Request.Context(), userId, id, domain.PermissionDeleteTask); err != nil {
+		h.logger.Warnw("Task deletion not permitted", "error", err, "id", id, "userId", userId)
+		handleAccessError(c, domain.PermissionDeleteTask, err)
+		return
+	}
+
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
 		h.logger.Errorw("Failed to delete task", "error", err, "id", id)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -134,6 +184,7 @@ func (h *TaskController) Delete(c *gin.Context) {
 // @Param event_id query int false "ID события для фильтрации задач"
 // @Param X-User-Id header string false "ID пользователя для фильтрации задач"
 // @Success 200 {object} domain.TasksResponse "Список задач"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не является участником события"
 // @Failure 500 {object} map[string]interface{} "Внутренняя ошибка сервера"
 // @Router /tasks [get]
 func (h *TaskController) List(c *gin.Context) {
@@ -174,6 +225,17 @@ func (h *TaskController) List(c *gin.Context) {
 	var err error
 
 	if eventId != nil {
+		if userId == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user Id"})
+			return
+		}
+
+		if err := h.access.CheckEvent(c.Request.Context(), *userId, *eventId, domain.PermissionViewEvent); err != nil {
+			h.logger.Warnw("Task list not permitted", "error", err, "event_id", *eventId, "user_id", *userId)
+			handleAccessError(c, domain.PermissionViewEvent, err)
+			return
+		}
+
 		tasks, err = h.service.ListByEvent(c.Request.Context(), *eventId, page, size)
 	} else if userId != nil {
 		tasks, err = h.service.ListByUser(c.Request.Context(), *userId, page, size)
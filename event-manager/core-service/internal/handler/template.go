@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TemplateService interface {
+	Save(ctx context.Context, eventId, userId int, req domain.TemplateCreateRequest) (*domain.TemplateResponse, error)
+	List(ctx context.Context, userId int) (*domain.TemplatesResponse, error)
+	Delete(ctx context.Context, id, userId int) error
+	CreateEvent(ctx context.Context, id, userId int, req domain.EventCopyRequest) (int, error)
+	Clone(ctx context.Context, eventId, userId int, req domain.EventCopyRequest) (int, error)
+}
+
+type TemplateController struct {
+	service TemplateService
+	access  AccessService
+	logger  *zap.SugaredLogger
+}
+
+func NewTemplate(service TemplateService, access AccessService, logger *zap.SugaredLogger) TemplateController {
+	return TemplateController{
+		service: service,
+		access:  access,
+		logger:  logger,
+	}
+}
+
+// Save godoc
+// @Summary Сохранить событие как шаблон
+// @Description Сохраняет снимок события: название, описание, место, длительность и правило повторения, задачи
+// @Description с иерархией подзадач, оценками, приоритетами и сроками, роли участников, общий лимит и категории
+// @Description расходов. Шаблон доступен только сохранившему его пользователю. Доступно организатору
+// @Description и администраторам события
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.TemplateCreateRequest true "Название шаблона"
+// @Success 201 {object} domain.TemplateResponse
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Событие не найдено"
+// @Failure 409 {object} map[string]string "Шаблон с таким названием уже есть"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/templates [post]
+func (h TemplateController) Save(c *gin.Context) {
+	eventId, userId, ok := h.parseEventRequest(c, domain.PermissionUpdateEvent)
+	if !ok {
+		return
+	}
+
+	var req domain.TemplateCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.Save(c.Request.Context(), eventId, userId, req)
+	if err != nil {
+		handleTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// Clone godoc
+// @Summary Скопировать событие
+// @Description Создает копию события, начинающуюся в start_date: даты события, окончание серии и сроки задач
+// @Description сдвигаются на одну и ту же величину. Копируются задачи с иерархией, роли участников, общий лимит
+// @Description и категории расходов; расходы, назначения задач и статусы не копируются. Пользователь становится
+// @Description организатором копии, прежний организатор — администратором. Копия создается в одной транзакции,
+// @Description после чего участники получают приглашения и становятся участниками копии, приняв их.
+// @Description Доступно организатору и администраторам события
+// @Tags events
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param event_id path int true "ID события"
+// @Param request body domain.EventCopyRequest true "Начало копии и необязательное новое название"
+// @Success 201 {object} map[string]interface{} "Возвращает ID созданного события"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Событие не найдено"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /events/{event_id}/clone [post]
+func (h TemplateController) Clone(c *gin.Context) {
+	eventId, userId, ok := h.parseEventRequest(c, domain.PermissionUpdateEvent)
+	if !ok {
+		return
+	}
+
+	var req domain.EventCopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.service.Clone(c.Request.Context(), eventId, userId, req)
+	if err != nil {
+		handleTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// List godoc
+// @Summary Список шаблонов
+// @Description Возвращает шаблоны событий текущего пользователя, новые первыми
+// @Tags templates
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Success 200 {object} domain.TemplatesResponse
+// @Failure 400 {object} map[string]string "Некорректный ID пользователя"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /templates [get]
+func (h TemplateController) List(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	resp, err := h.service.List(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete godoc
+// @Summary Удалить шаблон
+// @Description Удаляет шаблон текущего пользователя. События, созданные из шаблона, не меняются
+// @Tags templates
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param template_id path int true "ID шаблона"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string "Некорректные параметры"
+// @Failure 404 {object} map[string]string "Шаблон не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /templates/{template_id} [delete]
+func (h TemplateController) Delete(c *gin.Context) {
+	id, userId, ok := parseTemplateRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id, userId); err != nil {
+		handleTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "template deleted"})
+}
+
+// CreateEvent godoc
+// @Summary Создать событие из шаблона
+// @Description Создает событие из шаблона текущего пользователя, начинающееся в start_date: даты, окончание серии
+// @Description и сроки задач сдвигаются относительно события, из которого сохранен шаблон. Создаются задачи
+// @Description с иерархией, общий лимит и категории расходов. Пользователь становится организатором события.
+// @Description Событие создается в одной транзакции, после чего участники шаблона получают приглашения
+// @Description с ролями из шаблона
+// @Tags templates
+// @Accept json
+// @Produce json
+// @Param X-User-Id header string true "ID пользователя"
+// @Param template_id path int true "ID шаблона"
+// @Param request body domain.EventCopyRequest true "Начало события и необязательное новое название"
+// @Success 201 {object} map[string]interface{} "Возвращает ID созданного события"
+// @Failure 400 {object} map[string]string "Ошибка валидации"
+// @Failure 404 {object} map[string]string "Шаблон не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /templates/{template_id}/events [post]
+func (h TemplateController) CreateEvent(c *gin.Context) {
+	id, userId, ok := parseTemplateRequest(c)
+	if !ok {
+		return
+	}
+
+	var req domain.EventCopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	eventId, err := h.service.CreateEvent(c.Request.Context(), id, userId, req)
+	if err != nil {
+		handleTemplateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": eventId})
+}
+
+// parseEventRequest разбирает ID события и пользователя и проверяет право perm на событие
+func (h TemplateController) parseEventRequest(c *gin.Context, perm domain.Permission) (int, int, bool) {
+	eventId, err := strconv.Atoi(c.Param("event_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+		return 0, 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	if err := h.access.CheckEvent(c.Request.Context(), userId, eventId, perm); err != nil {
+		handleAccessError(c, perm, err)
+		return 0, 0, false
+	}
+
+	return eventId, userId, true
+}
+
+func parseTemplateRequest(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("template_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return 0, 0, false
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+
+	return id, userId, true
+}
+
+func handleTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrTemplateNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, sql.ErrNoRows):
+		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+	case errors.Is(err, model.ErrInvalidEventRecurrence), errors.Is(err, model.ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrTemplateExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Attachment файл чека, прикрепленный к расходу. Содержимое хранится в файловом хранилище по ключу StorageKey
+type Attachment struct {
+	AttachmentID int       `db:"attachment_id"`
+	ExpenseID    int       `db:"expense_id"`
+	UploadedBy   int       `db:"uploaded_by"`
+	FileName     string    `db:"file_name"`
+	ContentType  string    `db:"content_type"`
+	Size         int64     `db:"size"`
+	StorageKey   string    `db:"storage_key"`
+	CreatedAt    time.Time `db:"created_at"`
+}
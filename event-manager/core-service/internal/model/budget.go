@@ -0,0 +1,100 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Периодичность повторяющегося расхода
+const (
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// ExpenseCategory категория расходов события с необязательным лимитом в базовой валюте события
+type ExpenseCategory struct {
+	CategoryID     int       `db:"category_id"`
+	EventID        int       `db:"event_id"`
+	Name           string    `db:"name"`
+	Budget         *Money    `db:"budget"`
+	BudgetExceeded bool      `db:"budget_exceeded"` // Уведомление о превышении лимита уже отправлено
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+// EventBudget общий лимит расходов события в его базовой валюте
+type EventBudget struct {
+	EventID        int       `db:"event_id"`
+	TotalBudget    *Money    `db:"total_budget"`
+	BudgetExceeded bool      `db:"budget_exceeded"` // Уведомление о превышении лимита уже отправлено
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// CategorySpending лимит категории и сумма ее расходов в базовой валюте события
+type CategorySpending struct {
+	ExpenseCategory
+	Spent Money `db:"spent"`
+}
+
+// RecurringExpense правило, по которому планировщик создает одинаковые расходы каждый день или неделю
+// с NextDate до EndDate, а если она не задана — до окончания события
+type RecurringExpense struct {
+	RecurringID int            `db:"recurring_id"`
+	EventID     int            `db:"event_id"`
+	CreatedBy   int            `db:"created_by"`
+	CategoryID  *int           `db:"category_id"`
+	Description string         `db:"description"`
+	Amount      Money          `db:"amount"`
+	Currency    string         `db:"currency"`
+	SplitMethod string         `db:"split_method"`
+	Split       RecurringSplit `db:"split"`
+	Frequency   string         `db:"frequency"`
+	NextDate    time.Time      `db:"next_date"` // Дата следующего создаваемого расхода
+	EndDate     *time.Time     `db:"end_date"`
+	CreatedAt   time.Time      `db:"created_at"`
+}
+
+// Step возвращает дату расхода, следующего за date
+func (r RecurringExpense) Step(date time.Time) time.Time {
+	if r.Frequency == FrequencyWeekly {
+		return date.AddDate(0, 0, 7)
+	}
+	return date.AddDate(0, 0, 1)
+}
+
+// RecurringShare вес доли участника повторяющегося расхода
+type RecurringShare struct {
+	UserID int     `json:"user_id"`
+	Value  float64 `json:"value"`
+}
+
+// RecurringSplit участники повторяющегося расхода, хранящиеся в БД как JSONB
+type RecurringSplit struct {
+	UserIDs []int            `json:"user_ids,omitempty"`
+	Shares  []RecurringShare `json:"shares,omitempty"`
+}
+
+func (s RecurringSplit) Value() (driver.Value, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.WithMessage(err, "marshal recurring split")
+	}
+
+	return data, nil
+}
+
+func (s *RecurringSplit) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.Errorf("cannot scan %T into RecurringSplit", src)
+	}
+
+	return json.Unmarshal(data, s)
+}
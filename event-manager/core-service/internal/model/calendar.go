@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// CalendarToken секретный токен, по которому календарь пользователя открывается без авторизации
+type CalendarToken struct {
+	UserID    int       `db:"user_id"`
+	Token     string    `db:"token"`
+	CreatedAt time.Time `db:"created_at"`
+}
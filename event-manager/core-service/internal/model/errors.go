@@ -5,4 +5,41 @@ import "errors"
 var (
 	ErrUserNotFound             = errors.New("user not found")
 	ErrUserAlreadyAnParticipant = errors.New("user is already a participant of event")
+	ErrNotEventParticipant      = errors.New("user is not a participant of event")
+	ErrAccessDenied             = errors.New("access denied")
+	ErrExpenseNotFound          = errors.New("expense not found")
+	ErrExpenseShareNotFound     = errors.New("expense share not found")
+	ErrInvalidExpenseSplit      = errors.New("invalid expense split")
+	ErrInvalidSettlement        = errors.New("invalid settlement")
+	ErrExchangeRateNotFound     = errors.New("exchange rate not found")
+	ErrInvalidCursor            = errors.New("invalid pagination cursor")
+	ErrPaidShareReduced         = errors.New("paid expense share cannot be reduced")
+	ErrAttachmentNotFound       = errors.New("attachment not found")
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrInvalidAttachment        = errors.New("invalid attachment")
+	ErrCategoryNotFound         = errors.New("expense category not found")
+	ErrCategoryExists           = errors.New("expense category already exists")
+	ErrRecurringExpenseNotFound = errors.New("recurring expense not found")
+	ErrInvalidRecurrence        = errors.New("invalid expense recurrence")
+	ErrInvalidEventDates        = errors.New("event end date must be after start date")
+	ErrEventVersionConflict     = errors.New("event was modified by another request")
+	ErrInvalidEventTransition   = errors.New("invalid event status transition")
+	ErrEventArchived            = errors.New("event is archived")
+	ErrInvalidEventRecurrence   = errors.New("invalid event recurrence")
+	ErrEventNotRecurring        = errors.New("event is not recurring")
+	ErrOccurrenceNotFound       = errors.New("event occurrence not found")
+	ErrCalendarTokenNotFound    = errors.New("calendar token not found")
+	ErrTemplateNotFound         = errors.New("event template not found")
+	ErrTemplateExists           = errors.New("event template with this name already exists")
+	ErrInvalidTemplate          = errors.New("invalid event template")
+	ErrInvitationNotFound       = errors.New("invitation not found")
+	ErrInvitationExpired        = errors.New("invitation expired")
+	ErrInviteCodeNotFound       = errors.New("invite code not found")
+	ErrInviteCodeExpired        = errors.New("invite code expired")
+	ErrInviteCodeExhausted      = errors.New("invite code usage limit reached")
+	ErrInvalidInviteCode        = errors.New("invalid invite code")
+	ErrOrganizerRoleChange      = errors.New("organizer role can only be changed by ownership transfer")
+	ErrOrganizerCannotLeave     = errors.New("organizer cannot leave event without transferring ownership")
+	ErrInvalidOwnershipTransfer = errors.New("ownership can only be transferred to a confirmed participant")
+	ErrInvalidRemovalPolicy     = errors.New("invalid participant removal policy")
 )
@@ -12,16 +12,79 @@ const (
 	RoleParticipant ParticipantRole = "participant"
 )
 
+// EventStatus этап жизненного цикла события
+type EventStatus string
+
+const (
+	EventStatusDraft     EventStatus = "draft"     // Черновик, даты еще не окончательные
+	EventStatusPlanned   EventStatus = "planned"   // Запланировано, ждет даты начала
+	EventStatusActive    EventStatus = "active"    // Идет
+	EventStatusCompleted EventStatus = "completed" // Прошло
+	EventStatusCancelled EventStatus = "cancelled" // Отменено
+	EventStatusArchived  EventStatus = "archived"  // В архиве, доступно только для чтения
+)
+
+// eventTransitions допустимые переходы между статусами. Запланированные события становятся
+// активными, а активные — прошедшими и по датам, без участия пользователя
+var eventTransitions = map[EventStatus][]EventStatus{
+	EventStatusDraft:     {EventStatusPlanned, EventStatusCancelled},
+	EventStatusPlanned:   {EventStatusDraft, EventStatusActive, EventStatusCancelled},
+	EventStatusActive:    {EventStatusCompleted, EventStatusCancelled},
+	EventStatusCompleted: {EventStatusArchived},
+	EventStatusCancelled: {EventStatusArchived},
+}
+
+// CanTransitionTo проверяет, можно ли перевести событие из статуса s в статус to
+func (s EventStatus) CanTransitionTo(to EventStatus) bool {
+	for _, allowed := range eventTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadOnly сообщает, что событие нельзя изменять и добавлять в него расходы
+func (s EventStatus) ReadOnly() bool {
+	return s == EventStatusArchived
+}
+
 type Event struct {
-	EventId     int       `db:"event_id"`
-	OrganizerID int       `db:"organizer_id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	StartDate   time.Time `db:"start_date"`
-	EndDate     time.Time `db:"end_date"`
-	Location    *string   `db:"location"`
-	Status      string    `db:"status"`
-	CreatedAt   time.Time `db:"created_at"`
+	EventId      int         `db:"event_id"`
+	OrganizerID  int         `db:"organizer_id"`
+	Title        string      `db:"title"`
+	Description  string      `db:"description"`
+	StartDate    time.Time   `db:"start_date"`
+	EndDate      time.Time   `db:"end_date"`
+	Location     *string     `db:"location"`
+	Status       EventStatus `db:"status"`
+	BaseCurrency string      `db:"base_currency"`
+	Version      int         `db:"version"` // Увеличивается при каждом изменении события
+	CreatedAt    time.Time   `db:"created_at"`
+	UpdatedAt    time.Time   `db:"updated_at"`
+	// RecurrenceRule правило повторения RFC 5545. Если задано, событие — серия, StartDate и EndDate —
+	// время первого повторения
+	RecurrenceRule *string `db:"recurrence_rule"`
+	// SeriesEnd окончание последнего повторения серии, для обычного события — EndDate.
+	// Nil у бесконечной серии
+	SeriesEnd *time.Time `db:"series_end"`
+	// MaxParticipants наибольшее число участников, сверх него участники попадают в лист ожидания.
+	// Nil — без ограничения
+	MaxParticipants *int `db:"max_participants"`
+}
+
+// EventException изменение или отмена одного повторения серии. Незаданные поля берутся из серии
+type EventException struct {
+	EventID        int        `db:"event_id"`
+	OccurrenceDate time.Time  `db:"occurrence_date"` // Начало повторения по правилу серии
+	Cancelled      bool       `db:"cancelled"`
+	Title          *string    `db:"title"`
+	Description    *string    `db:"description"`
+	Location       *string    `db:"location"`
+	StartDate      *time.Time `db:"start_date"`
+	EndDate        *time.Time `db:"end_date"`
+	UpdatedAt      time.Time  `db:"updated_at"`
 }
 
 type EventParticipant struct {
@@ -31,4 +94,80 @@ type EventParticipant struct {
 	Role               ParticipantRole `db:"role"`
 	JoinedAt           *time.Time      `db:"joined_at"`
 	IsConfirmed        *bool           `db:"is_confirmed"`
+	// WaitlistedAt время постановки в лист ожидания, nil у участников, занявших место
+	WaitlistedAt *time.Time `db:"waitlisted_at"`
+	// WaitlistPosition место в листе ожидания начиная с 1, вычисляется при чтении
+	WaitlistPosition *int `db:"waitlist_position"`
+}
+
+// Active участник подтвердил участие и занимает место: только такой участник пользуется правами своей роли
+func (p EventParticipant) Active() bool {
+	return p.WaitlistedAt == nil && p.IsConfirmed != nil && *p.IsConfirmed
+}
+
+// ParticipantDetails участник вместе с пользователем и названием события
+type ParticipantDetails struct {
+	EventParticipant
+	User       User   `db:"user"`
+	EventTitle string `db:"event_title"`
+}
+
+// Состояния участия для фильтра списка участников
+const (
+	ParticipationConfirmed  = "confirmed"  // Подтвердил участие и занимает место
+	ParticipationPending    = "pending"    // Еще не ответил на приглашение
+	ParticipationDeclined   = "declined"   // Отказался от участия
+	ParticipationWaitlisted = "waitlisted" // В листе ожидания
+)
+
+// ParticipantFilter параметры списка участников. Nil и пустые поля не фильтруют
+type ParticipantFilter struct {
+	EventID *int
+	UserID  *int
+	Roles   []ParticipantRole // Любая из ролей
+	Status  string            // Состояние участия
+	Query   string            // Часть имени пользователя
+	Offset  int
+	Limit   int
+}
+
+// EventFilter параметры поиска событий. Nil и пустые поля не фильтруют
+type EventFilter struct {
+	ViewerID      *int          // Только события, в которых участвует пользователь
+	Query         string        // Поисковая фраза по названию, описанию и месту проведения
+	From          *time.Time    // События, которые заканчиваются не раньше From
+	To            *time.Time    // События, которые начинаются раньше To
+	Statuses      []EventStatus // Любой из статусов
+	OrganizerID   *int
+	ParticipantID *int
+	SortBy        string
+	Desc          bool
+	After         *EventCursor
+	Offset        int // Пропуск записей для постраничной навигации без курсора
+	Limit         int
+}
+
+// EventCursor позиция в списке событий: порядок сортировки, для которого выдан курсор,
+// ключ сортировки и id последнего полученного события
+type EventCursor struct {
+	Sort      string    `json:"sort"`
+	StartDate time.Time `json:"start_date"`
+	CreatedAt time.Time `json:"created_at"`
+	Title     string    `json:"title,omitempty"`
+	Rank      float64   `json:"rank,omitempty"`
+	EventID   int       `json:"event_id"`
+}
+
+// Поля сортировки событий
+const (
+	EventSortStartDate = "start_date"
+	EventSortCreatedAt = "created_at"
+	EventSortTitle     = "title"
+	EventSortRelevance = "relevance" // По релевантности поисковой фразе
+)
+
+// FoundEvent событие из результатов поиска с релевантностью поисковой фразе
+type FoundEvent struct {
+	Event
+	Rank float64 `db:"rank"`
 }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// DefaultCurrency базовая валюта события, если она не указана при создании
+const DefaultCurrency = "RUB"
+
+// ExchangeRate курс пересчета: 1 единица FromCurrency стоит Rate единиц ToCurrency
+type ExchangeRate struct {
+	FromCurrency string    `db:"from_currency"`
+	ToCurrency   string    `db:"to_currency"`
+	Rate         float64   `db:"rate"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
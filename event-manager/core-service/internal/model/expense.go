@@ -4,39 +4,92 @@ import "time"
 
 // Структуры для работы с БД
 type Expense struct {
-	ExpenseID   int       `db:"expense_id"`
-	EventID     int       `db:"event_id"`
-	CreatedBy   int       `db:"created_by"`
-	Description string    `db:"description"`
-	Amount      float64   `db:"amount"`
-	Currency    string    `db:"currency"`
-	SplitMethod string    `db:"split_method"`
-	CreatedAt   time.Time `db:"created_at"`
+	ExpenseID    int        `db:"expense_id"`
+	EventID      int        `db:"event_id"`
+	CreatedBy    int        `db:"created_by"`
+	Description  string     `db:"description"`
+	Amount       Money      `db:"amount"`
+	Currency     string     `db:"currency"`
+	ExchangeRate float64    `db:"exchange_rate"` // Курс валюты расхода к базовой валюте события на момент создания
+	BaseAmount   Money      `db:"base_amount"`   // Сумма в базовой валюте события
+	SplitMethod  string     `db:"split_method"`
+	CategoryID   *int       `db:"category_id"`
+	RecurringID  *int       `db:"recurring_id"`    // Правило, по которому расход создан планировщиком
+	Occurrence   *time.Time `db:"occurrence_date"` // Дата повторения, за которую создан расход
+	CreatedAt    time.Time  `db:"created_at"`
 }
 
 // ExpenseShare представляет долю расхода для конкретного пользователя
 type ExpenseShare struct {
-	ShareID   int        `db:"share_id"`
-	ExpenseID int        `db:"expense_id"`
-	UserID    int        `db:"user_id"`
-	Amount    float64    `db:"amount"`
-	IsPaid    bool       `db:"is_paid"`
-	PaidAt    *time.Time `db:"paid_at"`
+	ShareID    int        `db:"share_id"`
+	ExpenseID  int        `db:"expense_id"`
+	UserID     int        `db:"user_id"`
+	Amount     Money      `db:"amount"`
+	BaseAmount Money      `db:"base_amount"` // Доля в базовой валюте события
+	SplitValue *float64   `db:"split_value"` // Исходный вес доли: процент, точная сумма или количество долей
+	IsPaid     bool       `db:"is_paid"`
+	PaidAt     *time.Time `db:"paid_at"`
 }
 
 // UserBalance представляет баланс пользователя в событии
 type UserBalance struct {
-	UserID       int     `db:"user_id"`
-	Username     string  `db:"username"`
-	Balance      float64 `db:"balance"`
-	PaidAmount   float64 `db:"paid_amount"`
-	UnpaidAmount float64 `db:"unpaid_amount"`
-	TotalDue     float64 `db:"total_due"`
+	UserID       int    `db:"user_id"`
+	Username     string `db:"username"`
+	Balance      Money  `db:"balance"`
+	PaidAmount   Money  `db:"paid_amount"`
+	UnpaidAmount Money  `db:"unpaid_amount"`
+	TotalDue     Money  `db:"total_due"`
 }
 
+// CounterpartyBalance взаимные долги пользователя и другого участника события в базовой валюте события
+type CounterpartyBalance struct {
+	EventID        int    `db:"event_id"`
+	EventTitle     string `db:"event_title"`
+	Currency       string `db:"currency"`
+	CounterpartyID int    `db:"counterparty_id"`
+	Username       string `db:"username"`
+	OwedToUser     Money  `db:"owed_to_user"` // Неоплаченные доли участника в расходах пользователя
+	UserOwes       Money  `db:"user_owes"`    // Неоплаченные доли пользователя в расходах участника
+	PaidToUser     Money  `db:"paid_to_user"` // Оплаченные доли участника в расходах пользователя
+	PaidByUser     Money  `db:"paid_by_user"` // Оплаченные доли пользователя в расходах участника
+	Transfers      Money  `db:"transfers"`    // Нераспределенный по долям остаток платежей пользователя участнику минус встречных
+}
+
+// ExpenseFilter параметры выборки расходов события. Nil-поля не фильтруют
+type ExpenseFilter struct {
+	EventID       int
+	CreatedBy     *int
+	ParticipantID *int // Участник, у которого есть доля в расходе
+	CategoryID    *int
+	Currency      string
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time // Не включительно
+	Paid          *bool      // Статус доли участника ParticipantID, а без него — всего расхода
+	SortBy        string
+	Desc          bool
+	After         *ExpenseCursor
+	Limit         int
+}
+
+// ExpenseCursor позиция в списке расходов: порядок сортировки, для которого выдан курсор,
+// ключ сортировки и id последнего полученного расхода
+type ExpenseCursor struct {
+	Sort       string    `json:"sort"`
+	CreatedAt  time.Time `json:"created_at"`
+	BaseAmount Money     `json:"base_amount"`
+	ExpenseID  int       `json:"expense_id"`
+}
+
+// Поля сортировки расходов
+const (
+	ExpenseSortCreatedAt = "created_at"
+	ExpenseSortAmount    = "amount" // По сумме в базовой валюте события
+)
+
 // Константы для методов разделения расходов
 const (
 	SplitMethodEqual   = "equal"   // Поровну между всеми
 	SplitMethodPercent = "percent" // Процентное соотношение
 	SplitMethodExact   = "exact"   // Точные суммы
+	SplitMethodShares  = "shares"  // Пропорционально количеству долей
 )
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ExpenseHistory запись журнала изменений расхода: одно редактирование со всеми измененными полями
+type ExpenseHistory struct {
+	HistoryID int            `db:"history_id"`
+	ExpenseID int            `db:"expense_id"`
+	ChangedBy int            `db:"changed_by"`
+	Changes   ExpenseChanges `db:"changes"`
+	ChangedAt time.Time      `db:"changed_at"`
+}
+
+// ExpenseChange изменение одного поля расхода. Для долей Field равно "shares",
+// а значения — списки долей до и после изменения
+type ExpenseChange struct {
+	Field    string `json:"field"`
+	OldValue any    `json:"old_value"`
+	NewValue any    `json:"new_value"`
+}
+
+// ExpenseShareSnapshot состояние доли расхода, сохраняемое в журнале изменений
+type ExpenseShareSnapshot struct {
+	UserID     int   `json:"user_id"`
+	Amount     Money `json:"amount"`
+	BaseAmount Money `json:"base_amount"`
+	IsPaid     bool  `json:"is_paid"`
+}
+
+// ExpenseChanges список изменений, хранящийся в БД как JSONB
+type ExpenseChanges []ExpenseChange
+
+func (c ExpenseChanges) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.WithMessage(err, "marshal expense changes")
+	}
+
+	return data, nil
+}
+
+func (c *ExpenseChanges) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.Errorf("cannot scan %T into ExpenseChanges", src)
+	}
+
+	return json.Unmarshal(data, c)
+}
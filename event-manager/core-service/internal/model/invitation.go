@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// EventInvitation приглашение участника, ожидающее ответа. Nonce подписывается в ссылке приглашения
+// и меняется при повторной отправке
+type EventInvitation struct {
+	EventParticipantID int       `db:"event_participant_id"`
+	Nonce              string    `db:"nonce"`
+	ExpiresAt          time.Time `db:"expires_at"`
+	SentAt             time.Time `db:"sent_at"`
+}
+
+// Expired сообщает, что ссылка приглашения больше не действует
+func (i EventInvitation) Expired(now time.Time) bool {
+	return !i.ExpiresAt.After(now)
+}
+
+// PendingInvitation приглашение вместе с приглашенным пользователем
+type PendingInvitation struct {
+	EventInvitation
+	EventID          int             `db:"event_id"`
+	UserID           int             `db:"user_id"`
+	Username         string          `db:"username"`
+	Email            string          `db:"email"`
+	Role             ParticipantRole `db:"role"`
+	WaitlistPosition *int            `db:"waitlist_position"`
+}
+
+// EventEmailInvitation приглашение в событие по адресу, для которого еще нет пользователя.
+// Адрес хранится в нижнем регистре
+type EventEmailInvitation struct {
+	EmailInvitationID int       `db:"email_invitation_id"`
+	EventID           int       `db:"event_id"`
+	Email             string    `db:"email"`
+	InvitedBy         int       `db:"invited_by"`
+	CreatedAt         time.Time `db:"created_at"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// EventInviteCode код приглашения в событие. По коду вступают с ролью Role
+type EventInviteCode struct {
+	InviteCodeID int             `db:"invite_code_id"`
+	EventID      int             `db:"event_id"`
+	Code         string          `db:"code"`
+	Role         ParticipantRole `db:"role"`
+	MaxUses      *int            `db:"max_uses"` // Не задано — без ограничения числа вступлений
+	UsesCount    int             `db:"uses_count"`
+	ExpiresAt    *time.Time      `db:"expires_at"` // Не задано — бессрочный код
+	CreatedBy    int             `db:"created_by"`
+	CreatedAt    time.Time       `db:"created_at"`
+	LastUsedAt   *time.Time      `db:"last_used_at"`
+	RevokedAt    *time.Time      `db:"revoked_at"`
+}
+
+// Expired сообщает, что срок действия кода истек
+func (c EventInviteCode) Expired(now time.Time) bool {
+	return c.ExpiresAt != nil && !c.ExpiresAt.After(now)
+}
+
+// Exhausted сообщает, что по коду вступило максимальное число пользователей
+func (c EventInviteCode) Exhausted() bool {
+	return c.MaxUses != nil && c.UsesCount >= *c.MaxUses
+}
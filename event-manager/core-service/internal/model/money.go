@@ -0,0 +1,196 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Money денежная сумма с фиксированной точкой в сотых долях единицы валюты (копейках, центах).
+// В БД хранится как NUMERIC(14, 2), в JSON передается числом, как раньше передавался float64
+type Money int64
+
+// moneyScale количество сотых в единице валюты
+const moneyScale = 100
+
+// currencyDigits валюты, у которых меньше двух знаков после запятой.
+// Валюты с тремя знаками округляются до двух
+var currencyDigits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	"ISK": 0,
+}
+
+// MoneyFromFloat округляет сумму до копеек
+func MoneyFromFloat(amount float64) Money {
+	return Money(math.Round(amount * moneyScale))
+}
+
+// ParseMoney разбирает десятичную запись суммы без потери точности.
+// Знаки после второго округляются половиной от нуля
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty money value")
+	}
+
+	// Экспоненциальную запись, которую может прислать JSON-клиент, разбираем как float
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, errors.WithMessagef(err, "parse money %q", s)
+		}
+		return MoneyFromFloat(f), nil
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "parse money %q", s)
+	}
+
+	var cents int64
+	for i, c := range fracPart {
+		if c < '0' || c > '9' {
+			return 0, errors.Errorf("parse money %q: invalid digit", s)
+		}
+		digit := int64(c - '0')
+		switch {
+		case i < 2:
+			cents = cents*10 + digit
+		case i == 2 && digit >= 5:
+			cents++
+		}
+	}
+	for i := len(fracPart); i < 2; i++ {
+		cents *= 10
+	}
+
+	m := Money(units*moneyScale + cents)
+	if negative {
+		m = -m
+	}
+
+	return m, nil
+}
+
+// CurrencyDigits возвращает количество знаков после запятой, с которым записываются суммы в валюте
+func CurrencyDigits(currency string) int {
+	digits, ok := currencyDigits[strings.ToUpper(currency)]
+	if !ok {
+		return 2
+	}
+
+	return digits
+}
+
+// MinorUnit возвращает наименьшую сумму, представимую в валюте: 1 копейку для рубля, 100 копеек для иены
+func MinorUnit(currency string) Money {
+	return Money(math.Pow10(2 - CurrencyDigits(currency)))
+}
+
+// Round округляет сумму до минимальной единицы валюты половиной от нуля
+func (m Money) Round(currency string) Money {
+	unit := MinorUnit(currency)
+	if unit == 1 {
+		return m
+	}
+
+	rem := m % unit
+	m -= rem
+	if 2*rem >= unit {
+		m += unit
+	} else if 2*rem <= -unit {
+		m -= unit
+	}
+
+	return m
+}
+
+// MulRate пересчитывает сумму по курсу с округлением до копеек
+func (m Money) MulRate(rate float64) Money {
+	return Money(math.Round(float64(m) * rate))
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+// String возвращает сумму с двумя знаками после запятой
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, m/moneyScale, m%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	// Незначащие нули отбрасываются, чтобы формат совпадал с прежним float64: 12.5, а не 12.50
+	s := strings.TrimSuffix(strings.TrimRight(m.String(), "0"), ".")
+
+	return []byte(s), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	parsed, err := ParseMoney(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+
+	return nil
+}
+
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v * moneyScale)
+	case float64:
+		*m = MoneyFromFloat(v)
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return errors.Errorf("cannot scan %T into Money", src)
+	}
+
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
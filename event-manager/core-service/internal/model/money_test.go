@@ -0,0 +1,83 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест 1: разбор десятичной записи без потерь и с округлением третьего знака
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Money{
+		"0":       0,
+		"12":      1200,
+		"12.3":    1230,
+		"12.34":   1234,
+		"12.345":  1235,
+		"12.344":  1234,
+		"-12.345": -1235,
+		".5":      50,
+		"1e2":     10000,
+	}
+
+	for input, expected := range cases {
+		m, err := ParseMoney(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, m, input)
+	}
+
+	_, err := ParseMoney("12.3a")
+	assert.Error(t, err)
+}
+
+// Тест 2: JSON совместим с прежним форматом float64
+func TestMoney_JSON(t *testing.T) {
+	var payload struct {
+		Amount Money `json:"amount"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 0.3}`), &payload))
+	assert.Equal(t, Money(30), payload.Amount)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": "10.10"}`), &payload))
+	assert.Equal(t, Money(1010), payload.Amount)
+
+	for amount, expected := range map[Money]string{1050: "10.5", 1000: "10", -1: "-0.01", 0: "0"} {
+		payload.Amount = amount
+		data, err := json.Marshal(payload)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"amount": `+expected+`}`, string(data))
+	}
+}
+
+// Тест 3: сложение не накапливает ошибку округления
+func TestMoney_NoDrift(t *testing.T) {
+	sum := MoneyFromFloat(0.1) + MoneyFromFloat(0.2)
+
+	assert.Equal(t, MoneyFromFloat(0.3), sum)
+	assert.Equal(t, "0.30", sum.String())
+}
+
+// Тест 4: округление до минимальной единицы валюты
+func TestMoney_Round(t *testing.T) {
+	assert.Equal(t, Money(1234), Money(1234).Round("RUB"))
+	assert.Equal(t, Money(1200), Money(1249).Round("JPY"))
+	assert.Equal(t, Money(1300), Money(1250).Round("jpy"))
+	assert.Equal(t, Money(-1300), Money(-1250).Round("JPY"))
+}
+
+// Тест 5: значения из БД читаются из NUMERIC и записываются строкой
+func TestMoney_SQL(t *testing.T) {
+	var m Money
+
+	assert.NoError(t, m.Scan([]byte("123.45")))
+	assert.Equal(t, Money(12345), m)
+
+	assert.NoError(t, m.Scan(int64(7)))
+	assert.Equal(t, Money(700), m)
+
+	value, err := Money(-5).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "-0.05", value)
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Settlement платеж одного участника события другому в счет погашения долей расходов
+type Settlement struct {
+	SettlementID int       `db:"settlement_id"`
+	EventID      int       `db:"event_id"`
+	FromUserID   int       `db:"from_user_id"`
+	ToUserID     int       `db:"to_user_id"`
+	Amount       Money     `db:"amount"`
+	CreatedBy    int       `db:"created_by"`
+	CreatedAt    time.Time `db:"created_at"`
+}
@@ -5,15 +5,16 @@ import (
 )
 
 type Task struct {
-	TaskId      int       `db:"task_id"`
-	EventId     int       `db:"event_id"`
-	ParentId    *int      `db:"parent_id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	StoryPoints *int      `db:"story_points"`
-	Priority    *string   `db:"priority"`
-	Status      string    `db:"status"`
-	CreatedAt   time.Time `db:"created_at"`
+	TaskId      int        `db:"task_id"`
+	EventId     int        `db:"event_id"`
+	ParentId    *int       `db:"parent_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	StoryPoints *int       `db:"story_points"`
+	Priority    *string    `db:"priority"`
+	Status      string     `db:"status"`
+	DueDate     *time.Time `db:"due_date"`
+	CreatedAt   time.Time  `db:"created_at"`
 }
 
 type TaskAssignment struct {
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EventTemplate шаблон события, доступный только его владельцу
+type EventTemplate struct {
+	TemplateID    int             `db:"template_id"`
+	OwnerID       int             `db:"owner_id"`
+	Name          string          `db:"name"`
+	SourceEventID *int            `db:"source_event_id"` // Событие, из которого сохранен шаблон; nil, если оно удалено
+	Content       TemplateContent `db:"content"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
+
+// TemplateContent снимок события, хранящийся в БД как JSONB. Даты задач отсчитываются от StartDate:
+// при создании события все даты сдвигаются на разницу между новым и исходным началом
+type TemplateContent struct {
+	Title           string                `json:"title"`
+	Description     string                `json:"description"`
+	Location        *string               `json:"location,omitempty"`
+	BaseCurrency    string                `json:"base_currency"`
+	StartDate       time.Time             `json:"start_date"`
+	EndDate         time.Time             `json:"end_date"`
+	RecurrenceRule  *string               `json:"recurrence_rule,omitempty"`
+	MaxParticipants *int                  `json:"max_participants,omitempty"`
+	Tasks           []TemplateTask        `json:"tasks"`
+	Participants    []TemplateParticipant `json:"participants"`
+	TotalBudget     *Money                `json:"total_budget,omitempty"`
+	Categories      []TemplateCategory    `json:"categories"`
+}
+
+// TemplateTask задача шаблона. Key — id задачи в исходном событии, ParentKey ссылается на Key родителя.
+// Родитель всегда идет в списке раньше своих подзадач
+type TemplateTask struct {
+	Key         int        `json:"key"`
+	ParentKey   *int       `json:"parent_key,omitempty"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	StoryPoints *int       `json:"story_points,omitempty"`
+	Priority    *string    `json:"priority,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+}
+
+// TemplateParticipant участник шаблона и его роль
+type TemplateParticipant struct {
+	UserID int             `json:"user_id"`
+	Role   ParticipantRole `json:"role"`
+}
+
+// TemplateCategory категория расходов шаблона с необязательным лимитом
+type TemplateCategory struct {
+	Name   string `json:"name"`
+	Budget *Money `json:"budget,omitempty"`
+}
+
+func (c TemplateContent) Value() (driver.Value, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, errors.WithMessage(err, "marshal template content")
+	}
+
+	return data, nil
+}
+
+func (c *TemplateContent) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.Errorf("cannot scan %T into TemplateContent", src)
+	}
+
+	return json.Unmarshal(data, c)
+}
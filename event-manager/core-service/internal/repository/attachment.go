@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type Attachment struct {
+	db *sqlx.DB
+}
+
+func NewAttachment(db *sqlx.DB) Attachment {
+	return Attachment{
+		db: db,
+	}
+}
+
+func (r Attachment) Create(ctx context.Context, attachment model.Attachment) (int, error) {
+	query := `
+		INSERT INTO expense_attachment (expense_id, uploaded_by, file_name, content_type, size, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING attachment_id
+	`
+
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		attachment.ExpenseID,
+		attachment.UploadedBy,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.StorageKey,
+		attachment.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.WithMessage(err, "create attachment")
+	}
+
+	return id, nil
+}
+
+func (r Attachment) GetById(ctx context.Context, id int) (model.Attachment, error) {
+	var attachment model.Attachment
+
+	query := `
+		SELECT attachment_id, expense_id, uploaded_by, file_name, content_type, size, storage_key, created_at
+		FROM expense_attachment
+		WHERE attachment_id = $1
+	`
+
+	err := conn(ctx, r.db).GetContext(ctx, &attachment, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Attachment{}, model.ErrAttachmentNotFound
+	}
+	if err != nil {
+		return model.Attachment{}, errors.WithMessage(err, "get attachment by id")
+	}
+
+	return attachment, nil
+}
+
+func (r Attachment) ListByExpense(ctx context.Context, expenseId int) ([]model.Attachment, error) {
+	attachments := make([]model.Attachment, 0)
+
+	query := `
+		SELECT attachment_id, expense_id, uploaded_by, file_name, content_type, size, storage_key, created_at
+		FROM expense_attachment
+		WHERE expense_id = $1
+		ORDER BY created_at, attachment_id
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &attachments, query, expenseId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list expense attachments")
+	}
+
+	return attachments, nil
+}
+
+func (r Attachment) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM expense_attachment WHERE attachment_id = $1`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete attachment")
+	}
+
+	return nil
+}
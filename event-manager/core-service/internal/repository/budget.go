@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// uniqueViolation код ошибки PostgreSQL при нарушении ограничения уникальности
+const uniqueViolation = "23505"
+
+type Budget struct {
+	db *sqlx.DB
+}
+
+func NewBudget(db *sqlx.DB) Budget {
+	return Budget{
+		db: db,
+	}
+}
+
+func (r Budget) CreateCategory(ctx context.Context, category model.ExpenseCategory) (int, error) {
+	query := `
+		INSERT INTO expense_category (event_id, name, budget, created_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (event_id, name) DO NOTHING
+		RETURNING category_id
+	`
+
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, category.EventID, category.Name, category.Budget).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrCategoryExists
+	}
+	if err != nil {
+		return 0, errors.WithMessage(err, "create expense category")
+	}
+
+	return id, nil
+}
+
+func (r Budget) GetCategoryById(ctx context.Context, id int) (model.ExpenseCategory, error) {
+	var category model.ExpenseCategory
+
+	query := `
+		SELECT category_id, event_id, name, budget, budget_exceeded, created_at
+		FROM expense_category
+		WHERE category_id = $1
+	`
+
+	err := conn(ctx, r.db).GetContext(ctx, &category, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ExpenseCategory{}, model.ErrCategoryNotFound
+	}
+	if err != nil {
+		return model.ExpenseCategory{}, errors.WithMessage(err, "get expense category by id")
+	}
+
+	return category, nil
+}
+
+func (r Budget) UpdateCategory(ctx context.Context, category model.ExpenseCategory) error {
+	query := `UPDATE expense_category SET name = $1, budget = $2 WHERE category_id = $3`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, category.Name, category.Budget, category.CategoryID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return model.ErrCategoryExists
+	}
+	if err != nil {
+		return errors.WithMessage(err, "update expense category")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithMessage(err, "get affected rows")
+	}
+	if rows == 0 {
+		return model.ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// DeleteCategory удаляет категорию. Расходы и повторяющиеся расходы категории остаются без категории
+func (r Budget) DeleteCategory(ctx context.Context, id int) error {
+	query := `DELETE FROM expense_category WHERE category_id = $1`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete expense category")
+	}
+
+	return nil
+}
+
+// ListCategorySpending возвращает категории события с суммой их расходов в базовой валюте события
+func (r Budget) ListCategorySpending(ctx context.Context, eventId int) ([]model.CategorySpending, error) {
+	categories := make([]model.CategorySpending, 0)
+
+	query := `
+		SELECT c.category_id, c.event_id, c.name, c.budget, c.budget_exceeded, c.created_at,
+			COALESCE(SUM(e.base_amount), 0) AS spent
+		FROM expense_category c
+		LEFT JOIN expense e ON e.category_id = c.category_id
+		WHERE c.event_id = $1
+		GROUP BY c.category_id
+		ORDER BY c.name
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &categories, query, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list category spending")
+	}
+
+	return categories, nil
+}
+
+// GetEventBudget возвращает общий лимит события. Если лимит не задавался, TotalBudget равен nil
+func (r Budget) GetEventBudget(ctx context.Context, eventId int) (model.EventBudget, error) {
+	var budget model.EventBudget
+
+	query := `
+		SELECT event_id, total_budget, budget_exceeded, updated_at
+		FROM event_budget
+		WHERE event_id = $1
+	`
+
+	err := conn(ctx, r.db).GetContext(ctx, &budget, query, eventId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.EventBudget{EventID: eventId}, nil
+	}
+	if err != nil {
+		return model.EventBudget{}, errors.WithMessage(err, "get event budget")
+	}
+
+	return budget, nil
+}
+
+// SetEventBudget задает общий лимит события, nil снимает лимит
+func (r Budget) SetEventBudget(ctx context.Context, eventId int, total *model.Money) error {
+	query := `
+		INSERT INTO event_budget (event_id, total_budget, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (event_id) DO UPDATE SET total_budget = EXCLUDED.total_budget, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventId, total)
+	if err != nil {
+		return errors.WithMessage(err, "set event budget")
+	}
+
+	return nil
+}
+
+// MarkEventBudgetExceeded меняет отметку о превышении общего лимита и сообщает, изменилась ли она.
+// Отметка меняется одним запросом, поэтому при параллельных изменениях уведомление отправляется один раз
+func (r Budget) MarkEventBudgetExceeded(ctx context.Context, eventId int, exceeded bool) (bool, error) {
+	query := `UPDATE event_budget SET budget_exceeded = $2 WHERE event_id = $1 AND budget_exceeded <> $2`
+	return r.mark(ctx, query, eventId, exceeded)
+}
+
+// MarkCategoryExceeded меняет отметку о превышении лимита категории и сообщает, изменилась ли она
+func (r Budget) MarkCategoryExceeded(ctx context.Context, categoryId int, exceeded bool) (bool, error) {
+	query := `UPDATE expense_category SET budget_exceeded = $2 WHERE category_id = $1 AND budget_exceeded <> $2`
+	return r.mark(ctx, query, categoryId, exceeded)
+}
+
+func (r Budget) mark(ctx context.Context, query string, id int, exceeded bool) (bool, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, exceeded)
+	if err != nil {
+		return false, errors.WithMessage(err, "mark budget exceeded")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.WithMessage(err, "get affected rows")
+	}
+
+	return rows > 0, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type CalendarToken struct {
+	db *sqlx.DB
+}
+
+func NewCalendarToken(db *sqlx.DB) CalendarToken {
+	return CalendarToken{
+		db: db,
+	}
+}
+
+// GetByUser возвращает токен календаря пользователя
+func (r CalendarToken) GetByUser(ctx context.Context, userId int) (model.CalendarToken, error) {
+	var token model.CalendarToken
+
+	query := `SELECT user_id, token, created_at FROM calendar_token WHERE user_id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, &token, query, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CalendarToken{}, model.ErrCalendarTokenNotFound
+		}
+		return model.CalendarToken{}, errors.WithMessage(err, "get user calendar token")
+	}
+
+	return token, nil
+}
+
+// GetByToken возвращает владельца токена календаря
+func (r CalendarToken) GetByToken(ctx context.Context, value string) (model.CalendarToken, error) {
+	var token model.CalendarToken
+
+	query := `SELECT user_id, token, created_at FROM calendar_token WHERE token = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, &token, query, value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.CalendarToken{}, model.ErrCalendarTokenNotFound
+		}
+		return model.CalendarToken{}, errors.WithMessage(err, "get calendar token")
+	}
+
+	return token, nil
+}
+
+// Create сохраняет токен календаря, если у пользователя его еще нет
+func (r CalendarToken) Create(ctx context.Context, token model.CalendarToken) error {
+	query := `
+		INSERT INTO calendar_token (user_id, token, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, token.UserID, token.Token, token.CreatedAt)
+	if err != nil {
+		return errors.WithMessage(err, "create calendar token")
+	}
+
+	return nil
+}
+
+// Replace сохраняет токен календаря пользователя, заменяя предыдущий
+func (r CalendarToken) Replace(ctx context.Context, token model.CalendarToken) error {
+	query := `
+		INSERT INTO calendar_token (user_id, token, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, created_at = EXCLUDED.created_at
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, token.UserID, token.Token, token.CreatedAt)
+	if err != nil {
+		return errors.WithMessage(err, "replace calendar token")
+	}
+
+	return nil
+}
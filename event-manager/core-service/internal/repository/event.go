@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
+	"strings"
 	"time"
 
 	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
@@ -24,13 +27,14 @@ func (r Event) Create(ctx context.Context, event model.Event) (int, error) {
 	event.CreatedAt = time.Now()
 
 	query := `
-		INSERT INTO events (organizer_id, title, description, start_date, end_date, location, status, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO events (organizer_id, title, description, start_date, end_date, location, status, base_currency,
+			recurrence_rule, series_end, max_participants, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $12)
 		RETURNING event_id
 	`
 
 	var eventID int
-	err := r.db.QueryRowContext(
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		event.OrganizerID,
@@ -40,6 +44,10 @@ func (r Event) Create(ctx context.Context, event model.Event) (int, error) {
 		event.EndDate,
 		event.Location,
 		event.Status,
+		event.BaseCurrency,
+		event.RecurrenceRule,
+		event.SeriesEnd,
+		event.MaxParticipants,
 		event.CreatedAt,
 	).Scan(&eventID)
 	if err != nil {
@@ -53,12 +61,13 @@ func (r Event) GetById(ctx context.Context, eventID int) (model.Event, error) {
 	var event model.Event
 
 	query := `
-		SELECT event_id, organizer_id, title, description, start_date, end_date, location, status, created_at
+		SELECT event_id, organizer_id, title, description, start_date, end_date, location, status, base_currency, version,
+			created_at, updated_at, recurrence_rule, series_end, max_participants
 		FROM events
 		WHERE event_id = $1
 	`
 
-	err := r.db.GetContext(ctx, &event, query, eventID)
+	err := conn(ctx, r.db).GetContext(ctx, &event, query, eventID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.Event{}, errors.WithMessage(err, "event not found")
@@ -69,14 +78,43 @@ func (r Event) GetById(ctx context.Context, eventID int) (model.Event, error) {
 	return event, nil
 }
 
-func (r Event) Update(ctx context.Context, event model.Event) error {
+// GetByIdForUpdate возвращает событие и блокирует его до конца транзакции. Используется, чтобы
+// одновременные изменения состава участников не превысили ограничение числа участников
+func (r Event) GetByIdForUpdate(ctx context.Context, eventID int) (model.Event, error) {
+	var event model.Event
+
+	query := `
+		SELECT event_id, organizer_id, title, description, start_date, end_date, location, status, base_currency, version,
+			created_at, updated_at, recurrence_rule, series_end, max_participants
+		FROM events
+		WHERE event_id = $1
+		FOR UPDATE
+	`
+
+	err := conn(ctx, r.db).GetContext(ctx, &event, query, eventID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Event{}, errors.WithMessage(err, "event not found")
+		}
+		return model.Event{}, errors.WithMessage(err, "get event for update")
+	}
+
+	return event, nil
+}
+
+// Update сохраняет событие, если его версия в БД совпадает с event.Version, и возвращает новую версию.
+// Если событие успели изменить, возвращается ErrEventVersionConflict
+func (r Event) Update(ctx context.Context, event model.Event) (int, error) {
 	query := `
 		UPDATE events
-		SET title = $1, description = $2, start_date = $3, end_date = $4, location = $5, status = $6
-		WHERE event_id = $7
+		SET title = $1, description = $2, start_date = $3, end_date = $4, location = $5, status = $6,
+			recurrence_rule = $7, series_end = $8, max_participants = $9, version = version + 1, updated_at = $10
+		WHERE event_id = $11 AND version = $12
+		RETURNING version
 	`
 
-	_, err := r.db.ExecContext(
+	var version int
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		event.Title,
@@ -85,79 +123,230 @@ func (r Event) Update(ctx context.Context, event model.Event) error {
 		event.EndDate,
 		event.Location,
 		event.Status,
+		event.RecurrenceRule,
+		event.SeriesEnd,
+		event.MaxParticipants,
+		event.UpdatedAt,
 		event.EventId,
-	)
+		event.Version,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrEventVersionConflict
+	}
 	if err != nil {
-		return errors.WithMessage(err, "update event")
+		return 0, errors.WithMessage(err, "update event")
 	}
 
-	return nil
+	return version, nil
 }
 
-func (r Event) Delete(ctx context.Context, eventID int) error {
-	query := `DELETE FROM events WHERE event_id = $1`
+// UpdateStatus переводит событие из статуса from в статус to и возвращает новую версию события.
+// Если статус успели изменить, возвращается ErrEventVersionConflict
+func (r Event) UpdateStatus(ctx context.Context, eventID int, from, to model.EventStatus, now time.Time) (int, error) {
+	query := `
+		UPDATE events
+		SET status = $1, version = version + 1, updated_at = $2
+		WHERE event_id = $3 AND status = $4
+		RETURNING version
+	`
 
-	_, err := r.db.ExecContext(ctx, query, eventID)
+	var version int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, to, now, eventID, from).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, model.ErrEventVersionConflict
+	}
 	if err != nil {
-		return errors.WithMessage(err, "delete event")
+		return 0, errors.WithMessage(err, "update event status")
 	}
 
-	return nil
+	return version, nil
 }
 
-func (r Event) List(ctx context.Context, limit, offset int) ([]model.Event, error) {
-	var events []model.Event
-
+// UpdateOrganizer передает событие новому организатору и возвращает новую версию события
+func (r Event) UpdateOrganizer(ctx context.Context, eventID, organizerID int, now time.Time) (int, error) {
 	query := `
-		SELECT event_id, organizer_id, title, description, start_date, end_date, location, status, created_at
-		FROM events
-		ORDER BY start_date DESC
-		LIMIT $1 OFFSET $2
+		UPDATE events
+		SET organizer_id = $1, version = version + 1, updated_at = $2
+		WHERE event_id = $3
+		RETURNING version
 	`
 
-	err := r.db.SelectContext(ctx, &events, query, limit, offset)
+	var version int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, organizerID, now, eventID).Scan(&version)
 	if err != nil {
-		return nil, errors.WithMessage(err, "list events")
+		return 0, errors.WithMessage(err, "update event organizer")
 	}
 
-	return events, nil
+	return version, nil
 }
 
-func (r Event) ListByOrganizer(ctx context.Context, organizerID, limit, offset int) ([]model.Event, error) {
-	var events []model.Event
+// StartDue делает активными запланированные события, дата начала которых наступила, и возвращает их id
+func (r Event) StartDue(ctx context.Context, now time.Time) ([]int, error) {
+	query := `
+		UPDATE events
+		SET status = $1, version = version + 1, updated_at = $2
+		WHERE status = $3 AND start_date <= $2
+		RETURNING event_id
+	`
+
+	ids := make([]int, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &ids, query, model.EventStatusActive, now, model.EventStatusPlanned)
+	if err != nil {
+		return nil, errors.WithMessage(err, "start due events")
+	}
+
+	return ids, nil
+}
 
+// FinishDue завершает активные события, последнее повторение которых закончилось, и возвращает их id.
+// Бесконечные серии не завершаются
+func (r Event) FinishDue(ctx context.Context, now time.Time) ([]int, error) {
 	query := `
-		SELECT event_id, organizer_id, title, description, start_date, end_date, location, status, created_at
-		FROM events
-		WHERE organizer_id = $1
-		ORDER BY start_date DESC
-		LIMIT $2 OFFSET $3
+		UPDATE events
+		SET status = $1, version = version + 1, updated_at = $2
+		WHERE status = $3 AND series_end <= $2
+		RETURNING event_id
 	`
 
-	err := r.db.SelectContext(ctx, &events, query, organizerID, limit, offset)
+	ids := make([]int, 0)
+	err := conn(ctx, r.db).SelectContext(ctx, &ids, query, model.EventStatusCompleted, now, model.EventStatusActive)
 	if err != nil {
-		return nil, errors.WithMessage(err, "list organizer events")
+		return nil, errors.WithMessage(err, "finish due events")
 	}
 
-	return events, nil
+	return ids, nil
 }
 
-func (r Event) ListByParticipant(ctx context.Context, participantID, limit, offset int) ([]model.Event, error) {
-	var events []model.Event
+func (r Event) Delete(ctx context.Context, eventID int) error {
+	query := `DELETE FROM events WHERE event_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventID)
+	if err != nil {
+		return errors.WithMessage(err, "delete event")
+	}
+
+	return nil
+}
+
+// ListByParticipant возвращает все события, в которых пользователь подтвердил участие и занимает место,
+// вместе с сериями
+func (r Event) ListByParticipant(ctx context.Context, participantID int) ([]model.Event, error) {
+	events := make([]model.Event, 0)
 
 	query := `
-		SELECT e.event_id, e.organizer_id, e.title, e.description, e.start_date, e.end_date, e.location, e.status, e.created_at
+		SELECT e.event_id, e.organizer_id, e.title, e.description, e.start_date, e.end_date, e.location, e.status,
+			e.base_currency, e.version, e.created_at, e.updated_at, e.recurrence_rule, e.series_end,
+			e.max_participants
 		FROM events e
 		JOIN event_participant ep ON e.event_id = ep.event_id
-		WHERE ep.user_id = $1
-		ORDER BY e.start_date DESC
-		LIMIT $2 OFFSET $3
+		WHERE ep.user_id = $1 AND ep.waitlisted_at IS NULL AND ep.is_confirmed = TRUE
+		ORDER BY e.start_date DESC, e.event_id
 	`
 
-	err := r.db.SelectContext(ctx, &events, query, participantID, limit, offset)
+	err := conn(ctx, r.db).SelectContext(ctx, &events, query, participantID)
 	if err != nil {
 		return nil, errors.WithMessage(err, "list participant events")
 	}
 
 	return events, nil
 }
+
+// Search возвращает страницу событий по фильтру и общее количество подходящих событий
+func (r Event) Search(ctx context.Context, filter model.EventFilter) ([]model.FoundEvent, int, error) {
+	args := make([]any, 0)
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"TRUE"}
+	rank := "0::float8"
+	if filter.Query != "" {
+		query := "websearch_to_tsquery('ru_en', " + arg(filter.Query) + ")"
+		where = append(where, "e.search_vector @@ "+query)
+		rank = "ts_rank(e.search_vector, " + query + ")::float8"
+	}
+	if filter.ViewerID != nil {
+		where = append(where, "EXISTS (SELECT 1 FROM event_participant ep WHERE ep.event_id = e.event_id AND ep.user_id = "+arg(*filter.ViewerID)+")")
+	}
+	if filter.ParticipantID != nil {
+		where = append(where, "EXISTS (SELECT 1 FROM event_participant ep WHERE ep.event_id = e.event_id AND ep.user_id = "+arg(*filter.ParticipantID)+")")
+	}
+	if filter.OrganizerID != nil {
+		where = append(where, "e.organizer_id = "+arg(*filter.OrganizerID))
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		where = append(where, "e.status = ANY("+arg(pq.Array(statuses))+")")
+	}
+	// Событие попадает в диапазон, если пересекается с ним хотя бы частично, а серия —
+	// если идет в какой-то момент диапазона, с первого повторения по последнее
+	if filter.From != nil {
+		where = append(where, "(e.series_end IS NULL OR e.series_end >= "+arg(*filter.From)+")")
+	}
+	if filter.To != nil {
+		where = append(where, "e.start_date < "+arg(*filter.To))
+	}
+
+	countQuery := `SELECT COUNT(*) FROM events e WHERE ` + strings.Join(where, " AND ")
+	var total int
+	err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "count events")
+	}
+
+	var column string
+	var value any
+	switch filter.SortBy {
+	case model.EventSortCreatedAt:
+		column = "e.created_at"
+		if filter.After != nil {
+			value = filter.After.CreatedAt
+		}
+	case model.EventSortTitle:
+		column = "e.title"
+		if filter.After != nil {
+			value = filter.After.Title
+		}
+	case model.EventSortRelevance:
+		column = rank
+		if filter.After != nil {
+			value = filter.After.Rank
+		}
+	default:
+		column = "e.start_date"
+		if filter.After != nil {
+			value = filter.After.StartDate
+		}
+	}
+	direction, cmp := "ASC", ">"
+	if filter.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		where = append(where, fmt.Sprintf("(%s, e.event_id) %s (%s, %s)", column, cmp, arg(value), arg(filter.After.EventID)))
+	}
+
+	query := `
+		SELECT e.event_id, e.organizer_id, e.title, e.description, e.start_date, e.end_date, e.location, e.status, e.base_currency,
+			e.version, e.created_at, e.updated_at, e.recurrence_rule, e.series_end, e.max_participants, ` + rank + ` AS rank
+		FROM events e
+		WHERE ` + strings.Join(where, " AND ") + fmt.Sprintf(`
+		ORDER BY %[1]s %[2]s, e.event_id %[2]s
+		LIMIT %[3]s`, column, direction, arg(filter.Limit))
+	if filter.Offset > 0 {
+		query += " OFFSET " + arg(filter.Offset)
+	}
+
+	events := make([]model.FoundEvent, 0)
+	err = conn(ctx, r.db).SelectContext(ctx, &events, query, args...)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "search events")
+	}
+
+	return events, total, nil
+}
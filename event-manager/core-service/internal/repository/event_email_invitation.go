@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type EventEmailInvitation struct {
+	db *sqlx.DB
+}
+
+func NewEventEmailInvitation(db *sqlx.DB) EventEmailInvitation {
+	return EventEmailInvitation{
+		db: db,
+	}
+}
+
+// Save сохраняет приглашение по адресу. Повторное приглашение того же адреса в событие
+// обновляет пригласившего и время приглашения
+func (r EventEmailInvitation) Save(ctx context.Context, invitation model.EventEmailInvitation) error {
+	query := `
+		INSERT INTO event_email_invitation (event_id, email, invited_by, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_id, email) DO UPDATE
+		SET invited_by = EXCLUDED.invited_by, created_at = EXCLUDED.created_at
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		invitation.EventID,
+		invitation.Email,
+		invitation.InvitedBy,
+		invitation.CreatedAt,
+	)
+	if err != nil {
+		return errors.WithMessage(err, "save event email invitation")
+	}
+
+	return nil
+}
+
+// ListByEmail возвращает приглашения адреса в порядке отправки. Приглашения блокируются,
+// чтобы их не приняли дважды
+func (r EventEmailInvitation) ListByEmail(ctx context.Context, email string) ([]model.EventEmailInvitation, error) {
+	invitations := make([]model.EventEmailInvitation, 0)
+
+	query := `
+		SELECT email_invitation_id, event_id, email, invited_by, created_at
+		FROM event_email_invitation
+		WHERE email = $1
+		ORDER BY created_at, email_invitation_id
+		FOR UPDATE
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &invitations, query, email)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list event email invitations")
+	}
+
+	return invitations, nil
+}
+
+// Delete удаляет приглашение после того, как пользователь получил приглашение участника
+func (r EventEmailInvitation) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM event_email_invitation WHERE email_invitation_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete event email invitation")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type EventException struct {
+	db *sqlx.DB
+}
+
+func NewEventException(db *sqlx.DB) EventException {
+	return EventException{
+		db: db,
+	}
+}
+
+// Upsert сохраняет изменение повторения, заменяя предыдущее изменение того же повторения
+func (r EventException) Upsert(ctx context.Context, exception model.EventException) error {
+	query := `
+		INSERT INTO event_exception (event_id, occurrence_date, cancelled, title, description, location, start_date, end_date, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (event_id, occurrence_date) DO UPDATE
+		SET cancelled = EXCLUDED.cancelled, title = EXCLUDED.title, description = EXCLUDED.description,
+			location = EXCLUDED.location, start_date = EXCLUDED.start_date, end_date = EXCLUDED.end_date,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		exception.EventID,
+		exception.OccurrenceDate,
+		exception.Cancelled,
+		exception.Title,
+		exception.Description,
+		exception.Location,
+		exception.StartDate,
+		exception.EndDate,
+		exception.UpdatedAt,
+	)
+	if err != nil {
+		return errors.WithMessage(err, "upsert event exception")
+	}
+
+	return nil
+}
+
+// ListByEvents возвращает изменения повторений указанных серий
+func (r EventException) ListByEvents(ctx context.Context, eventIds []int) ([]model.EventException, error) {
+	exceptions := make([]model.EventException, 0)
+	if len(eventIds) == 0 {
+		return exceptions, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT event_id, occurrence_date, cancelled, title, description, location, start_date, end_date, updated_at
+		FROM event_exception
+		WHERE event_id IN (?)
+		ORDER BY event_id, occurrence_date
+	`, eventIds)
+	if err != nil {
+		return nil, errors.WithMessage(err, "build list event exceptions query")
+	}
+
+	err = conn(ctx, r.db).SelectContext(ctx, &exceptions, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list event exceptions")
+	}
+
+	return exceptions, nil
+}
+
+// Move переносит изменения повторений серии fromEventId, начиная с повторения from, в серию toEventId,
+// сдвигая их на shift вместе с повторениями серии
+func (r EventException) Move(ctx context.Context, fromEventId, toEventId int, from time.Time, shift time.Duration) error {
+	query := `
+		UPDATE event_exception
+		SET event_id = $1, occurrence_date = occurrence_date + make_interval(secs => $2)
+		WHERE event_id = $3 AND occurrence_date >= $4
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, toEventId, shift.Seconds(), fromEventId, from)
+	if err != nil {
+		return errors.WithMessage(err, "move event exceptions")
+	}
+
+	return nil
+}
+
+// DeleteFrom удаляет изменения повторений серии, начиная с повторения from
+func (r EventException) DeleteFrom(ctx context.Context, eventId int, from time.Time) error {
+	query := `DELETE FROM event_exception WHERE event_id = $1 AND occurrence_date >= $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventId, from)
+	if err != nil {
+		return errors.WithMessage(err, "delete event exceptions")
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type EventInvitation struct {
+	db *sqlx.DB
+}
+
+func NewEventInvitation(db *sqlx.DB) EventInvitation {
+	return EventInvitation{
+		db: db,
+	}
+}
+
+// Save сохраняет приглашение участника. Повторная отправка заменяет nonce и срок действия,
+// поэтому прежние ссылки перестают действовать
+func (r EventInvitation) Save(ctx context.Context, invitation model.EventInvitation) error {
+	query := `
+		INSERT INTO event_invitation (event_participant_id, nonce, expires_at, sent_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (event_participant_id) DO UPDATE
+		SET nonce = EXCLUDED.nonce, expires_at = EXCLUDED.expires_at, sent_at = EXCLUDED.sent_at
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(
+		ctx,
+		query,
+		invitation.EventParticipantID,
+		invitation.Nonce,
+		invitation.ExpiresAt,
+		invitation.SentAt,
+	)
+	if err != nil {
+		return errors.WithMessage(err, "save event invitation")
+	}
+
+	return nil
+}
+
+// GetByParticipant возвращает приглашение участника, ожидающее ответа
+func (r EventInvitation) GetByParticipant(ctx context.Context, participantId int) (model.EventInvitation, error) {
+	var invitation model.EventInvitation
+
+	query := `
+		SELECT event_participant_id, nonce, expires_at, sent_at
+		FROM event_invitation
+		WHERE event_participant_id = $1
+	`
+
+	err := conn(ctx, r.db).GetContext(ctx, &invitation, query, participantId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EventInvitation{}, model.ErrInvitationNotFound
+		}
+		return model.EventInvitation{}, errors.WithMessage(err, "get event invitation")
+	}
+
+	return invitation, nil
+}
+
+// Delete удаляет приглашение после ответа участника
+func (r EventInvitation) Delete(ctx context.Context, participantId int) error {
+	query := `DELETE FROM event_invitation WHERE event_participant_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, participantId)
+	if err != nil {
+		return errors.WithMessage(err, "delete event invitation")
+	}
+
+	return nil
+}
+
+// ListByEvent возвращает приглашения события, ожидающие ответа, в порядке отправки
+func (r EventInvitation) ListByEvent(ctx context.Context, eventId int) ([]model.PendingInvitation, error) {
+	invitations := make([]model.PendingInvitation, 0)
+
+	query := `
+		SELECT i.event_participant_id, i.nonce, i.expires_at, i.sent_at, p.event_id, p.user_id, p.role,
+			u.username, u.email,` + waitlistPosition + `
+		FROM event_invitation i
+		JOIN event_participant p ON p.event_participant_id = i.event_participant_id
+		JOIN users u ON u.user_id = p.user_id
+		WHERE p.event_id = $1
+		ORDER BY i.sent_at, i.event_participant_id
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &invitations, query, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list event invitations")
+	}
+
+	return invitations, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+const inviteCodeColumns = `
+	invite_code_id, event_id, code, role, max_uses, uses_count, expires_at, created_by, created_at,
+	last_used_at, revoked_at
+`
+
+type EventInviteCode struct {
+	db *sqlx.DB
+}
+
+func NewEventInviteCode(db *sqlx.DB) EventInviteCode {
+	return EventInviteCode{
+		db: db,
+	}
+}
+
+func (r EventInviteCode) Create(ctx context.Context, code model.EventInviteCode) (int, error) {
+	var id int
+
+	query := `
+		INSERT INTO event_invite_code (event_id, code, role, max_uses, expires_at, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING invite_code_id
+	`
+
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		code.EventID,
+		code.Code,
+		code.Role,
+		code.MaxUses,
+		code.ExpiresAt,
+		code.CreatedBy,
+		code.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.WithMessage(err, "create event invite code")
+	}
+
+	return id, nil
+}
+
+// GetById возвращает код приглашения, в том числе отозванный
+func (r EventInviteCode) GetById(ctx context.Context, id int) (model.EventInviteCode, error) {
+	var code model.EventInviteCode
+
+	query := `SELECT ` + inviteCodeColumns + ` FROM event_invite_code WHERE invite_code_id = $1`
+
+	err := conn(ctx, r.db).GetContext(ctx, &code, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EventInviteCode{}, model.ErrInviteCodeNotFound
+		}
+		return model.EventInviteCode{}, errors.WithMessage(err, "get event invite code")
+	}
+
+	return code, nil
+}
+
+// GetByCodeForUpdate возвращает код приглашения и блокирует его до конца транзакции, чтобы
+// одновременные вступления не превысили ограничение числа использований
+func (r EventInviteCode) GetByCodeForUpdate(ctx context.Context, value string) (model.EventInviteCode, error) {
+	var code model.EventInviteCode
+
+	query := `SELECT ` + inviteCodeColumns + ` FROM event_invite_code WHERE code = $1 FOR UPDATE`
+
+	err := conn(ctx, r.db).GetContext(ctx, &code, query, value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EventInviteCode{}, model.ErrInviteCodeNotFound
+		}
+		return model.EventInviteCode{}, errors.WithMessage(err, "get event invite code by code")
+	}
+
+	return code, nil
+}
+
+// RecordUse учитывает вступление по коду
+func (r EventInviteCode) RecordUse(ctx context.Context, id int, now time.Time) error {
+	query := `UPDATE event_invite_code SET uses_count = uses_count + 1, last_used_at = $2 WHERE invite_code_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id, now)
+	if err != nil {
+		return errors.WithMessage(err, "record event invite code use")
+	}
+
+	return nil
+}
+
+// Revoke отзывает код. Статистика использования отозванного кода сохраняется
+func (r EventInviteCode) Revoke(ctx context.Context, id int, now time.Time) error {
+	query := `UPDATE event_invite_code SET revoked_at = $2 WHERE invite_code_id = $1 AND revoked_at IS NULL`
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, now)
+	if err != nil {
+		return errors.WithMessage(err, "revoke event invite code")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithMessage(err, "get affected rows")
+	}
+	if rows == 0 {
+		return model.ErrInviteCodeNotFound
+	}
+
+	return nil
+}
+
+// ListByEvent возвращает коды приглашения события, новые первыми
+func (r EventInviteCode) ListByEvent(ctx context.Context, eventId int) ([]model.EventInviteCode, error) {
+	codes := make([]model.EventInviteCode, 0)
+
+	query := `
+		SELECT ` + inviteCodeColumns + `
+		FROM event_invite_code
+		WHERE event_id = $1
+		ORDER BY created_at DESC, invite_code_id DESC
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &codes, query, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list event invite codes")
+	}
+
+	return codes, nil
+}
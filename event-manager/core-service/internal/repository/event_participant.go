@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/pkg/errors"
+	"strings"
+	"time"
 
 	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type Participant struct {
@@ -19,14 +23,27 @@ func NewParticipant(db *sqlx.DB) Participant {
 	}
 }
 
+// waitlistPosition место участника p в листе ожидания события, NULL у участника, занявшего место
+const waitlistPosition = `
+	CASE WHEN p.waitlisted_at IS NULL THEN NULL ELSE (
+		SELECT COUNT(*)
+		FROM event_participant w
+		WHERE w.event_id = p.event_id AND w.waitlisted_at IS NOT NULL
+			AND (w.waitlisted_at, w.event_participant_id) <= (p.waitlisted_at, p.event_participant_id)
+	) END AS waitlist_position`
+
+// participantColumns колонки участника p вместе с его местом в листе ожидания события
+const participantColumns = `
+	p.event_participant_id, p.event_id, p.user_id, p.role, p.joined_at, p.is_confirmed, p.waitlisted_at,` + waitlistPosition
+
 func (r Participant) Create(ctx context.Context, participant model.EventParticipant) (int, error) {
 	query := `
-		INSERT INTO event_participant (event_id, user_id, role, joined_at, is_confirmed)
-		VALUES ($1, $2, $3, $4, $5) RETURNING event_participant_id
+		INSERT INTO event_participant (event_id, user_id, role, joined_at, is_confirmed, waitlisted_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING event_participant_id
 	`
 
 	var id int
-	err := r.db.QueryRowContext(
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		participant.EventID,
@@ -34,6 +51,7 @@ func (r Participant) Create(ctx context.Context, participant model.EventParticip
 		participant.Role,
 		participant.JoinedAt,
 		participant.IsConfirmed,
+		participant.WaitlistedAt,
 	).Scan(&id)
 	if err != nil {
 		return 0, errors.WithMessage(err, "create event participant")
@@ -42,10 +60,28 @@ func (r Participant) Create(ctx context.Context, participant model.EventParticip
 	return id, nil
 }
 
+// CopyToEvent добавляет участников события fromEventId в событие toEventId с теми же ролями
+func (r Participant) CopyToEvent(ctx context.Context, fromEventId, toEventId int) error {
+	query := `
+		INSERT INTO event_participant (event_id, user_id, role, joined_at, is_confirmed, waitlisted_at)
+		SELECT $1, user_id, role, joined_at, is_confirmed, waitlisted_at
+		FROM event_participant
+		WHERE event_id = $2
+		ON CONFLICT (event_id, user_id) DO NOTHING
+	`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, toEventId, fromEventId)
+	if err != nil {
+		return errors.WithMessage(err, "copy event participants")
+	}
+
+	return nil
+}
+
 func (r Participant) GetById(ctx context.Context, id int) (model.EventParticipant, error) {
 	var participant model.EventParticipant
-	query := `SELECT * FROM event_participant WHERE event_participant_id = $1`
-	err := r.db.GetContext(ctx, &participant, query, id)
+	query := `SELECT ` + participantColumns + ` FROM event_participant p WHERE p.event_participant_id = $1`
+	err := conn(ctx, r.db).GetContext(ctx, &participant, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.EventParticipant{}, errors.WithMessage(err, "event participant not found")
@@ -57,8 +93,8 @@ func (r Participant) GetById(ctx context.Context, id int) (model.EventParticipan
 
 func (r Participant) GetByEventAndUser(ctx context.Context, eventId, userId int) (model.EventParticipant, error) {
 	var participant model.EventParticipant
-	query := `SELECT * FROM event_participant WHERE event_id = $1 AND user_id = $2`
-	err := r.db.GetContext(ctx, &participant, query, eventId, userId)
+	query := `SELECT ` + participantColumns + ` FROM event_participant p WHERE p.event_id = $1 AND p.user_id = $2`
+	err := conn(ctx, r.db).GetContext(ctx, &participant, query, eventId, userId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.EventParticipant{}, errors.WithMessage(err, "event participant not found")
@@ -69,8 +105,9 @@ func (r Participant) GetByEventAndUser(ctx context.Context, eventId, userId int)
 }
 
 func (r Participant) Update(ctx context.Context, participant model.EventParticipant) error {
-	query := `UPDATE event_participant SET role = $1, is_confirmed = $2 WHERE event_participant_id = $3`
-	_, err := r.db.ExecContext(ctx, query, participant.Role, participant.IsConfirmed, participant.EventParticipantID)
+	query := `UPDATE event_participant SET role = $1, is_confirmed = $2, waitlisted_at = $3 WHERE event_participant_id = $4`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, participant.Role, participant.IsConfirmed, participant.WaitlistedAt,
+		participant.EventParticipantID)
 	if err != nil {
 		return errors.WithMessage(err, "update event participant")
 	}
@@ -79,7 +116,7 @@ func (r Participant) Update(ctx context.Context, participant model.EventParticip
 
 func (r Participant) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM event_participant WHERE event_participant_id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return errors.WithMessage(err, "delete event participant")
 	}
@@ -88,31 +125,194 @@ func (r Participant) Delete(ctx context.Context, id int) error {
 
 func (r Participant) DeleteByEventAndUser(ctx context.Context, eventId, userId int) error {
 	query := `DELETE FROM event_participant WHERE event_id = $1 AND user_id = $2`
-	_, err := r.db.ExecContext(ctx, query, eventId, userId)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, eventId, userId)
 	if err != nil {
 		return errors.WithMessage(err, "delete event participant")
 	}
 	return nil
 }
 
-func (r Participant) ListByEvent(ctx context.Context, eventId, limit, offset int) ([]model.EventParticipant, error) {
-	var participants []model.EventParticipant
+// ListAllByEvent возвращает всех участников события в порядке присоединения
+func (r Participant) ListAllByEvent(ctx context.Context, eventId int) ([]model.EventParticipant, error) {
+	participants := make([]model.EventParticipant, 0)
 
-	query := `SELECT * FROM event_participant WHERE event_id = $1 ORDER BY joined_at DESC LIMIT $2 OFFSET $3`
-	if err := r.db.SelectContext(ctx, &participants, query, eventId, limit, offset); err != nil {
-		return nil, errors.WithMessage(err, "list event participants")
+	query := `SELECT ` + participantColumns + ` FROM event_participant p WHERE p.event_id = $1 ORDER BY p.event_participant_id`
+	if err := conn(ctx, r.db).SelectContext(ctx, &participants, query, eventId); err != nil {
+		return nil, errors.WithMessage(err, "list all event participants")
 	}
 
 	return participants, nil
 }
 
-func (r Participant) ListByUser(ctx context.Context, userId, limit, offset int) ([]model.EventParticipant, error) {
-	var participants []model.EventParticipant
+// likeEscaper экранирует спецсимволы шаблона LIKE в поисковой фразе
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
 
-	query := `SELECT * FROM event_participant WHERE user_id = $1 ORDER BY joined_at DESC LIMIT $2 OFFSET $3`
-	if err := r.db.SelectContext(ctx, &participants, query, userId, limit, offset); err != nil {
-		return nil, errors.WithMessage(err, "list user events")
+// Search возвращает участников вместе с пользователями и названиями событий одним запросом
+// и общее число участников, подходящих под фильтр
+func (r Participant) Search(ctx context.Context, filter model.ParticipantFilter) ([]model.ParticipantDetails, int, error) {
+	args := make([]any, 0)
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
 	}
 
-	return participants, nil
+	where := []string{"TRUE"}
+	if filter.EventID != nil {
+		where = append(where, "p.event_id = "+arg(*filter.EventID))
+	}
+	if filter.UserID != nil {
+		where = append(where, "p.user_id = "+arg(*filter.UserID))
+	}
+	if len(filter.Roles) > 0 {
+		roles := make([]string, len(filter.Roles))
+		for i, role := range filter.Roles {
+			roles[i] = string(role)
+		}
+		where = append(where, "p.role = ANY("+arg(pq.Array(roles))+")")
+	}
+	switch filter.Status {
+	case model.ParticipationConfirmed:
+		where = append(where, "p.waitlisted_at IS NULL AND p.is_confirmed = TRUE")
+	case model.ParticipationPending:
+		where = append(where, "p.waitlisted_at IS NULL AND p.is_confirmed IS NULL")
+	case model.ParticipationDeclined:
+		where = append(where, "p.is_confirmed = FALSE")
+	case model.ParticipationWaitlisted:
+		where = append(where, "p.waitlisted_at IS NOT NULL")
+	}
+	if filter.Query != "" {
+		where = append(where, "u.username ILIKE '%' || "+arg(likeEscaper.Replace(filter.Query))+" || '%'")
+	}
+
+	from := `
+		FROM event_participant p
+		JOIN users u ON u.user_id = p.user_id
+		JOIN events e ON e.event_id = p.event_id
+		WHERE ` + strings.Join(where, " AND ")
+
+	var total int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*)`+from, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, errors.WithMessage(err, "count event participants")
+	}
+
+	query := `
+		SELECT ` + participantColumns + `,
+			u.user_id AS "user.id", u.username AS "user.username", u.email AS "user.email", u.role AS "user.role",
+			u.is_active AS "user.is_active", u.is_deleted AS "user.is_deleted", u.created_at AS "user.created_at",
+			e.title AS event_title` + from + `
+		ORDER BY p.joined_at DESC NULLS LAST, p.event_participant_id DESC
+		LIMIT ` + arg(filter.Limit) + ` OFFSET ` + arg(filter.Offset)
+
+	participants := make([]model.ParticipantDetails, 0)
+	if err := conn(ctx, r.db).SelectContext(ctx, &participants, query, args...); err != nil {
+		return nil, 0, errors.WithMessage(err, "search event participants")
+	}
+
+	return participants, total, nil
+}
+
+// CountActive возвращает число участников события, занимающих место: не в листе ожидания и не отказавшихся
+func (r Participant) CountActive(ctx context.Context, eventId int) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM event_participant
+		WHERE event_id = $1 AND waitlisted_at IS NULL AND is_confirmed IS DISTINCT FROM FALSE
+	`
+
+	var count int
+	if err := conn(ctx, r.db).GetContext(ctx, &count, query, eventId); err != nil {
+		return 0, errors.WithMessage(err, "count active event participants")
+	}
+
+	return count, nil
+}
+
+// PromoteWaitlist переводит участников из листа ожидания на свободные места события в порядке очереди
+// и возвращает переведенных пользователей. У события без ограничения переводится весь лист ожидания
+func (r Participant) PromoteWaitlist(ctx context.Context, eventId int, now time.Time) ([]model.User, error) {
+	query := `
+		WITH free AS (
+			SELECT CASE WHEN e.max_participants IS NULL THEN NULL ELSE GREATEST(e.max_participants - (
+				SELECT COUNT(*)
+				FROM event_participant a
+				WHERE a.event_id = e.event_id AND a.waitlisted_at IS NULL AND a.is_confirmed IS DISTINCT FROM FALSE
+			), 0) END AS slots
+			FROM events e
+			WHERE e.event_id = $1
+		), promoted AS (
+			UPDATE event_participant
+			SET waitlisted_at = NULL, joined_at = $2
+			WHERE event_participant_id IN (
+				SELECT w.event_participant_id
+				FROM event_participant w
+				WHERE w.event_id = $1 AND w.waitlisted_at IS NOT NULL
+				ORDER BY w.waitlisted_at, w.event_participant_id
+				LIMIT (SELECT slots FROM free)
+			)
+			RETURNING user_id
+		)
+		SELECT u.user_id, u.username, u.email
+		FROM promoted p
+		JOIN users u ON u.user_id = p.user_id
+		ORDER BY u.user_id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, eventId, now)
+	if err != nil {
+		return nil, errors.WithMessage(err, "promote event waitlist")
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(&user.UserId, &user.Username, &user.Email); err != nil {
+			return nil, errors.WithMessage(err, "scan promoted user")
+		}
+		users = append(users, user)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.WithMessage(rows.Err(), "rows err")
+	}
+
+	return users, nil
+}
+
+// ListEventUsers возвращает подтвердивших участие и занимающих место пользователей события
+// с указанными ролями для рассылки уведомлений
+func (r Participant) ListEventUsers(ctx context.Context, eventId int, roles []model.ParticipantRole) ([]model.User, error) {
+	query, args, err := sqlx.In(`
+		SELECT u.user_id, u.username, u.email
+		FROM event_participant ep
+		JOIN users u ON u.user_id = ep.user_id
+		WHERE ep.event_id = ? AND ep.role IN (?) AND u.is_deleted = FALSE
+			AND ep.waitlisted_at IS NULL AND ep.is_confirmed = TRUE
+		ORDER BY u.user_id
+	`, eventId, roles)
+	if err != nil {
+		return nil, errors.WithMessage(err, "build list event users query")
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list event users")
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0)
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(&user.UserId, &user.Username, &user.Email); err != nil {
+			return nil, errors.WithMessage(err, "scan event user")
+		}
+		users = append(users, user)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.WithMessage(rows.Err(), "rows err")
+	}
+
+	return users, nil
 }
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+type EventTemplate struct {
+	db *sqlx.DB
+}
+
+func NewEventTemplate(db *sqlx.DB) EventTemplate {
+	return EventTemplate{
+		db: db,
+	}
+}
+
+func (r EventTemplate) Create(ctx context.Context, template model.EventTemplate) (int, error) {
+	query := `
+		INSERT INTO event_template (owner_id, name, source_event_id, content, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING template_id
+	`
+
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		template.OwnerID,
+		template.Name,
+		template.SourceEventID,
+		template.Content,
+		template.CreatedAt,
+	).Scan(&id)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return 0, model.ErrTemplateExists
+	}
+	if err != nil {
+		return 0, errors.WithMessage(err, "create event template")
+	}
+
+	return id, nil
+}
+
+func (r EventTemplate) GetById(ctx context.Context, id int) (model.EventTemplate, error) {
+	var template model.EventTemplate
+
+	query := `
+		SELECT template_id, owner_id, name, source_event_id, content, created_at
+		FROM event_template
+		WHERE template_id = $1
+	`
+
+	err := conn(ctx, r.db).GetContext(ctx, &template, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.EventTemplate{}, model.ErrTemplateNotFound
+	}
+	if err != nil {
+		return model.EventTemplate{}, errors.WithMessage(err, "get event template by id")
+	}
+
+	return template, nil
+}
+
+// ListByOwner возвращает шаблоны пользователя, новые первыми
+func (r EventTemplate) ListByOwner(ctx context.Context, ownerId int) ([]model.EventTemplate, error) {
+	templates := make([]model.EventTemplate, 0)
+
+	query := `
+		SELECT template_id, owner_id, name, source_event_id, content, created_at
+		FROM event_template
+		WHERE owner_id = $1
+		ORDER BY created_at DESC, template_id DESC
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &templates, query, ownerId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list event templates")
+	}
+
+	return templates, nil
+}
+
+func (r EventTemplate) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM event_template WHERE template_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete event template")
+	}
+
+	return nil
+}
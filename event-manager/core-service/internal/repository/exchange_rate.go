@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type ExchangeRate struct {
+	db *sqlx.DB
+}
+
+func NewExchangeRate(db *sqlx.DB) ExchangeRate {
+	return ExchangeRate{
+		db: db,
+	}
+}
+
+func (r ExchangeRate) Get(ctx context.Context, fromCurrency, toCurrency string) (model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+
+	query := `
+		SELECT from_currency, to_currency, rate, updated_at
+		FROM exchange_rate
+		WHERE from_currency = $1 AND to_currency = $2
+	`
+
+	err := conn(ctx, r.db).GetContext(ctx, &rate, query, fromCurrency, toCurrency)
+	if err != nil {
+		return model.ExchangeRate{}, errors.WithMessage(err, "get exchange rate")
+	}
+
+	return rate, nil
+}
+
+func (r ExchangeRate) List(ctx context.Context) ([]model.ExchangeRate, error) {
+	rates := make([]model.ExchangeRate, 0)
+
+	query := `
+		SELECT from_currency, to_currency, rate, updated_at
+		FROM exchange_rate
+		ORDER BY from_currency, to_currency
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &rates, query)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list exchange rates")
+	}
+
+	return rates, nil
+}
+
+// Upsert сохраняет курсы, перезаписывая существующие пары валют
+func (r ExchangeRate) Upsert(ctx context.Context, rates []model.ExchangeRate) error {
+	query := `
+		INSERT INTO exchange_rate (from_currency, to_currency, rate, updated_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (from_currency, to_currency) DO UPDATE
+		SET rate = EXCLUDED.rate, updated_at = EXCLUDED.updated_at
+	`
+
+	for _, rate := range rates {
+		_, err := conn(ctx, r.db).ExecContext(ctx, query, rate.FromCurrency, rate.ToCurrency, rate.Rate)
+		if err != nil {
+			return errors.WithMessagef(err, "upsert exchange rate %s/%s", rate.FromCurrency, rate.ToCurrency)
+		}
+	}
+
+	return nil
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
+	"strings"
 )
 
 type Expense struct {
@@ -20,18 +22,24 @@ func NewExpense(db *sqlx.DB) Expense {
 
 func (r Expense) CreateExpense(ctx context.Context, expense model.Expense) (int, error) {
 	query := `
-		INSERT INTO expense (event_id, created_by, description, amount, currency, split_method, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		INSERT INTO expense (event_id, created_by, description, amount, currency, exchange_rate, base_amount, split_method,
+			category_id, recurring_id, occurrence_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CURRENT_TIMESTAMP)
 		RETURNING expense_id
 	`
 	var id int
-	err := r.db.QueryRowContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		expense.EventID,
 		expense.CreatedBy,
 		expense.Description,
 		expense.Amount,
 		expense.Currency,
+		expense.ExchangeRate,
+		expense.BaseAmount,
 		expense.SplitMethod,
+		expense.CategoryID,
+		expense.RecurringID,
+		expense.Occurrence,
 	).Scan(&id)
 	if err != nil {
 		return 0, errors.WithMessage(err, "create expense")
@@ -40,9 +48,41 @@ func (r Expense) CreateExpense(ctx context.Context, expense model.Expense) (int,
 	return id, nil
 }
 
+// UpdateExpense сохраняет редактируемые поля расхода вместе с пересчитанным курсом и суммой в базовой валюте
+func (r Expense) UpdateExpense(ctx context.Context, expense model.Expense) error {
+	query := `
+		UPDATE expense
+		SET description = $1, amount = $2, currency = $3, exchange_rate = $4, base_amount = $5, split_method = $6, category_id = $7
+		WHERE expense_id = $8
+	`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		expense.Description,
+		expense.Amount,
+		expense.Currency,
+		expense.ExchangeRate,
+		expense.BaseAmount,
+		expense.SplitMethod,
+		expense.CategoryID,
+		expense.ExpenseID,
+	)
+	if err != nil {
+		return errors.WithMessage(err, "update expense")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return errors.WithMessage(err, "get affected rows")
+	}
+	if rows == 0 {
+		return model.ErrExpenseNotFound
+	}
+
+	return nil
+}
+
 func (r Expense) DeleteExpense(ctx context.Context, id int) error {
 	query := `DELETE FROM expense WHERE expense_id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return errors.WithMessage(err, "delete expense")
 	}
@@ -52,23 +92,29 @@ func (r Expense) DeleteExpense(ctx context.Context, id int) error {
 
 func (r Expense) GetExpenseById(ctx context.Context, id int) (*model.Expense, error) {
 	query := `
-		SELECT expense_id, event_id, created_by, description, amount, currency, split_method, created_at
+		SELECT expense_id, event_id, created_by, description, amount, currency, exchange_rate, base_amount, split_method,
+			category_id, recurring_id, occurrence_date, created_at
 		FROM expense
 		WHERE expense_id = $1
 	`
 	var expense model.Expense
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&expense.ExpenseID,
 		&expense.EventID,
 		&expense.CreatedBy,
 		&expense.Description,
 		&expense.Amount,
 		&expense.Currency,
+		&expense.ExchangeRate,
+		&expense.BaseAmount,
 		&expense.SplitMethod,
+		&expense.CategoryID,
+		&expense.RecurringID,
+		&expense.Occurrence,
 		&expense.CreatedAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, errors.New("expense not found")
+		return nil, model.ErrExpenseNotFound
 	}
 	if err != nil {
 		return nil, errors.WithMessage(err, "get expense by id")
@@ -77,24 +123,82 @@ func (r Expense) GetExpenseById(ctx context.Context, id int) (*model.Expense, er
 	return &expense, nil
 }
 
-func (r Expense) ListExpensesByEventId(ctx context.Context, eventId int, limit, offset int) ([]model.Expense, int, error) {
-	countQuery := `SELECT COUNT(*) FROM expense WHERE event_id = $1`
+// ListExpenses возвращает страницу расходов по фильтру и общее число расходов, подходящих под фильтр
+// без учета курсора. Пагинация ключевая: по полю сортировки и expense_id
+func (r Expense) ListExpenses(ctx context.Context, filter model.ExpenseFilter) ([]model.Expense, int, error) {
+	args := []any{filter.EventID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := []string{"e.event_id = $1"}
+	if filter.CreatedBy != nil {
+		where = append(where, "e.created_by = "+arg(*filter.CreatedBy))
+	}
+	if filter.CategoryID != nil {
+		where = append(where, "e.category_id = "+arg(*filter.CategoryID))
+	}
+	if filter.Currency != "" {
+		where = append(where, "e.currency = "+arg(filter.Currency))
+	}
+	if filter.CreatedFrom != nil {
+		where = append(where, "e.created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		where = append(where, "e.created_at < "+arg(*filter.CreatedTo))
+	}
+	switch {
+	case filter.ParticipantID != nil:
+		cond := "EXISTS (SELECT 1 FROM expense_share es WHERE es.expense_id = e.expense_id AND es.user_id = " + arg(*filter.ParticipantID)
+		if filter.Paid != nil {
+			cond += " AND es.is_paid = " + arg(*filter.Paid)
+		}
+		where = append(where, cond+")")
+	case filter.Paid != nil && *filter.Paid:
+		// Оплаченным считается расход с долями, среди которых нет неоплаченных
+		where = append(where,
+			"EXISTS (SELECT 1 FROM expense_share es WHERE es.expense_id = e.expense_id)",
+			"NOT EXISTS (SELECT 1 FROM expense_share es WHERE es.expense_id = e.expense_id AND es.is_paid = false)")
+	case filter.Paid != nil:
+		where = append(where, "EXISTS (SELECT 1 FROM expense_share es WHERE es.expense_id = e.expense_id AND es.is_paid = false)")
+	}
+
+	countQuery := `SELECT COUNT(*) FROM expense e WHERE ` + strings.Join(where, " AND ")
 	var total int
-	err := r.db.QueryRowContext(ctx, countQuery, eventId).Scan(&total)
+	err := conn(ctx, r.db).QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, errors.WithMessage(err, "count expenses")
 	}
 
+	column := "e.created_at"
+	if filter.SortBy == model.ExpenseSortAmount {
+		column = "e.base_amount"
+	}
+	direction, cmp := "ASC", ">"
+	if filter.Desc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if filter.After != nil {
+		var value any = filter.After.CreatedAt
+		if filter.SortBy == model.ExpenseSortAmount {
+			value = filter.After.BaseAmount
+		}
+		where = append(where, fmt.Sprintf("(%s, e.expense_id) %s (%s, %s)", column, cmp, arg(value), arg(filter.After.ExpenseID)))
+	}
+
 	query := `
-		SELECT expense_id, event_id, created_by, description, amount, currency, split_method, created_at
-		FROM expense
-		WHERE event_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
-	rows, err := r.db.QueryContext(ctx, query, eventId, limit, offset)
+		SELECT e.expense_id, e.event_id, e.created_by, e.description, e.amount, e.currency, e.exchange_rate, e.base_amount, e.split_method,
+			e.category_id, e.recurring_id, e.occurrence_date, e.created_at
+		FROM expense e
+		WHERE ` + strings.Join(where, " AND ") + fmt.Sprintf(`
+		ORDER BY %[1]s %[2]s, e.expense_id %[2]s
+		LIMIT %[3]s`, column, direction, arg(filter.Limit))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, errors.WithMessage(err, "list expenses by event")
+		return nil, 0, errors.WithMessage(err, "list expenses")
 	}
 	defer rows.Close()
 
@@ -108,7 +212,12 @@ func (r Expense) ListExpensesByEventId(ctx context.Context, eventId int, limit,
 			&expense.Description,
 			&expense.Amount,
 			&expense.Currency,
+			&expense.ExchangeRate,
+			&expense.BaseAmount,
 			&expense.SplitMethod,
+			&expense.CategoryID,
+			&expense.RecurringID,
+			&expense.Occurrence,
 			&expense.CreatedAt,
 		)
 		if err != nil {
@@ -124,10 +233,11 @@ func (r Expense) ListExpensesByEventId(ctx context.Context, eventId int, limit,
 	return expenses, total, nil
 }
 
-func (r Expense) GetEventTotalExpenses(ctx context.Context, eventId int) (float64, error) {
-	query := `SELECT COALESCE(SUM(amount), 0) FROM expense WHERE event_id = $1`
-	var total float64
-	err := r.db.QueryRowContext(ctx, query, eventId).Scan(&total)
+// GetEventTotalExpenses возвращает сумму расходов события в его базовой валюте
+func (r Expense) GetEventTotalExpenses(ctx context.Context, eventId int) (model.Money, error) {
+	query := `SELECT COALESCE(SUM(base_amount), 0) FROM expense WHERE event_id = $1`
+	var total model.Money
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, eventId).Scan(&total)
 	if err != nil {
 		return 0, errors.WithMessage(err, "get event total expenses")
 	}
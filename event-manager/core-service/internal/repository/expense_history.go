@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type ExpenseHistory struct {
+	db *sqlx.DB
+}
+
+func NewExpenseHistory(db *sqlx.DB) ExpenseHistory {
+	return ExpenseHistory{
+		db: db,
+	}
+}
+
+func (r ExpenseHistory) Create(ctx context.Context, history model.ExpenseHistory) (int, error) {
+	query := `
+		INSERT INTO expense_history (expense_id, changed_by, changes, changed_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+		RETURNING history_id
+	`
+
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		history.ExpenseID,
+		history.ChangedBy,
+		history.Changes,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.WithMessage(err, "create expense history")
+	}
+
+	return id, nil
+}
+
+func (r ExpenseHistory) ListByExpense(ctx context.Context, expenseId int) ([]model.ExpenseHistory, error) {
+	history := make([]model.ExpenseHistory, 0)
+
+	query := `
+		SELECT history_id, expense_id, changed_by, changes, changed_at
+		FROM expense_history
+		WHERE expense_id = $1
+		ORDER BY changed_at DESC, history_id DESC
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &history, query, expenseId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list expense history")
+	}
+
+	return history, nil
+}
@@ -22,15 +22,17 @@ func NewExpenseShare(db *sqlx.DB) ExpenseShare {
 
 func (r ExpenseShare) CreateExpenseShare(ctx context.Context, share model.ExpenseShare) (int, error) {
 	query := `
-		INSERT INTO expense_share (expense_id, user_id, amount, is_paid, paid_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO expense_share (expense_id, user_id, amount, base_amount, split_value, is_paid, paid_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING share_id
 	`
 	var id int
-	err := r.db.QueryRowContext(ctx, query,
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
 		share.ExpenseID,
 		share.UserID,
 		share.Amount,
+		share.BaseAmount,
+		share.SplitValue,
 		share.IsPaid,
 		share.PaidAt,
 	).Scan(&id)
@@ -43,21 +45,23 @@ func (r ExpenseShare) CreateExpenseShare(ctx context.Context, share model.Expens
 
 func (r ExpenseShare) GetExpenseShareById(ctx context.Context, id int) (*model.ExpenseShare, error) {
 	query := `
-		SELECT share_id, expense_id, user_id, amount, is_paid, paid_at
+		SELECT share_id, expense_id, user_id, amount, base_amount, split_value, is_paid, paid_at
 		FROM expense_share
 		WHERE share_id = $1
 	`
 	var share model.ExpenseShare
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&share.ShareID,
 		&share.ExpenseID,
 		&share.UserID,
 		&share.Amount,
+		&share.BaseAmount,
+		&share.SplitValue,
 		&share.IsPaid,
 		&share.PaidAt,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, errors.New("expense share not found")
+		return nil, model.ErrExpenseShareNotFound
 	}
 	if err != nil {
 		return nil, errors.WithMessage(err, "get expense share by id")
@@ -68,11 +72,12 @@ func (r ExpenseShare) GetExpenseShareById(ctx context.Context, id int) (*model.E
 
 func (r ExpenseShare) ListExpenseSharesByExpenseId(ctx context.Context, expenseId int) ([]model.ExpenseShare, error) {
 	query := `
-		SELECT share_id, expense_id, user_id, amount, is_paid, paid_at
+		SELECT share_id, expense_id, user_id, amount, base_amount, split_value, is_paid, paid_at
 		FROM expense_share
 		WHERE expense_id = $1
+		ORDER BY share_id
 	`
-	rows, err := r.db.QueryContext(ctx, query, expenseId)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, expenseId)
 	if err != nil {
 		return nil, errors.WithMessage(err, "list expense shares by expense id")
 	}
@@ -86,6 +91,8 @@ func (r ExpenseShare) ListExpenseSharesByExpenseId(ctx context.Context, expenseI
 			&share.ExpenseID,
 			&share.UserID,
 			&share.Amount,
+			&share.BaseAmount,
+			&share.SplitValue,
 			&share.IsPaid,
 			&share.PaidAt,
 		)
@@ -102,18 +109,44 @@ func (r ExpenseShare) ListExpenseSharesByExpenseId(ctx context.Context, expenseI
 	return shares, nil
 }
 
+// ListExpenseSharesByExpenseIds загружает доли нескольких расходов одним запросом
+func (r ExpenseShare) ListExpenseSharesByExpenseIds(ctx context.Context, expenseIds []int) ([]model.ExpenseShare, error) {
+	shares := make([]model.ExpenseShare, 0)
+	if len(expenseIds) == 0 {
+		return shares, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT share_id, expense_id, user_id, amount, base_amount, split_value, is_paid, paid_at
+		FROM expense_share
+		WHERE expense_id IN (?)
+		ORDER BY expense_id, share_id
+	`, expenseIds)
+	if err != nil {
+		return nil, errors.WithMessage(err, "build list shares query")
+	}
+
+	err = conn(ctx, r.db).SelectContext(ctx, &shares, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list expense shares by expense ids")
+	}
+
+	return shares, nil
+}
+
 func (r ExpenseShare) UpdateExpenseSharePaidStatus(ctx context.Context, shareId int, isPaid bool) error {
 	var paidAt interface{} = nil
 	if isPaid {
 		paidAt = time.Now()
 	}
 
+	// При снятии отметки об оплате доля перестает быть погашенной платежом
 	query := `
 		UPDATE expense_share
-		SET is_paid = $1, paid_at = $2
+		SET is_paid = $1, paid_at = $2, settlement_id = CASE WHEN $1 THEN settlement_id END
 		WHERE share_id = $3
 	`
-	_, err := r.db.ExecContext(ctx, query, isPaid, paidAt, shareId)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, isPaid, paidAt, shareId)
 	if err != nil {
 		return errors.WithMessage(err, "update expense share paid status")
 	}
@@ -121,9 +154,130 @@ func (r ExpenseShare) UpdateExpenseSharePaidStatus(ctx context.Context, shareId
 	return nil
 }
 
+// UpdateExpenseShare сохраняет пересчитанную долю. Если доля перестает быть оплаченной,
+// она отвязывается от погасившего ее платежа
+func (r ExpenseShare) UpdateExpenseShare(ctx context.Context, share model.ExpenseShare) error {
+	query := `
+		UPDATE expense_share
+		SET amount = $1, base_amount = $2, split_value = $3, is_paid = $4, paid_at = $5,
+			settlement_id = CASE WHEN $4 THEN settlement_id END
+		WHERE share_id = $6
+	`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		share.Amount,
+		share.BaseAmount,
+		share.SplitValue,
+		share.IsPaid,
+		share.PaidAt,
+		share.ShareID,
+	)
+	if err != nil {
+		return errors.WithMessage(err, "update expense share")
+	}
+
+	return nil
+}
+
+func (r ExpenseShare) DeleteExpenseShare(ctx context.Context, shareId int) error {
+	query := `DELETE FROM expense_share WHERE share_id = $1`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, shareId)
+	if err != nil {
+		return errors.WithMessage(err, "delete expense share")
+	}
+
+	return nil
+}
+
+// ListUnpaidSharesBetween возвращает неоплаченные доли debtorId в расходах, созданных creditorId.
+// Доли блокируются, чтобы одновременные платежи не погасили одну долю дважды
+func (r ExpenseShare) ListUnpaidSharesBetween(ctx context.Context, eventId, debtorId, creditorId int) ([]model.ExpenseShare, error) {
+	shares := make([]model.ExpenseShare, 0)
+
+	query := `
+		SELECT es.share_id, es.expense_id, es.user_id, es.amount, es.base_amount, es.split_value, es.is_paid, es.paid_at
+		FROM expense_share es
+		JOIN expense e ON e.expense_id = es.expense_id
+		WHERE e.event_id = $1 AND es.user_id = $2 AND e.created_by = $3 AND es.is_paid = false
+		ORDER BY e.created_at, es.share_id
+		FOR UPDATE OF es
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &shares, query, eventId, debtorId, creditorId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list unpaid shares between users")
+	}
+
+	return shares, nil
+}
+
+// ListUnpaidSharesByUser возвращает неоплаченные доли пользователя в расходах события, созданных другими.
+// Доли блокируются, чтобы их не оплатили во время переноса
+func (r ExpenseShare) ListUnpaidSharesByUser(ctx context.Context, eventId, userId int) ([]model.ExpenseShare, error) {
+	shares := make([]model.ExpenseShare, 0)
+
+	query := `
+		SELECT es.share_id, es.expense_id, es.user_id, es.amount, es.base_amount, es.split_value, es.is_paid, es.paid_at
+		FROM expense_share es
+		JOIN expense e ON e.expense_id = es.expense_id
+		WHERE e.event_id = $1 AND es.user_id = $2 AND e.created_by <> $2 AND es.is_paid = false
+		ORDER BY es.expense_id, es.share_id
+		FOR UPDATE OF es
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &shares, query, eventId, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list unpaid user shares")
+	}
+
+	return shares, nil
+}
+
+// HasUnpaidShares сообщает, что у пользователя есть неоплаченные доли в чужих расходах события
+// или другие должны ему по его расходам
+func (r ExpenseShare) HasUnpaidShares(ctx context.Context, eventId, userId int) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM expense e
+			JOIN expense_share es ON es.expense_id = e.expense_id
+			WHERE e.event_id = $1 AND es.is_paid = false AND es.user_id <> e.created_by
+				AND (es.user_id = $2 OR e.created_by = $2)
+		)
+	`
+
+	var exists bool
+	if err := conn(ctx, r.db).GetContext(ctx, &exists, query, eventId, userId); err != nil {
+		return false, errors.WithMessage(err, "check unpaid shares")
+	}
+
+	return exists, nil
+}
+
+func (r ExpenseShare) MarkSharesPaidBySettlement(ctx context.Context, settlementId int, shareIds []int) error {
+	if len(shareIds) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`
+		UPDATE expense_share
+		SET is_paid = true, paid_at = CURRENT_TIMESTAMP, settlement_id = ?
+		WHERE share_id IN (?)
+	`, settlementId, shareIds)
+	if err != nil {
+		return errors.WithMessage(err, "build mark shares query")
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return errors.WithMessage(err, "mark shares paid by settlement")
+	}
+
+	return nil
+}
+
 func (r ExpenseShare) DeleteExpenseSharesByExpenseId(ctx context.Context, expenseId int) error {
 	query := `DELETE FROM expense_share WHERE expense_id = $1`
-	_, err := r.db.ExecContext(ctx, query, expenseId)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, expenseId)
 	if err != nil {
 		return errors.WithMessage(err, "delete expense shares")
 	}
@@ -131,24 +285,24 @@ func (r ExpenseShare) DeleteExpenseSharesByExpenseId(ctx context.Context, expens
 	return nil
 }
 
-// GetUserBalanceInEvent рассчитывает баланс пользователя в событии
+// GetUserBalanceInEvent рассчитывает баланс пользователя в событии в его базовой валюте
 // Положительный баланс означает, что пользователь должен получить деньги
 // Отрицательный - должен заплатить
-func (r ExpenseShare) GetUserBalanceInEvent(ctx context.Context, userId int, eventId int) (float64, error) {
+func (r ExpenseShare) GetUserBalanceInEvent(ctx context.Context, userId int, eventId int) (model.Money, error) {
 	query := `
 		SELECT
     		(
                 -- Сумма расходов, созданных пользователем
-                COALESCE(SUM(CASE WHEN e.created_by = $2 THEN e.amount ELSE 0 END), 0) 
+                COALESCE(SUM(CASE WHEN e.created_by = $2 THEN e.base_amount ELSE 0 END), 0) 
                 -- Минус сумма неоплаченных долей пользователя
-                - COALESCE(SUM(CASE WHEN es.user_id = $2 AND es.is_paid = false THEN es.amount ELSE 0 END), 0)
+                - COALESCE(SUM(CASE WHEN es.user_id = $2 AND es.is_paid = false THEN es.base_amount ELSE 0 END), 0)
             ) AS balance
 		FROM expense e
 		LEFT JOIN expense_share es ON es.expense_id = e.expense_id
 		WHERE e.event_id = $1
 	`
-	var balance float64
-	err := r.db.QueryRowContext(ctx, query, eventId, userId).Scan(&balance)
+	var balance model.Money
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, eventId, userId).Scan(&balance)
 	if err != nil {
 		return 0, errors.WithMessage(err, "get user balance in event")
 	}
@@ -156,28 +310,66 @@ func (r ExpenseShare) GetUserBalanceInEvent(ctx context.Context, userId int, eve
 	return balance, nil
 }
 
+// GetEventBalanceReport рассчитывает балансы участников события в его базовой валюте.
+// Баланс — сумма, которую участнику должны другие, минус сумма, которую должен он сам,
+// по неоплаченным долям. Часть платежа, не покрывшая ни одной доли, учитывается как перевод.
+// В отчет попадают и бывшие участники, у которых остались расходы, доли или платежи события,
+// чтобы сумма балансов оставалась нулевой
 func (r ExpenseShare) GetEventBalanceReport(ctx context.Context, eventId int) ([]domain.UserBalance, error) {
 	query := `
-		SELECT 
-    		ep.user_id,
-    		u.username,
-    		(
-                -- Сумма расходов, которые создал пользователь
-                COALESCE(SUM(CASE WHEN e.created_by = ep.user_id THEN e.amount ELSE 0 END), 0) 
-                -- Минус сумма долей, которые пользователь должен оплатить
-                - COALESCE(SUM(CASE WHEN es.user_id = ep.user_id AND es.is_paid = false THEN es.amount ELSE 0 END), 0)
-                -- Учитываем только неоплаченные доли, оплаченные уже не влияют на баланс
-            ) AS balance
-		FROM event_participant ep
-		JOIN users u ON u.user_id = ep.user_id
-		LEFT JOIN expense e ON e.event_id = ep.event_id
-		LEFT JOIN expense_share es ON es.expense_id = e.expense_id AND es.user_id = ep.user_id
-		WHERE ep.event_id = $1
-		GROUP BY ep.user_id, u.username
-		ORDER BY balance DESC
+		WITH debts AS (
+			-- Неоплаченные доли участников в чужих расходах
+			SELECT e.created_by AS creditor_id, es.user_id AS debtor_id, es.base_amount AS amount
+			FROM expense e
+			JOIN expense_share es ON es.expense_id = e.expense_id
+			WHERE e.event_id = $1 AND es.is_paid = false AND es.user_id <> e.created_by
+		), transfers AS (
+			-- Остаток платежей, не распределенный по долям
+			SELECT s.from_user_id, s.to_user_id, s.amount - COALESCE(SUM(es.base_amount), 0) AS remainder
+			FROM settlement s
+			LEFT JOIN expense_share es ON es.settlement_id = s.settlement_id
+			WHERE s.event_id = $1
+			GROUP BY s.settlement_id
+		), shares AS (
+			SELECT es.user_id,
+				SUM(CASE WHEN es.is_paid = true THEN es.base_amount ELSE 0 END) AS paid_amount,
+				SUM(CASE WHEN es.is_paid = false THEN es.base_amount ELSE 0 END) AS unpaid_amount,
+				SUM(es.base_amount) AS total_due
+			FROM expense e
+			JOIN expense_share es ON es.expense_id = e.expense_id
+			WHERE e.event_id = $1
+			GROUP BY es.user_id
+		), members AS (
+			-- Текущие участники и все, кто фигурирует в расходах, долях и платежах события
+			SELECT user_id FROM event_participant WHERE event_id = $1
+			UNION
+			SELECT created_by FROM expense WHERE event_id = $1
+			UNION
+			SELECT es.user_id FROM expense e JOIN expense_share es ON es.expense_id = e.expense_id WHERE e.event_id = $1
+			UNION
+			SELECT from_user_id FROM settlement WHERE event_id = $1
+			UNION
+			SELECT to_user_id FROM settlement WHERE event_id = $1
+		)
+		SELECT
+			m.user_id,
+			u.username,
+			(
+				COALESCE((SELECT SUM(d.amount) FROM debts d WHERE d.creditor_id = m.user_id), 0)
+				- COALESCE((SELECT SUM(d.amount) FROM debts d WHERE d.debtor_id = m.user_id), 0)
+				+ COALESCE((SELECT SUM(t.remainder) FROM transfers t WHERE t.from_user_id = m.user_id), 0)
+				- COALESCE((SELECT SUM(t.remainder) FROM transfers t WHERE t.to_user_id = m.user_id), 0)
+			) AS balance,
+			COALESCE(sh.paid_amount, 0) AS paid_amount,
+			COALESCE(sh.unpaid_amount, 0) AS unpaid_amount,
+			COALESCE(sh.total_due, 0) AS total_due
+		FROM members m
+		JOIN users u ON u.user_id = m.user_id
+		LEFT JOIN shares sh ON sh.user_id = m.user_id
+		ORDER BY balance DESC, m.user_id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, eventId)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, eventId)
 	if err != nil {
 		return nil, errors.WithMessage(err, "get event balance report")
 	}
@@ -190,6 +382,9 @@ func (r ExpenseShare) GetEventBalanceReport(ctx context.Context, eventId int) ([
 			&balance.UserID,
 			&balance.Username,
 			&balance.Balance,
+			&balance.PaidAmount,
+			&balance.UnpaidAmount,
+			&balance.TotalDue,
 		)
 		if err != nil {
 			return nil, errors.WithMessage(err, "scan user balance")
@@ -203,3 +398,71 @@ func (r ExpenseShare) GetEventBalanceReport(ctx context.Context, eventId int) ([
 
 	return balances, nil
 }
+
+// GetUserCounterpartyBalances рассчитывает долги пользователя с каждым участником каждого его события.
+// Суммы указаны в базовой валюте события, пары без общих расходов и платежей не возвращаются
+func (r ExpenseShare) GetUserCounterpartyBalances(ctx context.Context, userId int) ([]model.CounterpartyBalance, error) {
+	balances := make([]model.CounterpartyBalance, 0)
+
+	query := `
+		WITH user_events AS (
+			SELECT event_id FROM event_participant WHERE user_id = $1
+		), pairs AS (
+			-- Доли других участников в расходах пользователя
+			SELECT e.event_id, es.user_id AS counterparty_id,
+				SUM(CASE WHEN es.is_paid = false THEN es.base_amount ELSE 0 END) AS owed_to_user,
+				0::NUMERIC AS user_owes,
+				SUM(CASE WHEN es.is_paid = true THEN es.base_amount ELSE 0 END) AS paid_to_user,
+				0::NUMERIC AS paid_by_user,
+				0::NUMERIC AS transfers
+			FROM expense e
+			JOIN expense_share es ON es.expense_id = e.expense_id
+			WHERE e.created_by = $1 AND es.user_id <> $1 AND e.event_id IN (SELECT event_id FROM user_events)
+			GROUP BY e.event_id, es.user_id
+			UNION ALL
+			-- Доли пользователя в чужих расходах
+			SELECT e.event_id, e.created_by,
+				0,
+				SUM(CASE WHEN es.is_paid = false THEN es.base_amount ELSE 0 END),
+				0,
+				SUM(CASE WHEN es.is_paid = true THEN es.base_amount ELSE 0 END),
+				0
+			FROM expense e
+			JOIN expense_share es ON es.expense_id = e.expense_id
+			WHERE es.user_id = $1 AND e.created_by <> $1 AND e.event_id IN (SELECT event_id FROM user_events)
+			GROUP BY e.event_id, e.created_by
+			UNION ALL
+			-- Остатки платежей, не покрывшие ни одной доли
+			SELECT s.event_id,
+				CASE WHEN s.from_user_id = $1 THEN s.to_user_id ELSE s.from_user_id END,
+				0, 0, 0, 0,
+				CASE WHEN s.from_user_id = $1 THEN 1 ELSE -1 END
+					* (s.amount - COALESCE((SELECT SUM(es.base_amount) FROM expense_share es WHERE es.settlement_id = s.settlement_id), 0))
+			FROM settlement s
+			WHERE $1 IN (s.from_user_id, s.to_user_id) AND s.event_id IN (SELECT event_id FROM user_events)
+		)
+		SELECT
+			p.event_id,
+			ev.title AS event_title,
+			ev.base_currency AS currency,
+			p.counterparty_id,
+			u.username,
+			SUM(p.owed_to_user) AS owed_to_user,
+			SUM(p.user_owes) AS user_owes,
+			SUM(p.paid_to_user) AS paid_to_user,
+			SUM(p.paid_by_user) AS paid_by_user,
+			SUM(p.transfers) AS transfers
+		FROM pairs p
+		JOIN events ev ON ev.event_id = p.event_id
+		JOIN users u ON u.user_id = p.counterparty_id
+		GROUP BY p.event_id, ev.title, ev.base_currency, ev.start_date, p.counterparty_id, u.username
+		ORDER BY ev.start_date DESC, p.event_id, p.counterparty_id
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &balances, query, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get user counterparty balances")
+	}
+
+	return balances, nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type RecurringExpense struct {
+	db *sqlx.DB
+}
+
+func NewRecurringExpense(db *sqlx.DB) RecurringExpense {
+	return RecurringExpense{
+		db: db,
+	}
+}
+
+const recurringExpenseColumns = `
+	recurring_id, event_id, created_by, category_id, description, amount, currency, split_method, split,
+	frequency, next_date, end_date, created_at
+`
+
+func (r RecurringExpense) Create(ctx context.Context, recurring model.RecurringExpense) (int, error) {
+	query := `
+		INSERT INTO recurring_expense (event_id, created_by, category_id, description, amount, currency, split_method, split,
+			frequency, next_date, end_date, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CURRENT_TIMESTAMP)
+		RETURNING recurring_id
+	`
+
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		recurring.EventID,
+		recurring.CreatedBy,
+		recurring.CategoryID,
+		recurring.Description,
+		recurring.Amount,
+		recurring.Currency,
+		recurring.SplitMethod,
+		recurring.Split,
+		recurring.Frequency,
+		recurring.NextDate,
+		recurring.EndDate,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.WithMessage(err, "create recurring expense")
+	}
+
+	return id, nil
+}
+
+func (r RecurringExpense) GetById(ctx context.Context, id int) (model.RecurringExpense, error) {
+	return r.get(ctx, `SELECT `+recurringExpenseColumns+` FROM recurring_expense WHERE recurring_id = $1`, id)
+}
+
+// GetByIdForUpdate возвращает правило, блокируя его до конца транзакции, чтобы одну дату
+// не обработали два экземпляра планировщика
+func (r RecurringExpense) GetByIdForUpdate(ctx context.Context, id int) (model.RecurringExpense, error) {
+	return r.get(ctx, `SELECT `+recurringExpenseColumns+` FROM recurring_expense WHERE recurring_id = $1 FOR UPDATE`, id)
+}
+
+func (r RecurringExpense) get(ctx context.Context, query string, id int) (model.RecurringExpense, error) {
+	var recurring model.RecurringExpense
+	err := conn(ctx, r.db).GetContext(ctx, &recurring, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.RecurringExpense{}, model.ErrRecurringExpenseNotFound
+	}
+	if err != nil {
+		return model.RecurringExpense{}, errors.WithMessage(err, "get recurring expense by id")
+	}
+
+	return recurring, nil
+}
+
+func (r RecurringExpense) ListByEvent(ctx context.Context, eventId int) ([]model.RecurringExpense, error) {
+	recurring := make([]model.RecurringExpense, 0)
+
+	query := `SELECT ` + recurringExpenseColumns + ` FROM recurring_expense WHERE event_id = $1 ORDER BY recurring_id`
+	err := conn(ctx, r.db).SelectContext(ctx, &recurring, query, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list recurring expenses")
+	}
+
+	return recurring, nil
+}
+
+// ListDueIds возвращает id правил, у которых наступила дата следующего расхода и она не позже
+// окончания правила или события. Правила архивных событий пропускаются
+func (r RecurringExpense) ListDueIds(ctx context.Context, date time.Time) ([]int, error) {
+	ids := make([]int, 0)
+
+	query := `
+		SELECT r.recurring_id
+		FROM recurring_expense r
+		JOIN events e ON e.event_id = r.event_id
+		WHERE r.next_date <= $1 AND r.next_date <= COALESCE(r.end_date, e.end_date::date) AND e.status <> $2
+		ORDER BY r.recurring_id
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &ids, query, date, model.EventStatusArchived)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list due recurring expenses")
+	}
+
+	return ids, nil
+}
+
+func (r RecurringExpense) UpdateNextDate(ctx context.Context, id int, nextDate time.Time) error {
+	query := `UPDATE recurring_expense SET next_date = $1 WHERE recurring_id = $2`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, nextDate, id)
+	if err != nil {
+		return errors.WithMessage(err, "update recurring expense next date")
+	}
+
+	return nil
+}
+
+// UpdateSplit сохраняет метод разделения и участников правила
+func (r RecurringExpense) UpdateSplit(ctx context.Context, id int, splitMethod string, split model.RecurringSplit) error {
+	query := `UPDATE recurring_expense SET split_method = $1, split = $2 WHERE recurring_id = $3`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, splitMethod, split, id)
+	if err != nil {
+		return errors.WithMessage(err, "update recurring expense split")
+	}
+
+	return nil
+}
+
+// Delete удаляет правило. Уже созданные по нему расходы сохраняются
+func (r RecurringExpense) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM recurring_expense WHERE recurring_id = $1`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.WithMessage(err, "delete recurring expense")
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type Settlement struct {
+	db *sqlx.DB
+}
+
+func NewSettlement(db *sqlx.DB) Settlement {
+	return Settlement{
+		db: db,
+	}
+}
+
+func (r Settlement) Create(ctx context.Context, settlement model.Settlement) (int, error) {
+	query := `
+		INSERT INTO settlement (event_id, from_user_id, to_user_id, amount, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING settlement_id
+	`
+
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query,
+		settlement.EventID,
+		settlement.FromUserID,
+		settlement.ToUserID,
+		settlement.Amount,
+		settlement.CreatedBy,
+		settlement.CreatedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.WithMessage(err, "create settlement")
+	}
+
+	return id, nil
+}
+
+func (r Settlement) ListByEvent(ctx context.Context, eventId int) ([]model.Settlement, error) {
+	settlements := make([]model.Settlement, 0)
+
+	query := `
+		SELECT settlement_id, event_id, from_user_id, to_user_id, amount, created_by, created_at
+		FROM settlement
+		WHERE event_id = $1
+		ORDER BY created_at DESC
+	`
+
+	err := conn(ctx, r.db).SelectContext(ctx, &settlements, query, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list event settlements")
+	}
+
+	return settlements, nil
+}
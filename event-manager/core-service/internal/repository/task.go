@@ -35,13 +35,13 @@ func (r Task) Create(ctx context.Context, task model.Task) (int, error) {
 	}
 
 	query := `
-        INSERT INTO tasks (event_id, parent_id, title, description, story_points, priority, status, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        INSERT INTO tasks (event_id, parent_id, title, description, story_points, priority, status, due_date, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
         RETURNING task_id
     `
 
 	var taskID int
-	err := r.db.QueryRowContext(
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		task.EventId,
@@ -51,6 +51,7 @@ func (r Task) Create(ctx context.Context, task model.Task) (int, error) {
 		task.StoryPoints,
 		task.Priority,
 		task.Status,
+		task.DueDate,
 		task.CreatedAt,
 	).Scan(&taskID)
 	if err != nil {
@@ -63,12 +64,12 @@ func (r Task) Create(ctx context.Context, task model.Task) (int, error) {
 func (r Task) GetById(ctx context.Context, id int) (model.Task, error) {
 	var task model.Task
 	query := `
-        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, created_at
+        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, due_date, created_at
         FROM tasks
         WHERE task_id = $1
     `
 
-	err := r.db.GetContext(ctx, &task, query, id)
+	err := conn(ctx, r.db).GetContext(ctx, &task, query, id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.Task{}, errors.WithMessage(err, "task not found")
@@ -82,11 +83,11 @@ func (r Task) GetById(ctx context.Context, id int) (model.Task, error) {
 func (r Task) Update(ctx context.Context, task model.Task) error {
 	query := `
         UPDATE tasks
-        SET title = $1, description = $2, story_points = $3, priority = $4, status = $5, parent_id = $6
-        WHERE task_id = $7
+        SET title = $1, description = $2, story_points = $3, priority = $4, status = $5, parent_id = $6, due_date = $7
+        WHERE task_id = $8
     `
 
-	_, err := r.db.ExecContext(
+	_, err := conn(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		task.Title,
@@ -95,6 +96,7 @@ func (r Task) Update(ctx context.Context, task model.Task) error {
 		task.Priority,
 		task.Status,
 		task.ParentId,
+		task.DueDate,
 		task.TaskId,
 	)
 	if err != nil {
@@ -107,7 +109,7 @@ func (r Task) Update(ctx context.Context, task model.Task) error {
 func (r Task) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM tasks WHERE task_id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return errors.WithMessage(err, "delete task")
 	}
@@ -119,14 +121,14 @@ func (r Task) ListByEvent(ctx context.Context, eventId, limit, offset int) ([]mo
 	var tasks []model.Task
 
 	query := `
-        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, created_at
+        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, due_date, created_at
         FROM tasks
         WHERE event_id = $1
         ORDER BY created_at DESC
         LIMIT $2 OFFSET $3
     `
 
-	err := r.db.SelectContext(ctx, &tasks, query, eventId, limit, offset)
+	err := conn(ctx, r.db).SelectContext(ctx, &tasks, query, eventId, limit, offset)
 	if err != nil {
 		return nil, errors.WithMessage(err, "list event tasks")
 	}
@@ -134,11 +136,30 @@ func (r Task) ListByEvent(ctx context.Context, eventId, limit, offset int) ([]mo
 	return tasks, nil
 }
 
+// ListAllByEvent возвращает все задачи события в порядке создания
+func (r Task) ListAllByEvent(ctx context.Context, eventId int) ([]model.Task, error) {
+	tasks := make([]model.Task, 0)
+
+	query := `
+        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, due_date, created_at
+        FROM tasks
+        WHERE event_id = $1
+        ORDER BY task_id
+    `
+
+	err := conn(ctx, r.db).SelectContext(ctx, &tasks, query, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list all event tasks")
+	}
+
+	return tasks, nil
+}
+
 func (r Task) ListByUser(ctx context.Context, userId, limit, offset int) ([]model.Task, error) {
 	var tasks []model.Task
 
 	query := `
-        SELECT DISTINCT t.task_id, t.event_id, t.parent_id, t.title, t.description, t.story_points, t.priority, t.status, t.created_at
+        SELECT DISTINCT t.task_id, t.event_id, t.parent_id, t.title, t.description, t.story_points, t.priority, t.status, t.due_date, t.created_at
         FROM tasks t
         JOIN task_assignment ta ON t.task_id = ta.task_id
         WHERE ta.user_id = $1
@@ -146,7 +167,7 @@ func (r Task) ListByUser(ctx context.Context, userId, limit, offset int) ([]mode
         LIMIT $2 OFFSET $3
     `
 
-	err := r.db.SelectContext(ctx, &tasks, query, userId, limit, offset)
+	err := conn(ctx, r.db).SelectContext(ctx, &tasks, query, userId, limit, offset)
 	if err != nil {
 		return nil, errors.WithMessage(err, "list user tasks")
 	}
@@ -158,17 +179,42 @@ func (r Task) ListByStatus(ctx context.Context, eventId int, status string, limi
 	var tasks []model.Task
 
 	query := `
-        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, created_at
+        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, due_date, created_at
         FROM tasks
         WHERE event_id = $1 AND status = $2
         ORDER BY created_at DESC
         LIMIT $3 OFFSET $4
     `
 
-	err := r.db.SelectContext(ctx, &tasks, query, eventId, status, limit, offset)
+	err := conn(ctx, r.db).SelectContext(ctx, &tasks, query, eventId, status, limit, offset)
 	if err != nil {
 		return nil, errors.WithMessage(err, "list event tasks by status")
 	}
 
 	return tasks, nil
 }
+
+// ListDueByEvents возвращает задачи указанных событий, у которых задан срок
+func (r Task) ListDueByEvents(ctx context.Context, eventIds []int) ([]model.Task, error) {
+	tasks := make([]model.Task, 0)
+	if len(eventIds) == 0 {
+		return tasks, nil
+	}
+
+	query, args, err := sqlx.In(`
+        SELECT task_id, event_id, parent_id, title, description, story_points, priority, status, due_date, created_at
+        FROM tasks
+        WHERE event_id IN (?) AND due_date IS NOT NULL
+        ORDER BY due_date, task_id
+    `, eventIds)
+	if err != nil {
+		return nil, errors.WithMessage(err, "build list due tasks query")
+	}
+
+	err = conn(ctx, r.db).SelectContext(ctx, &tasks, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list due tasks")
+	}
+
+	return tasks, nil
+}
@@ -40,7 +40,7 @@ func (r TaskAssignment) Create(ctx context.Context, assignment model.TaskAssignm
     `
 
 	var assignmentID int
-	err := r.db.QueryRowContext(
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		assignment.TaskId,
@@ -63,7 +63,7 @@ func (r TaskAssignment) GetByTaskAndUser(ctx context.Context, taskId, userId int
         WHERE task_id = $1 AND user_id = $2
     `
 
-	err := r.db.GetContext(ctx, &assignment, query, taskId, userId)
+	err := conn(ctx, r.db).GetContext(ctx, &assignment, query, taskId, userId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.TaskAssignment{}, errors.WithMessage(err, "task assignment not found")
@@ -81,7 +81,7 @@ func (r TaskAssignment) Update(ctx context.Context, assignment model.TaskAssignm
         WHERE task_id = $2
     `
 
-	_, err := r.db.ExecContext(
+	_, err := conn(ctx, r.db).ExecContext(
 		ctx,
 		query,
 		assignment.CompletedAt,
@@ -97,7 +97,7 @@ func (r TaskAssignment) Update(ctx context.Context, assignment model.TaskAssignm
 func (r TaskAssignment) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM task_assignment WHERE user_id = $1`
 
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		return errors.WithMessage(err, "delete task assignment")
 	}
@@ -116,7 +116,7 @@ func (r TaskAssignment) ListByTask(ctx context.Context, taskId, limit, offset in
         LIMIT $2 OFFSET $3
     `
 
-	err := r.db.SelectContext(ctx, &assignments, query, taskId, limit, offset)
+	err := conn(ctx, r.db).SelectContext(ctx, &assignments, query, taskId, limit, offset)
 	if err != nil {
 		return nil, errors.WithMessage(err, "list task assignments")
 	}
@@ -135,10 +135,59 @@ func (r TaskAssignment) ListByUser(ctx context.Context, userId, limit, offset in
         LIMIT $2 OFFSET $3
     `
 
-	err := r.db.SelectContext(ctx, &assignments, query, userId, limit, offset)
+	err := conn(ctx, r.db).SelectContext(ctx, &assignments, query, userId, limit, offset)
 	if err != nil {
 		return nil, errors.WithMessage(err, "list user task assignments")
 	}
 
 	return assignments, nil
 }
+
+// ListOpenByEventAndUser возвращает назначения пользователя на незавершенные задачи события
+func (r TaskAssignment) ListOpenByEventAndUser(ctx context.Context, eventId, userId int) ([]model.TaskAssignment, error) {
+	assignments := make([]model.TaskAssignment, 0)
+
+	query := `
+        SELECT ta.task_assignment_id, ta.task_id, ta.user_id, ta.assigned_at, ta.completed_at
+        FROM task_assignment ta
+        JOIN tasks t ON t.task_id = ta.task_id
+        WHERE t.event_id = $1 AND ta.user_id = $2 AND ta.completed_at IS NULL
+            AND t.status NOT IN ('completed', 'cancelled')
+        ORDER BY ta.task_id
+    `
+
+	err := conn(ctx, r.db).SelectContext(ctx, &assignments, query, eventId, userId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list open event task assignments")
+	}
+
+	return assignments, nil
+}
+
+// Reassign передает назначение другому пользователю
+func (r TaskAssignment) Reassign(ctx context.Context, id, userId int) error {
+	query := `
+        UPDATE task_assignment
+        SET user_id = $1, assigned_at = $2
+        WHERE task_assignment_id = $3
+    `
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, userId, time.Now(), id)
+	if err != nil {
+		return errors.WithMessage(err, "reassign task assignment")
+	}
+
+	return nil
+}
+
+// Unassign удаляет одно назначение
+func (r TaskAssignment) Unassign(ctx context.Context, id int) error {
+	query := `DELETE FROM task_assignment WHERE task_assignment_id = $1`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return errors.WithMessage(err, "unassign task")
+	}
+
+	return nil
+}
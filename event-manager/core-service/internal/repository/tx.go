@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+type txKey struct{}
+
+// queryer общий набор методов *sqlx.DB и *sqlx.Tx, используемый репозиториями
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+// conn возвращает транзакцию из контекста, если она открыта через Transactor, иначе пул соединений
+func conn(ctx context.Context, db *sqlx.DB) queryer {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// Transactor выполняет вызовы репозиториев в одной транзакции
+type Transactor struct {
+	db *sqlx.DB
+}
+
+func NewTransactor(db *sqlx.DB) Transactor {
+	return Transactor{
+		db: db,
+	}
+}
+
+// WithinTx выполняет fn в транзакции: все репозитории, вызванные с переданным в fn контекстом,
+// работают в ней. Если fn вернула ошибку, транзакция откатывается. Вложенный вызов
+// присоединяется к уже открытой транзакции
+func (t Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.WithMessage(err, "begin tx")
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.WithMessage(err, "commit tx")
+	}
+
+	return nil
+}
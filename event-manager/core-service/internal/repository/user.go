@@ -26,7 +26,7 @@ func (r User) GetUserById(ctx context.Context, id int) (*model.User, error) {
 		WHERE user_id = $1 AND is_deleted = FALSE
 	`
 	var user model.User
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&user.UserId,
 		&user.Username,
 		&user.PasswordHash,
@@ -53,7 +53,7 @@ func (r User) GetUserByUsername(ctx context.Context, username string) (*model.Us
 		WHERE username = $1 AND is_deleted = FALSE
 	`
 	var user model.User
-	err := r.db.QueryRowContext(ctx, query, username).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, username).Scan(
 		&user.UserId,
 		&user.Username,
 		&user.PasswordHash,
@@ -73,10 +73,38 @@ func (r User) GetUserByUsername(ctx context.Context, username string) (*model.Us
 	return &user, nil
 }
 
+// GetUserByEmail ищет пользователя по адресу без учета регистра
+func (r User) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	query := `
+		SELECT user_id, username, password_hash, email, is_active, is_deleted, role, created_at
+		FROM users
+		WHERE LOWER(email) = LOWER($1) AND is_deleted = FALSE
+	`
+	var user model.User
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(
+		&user.UserId,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Email,
+		&user.IsActive,
+		&user.IsDeleted,
+		&user.Role,
+		&user.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "get user by email")
+	}
+
+	return &user, nil
+}
+
 func (r User) ListUsers(ctx context.Context, limit, offset int) ([]model.User, int, error) {
 	countQuery := `SELECT COUNT(*) FROM users WHERE is_deleted = FALSE`
 	var total int
-	err := r.db.QueryRowContext(ctx, countQuery).Scan(&total)
+	err := conn(ctx, r.db).QueryRowContext(ctx, countQuery).Scan(&total)
 	if err != nil {
 		return nil, 0, errors.WithMessage(err, "count users")
 	}
@@ -88,7 +116,7 @@ func (r User) ListUsers(ctx context.Context, limit, offset int) ([]model.User, i
 		ORDER BY user_id
 		LIMIT $1 OFFSET $2
 	`
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, 0, errors.WithMessage(err, "list users")
 	}
@@ -11,6 +11,15 @@ type Controllers struct {
 	EventParticipantCtrl handler.ParticipantController
 	TaskCtrl             handler.TaskController
 	ExpenseCtrl          handler.ExpenseController
+	ExportCtrl           handler.ExportController
+	AttachmentCtrl       handler.AttachmentController
+	SettlementCtrl       handler.SettlementController
+	BudgetCtrl           handler.BudgetController
+	ExchangeRateCtrl     handler.ExchangeRateController
+	CalendarCtrl         handler.CalendarController
+	TemplateCtrl         handler.TemplateController
+	InvitationCtrl       handler.InvitationController
+	InviteCodeCtrl       handler.InviteCodeController
 }
 
 func SetupRoutes(router *gin.Engine, controllers *Controllers) {
@@ -24,18 +33,92 @@ func SetupRoutes(router *gin.Engine, controllers *Controllers) {
 		{
 			events.GET("", controllers.EventCtrl.List)
 			events.POST("", controllers.EventCtrl.Create)
-			//events.PUT("/:id", controllers.EventCtrl.Update)
+			events.PUT("/:event_id", controllers.EventCtrl.Update)
+			events.POST("/:event_id/status", controllers.EventCtrl.ChangeStatus)
 			events.DELETE("/:event_id", controllers.EventCtrl.Delete)
 			events.GET("/:event_id", controllers.EventCtrl.EventSummary)
+			events.GET("/:event_id/ics", controllers.CalendarCtrl.ExportEvent)
+
+			// Копирование события и сохранение его шаблоном
+			events.POST("/:event_id/clone", controllers.TemplateCtrl.Clone)
+			events.POST("/:event_id/templates", controllers.TemplateCtrl.Save)
+
+			// Маршруты повторений серии событий
+			occurrences := events.Group("/:event_id/occurrences")
+			{
+				occurrences.GET("", controllers.EventCtrl.ListOccurrences)
+				occurrences.PUT("", controllers.EventCtrl.UpdateOccurrence)
+				occurrences.POST("/cancel", controllers.EventCtrl.CancelOccurrence)
+			}
 
 			// Маршруты участников событий
 			participants := events.Group("/:event_id/participants")
 			{
+				participants.GET("", controllers.EventParticipantCtrl.ListByEvent)
 				participants.POST("", controllers.EventParticipantCtrl.Create)
-				participants.DELETE("/:user_id", controllers.EventParticipantCtrl.Delete)
+				participants.POST("/import", controllers.EventParticipantCtrl.Import)
+				participants.DELETE("/:event_part_id", controllers.EventParticipantCtrl.Delete)
+				participants.PUT("/:event_part_id/confirm", controllers.EventParticipantCtrl.ConfirmParticipation)
+				participants.PUT("/:event_part_id/decline", controllers.EventParticipantCtrl.DeclineParticipation)
+				participants.PUT("/:event_part_id/role", controllers.EventParticipantCtrl.ChangeRole)
+			}
+			events.POST("/:event_id/transfer", controllers.EventParticipantCtrl.TransferOwnership)
+
+			// Маршруты приглашений, ожидающих ответа
+			invitations := events.Group("/:event_id/invitations")
+			{
+				invitations.GET("", controllers.InvitationCtrl.List)
+				invitations.POST("/:event_part_id/resend", controllers.InvitationCtrl.Resend)
+				invitations.DELETE("/:event_part_id", controllers.InvitationCtrl.Revoke)
+			}
+
+			// Маршруты кодов приглашения и вступления в событие по коду
+			inviteCodes := events.Group("/:event_id/invite-codes")
+			{
+				inviteCodes.GET("", controllers.InviteCodeCtrl.List)
+				inviteCodes.POST("", controllers.InviteCodeCtrl.Create)
+				inviteCodes.DELETE("/:code_id", controllers.InviteCodeCtrl.Revoke)
+			}
+			events.POST("/join/:code", controllers.InviteCodeCtrl.Join)
+
+			// Маршруты взаиморасчетов
+			settlements := events.Group("/:event_id/settlements")
+			{
+				settlements.GET("", controllers.SettlementCtrl.GetPlan)
+				settlements.POST("", controllers.SettlementCtrl.Create)
+			}
+
+			// Выгрузка расходов, долей и балансов события
+			events.GET("/:event_id/expenses/export", controllers.ExportCtrl.ExportExpenses)
+
+			// Маршруты бюджета, категорий и повторяющихся расходов
+			events.GET("/:event_id/budget", controllers.BudgetCtrl.GetBudget)
+			events.PUT("/:event_id/budget", controllers.BudgetCtrl.UpdateBudget)
+
+			categories := events.Group("/:event_id/categories")
+			{
+				categories.GET("", controllers.BudgetCtrl.ListCategories)
+				categories.POST("", controllers.BudgetCtrl.CreateCategory)
+				categories.PUT("/:category_id", controllers.BudgetCtrl.UpdateCategory)
+				categories.DELETE("/:category_id", controllers.BudgetCtrl.DeleteCategory)
+			}
+
+			recurringExpenses := events.Group("/:event_id/recurring-expenses")
+			{
+				recurringExpenses.GET("", controllers.BudgetCtrl.ListRecurring)
+				recurringExpenses.POST("", controllers.BudgetCtrl.CreateRecurring)
+				recurringExpenses.DELETE("/:recurring_id", controllers.BudgetCtrl.DeleteRecurring)
 			}
 		}
 
+		// Маршруты шаблонов событий текущего пользователя
+		templates := api.Group("/templates")
+		{
+			templates.GET("", controllers.TemplateCtrl.List)
+			templates.DELETE("/:template_id", controllers.TemplateCtrl.Delete)
+			templates.POST("/:template_id/events", controllers.TemplateCtrl.CreateEvent)
+		}
+
 		// Маршруты задач
 		tasks := api.Group("/tasks")
 		{
@@ -45,10 +128,22 @@ func SetupRoutes(router *gin.Engine, controllers *Controllers) {
 			tasks.DELETE("/:task_id", controllers.TaskCtrl.Delete)
 		}
 
-		// Маршруты расходов - создание, обновление и удаление
+		// Маршруты расходов - список, создание, обновление и удаление
 		expenses := api.Group("/expenses")
 		{
+			expenses.GET("", controllers.ExpenseCtrl.List)
 			expenses.POST("", controllers.ExpenseCtrl.Create)
+			expenses.PUT("/:id", controllers.ExpenseCtrl.Update)
+			expenses.GET("/:id/history", controllers.ExpenseCtrl.History)
+
+			// Маршруты чеков расходов
+			attachments := expenses.Group("/:id/attachments")
+			{
+				attachments.GET("", controllers.AttachmentCtrl.List)
+				attachments.POST("", controllers.AttachmentCtrl.Upload)
+				attachments.GET("/:attachment_id", controllers.AttachmentCtrl.Download)
+				attachments.DELETE("/:attachment_id", controllers.AttachmentCtrl.Delete)
+			}
 			expenses.DELETE("/:id", controllers.ExpenseCtrl.Delete)
 
 			// Маршруты для долей расходов
@@ -57,5 +152,32 @@ func SetupRoutes(router *gin.Engine, controllers *Controllers) {
 				expenseShares.PUT("/:id/paid-status", controllers.ExpenseCtrl.UpdateExpenseSharePaidStatus)
 			}
 		}
+
+		// Маршруты текущего пользователя
+		users := api.Group("/users/me")
+		{
+			users.GET("/balances", controllers.ExpenseCtrl.UserBalances)
+			users.GET("/calendar", controllers.CalendarCtrl.FeedLink)
+			users.POST("/calendar/token", controllers.CalendarCtrl.RotateFeedLink)
+			users.POST("/invitations/claim", controllers.InvitationCtrl.Claim)
+			users.GET("/participations", controllers.EventParticipantCtrl.ListByUser)
+		}
+
+		// Лента календаря по токену, открывается без авторизации
+		api.GET("/calendar/:token", controllers.CalendarCtrl.Feed)
+
+		// Ссылка из письма открывает без авторизации страницу подтверждения, ответ на приглашение
+		// отправляется с нее POST-запросом: GET не меняет состояние и безопасен для почтовых сканеров
+		api.GET("/invitations/:token/accept", controllers.InvitationCtrl.AcceptPage)
+		api.GET("/invitations/:token/decline", controllers.InvitationCtrl.DeclinePage)
+		api.POST("/invitations/:token/accept", controllers.InvitationCtrl.Accept)
+		api.POST("/invitations/:token/decline", controllers.InvitationCtrl.Decline)
+
+		// Маршруты курсов валют
+		exchangeRates := api.Group("/exchange-rates")
+		{
+			exchangeRates.GET("", controllers.ExchangeRateCtrl.List)
+			exchangeRates.PUT("", controllers.ExchangeRateCtrl.Update)
+		}
 	}
 }
@@ -0,0 +1,190 @@
+package access
+
+import (
+	"context"
+	"database/sql"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type ParticipantRepo interface {
+	GetById(ctx context.Context, id int) (model.EventParticipant, error)
+	GetByEventAndUser(ctx context.Context, eventId, userId int) (model.EventParticipant, error)
+}
+
+type TaskRepo interface {
+	GetById(ctx context.Context, id int) (model.Task, error)
+}
+
+type ExpenseRepo interface {
+	GetExpenseById(ctx context.Context, id int) (*model.Expense, error)
+}
+
+type ExpenseShareRepo interface {
+	GetExpenseShareById(ctx context.Context, id int) (*model.ExpenseShare, error)
+}
+
+// permissions матрица прав: какие роли участника могут выполнять действие
+var permissions = map[domain.Permission][]model.ParticipantRole{
+	domain.PermissionViewEvent:          {model.RoleOrganizer, model.RoleAdmin, model.RoleParticipant},
+	domain.PermissionUpdateEvent:        {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionDeleteEvent:        {model.RoleOrganizer},
+	domain.PermissionManageParticipants: {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionCreateTask:         {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionUpdateTask:         {model.RoleOrganizer, model.RoleAdmin, model.RoleParticipant},
+	domain.PermissionDeleteTask:         {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionCreateExpense:      {model.RoleOrganizer, model.RoleAdmin, model.RoleParticipant},
+	domain.PermissionUpdateExpense:      {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionDeleteExpense:      {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionUpdateExpenseShare: {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionUploadAttachment:   {model.RoleOrganizer, model.RoleAdmin, model.RoleParticipant},
+	domain.PermissionRecordSettlement:   {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionManageBudget:       {model.RoleOrganizer, model.RoleAdmin},
+	domain.PermissionChangeRole:         {model.RoleOrganizer},
+	domain.PermissionTransferEvent:      {model.RoleOrganizer},
+	// На приглашение отвечает только сам приглашенный
+	domain.PermissionAnswerInvitation: {},
+}
+
+// inactivePermissions действия, доступные приглашенному, еще не ответившему, отказавшемуся
+// и ожидающему места участнику: посмотреть событие и ответить на приглашение
+var inactivePermissions = map[domain.Permission]bool{
+	domain.PermissionViewEvent:        true,
+	domain.PermissionAnswerInvitation: true,
+}
+
+// ownerPermissions действия, разрешенные владельцу ресурса независимо от роли:
+// автору расхода, самому участнику в отношении своего участия и сторонам платежа
+var ownerPermissions = map[domain.Permission]bool{
+	domain.PermissionUpdateExpense:      true,
+	domain.PermissionDeleteExpense:      true,
+	domain.PermissionUpdateExpenseShare: true,
+	domain.PermissionManageParticipants: true,
+	domain.PermissionRecordSettlement:   true,
+	domain.PermissionAnswerInvitation:   true,
+}
+
+type Service struct {
+	participantRepo  ParticipantRepo
+	taskRepo         TaskRepo
+	expenseRepo      ExpenseRepo
+	expenseShareRepo ExpenseShareRepo
+	logger           *zap.SugaredLogger
+}
+
+func NewService(participantRepo ParticipantRepo, taskRepo TaskRepo, expenseRepo ExpenseRepo, expenseShareRepo ExpenseShareRepo, logger *zap.SugaredLogger) Service {
+	return Service{
+		participantRepo:  participantRepo,
+		taskRepo:         taskRepo,
+		expenseRepo:      expenseRepo,
+		expenseShareRepo: expenseShareRepo,
+		logger:           logger,
+	}
+}
+
+// GetRole возвращает роль пользователя в событии
+func (s Service) GetRole(ctx context.Context, userId, eventId int) (model.ParticipantRole, error) {
+	participant, err := s.getParticipant(ctx, userId, eventId)
+	if err != nil {
+		return "", err
+	}
+
+	return participant.Role, nil
+}
+
+func (s Service) getParticipant(ctx context.Context, userId, eventId int) (model.EventParticipant, error) {
+	participant, err := s.participantRepo.GetByEventAndUser(ctx, eventId, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.EventParticipant{}, model.ErrNotEventParticipant
+	}
+	if err != nil {
+		s.logger.Errorw("Failed to get participant role", "error", err, "eventId", eventId, "userId", userId)
+		return model.EventParticipant{}, errors.WithMessage(err, "get participant")
+	}
+
+	return participant, nil
+}
+
+func (s Service) CheckEvent(ctx context.Context, userId, eventId int, perm domain.Permission) error {
+	return s.authorize(ctx, userId, eventId, perm, false)
+}
+
+func (s Service) CheckTask(ctx context.Context, userId, taskId int, perm domain.Permission) error {
+	task, err := s.taskRepo.GetById(ctx, taskId)
+	if err != nil {
+		return errors.WithMessage(err, "get task")
+	}
+
+	return s.authorize(ctx, userId, task.EventId, perm, false)
+}
+
+func (s Service) CheckExpense(ctx context.Context, userId, expenseId int, perm domain.Permission) error {
+	expense, err := s.expenseRepo.GetExpenseById(ctx, expenseId)
+	if err != nil {
+		return errors.WithMessage(err, "get expense")
+	}
+
+	return s.authorize(ctx, userId, expense.EventID, perm, expense.CreatedBy == userId)
+}
+
+func (s Service) CheckExpenseShare(ctx context.Context, userId, shareId int, perm domain.Permission) error {
+	share, err := s.expenseShareRepo.GetExpenseShareById(ctx, shareId)
+	if err != nil {
+		return errors.WithMessage(err, "get expense share")
+	}
+
+	expense, err := s.expenseRepo.GetExpenseById(ctx, share.ExpenseID)
+	if err != nil {
+		return errors.WithMessage(err, "get expense")
+	}
+
+	return s.authorize(ctx, userId, expense.EventID, perm, expense.CreatedBy == userId)
+}
+
+// CheckParticipant проверяет права на запись участия eventPartId, которая должна принадлежать событию eventId
+func (s Service) CheckParticipant(ctx context.Context, userId, eventId, eventPartId int, perm domain.Permission) error {
+	participant, err := s.participantRepo.GetById(ctx, eventPartId)
+	if err != nil {
+		return errors.WithMessage(err, "get participant")
+	}
+
+	if participant.EventID != eventId {
+		return errors.WithMessage(sql.ErrNoRows, "participant does not belong to event")
+	}
+
+	return s.authorize(ctx, userId, eventId, perm, participant.UserID == userId)
+}
+
+// CheckSettlement проверяет права на регистрацию платежа: стороны платежа могут провести его сами
+func (s Service) CheckSettlement(ctx context.Context, userId, eventId, fromUserId, toUserId int) error {
+	return s.authorize(ctx, userId, eventId, domain.PermissionRecordSettlement, userId == fromUserId || userId == toUserId)
+}
+
+func (s Service) authorize(ctx context.Context, userId, eventId int, perm domain.Permission, isOwner bool) error {
+	participant, err := s.getParticipant(ctx, userId, eventId)
+	if err != nil {
+		return err
+	}
+	role := participant.Role
+
+	// Права роли действуют только после подтверждения участия и вне листа ожидания
+	if !participant.Active() && !inactivePermissions[perm] {
+		s.logger.Warnw("Access denied to inactive participant", "userId", userId, "eventId", eventId, "permission", perm)
+		return errors.WithMessagef(model.ErrAccessDenied, "participation is not active, no permission %s", perm)
+	}
+
+	if isOwner && ownerPermissions[perm] {
+		return nil
+	}
+
+	for _, allowed := range permissions[perm] {
+		if role == allowed {
+			return nil
+		}
+	}
+
+	s.logger.Warnw("Access denied", "userId", userId, "eventId", eventId, "role", role, "permission", perm)
+	return errors.WithMessagef(model.ErrAccessDenied, "role %s has no permission %s", role, perm)
+}
@@ -0,0 +1,208 @@
+package access
+
+import (
+	"context"
+	"database/sql"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// Mock репозитория участников
+type MockParticipantRepo struct {
+	mock.Mock
+}
+
+func (m *MockParticipantRepo) GetById(ctx context.Context, id int) (model.EventParticipant, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.EventParticipant), args.Error(1)
+}
+
+func (m *MockParticipantRepo) GetByEventAndUser(ctx context.Context, eventId, userId int) (model.EventParticipant, error) {
+	args := m.Called(ctx, eventId, userId)
+	return args.Get(0).(model.EventParticipant), args.Error(1)
+}
+
+// Mock репозитория задач
+type MockTaskRepo struct {
+	mock.Mock
+}
+
+func (m *MockTaskRepo) GetById(ctx context.Context, id int) (model.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Task), args.Error(1)
+}
+
+// Mock репозитория расходов
+type MockExpenseRepo struct {
+	mock.Mock
+}
+
+func (m *MockExpenseRepo) GetExpenseById(ctx context.Context, id int) (*model.Expense, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*model.Expense), args.Error(1)
+}
+
+// Mock репозитория долей расходов
+type MockExpenseShareRepo struct {
+	mock.Mock
+}
+
+func (m *MockExpenseShareRepo) GetExpenseShareById(ctx context.Context, id int) (*model.ExpenseShare, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*model.ExpenseShare), args.Error(1)
+}
+
+type mocks struct {
+	participantRepo  *MockParticipantRepo
+	taskRepo         *MockTaskRepo
+	expenseRepo      *MockExpenseRepo
+	expenseShareRepo *MockExpenseShareRepo
+}
+
+var confirmed = true
+
+func setupService() (*Service, mocks) {
+	m := mocks{
+		participantRepo:  new(MockParticipantRepo),
+		taskRepo:         new(MockTaskRepo),
+		expenseRepo:      new(MockExpenseRepo),
+		expenseShareRepo: new(MockExpenseShareRepo),
+	}
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(m.participantRepo, m.taskRepo, m.expenseRepo, m.expenseShareRepo, logger.Sugar())
+
+	return &service, m
+}
+
+// Тест 1: Организатор может удалить событие
+func TestCheckEvent_OrganizerAllowed(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 1).
+		Return(model.EventParticipant{EventID: 10, UserID: 1, Role: model.RoleOrganizer, IsConfirmed: &confirmed}, nil)
+
+	err := service.CheckEvent(ctx, 1, 10, domain.PermissionDeleteEvent)
+
+	assert.NoError(t, err)
+	m.participantRepo.AssertExpectations(t)
+}
+
+// Тест 2: Обычный участник не может удалить событие
+func TestCheckEvent_ParticipantDenied(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 2).
+		Return(model.EventParticipant{EventID: 10, UserID: 2, Role: model.RoleParticipant, IsConfirmed: &confirmed}, nil)
+
+	err := service.CheckEvent(ctx, 2, 10, domain.PermissionDeleteEvent)
+
+	assert.ErrorIs(t, err, model.ErrAccessDenied)
+}
+
+// Тест 3: Пользователь, не участвующий в событии, не имеет доступа даже на просмотр
+func TestCheckEvent_NotParticipant(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 3).
+		Return(model.EventParticipant{}, errors.WithMessage(sql.ErrNoRows, "event participant not found"))
+
+	err := service.CheckEvent(ctx, 3, 10, domain.PermissionViewEvent)
+
+	assert.ErrorIs(t, err, model.ErrNotEventParticipant)
+}
+
+// Тест 4: Права на задачу проверяются по событию, которому она принадлежит
+func TestCheckTask_ResolvesEvent(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+
+	m.taskRepo.On("GetById", ctx, 5).Return(model.Task{TaskId: 5, EventId: 10}, nil)
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 2).
+		Return(model.EventParticipant{EventID: 10, UserID: 2, Role: model.RoleParticipant, IsConfirmed: &confirmed}, nil)
+
+	assert.NoError(t, service.CheckTask(ctx, 2, 5, domain.PermissionUpdateTask))
+	assert.ErrorIs(t, service.CheckTask(ctx, 2, 5, domain.PermissionDeleteTask), model.ErrAccessDenied)
+	m.taskRepo.AssertExpectations(t)
+}
+
+// Тест 5: Автор расхода может удалить свой расход, не будучи администратором
+func TestCheckExpense_OwnerAllowed(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+
+	m.expenseRepo.On("GetExpenseById", ctx, 7).Return(&model.Expense{ExpenseID: 7, EventID: 10, CreatedBy: 2}, nil)
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 2).
+		Return(model.EventParticipant{EventID: 10, UserID: 2, Role: model.RoleParticipant, IsConfirmed: &confirmed}, nil)
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 3).
+		Return(model.EventParticipant{EventID: 10, UserID: 3, Role: model.RoleParticipant, IsConfirmed: &confirmed}, nil)
+
+	assert.NoError(t, service.CheckExpense(ctx, 2, 7, domain.PermissionDeleteExpense))
+	assert.ErrorIs(t, service.CheckExpense(ctx, 3, 7, domain.PermissionDeleteExpense), model.ErrAccessDenied)
+}
+
+// Тест 6: Запись участия из другого события считается не найденной
+func TestCheckParticipant_OtherEvent(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+
+	m.participantRepo.On("GetById", ctx, 42).Return(model.EventParticipant{EventParticipantID: 42, EventID: 11, UserID: 2}, nil)
+
+	err := service.CheckParticipant(ctx, 1, 10, 42, domain.PermissionManageParticipants)
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	m.participantRepo.AssertNotCalled(t, "GetByEventAndUser", ctx, 10, 1)
+}
+
+// Тест 7: Не подтвердивший участие, отказавшийся и ожидающий места участник может только смотреть событие и отвечать
+func TestCheckEvent_InactiveParticipant(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+	declined := false
+	now := time.Now()
+
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 2).
+		Return(model.EventParticipant{EventID: 10, UserID: 2, Role: model.RoleAdmin}, nil)
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 3).
+		Return(model.EventParticipant{EventID: 10, UserID: 3, Role: model.RoleParticipant, IsConfirmed: &declined}, nil)
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 4).
+		Return(model.EventParticipant{EventID: 10, UserID: 4, Role: model.RoleParticipant, IsConfirmed: &confirmed, WaitlistedAt: &now}, nil)
+
+	for _, userId := range []int{2, 3, 4} {
+		assert.NoError(t, service.CheckEvent(ctx, userId, 10, domain.PermissionViewEvent))
+		assert.ErrorIs(t, service.CheckEvent(ctx, userId, 10, domain.PermissionCreateExpense), model.ErrAccessDenied)
+		assert.ErrorIs(t, service.CheckEvent(ctx, userId, 10, domain.PermissionUploadAttachment), model.ErrAccessDenied)
+	}
+	assert.ErrorIs(t, service.CheckEvent(ctx, 2, 10, domain.PermissionUpdateEvent), model.ErrAccessDenied)
+
+	m.participantRepo.On("GetById", ctx, 43).Return(model.EventParticipant{EventParticipantID: 43, EventID: 10, UserID: 3}, nil)
+	assert.NoError(t, service.CheckParticipant(ctx, 3, 10, 43, domain.PermissionAnswerInvitation))
+}
+
+// Тест 8: Администратор выпускает коды приглашения участников, но не администраторов
+func TestCheckEvent_InviteCodeRole(t *testing.T) {
+	service, m := setupService()
+	ctx := context.Background()
+
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 1).
+		Return(model.EventParticipant{EventID: 10, UserID: 1, Role: model.RoleOrganizer, IsConfirmed: &confirmed}, nil)
+	m.participantRepo.On("GetByEventAndUser", ctx, 10, 2).
+		Return(model.EventParticipant{EventID: 10, UserID: 2, Role: model.RoleAdmin, IsConfirmed: &confirmed}, nil)
+
+	participantCode := domain.InviteCodeCreateRequest{Role: "participant"}
+	adminCode := domain.InviteCodeCreateRequest{Role: "admin"}
+
+	assert.NoError(t, service.CheckEvent(ctx, 2, 10, participantCode.Permission()))
+	assert.NoError(t, service.CheckEvent(ctx, 2, 10, domain.InviteCodeCreateRequest{}.Permission()))
+	assert.ErrorIs(t, service.CheckEvent(ctx, 2, 10, adminCode.Permission()), model.ErrAccessDenied)
+	assert.NoError(t, service.CheckEvent(ctx, 1, 10, adminCode.Permission()))
+}
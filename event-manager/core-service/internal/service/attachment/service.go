@@ -0,0 +1,251 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, attachment model.Attachment) (int, error)
+	GetById(ctx context.Context, id int) (model.Attachment, error)
+	ListByExpense(ctx context.Context, expenseId int) ([]model.Attachment, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// Storage файловое хранилище содержимого вложений
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// allowedContentTypes типы файлов чеков и расширения, с которыми они сохраняются.
+// Тип определяется по содержимому файла, а не по заголовкам запроса
+var allowedContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+// sniffLen количество байт, по которым определяется тип содержимого
+const sniffLen = 512
+
+type Service struct {
+	repo    Repository
+	storage Storage
+	maxSize int64
+	logger  *zap.SugaredLogger
+}
+
+func NewService(repo Repository, storage Storage, maxSize int64, logger *zap.SugaredLogger) Service {
+	return Service{
+		repo:    repo,
+		storage: storage,
+		maxSize: maxSize,
+		logger:  logger,
+	}
+}
+
+// Upload сохраняет файл чека расхода. size — размер, заявленный клиентом; фактический размер
+// проверяется при записи, и файл, превысивший лимит, удаляется
+func (s Service) Upload(ctx context.Context, expenseId, userId int, fileName string, size int64, r io.Reader) (*domain.AttachmentResponse, error) {
+	if size > s.maxSize {
+		return nil, errors.WithMessagef(model.ErrAttachmentTooLarge, "file size %d exceeds limit of %d bytes", size, s.maxSize)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, errors.WithMessage(err, "read file")
+	}
+	if n == 0 {
+		return nil, errors.WithMessage(model.ErrInvalidAttachment, "file is empty")
+	}
+	head = head[:n]
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	ext, ok := allowedContentTypes[contentType]
+	if !ok {
+		return nil, errors.WithMessagef(model.ErrInvalidAttachment, "unsupported file type %s", contentType)
+	}
+
+	key, err := storageKey(expenseId, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	// Читаем не больше лимита плюс один байт, чтобы обнаружить превышение, не сохраняя весь файл
+	body := &countingReader{r: io.LimitReader(io.MultiReader(bytes.NewReader(head), r), s.maxSize+1)}
+	if err := s.storage.Put(ctx, key, body); err != nil {
+		s.logger.Errorw("Failed to store attachment", "error", err, "expenseId", expenseId)
+		return nil, errors.WithMessage(err, "store file")
+	}
+	if body.n > s.maxSize {
+		s.removeFile(ctx, key)
+		return nil, errors.WithMessagef(model.ErrAttachmentTooLarge, "file exceeds limit of %d bytes", s.maxSize)
+	}
+
+	attachment := model.Attachment{
+		ExpenseID:   expenseId,
+		UploadedBy:  userId,
+		FileName:    cleanFileName(fileName, ext),
+		ContentType: contentType,
+		Size:        body.n,
+		StorageKey:  key,
+		CreatedAt:   time.Now(),
+	}
+
+	attachment.AttachmentID, err = s.repo.Create(ctx, attachment)
+	if err != nil {
+		s.logger.Errorw("Failed to create attachment", "error", err, "expenseId", expenseId)
+		s.removeFile(ctx, key)
+		return nil, errors.WithMessage(err, "create attachment")
+	}
+
+	resp := toResponse(attachment)
+	return &resp, nil
+}
+
+func (s Service) List(ctx context.Context, expenseId int) (domain.AttachmentsResponse, error) {
+	attachments, err := s.repo.ListByExpense(ctx, expenseId)
+	if err != nil {
+		s.logger.Errorw("Failed to list attachments", "error", err, "expenseId", expenseId)
+		return domain.AttachmentsResponse{}, errors.WithMessage(err, "list attachments")
+	}
+
+	items := make([]domain.AttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		items[i] = toResponse(attachment)
+	}
+
+	return domain.AttachmentsResponse{Items: items}, nil
+}
+
+// Open возвращает вложение расхода и его содержимое. Содержимое закрывает вызывающий
+func (s Service) Open(ctx context.Context, expenseId, attachmentId int) (model.Attachment, io.ReadCloser, error) {
+	attachment, err := s.get(ctx, expenseId, attachmentId)
+	if err != nil {
+		return model.Attachment{}, nil, err
+	}
+
+	file, err := s.storage.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		s.logger.Errorw("Failed to open attachment file", "error", err, "attachmentId", attachmentId)
+		return model.Attachment{}, nil, errors.WithMessage(err, "open file")
+	}
+
+	return attachment, file, nil
+}
+
+func (s Service) Delete(ctx context.Context, expenseId, attachmentId int) error {
+	attachment, err := s.get(ctx, expenseId, attachmentId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, attachmentId); err != nil {
+		s.logger.Errorw("Failed to delete attachment", "error", err, "attachmentId", attachmentId)
+		return errors.WithMessage(err, "delete attachment")
+	}
+	s.removeFile(ctx, attachment.StorageKey)
+
+	return nil
+}
+
+// ListByExpense возвращает вложения расхода для последующего удаления их файлов
+func (s Service) ListByExpense(ctx context.Context, expenseId int) ([]model.Attachment, error) {
+	attachments, err := s.repo.ListByExpense(ctx, expenseId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "list attachments")
+	}
+
+	return attachments, nil
+}
+
+// RemoveFiles удаляет из хранилища файлы вложений, записи о которых уже удалены.
+// Ошибки только логируются: оставшийся файл недоступен и не влияет на данные
+func (s Service) RemoveFiles(ctx context.Context, attachments []model.Attachment) {
+	for _, attachment := range attachments {
+		s.removeFile(ctx, attachment.StorageKey)
+	}
+}
+
+func (s Service) get(ctx context.Context, expenseId, attachmentId int) (model.Attachment, error) {
+	attachment, err := s.repo.GetById(ctx, attachmentId)
+	if err != nil {
+		return model.Attachment{}, errors.WithMessage(err, "get attachment")
+	}
+
+	if attachment.ExpenseID != expenseId {
+		return model.Attachment{}, errors.WithMessage(model.ErrAttachmentNotFound, "attachment does not belong to expense")
+	}
+
+	return attachment, nil
+}
+
+func (s Service) removeFile(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		s.logger.Warnw("Failed to delete attachment file", "error", err, "key", key)
+	}
+}
+
+// storageKey генерирует уникальный ключ файла в хранилище
+func storageKey(expenseId int, ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", errors.WithMessage(err, "generate storage key")
+	}
+
+	return fmt.Sprintf("expenses/%d/%s%s", expenseId, hex.EncodeToString(buf), ext), nil
+}
+
+// cleanFileName оставляет от имени файла клиента только базовое имя
+func cleanFileName(name, ext string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "receipt" + ext
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[len(runes)-255:])
+	}
+
+	return name
+}
+
+// countingReader считает прочитанные байты
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func toResponse(attachment model.Attachment) domain.AttachmentResponse {
+	return domain.AttachmentResponse{
+		AttachmentID: attachment.AttachmentID,
+		ExpenseID:    attachment.ExpenseID,
+		UploadedBy:   attachment.UploadedBy,
+		FileName:     attachment.FileName,
+		ContentType:  attachment.ContentType,
+		Size:         attachment.Size,
+		CreatedAt:    attachment.CreatedAt,
+	}
+}
@@ -0,0 +1,148 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"io"
+	"strings"
+	"testing"
+)
+
+// Mock репозитория вложений
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, attachment model.Attachment) (int, error) {
+	args := m.Called(ctx, attachment)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetById(ctx context.Context, id int) (model.Attachment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Attachment), args.Error(1)
+}
+
+func (m *MockRepository) ListByExpense(ctx context.Context, expenseId int) ([]model.Attachment, error) {
+	args := m.Called(ctx, expenseId)
+	return args.Get(0).([]model.Attachment), args.Error(1)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Хранилище файлов в памяти
+type memoryStorage map[string][]byte
+
+func (s memoryStorage) Put(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s[key] = data
+	return nil
+}
+
+func (s memoryStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s[key]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s memoryStorage) Delete(ctx context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+// pdf минимальное содержимое, распознаваемое как PDF
+var pdf = []byte("%PDF-1.4\n%receipt\n")
+
+func setupService(maxSize int64) (*Service, *MockRepository, memoryStorage) {
+	repo := new(MockRepository)
+	storage := memoryStorage{}
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(repo, storage, maxSize, logger.Sugar())
+
+	return &service, repo, storage
+}
+
+// Тест 1: PDF сохраняется в хранилище, тип определяется по содержимому, путь в имени файла отбрасывается
+func TestUpload_Success(t *testing.T) {
+	service, repo, storage := setupService(1024)
+	ctx := context.Background()
+
+	repo.On("Create", ctx, mock.MatchedBy(func(attachment model.Attachment) bool {
+		return attachment.ExpenseID == 7 && attachment.UploadedBy == 2 && attachment.ContentType == "application/pdf" &&
+			attachment.FileName == "check.pdf" && attachment.Size == int64(len(pdf)) &&
+			strings.HasPrefix(attachment.StorageKey, "expenses/7/")
+	})).Return(1, nil)
+
+	resp, err := service.Upload(ctx, 7, 2, "../../etc/check.pdf", int64(len(pdf)), bytes.NewReader(pdf))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.AttachmentID)
+	assert.Len(t, storage, 1)
+	for _, data := range storage {
+		assert.Equal(t, pdf, data)
+	}
+}
+
+// Тест 2: Файл неподдерживаемого типа отклоняется до записи в хранилище
+func TestUpload_UnsupportedType(t *testing.T) {
+	service, repo, storage := setupService(1024)
+	ctx := context.Background()
+
+	_, err := service.Upload(ctx, 7, 2, "receipt.pdf", 11, strings.NewReader("hello world"))
+
+	assert.ErrorIs(t, err, model.ErrInvalidAttachment)
+	assert.Empty(t, storage)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Тест 3: Файл больше лимита удаляется, даже если клиент занизил его размер
+func TestUpload_TooLarge(t *testing.T) {
+	service, repo, storage := setupService(int64(len(pdf)) - 1)
+	ctx := context.Background()
+
+	_, err := service.Upload(ctx, 7, 2, "receipt.pdf", 1, bytes.NewReader(pdf))
+
+	assert.ErrorIs(t, err, model.ErrAttachmentTooLarge)
+	assert.Empty(t, storage)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Тест 4: Если запись о файле не сохранилась, файл удаляется из хранилища
+func TestUpload_RepositoryError(t *testing.T) {
+	service, repo, storage := setupService(1024)
+	ctx := context.Background()
+	createErr := errors.New("insert failed")
+
+	repo.On("Create", ctx, mock.AnythingOfType("model.Attachment")).Return(0, createErr)
+
+	_, err := service.Upload(ctx, 7, 2, "receipt.pdf", int64(len(pdf)), bytes.NewReader(pdf))
+
+	assert.ErrorIs(t, err, createErr)
+	assert.Empty(t, storage)
+}
+
+// Тест 5: Вложение другого расхода считается не найденным
+func TestOpen_OtherExpense(t *testing.T) {
+	service, repo, _ := setupService(1024)
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 3).Return(model.Attachment{AttachmentID: 3, ExpenseID: 8, StorageKey: "expenses/8/a.pdf"}, nil)
+
+	_, _, err := service.Open(ctx, 7, 3)
+
+	assert.ErrorIs(t, err, model.ErrAttachmentNotFound)
+}
@@ -0,0 +1,326 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type Repository interface {
+	CreateCategory(ctx context.Context, category model.ExpenseCategory) (int, error)
+	GetCategoryById(ctx context.Context, id int) (model.ExpenseCategory, error)
+	UpdateCategory(ctx context.Context, category model.ExpenseCategory) error
+	DeleteCategory(ctx context.Context, id int) error
+	ListCategorySpending(ctx context.Context, eventId int) ([]model.CategorySpending, error)
+	GetEventBudget(ctx context.Context, eventId int) (model.EventBudget, error)
+	SetEventBudget(ctx context.Context, eventId int, total *model.Money) error
+	MarkEventBudgetExceeded(ctx context.Context, eventId int, exceeded bool) (bool, error)
+	MarkCategoryExceeded(ctx context.Context, categoryId int, exceeded bool) (bool, error)
+}
+
+type ExpenseRepo interface {
+	GetEventTotalExpenses(ctx context.Context, eventId int) (model.Money, error)
+}
+
+type EventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+}
+
+type ParticipantRepo interface {
+	ListEventUsers(ctx context.Context, eventId int, roles []model.ParticipantRole) ([]model.User, error)
+}
+
+type NotifyPublisher interface {
+	Publish(ctx context.Context, data []byte) error
+}
+
+// notifiedRoles роли участников, получающие уведомления о превышении бюджета
+var notifiedRoles = []model.ParticipantRole{model.RoleOrganizer, model.RoleAdmin}
+
+type Service struct {
+	repo            Repository
+	expenseRepo     ExpenseRepo
+	eventRepo       EventRepo
+	participantRepo ParticipantRepo
+	notifyPbl       NotifyPublisher
+	logger          *zap.SugaredLogger
+}
+
+func NewService(repo Repository, expenseRepo ExpenseRepo, eventRepo EventRepo, participantRepo ParticipantRepo, notifyPbl NotifyPublisher, logger *zap.SugaredLogger) Service {
+	return Service{
+		repo:            repo,
+		expenseRepo:     expenseRepo,
+		eventRepo:       eventRepo,
+		participantRepo: participantRepo,
+		notifyPbl:       notifyPbl,
+		logger:          logger,
+	}
+}
+
+func (s Service) ListCategories(ctx context.Context, eventId int) (domain.CategoriesResponse, error) {
+	categories, err := s.repo.ListCategorySpending(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list expense categories", "error", err, "eventId", eventId)
+		return domain.CategoriesResponse{}, errors.WithMessage(err, "list expense categories")
+	}
+
+	items := make([]domain.CategoryResponse, len(categories))
+	for i, category := range categories {
+		items[i] = toCategoryResponse(category.ExpenseCategory)
+	}
+
+	return domain.CategoriesResponse{Items: items}, nil
+}
+
+func (s Service) CreateCategory(ctx context.Context, eventId int, req domain.CategoryRequest) (*domain.CategoryResponse, error) {
+	category := model.ExpenseCategory{
+		EventID: eventId,
+		Name:    req.Name,
+		Budget:  req.Budget,
+	}
+
+	id, err := s.repo.CreateCategory(ctx, category)
+	if err != nil {
+		s.logger.Errorw("Failed to create expense category", "error", err, "eventId", eventId)
+		return nil, errors.WithMessage(err, "create expense category")
+	}
+
+	category, err = s.repo.GetCategoryById(ctx, id)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get created expense category")
+	}
+
+	resp := toCategoryResponse(category)
+	return &resp, nil
+}
+
+// UpdateCategory меняет название и лимит категории. Если новый лимит уже превышен, организаторы получают уведомление
+func (s Service) UpdateCategory(ctx context.Context, eventId, categoryId int, req domain.CategoryRequest) (*domain.CategoryResponse, error) {
+	category, err := s.getCategory(ctx, eventId, categoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	category.Name = req.Name
+	category.Budget = req.Budget
+	if err := s.repo.UpdateCategory(ctx, category); err != nil {
+		s.logger.Errorw("Failed to update expense category", "error", err, "categoryId", categoryId)
+		return nil, errors.WithMessage(err, "update expense category")
+	}
+
+	s.NotifyBudget(ctx, eventId)
+
+	resp := toCategoryResponse(category)
+	return &resp, nil
+}
+
+func (s Service) DeleteCategory(ctx context.Context, eventId, categoryId int) error {
+	if _, err := s.getCategory(ctx, eventId, categoryId); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteCategory(ctx, categoryId); err != nil {
+		s.logger.Errorw("Failed to delete expense category", "error", err, "categoryId", categoryId)
+		return errors.WithMessage(err, "delete expense category")
+	}
+
+	return nil
+}
+
+// CheckCategory проверяет, что категория относится к событию расхода
+func (s Service) CheckCategory(ctx context.Context, eventId, categoryId int) error {
+	_, err := s.getCategory(ctx, eventId, categoryId)
+	return err
+}
+
+// SetBudget задает общий лимит расходов события и возвращает обновленный отчет о бюджете
+func (s Service) SetBudget(ctx context.Context, eventId int, req domain.BudgetUpdateRequest) (domain.BudgetReportResponse, error) {
+	if err := s.repo.SetEventBudget(ctx, eventId, req.TotalBudget); err != nil {
+		s.logger.Errorw("Failed to set event budget", "error", err, "eventId", eventId)
+		return domain.BudgetReportResponse{}, errors.WithMessage(err, "set event budget")
+	}
+
+	s.NotifyBudget(ctx, eventId)
+
+	return s.GetReport(ctx, eventId)
+}
+
+// GetReport сравнивает общий лимит и лимиты категорий с фактическими расходами события
+func (s Service) GetReport(ctx context.Context, eventId int) (domain.BudgetReportResponse, error) {
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		return domain.BudgetReportResponse{}, errors.WithMessage(err, "get event")
+	}
+
+	budget, err := s.repo.GetEventBudget(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event budget", "error", err, "eventId", eventId)
+		return domain.BudgetReportResponse{}, errors.WithMessage(err, "get event budget")
+	}
+
+	spent, err := s.expenseRepo.GetEventTotalExpenses(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event total expenses for budget", "error", err, "eventId", eventId)
+		return domain.BudgetReportResponse{}, errors.WithMessage(err, "get event total expenses")
+	}
+
+	categories, err := s.repo.ListCategorySpending(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get category spending", "error", err, "eventId", eventId)
+		return domain.BudgetReportResponse{}, errors.WithMessage(err, "list category spending")
+	}
+
+	report := domain.BudgetReportResponse{
+		EventID:       eventId,
+		Currency:      event.BaseCurrency,
+		Uncategorized: spent,
+		Categories:    make([]domain.CategoryBudgetResponse, len(categories)),
+		BudgetUsage:   usage(budget.TotalBudget, spent),
+	}
+	for i, category := range categories {
+		report.Categories[i] = domain.CategoryBudgetResponse{
+			CategoryID:  category.CategoryID,
+			Name:        category.Name,
+			BudgetUsage: usage(category.Budget, category.Spent),
+		}
+		report.Uncategorized -= category.Spent
+	}
+
+	return report, nil
+}
+
+// NotifyBudget проверяет лимиты после изменения расходов или бюджета события. Ошибка проверки
+// не отменяет изменение и только логируется
+func (s Service) NotifyBudget(ctx context.Context, eventId int) {
+	if err := s.checkBudget(ctx, eventId); err != nil {
+		s.logger.Errorw("Failed to check event budget", "error", err, "eventId", eventId)
+	}
+}
+
+// checkBudget сверяет расходы события с лимитами и отправляет организатору и администраторам уведомление
+// budget_exceeded, когда расходы впервые превышают лимит. Когда расходы снова укладываются в лимит,
+// отметка снимается, и следующее превышение приводит к новому уведомлению
+func (s Service) checkBudget(ctx context.Context, eventId int) error {
+	report, err := s.GetReport(ctx, eventId)
+	if err != nil {
+		return err
+	}
+
+	var crossed []budgetCrossing
+	if report.Budget != nil {
+		changed, err := s.repo.MarkEventBudgetExceeded(ctx, eventId, report.Exceeded)
+		if err != nil {
+			return errors.WithMessage(err, "mark event budget exceeded")
+		}
+		if changed && report.Exceeded {
+			crossed = append(crossed, budgetCrossing{usage: report.BudgetUsage})
+		}
+	}
+
+	for _, category := range report.Categories {
+		if category.Budget == nil {
+			continue
+		}
+
+		changed, err := s.repo.MarkCategoryExceeded(ctx, category.CategoryID, category.Exceeded)
+		if err != nil {
+			return errors.WithMessagef(err, "mark category %d budget exceeded", category.CategoryID)
+		}
+		if changed && category.Exceeded {
+			crossed = append(crossed, budgetCrossing{category: category.Name, usage: category.BudgetUsage})
+		}
+	}
+
+	if len(crossed) == 0 {
+		return nil
+	}
+
+	return s.notifyExceeded(ctx, eventId, report.Currency, crossed)
+}
+
+// budgetCrossing превышенный лимит: общий, если категория не указана
+type budgetCrossing struct {
+	category string
+	usage    domain.BudgetUsage
+}
+
+func (s Service) notifyExceeded(ctx context.Context, eventId int, currency string, crossed []budgetCrossing) error {
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		return errors.WithMessage(err, "get event")
+	}
+
+	users, err := s.participantRepo.ListEventUsers(ctx, eventId, notifiedRoles)
+	if err != nil {
+		return errors.WithMessage(err, "list event organizers")
+	}
+
+	for _, crossing := range crossed {
+		for _, user := range users {
+			data := map[string]any{
+				"event": "budget_exceeded",
+				"data": map[string]any{
+					"event_id":   eventId,
+					"event_name": event.Title,
+					"category":   crossing.category,
+					"budget":     *crossing.usage.Budget,
+					"spent":      crossing.usage.Spent,
+					"currency":   currency,
+					"user_email": user.Email,
+				},
+			}
+
+			bytes, err := json.Marshal(data)
+			if err != nil {
+				return errors.WithMessage(err, "marshal budget exceeded notify")
+			}
+
+			if err := s.notifyPbl.Publish(ctx, bytes); err != nil {
+				return errors.WithMessage(err, "publish budget exceeded notify")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s Service) getCategory(ctx context.Context, eventId, categoryId int) (model.ExpenseCategory, error) {
+	category, err := s.repo.GetCategoryById(ctx, categoryId)
+	if err != nil {
+		return model.ExpenseCategory{}, errors.WithMessage(err, "get expense category")
+	}
+
+	if category.EventID != eventId {
+		return model.ExpenseCategory{}, errors.WithMessage(model.ErrCategoryNotFound, "category belongs to another event")
+	}
+
+	return category, nil
+}
+
+// usage сравнивает лимит с расходами. Лимит считается превышенным, когда расходы строго больше него
+func usage(budget *model.Money, spent model.Money) domain.BudgetUsage {
+	result := domain.BudgetUsage{
+		Budget: budget,
+		Spent:  spent,
+	}
+	if budget != nil {
+		remaining := *budget - spent
+		result.Remaining = &remaining
+		result.Exceeded = spent > *budget
+	}
+
+	return result
+}
+
+func toCategoryResponse(category model.ExpenseCategory) domain.CategoryResponse {
+	return domain.CategoryResponse{
+		CategoryID: category.CategoryID,
+		EventID:    category.EventID,
+		Name:       category.Name,
+		Budget:     category.Budget,
+		CreatedAt:  category.CreatedAt,
+	}
+}
@@ -0,0 +1,187 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"testing"
+)
+
+// Mock репозитория бюджета
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) CreateCategory(ctx context.Context, category model.ExpenseCategory) (int, error) {
+	args := m.Called(ctx, category)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetCategoryById(ctx context.Context, id int) (model.ExpenseCategory, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.ExpenseCategory), args.Error(1)
+}
+
+func (m *MockRepository) UpdateCategory(ctx context.Context, category model.ExpenseCategory) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteCategory(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListCategorySpending(ctx context.Context, eventId int) ([]model.CategorySpending, error) {
+	args := m.Called(ctx, eventId)
+	return args.Get(0).([]model.CategorySpending), args.Error(1)
+}
+
+func (m *MockRepository) GetEventBudget(ctx context.Context, eventId int) (model.EventBudget, error) {
+	args := m.Called(ctx, eventId)
+	return args.Get(0).(model.EventBudget), args.Error(1)
+}
+
+func (m *MockRepository) SetEventBudget(ctx context.Context, eventId int, total *model.Money) error {
+	args := m.Called(ctx, eventId, total)
+	return args.Error(0)
+}
+
+func (m *MockRepository) MarkEventBudgetExceeded(ctx context.Context, eventId int, exceeded bool) (bool, error) {
+	args := m.Called(ctx, eventId, exceeded)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) MarkCategoryExceeded(ctx context.Context, categoryId int, exceeded bool) (bool, error) {
+	args := m.Called(ctx, categoryId, exceeded)
+	return args.Bool(0), args.Error(1)
+}
+
+// Расходы события с фиксированной суммой
+type fixedSpending model.Money
+
+func (f fixedSpending) GetEventTotalExpenses(ctx context.Context, eventId int) (model.Money, error) {
+	return model.Money(f), nil
+}
+
+// Событие с фиксированными названием и валютой
+type fixedEvent struct{}
+
+func (fixedEvent) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	return model.Event{EventId: eventID, Title: "Поход", BaseCurrency: "RUB"}, nil
+}
+
+// Организатор события
+type organizers struct{}
+
+func (organizers) ListEventUsers(ctx context.Context, eventId int, roles []model.ParticipantRole) ([]model.User, error) {
+	return []model.User{{UserId: 1, Email: "org@example.com"}}, nil
+}
+
+// Публикатор, запоминающий отправленные уведомления
+type recordingPublisher struct {
+	messages []map[string]any
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, data []byte) error {
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func money(v model.Money) *model.Money {
+	return &v
+}
+
+func setupService(spent model.Money) (*Service, *MockRepository, *recordingPublisher) {
+	repo := new(MockRepository)
+	publisher := &recordingPublisher{}
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(repo, fixedSpending(spent), fixedEvent{}, organizers{}, publisher, logger.Sugar())
+
+	return &service, repo, publisher
+}
+
+// Тест 1: Отчет сравнивает лимиты с расходами, расходы без категории считаются отдельно
+func TestGetReport(t *testing.T) {
+	service, repo, _ := setupService(100000)
+	ctx := context.Background()
+
+	repo.On("GetEventBudget", ctx, 1).Return(model.EventBudget{EventID: 1, TotalBudget: money(80000)}, nil)
+	repo.On("ListCategorySpending", ctx, 1).Return([]model.CategorySpending{
+		{ExpenseCategory: model.ExpenseCategory{CategoryID: 2, Name: "Еда", Budget: money(50000)}, Spent: 30000},
+		{ExpenseCategory: model.ExpenseCategory{CategoryID: 3, Name: "Жилье"}, Spent: 60000},
+	}, nil)
+
+	report, err := service.GetReport(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "RUB", report.Currency)
+	assert.True(t, report.Exceeded)
+	assert.Equal(t, model.Money(-20000), *report.Remaining)
+	assert.Equal(t, model.Money(10000), report.Uncategorized)
+	assert.False(t, report.Categories[0].Exceeded)
+	assert.Equal(t, model.Money(20000), *report.Categories[0].Remaining)
+	assert.Nil(t, report.Categories[1].Budget)
+	assert.Nil(t, report.Categories[1].Remaining)
+}
+
+// Тест 2: При превышении лимита категории организатор получает уведомление budget_exceeded
+func TestCheckBudget_CategoryCrossed(t *testing.T) {
+	service, repo, publisher := setupService(60000)
+	ctx := context.Background()
+
+	repo.On("GetEventBudget", ctx, 1).Return(model.EventBudget{EventID: 1, TotalBudget: money(100000)}, nil)
+	repo.On("ListCategorySpending", ctx, 1).Return([]model.CategorySpending{
+		{ExpenseCategory: model.ExpenseCategory{CategoryID: 2, Name: "Еда", Budget: money(50000)}, Spent: 60000},
+	}, nil)
+	repo.On("MarkEventBudgetExceeded", ctx, 1, false).Return(false, nil)
+	repo.On("MarkCategoryExceeded", ctx, 2, true).Return(true, nil)
+
+	err := service.checkBudget(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, publisher.messages, 1)
+	assert.Equal(t, "budget_exceeded", publisher.messages[0]["event"])
+	data := publisher.messages[0]["data"].(map[string]any)
+	assert.Equal(t, "Еда", data["category"])
+	assert.Equal(t, 500.0, data["budget"])
+	assert.Equal(t, 600.0, data["spent"])
+	assert.Equal(t, "org@example.com", data["user_email"])
+	repo.AssertExpectations(t)
+}
+
+// Тест 3: Повторная проверка уже превышенного лимита не отправляет уведомление
+func TestCheckBudget_AlreadyNotified(t *testing.T) {
+	service, repo, publisher := setupService(120000)
+	ctx := context.Background()
+
+	repo.On("GetEventBudget", ctx, 1).Return(model.EventBudget{EventID: 1, TotalBudget: money(100000), BudgetExceeded: true}, nil)
+	repo.On("ListCategorySpending", ctx, 1).Return([]model.CategorySpending{}, nil)
+	repo.On("MarkEventBudgetExceeded", ctx, 1, true).Return(false, nil)
+
+	err := service.checkBudget(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Empty(t, publisher.messages)
+	repo.AssertExpectations(t)
+}
+
+// Тест 4: Категория другого события считается не найденной
+func TestCheckCategory_OtherEvent(t *testing.T) {
+	service, repo, _ := setupService(0)
+	ctx := context.Background()
+
+	repo.On("GetCategoryById", ctx, 2).Return(model.ExpenseCategory{CategoryID: 2, EventID: 5}, nil)
+
+	err := service.CheckCategory(ctx, 1, 2)
+
+	assert.ErrorIs(t, err, model.ErrCategoryNotFound)
+}
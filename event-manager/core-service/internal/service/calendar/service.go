@@ -0,0 +1,360 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/PabloPerdolie/event-manager/core-service/pkg/ical"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type EventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+	ListByParticipant(ctx context.Context, participantID int) ([]model.Event, error)
+}
+
+type EventExceptionRepo interface {
+	ListByEvents(ctx context.Context, eventIds []int) ([]model.EventException, error)
+}
+
+type TaskRepo interface {
+	ListDueByEvents(ctx context.Context, eventIds []int) ([]model.Task, error)
+}
+
+type TokenRepo interface {
+	GetByUser(ctx context.Context, userId int) (model.CalendarToken, error)
+	GetByToken(ctx context.Context, value string) (model.CalendarToken, error)
+	Create(ctx context.Context, token model.CalendarToken) error
+	Replace(ctx context.Context, token model.CalendarToken) error
+}
+
+// uidDomain правая часть UID событий и задач. UID зависят только от id, поэтому календарные приложения
+// обновляют уже добавленные записи, а не создают новые
+const uidDomain = "event-manager"
+
+// feedRefreshInterval рекомендуемый приложениям период обновления ленты
+const feedRefreshInterval = "PT1H"
+
+type Service struct {
+	eventRepo     EventRepo
+	exceptionRepo EventExceptionRepo
+	taskRepo      TaskRepo
+	tokenRepo     TokenRepo
+	feedURL       string
+	logger        *zap.SugaredLogger
+}
+
+func NewService(eventRepo EventRepo, exceptionRepo EventExceptionRepo, taskRepo TaskRepo, tokenRepo TokenRepo,
+	feedURL string, logger *zap.SugaredLogger) Service {
+	return Service{
+		eventRepo:     eventRepo,
+		exceptionRepo: exceptionRepo,
+		taskRepo:      taskRepo,
+		tokenRepo:     tokenRepo,
+		feedURL:       feedURL,
+		logger:        logger,
+	}
+}
+
+// ExportEvent записывает событие вместе с повторениями серии и сроками задач в формате iCalendar
+func (s Service) ExportEvent(ctx context.Context, eventId int, w io.Writer) error {
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for calendar export", "error", err, "eventId", eventId)
+		return errors.WithMessage(err, "get event")
+	}
+
+	return s.write(ctx, w, event.Title, false, []model.Event{event})
+}
+
+// Feed записывает календарь владельца токена: все события, в которых он участвует, и сроки их задач
+func (s Service) Feed(ctx context.Context, token string, w io.Writer) error {
+	owner, err := s.tokenRepo.GetByToken(ctx, token)
+	if err != nil {
+		if !errors.Is(err, model.ErrCalendarTokenNotFound) {
+			s.logger.Errorw("Failed to get calendar token", "error", err)
+		}
+		return errors.WithMessage(err, "get calendar token")
+	}
+
+	events, err := s.eventRepo.ListByParticipant(ctx, owner.UserID)
+	if err != nil {
+		s.logger.Errorw("Failed to list participant events for calendar", "error", err, "userId", owner.UserID)
+		return errors.WithMessage(err, "list participant events")
+	}
+
+	return s.write(ctx, w, "Event Manager", true, events)
+}
+
+// FeedLink возвращает ссылку на календарь пользователя, выпуская токен при первом обращении
+func (s Service) FeedLink(ctx context.Context, userId int) (*domain.CalendarFeedResponse, error) {
+	token, err := s.tokenRepo.GetByUser(ctx, userId)
+	if err == nil {
+		return s.feedResponse(token), nil
+	}
+	if !errors.Is(err, model.ErrCalendarTokenNotFound) {
+		s.logger.Errorw("Failed to get user calendar token", "error", err, "userId", userId)
+		return nil, errors.WithMessage(err, "get user calendar token")
+	}
+
+	token, err = newToken(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// При одновременных запросах сохраняется первый токен, остальные получают его же
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		s.logger.Errorw("Failed to create calendar token", "error", err, "userId", userId)
+		return nil, errors.WithMessage(err, "create calendar token")
+	}
+
+	token, err = s.tokenRepo.GetByUser(ctx, userId)
+	if err != nil {
+		s.logger.Errorw("Failed to get created calendar token", "error", err, "userId", userId)
+		return nil, errors.WithMessage(err, "get user calendar token")
+	}
+
+	return s.feedResponse(token), nil
+}
+
+// RotateFeedLink выпускает новый токен календаря, прежняя ссылка перестает открываться
+func (s Service) RotateFeedLink(ctx context.Context, userId int) (*domain.CalendarFeedResponse, error) {
+	token, err := newToken(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tokenRepo.Replace(ctx, token); err != nil {
+		s.logger.Errorw("Failed to replace calendar token", "error", err, "userId", userId)
+		return nil, errors.WithMessage(err, "replace calendar token")
+	}
+
+	return s.feedResponse(token), nil
+}
+
+func (s Service) feedResponse(token model.CalendarToken) *domain.CalendarFeedResponse {
+	return &domain.CalendarFeedResponse{
+		URL:       s.feedURL + "/" + token.Token + ".ics",
+		CreatedAt: token.CreatedAt,
+	}
+}
+
+// newToken генерирует случайный токен календаря
+func newToken(userId int) (model.CalendarToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return model.CalendarToken{}, errors.WithMessage(err, "generate calendar token")
+	}
+
+	return model.CalendarToken{
+		UserID:    userId,
+		Token:     hex.EncodeToString(buf),
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// write записывает календарь из событий, изменений их повторений и задач со сроком
+func (s Service) write(ctx context.Context, out io.Writer, name string, feed bool, events []model.Event) error {
+	ids := make([]int, len(events))
+	for i, event := range events {
+		ids[i] = event.EventId
+	}
+
+	exceptions, err := s.exceptionRepo.ListByEvents(ctx, ids)
+	if err != nil {
+		s.logger.Errorw("Failed to list event exceptions for calendar", "error", err, "eventIds", ids)
+		return errors.WithMessage(err, "list event exceptions")
+	}
+	byEvent := make(map[int][]model.EventException)
+	for _, exception := range exceptions {
+		byEvent[exception.EventID] = append(byEvent[exception.EventID], exception)
+	}
+
+	tasks, err := s.taskRepo.ListDueByEvents(ctx, ids)
+	if err != nil {
+		s.logger.Errorw("Failed to list due tasks for calendar", "error", err, "eventIds", ids)
+		return errors.WithMessage(err, "list due tasks")
+	}
+
+	w := ical.NewWriter(out)
+	w.Begin("VCALENDAR")
+	w.Prop("VERSION", "2.0")
+	w.Prop("PRODID", "-//event-manager//core-service//RU")
+	w.Prop("CALSCALE", "GREGORIAN")
+	w.Prop("METHOD", "PUBLISH")
+	w.Text("X-WR-CALNAME", name)
+	if feed {
+		w.Prop("REFRESH-INTERVAL;VALUE=DURATION", feedRefreshInterval)
+		w.Prop("X-PUBLISHED-TTL", feedRefreshInterval)
+	}
+
+	for _, event := range events {
+		writeEvent(w, event, byEvent[event.EventId])
+	}
+	for _, task := range tasks {
+		writeTask(w, task)
+	}
+
+	w.End("VCALENDAR")
+
+	return errors.WithMessage(w.Close(), "write calendar")
+}
+
+// writeEvent записывает событие. У серии отмененные повторения попадают в EXDATE, а измененные записываются
+// отдельными VEVENT с тем же UID и RECURRENCE-ID
+func writeEvent(w *ical.Writer, event model.Event, exceptions []model.EventException) {
+	uid := eventUID(event.EventId)
+
+	w.Begin("VEVENT")
+	writeEventProps(w, uid, event, event.UpdatedAt)
+	if event.RecurrenceRule != nil {
+		w.Prop("RRULE", *event.RecurrenceRule)
+
+		cancelled := make([]time.Time, 0)
+		for _, exception := range exceptions {
+			if exception.Cancelled {
+				cancelled = append(cancelled, exception.OccurrenceDate)
+			}
+		}
+		if len(cancelled) > 0 {
+			w.Times("EXDATE", cancelled)
+		}
+	}
+	w.End("VEVENT")
+
+	if event.RecurrenceRule == nil {
+		return
+	}
+
+	for _, exception := range exceptions {
+		if exception.Cancelled {
+			continue
+		}
+
+		occurrence := event
+		occurrence.StartDate = exception.OccurrenceDate
+		occurrence.EndDate = exception.OccurrenceDate.Add(event.EndDate.Sub(event.StartDate))
+		if exception.Title != nil {
+			occurrence.Title = *exception.Title
+		}
+		if exception.Description != nil {
+			occurrence.Description = *exception.Description
+		}
+		if exception.Location != nil {
+			occurrence.Location = exception.Location
+		}
+		if exception.StartDate != nil {
+			occurrence.StartDate = *exception.StartDate
+		}
+		if exception.EndDate != nil {
+			occurrence.EndDate = *exception.EndDate
+		}
+
+		updatedAt := event.UpdatedAt
+		if exception.UpdatedAt.After(updatedAt) {
+			updatedAt = exception.UpdatedAt
+		}
+
+		w.Begin("VEVENT")
+		writeEventProps(w, uid, occurrence, updatedAt)
+		w.Time("RECURRENCE-ID", exception.OccurrenceDate)
+		w.End("VEVENT")
+	}
+}
+
+func writeEventProps(w *ical.Writer, uid string, event model.Event, updatedAt time.Time) {
+	w.Prop("UID", uid)
+	w.Time("DTSTAMP", updatedAt)
+	w.Time("CREATED", event.CreatedAt)
+	w.Time("LAST-MODIFIED", updatedAt)
+	// SEQUENCE растет с версией события, чтобы приложения принимали изменения
+	w.Prop("SEQUENCE", strconv.Itoa(event.Version))
+	w.Time("DTSTART", event.StartDate)
+	w.Time("DTEND", event.EndDate)
+	w.Text("SUMMARY", event.Title)
+	if event.Description != "" {
+		w.Text("DESCRIPTION", event.Description)
+	}
+	if event.Location != nil && *event.Location != "" {
+		w.Text("LOCATION", *event.Location)
+	}
+	w.Prop("STATUS", eventStatus(event.Status))
+}
+
+func writeTask(w *ical.Writer, task model.Task) {
+	w.Begin("VTODO")
+	w.Prop("UID", fmt.Sprintf("task-%d@%s", task.TaskId, uidDomain))
+	w.Time("DTSTAMP", task.CreatedAt)
+	w.Time("CREATED", task.CreatedAt)
+	w.Time("DUE", *task.DueDate)
+	w.Text("SUMMARY", task.Title)
+	if task.Description != "" {
+		w.Text("DESCRIPTION", task.Description)
+	}
+	w.Prop("STATUS", taskStatus(task.Status))
+	if priority, ok := taskPriority(task.Priority); ok {
+		w.Prop("PRIORITY", strconv.Itoa(priority))
+	}
+	w.Prop("RELATED-TO", eventUID(task.EventId))
+	w.End("VTODO")
+}
+
+func eventUID(eventId int) string {
+	return fmt.Sprintf("event-%d@%s", eventId, uidDomain)
+}
+
+// eventStatus статус VEVENT: черновик еще не подтвержден, отмененное событие остается в календаре отмененным
+func eventStatus(status model.EventStatus) string {
+	switch status {
+	case model.EventStatusDraft:
+		return "TENTATIVE"
+	case model.EventStatusCancelled:
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+func taskStatus(status string) string {
+	switch domain.TaskStatus(status) {
+	case domain.TaskStatusInProgress:
+		return "IN-PROCESS"
+	case domain.TaskStatusCompleted:
+		return "COMPLETED"
+	case domain.TaskStatusCancelled:
+		return "CANCELLED"
+	default:
+		return "NEEDS-ACTION"
+	}
+}
+
+// taskPriority переводит приоритет задачи в шкалу iCalendar: 1 — наивысший, 9 — наименьший.
+// Приоритет хранится свободной строкой: число от 1 до 9 или high, medium и low
+func taskPriority(priority *string) (int, bool) {
+	if priority == nil {
+		return 0, false
+	}
+	if n, err := strconv.Atoi(*priority); err == nil {
+		return n, n >= 1 && n <= 9
+	}
+
+	switch strings.ToLower(*priority) {
+	case "high":
+		return 1, true
+	case "medium":
+		return 5, true
+	case "low":
+		return 9, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,189 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// Мок репозитория событий
+type MockEventRepo struct {
+	mock.Mock
+}
+
+func (m *MockEventRepo) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(model.Event), args.Error(1)
+}
+
+func (m *MockEventRepo) ListByParticipant(ctx context.Context, participantID int) ([]model.Event, error) {
+	args := m.Called(ctx, participantID)
+	return args.Get(0).([]model.Event), args.Error(1)
+}
+
+// Мок репозитория изменений повторений
+type MockExceptionRepo struct {
+	mock.Mock
+}
+
+func (m *MockExceptionRepo) ListByEvents(ctx context.Context, eventIds []int) ([]model.EventException, error) {
+	args := m.Called(ctx, eventIds)
+	return args.Get(0).([]model.EventException), args.Error(1)
+}
+
+// Мок репозитория задач
+type MockTaskRepo struct {
+	mock.Mock
+}
+
+func (m *MockTaskRepo) ListDueByEvents(ctx context.Context, eventIds []int) ([]model.Task, error) {
+	args := m.Called(ctx, eventIds)
+	return args.Get(0).([]model.Task), args.Error(1)
+}
+
+// Хранилище токенов календаря в памяти
+type tokenStore map[int]model.CalendarToken
+
+func (s tokenStore) GetByUser(ctx context.Context, userId int) (model.CalendarToken, error) {
+	token, ok := s[userId]
+	if !ok {
+		return model.CalendarToken{}, model.ErrCalendarTokenNotFound
+	}
+	return token, nil
+}
+
+func (s tokenStore) GetByToken(ctx context.Context, value string) (model.CalendarToken, error) {
+	for _, token := range s {
+		if token.Token == value {
+			return token, nil
+		}
+	}
+	return model.CalendarToken{}, model.ErrCalendarTokenNotFound
+}
+
+func (s tokenStore) Create(ctx context.Context, token model.CalendarToken) error {
+	if _, ok := s[token.UserID]; !ok {
+		s[token.UserID] = token
+	}
+	return nil
+}
+
+func (s tokenStore) Replace(ctx context.Context, token model.CalendarToken) error {
+	s[token.UserID] = token
+	return nil
+}
+
+func setupService() (*Service, *MockEventRepo, *MockExceptionRepo, *MockTaskRepo, tokenStore) {
+	eventRepo := new(MockEventRepo)
+	exceptionRepo := new(MockExceptionRepo)
+	taskRepo := new(MockTaskRepo)
+	tokens := tokenStore{}
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(eventRepo, exceptionRepo, taskRepo, tokens, "https://example.com/api/v1/calendar", logger.Sugar())
+
+	return &service, eventRepo, exceptionRepo, taskRepo, tokens
+}
+
+func date(month time.Month, day, hour int) time.Time {
+	return time.Date(2025, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+// Тест 1: Серия выгружается правилом с исключенными и измененными повторениями, задачи — как VTODO
+func TestExportEvent_Series(t *testing.T) {
+	service, eventRepo, exceptionRepo, taskRepo, _ := setupService()
+	ctx := context.Background()
+
+	rule := "FREQ=WEEKLY;BYDAY=TH;COUNT=4"
+	title := "Игра, финал"
+	dueDate := date(7, 9, 12)
+	priority := "high"
+
+	eventRepo.On("GetById", ctx, 5).Return(model.Event{
+		EventId: 5, Title: "Настолки", StartDate: date(7, 3, 19), EndDate: date(7, 3, 22), Status: model.EventStatusPlanned,
+		Version: 3, CreatedAt: date(6, 1, 10), UpdatedAt: date(6, 20, 10), RecurrenceRule: &rule,
+	}, nil)
+	exceptionRepo.On("ListByEvents", ctx, []int{5}).Return([]model.EventException{
+		{EventID: 5, OccurrenceDate: date(7, 10, 19), Cancelled: true, UpdatedAt: date(6, 21, 10)},
+		{EventID: 5, OccurrenceDate: date(7, 17, 19), Title: &title, UpdatedAt: date(6, 22, 10)},
+	}, nil)
+	taskRepo.On("ListDueByEvents", ctx, []int{5}).Return([]model.Task{
+		{TaskId: 8, EventId: 5, Title: "Купить фишки", Status: "in_progress", Priority: &priority, DueDate: &dueDate, CreatedAt: date(6, 2, 10)},
+	}, nil)
+
+	buf := &bytes.Buffer{}
+	err := service.ExportEvent(ctx, 5, buf)
+
+	assert.NoError(t, err)
+	ics := buf.String()
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+	assert.NotContains(t, ics, "REFRESH-INTERVAL")
+	assert.Equal(t, 2, strings.Count(ics, "UID:event-5@event-manager\r\n"))
+	assert.Contains(t, ics, "SEQUENCE:3\r\nDTSTART:20250703T190000Z\r\nDTEND:20250703T220000Z\r\nSUMMARY:Настолки\r\n"+
+		"STATUS:CONFIRMED\r\nRRULE:FREQ=WEEKLY;BYDAY=TH;COUNT=4\r\nEXDATE:20250710T190000Z\r\nEND:VEVENT\r\n")
+	assert.Contains(t, ics, "DTSTAMP:20250622T100000Z\r\n")
+	assert.Contains(t, ics, "DTSTART:20250717T190000Z\r\nDTEND:20250717T220000Z\r\nSUMMARY:Игра\\, финал\r\n"+
+		"STATUS:CONFIRMED\r\nRECURRENCE-ID:20250717T190000Z\r\nEND:VEVENT\r\n")
+	assert.Contains(t, ics, "BEGIN:VTODO\r\nUID:task-8@event-manager\r\n")
+	assert.Contains(t, ics, "DUE:20250709T120000Z\r\nSUMMARY:Купить фишки\r\nSTATUS:IN-PROCESS\r\nPRIORITY:1\r\n"+
+		"RELATED-TO:event-5@event-manager\r\nEND:VTODO\r\n")
+}
+
+// Тест 2: Лента по токену содержит все события пользователя, неизвестный токен не найден
+func TestFeed(t *testing.T) {
+	service, eventRepo, exceptionRepo, taskRepo, tokens := setupService()
+	ctx := context.Background()
+	tokens[2] = model.CalendarToken{UserID: 2, Token: "secret"}
+
+	eventRepo.On("ListByParticipant", ctx, 2).Return([]model.Event{
+		{EventId: 5, Title: "Поход", StartDate: date(8, 1, 9), EndDate: date(8, 3, 18), Status: model.EventStatusCancelled},
+		{EventId: 9, Title: "Ужин", StartDate: date(7, 20, 19), EndDate: date(7, 20, 22), Status: model.EventStatusDraft},
+	}, nil)
+	exceptionRepo.On("ListByEvents", ctx, []int{5, 9}).Return([]model.EventException{}, nil)
+	taskRepo.On("ListDueByEvents", ctx, []int{5, 9}).Return([]model.Task{}, nil)
+
+	buf := &bytes.Buffer{}
+	err := service.Feed(ctx, "secret", buf)
+
+	assert.NoError(t, err)
+	ics := buf.String()
+	assert.Contains(t, ics, "REFRESH-INTERVAL;VALUE=DURATION:PT1H\r\n")
+	assert.Contains(t, ics, "UID:event-5@event-manager\r\n")
+	assert.Contains(t, ics, "UID:event-9@event-manager\r\n")
+	assert.Contains(t, ics, "SUMMARY:Поход\r\nSTATUS:CANCELLED\r\n")
+	assert.Contains(t, ics, "SUMMARY:Ужин\r\nSTATUS:TENTATIVE\r\n")
+	assert.NotContains(t, ics, "VTODO")
+
+	err = service.Feed(ctx, "unknown", &bytes.Buffer{})
+	assert.ErrorIs(t, err, model.ErrCalendarTokenNotFound)
+}
+
+// Тест 3: Ссылка выпускается один раз, а после перевыпуска старый токен перестает действовать
+func TestFeedLink_CreateAndRotate(t *testing.T) {
+	service, _, _, _, tokens := setupService()
+	ctx := context.Background()
+
+	first, err := service.FeedLink(ctx, 2)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^https://example\.com/api/v1/calendar/[0-9a-f]{64}\.ics$`, first.URL)
+
+	again, err := service.FeedLink(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, first.URL, again.URL)
+
+	oldToken := tokens[2].Token
+	rotated, err := service.RotateFeedLink(ctx, 2)
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.URL, rotated.URL)
+
+	_, err = tokens.GetByToken(ctx, oldToken)
+	assert.ErrorIs(t, err, model.ErrCalendarTokenNotFound)
+}
@@ -0,0 +1,164 @@
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"os"
+	"strings"
+)
+
+type Repository interface {
+	Get(ctx context.Context, fromCurrency, toCurrency string) (model.ExchangeRate, error)
+	List(ctx context.Context) ([]model.ExchangeRate, error)
+	Upsert(ctx context.Context, rates []model.ExchangeRate) error
+}
+
+type EventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Service struct {
+	repo       Repository
+	eventRepo  EventRepo
+	transactor Transactor
+	logger     *zap.SugaredLogger
+}
+
+func NewService(repo Repository, eventRepo EventRepo, transactor Transactor, logger *zap.SugaredLogger) Service {
+	return Service{
+		repo:       repo,
+		eventRepo:  eventRepo,
+		transactor: transactor,
+		logger:     logger,
+	}
+}
+
+// BaseCurrency возвращает базовую валюту события
+func (s Service) BaseCurrency(ctx context.Context, eventId int) (string, error) {
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		return "", errors.WithMessage(err, "get event")
+	}
+
+	return event.BaseCurrency, nil
+}
+
+// Convert пересчитывает сумму из currency в базовую валюту события.
+// Возвращает сумму, округленную до минимальной единицы базовой валюты, и использованный курс
+func (s Service) Convert(ctx context.Context, eventId int, amount model.Money, currency string) (model.Money, float64, error) {
+	baseCurrency, err := s.BaseCurrency(ctx, eventId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rate, err := s.GetRate(ctx, currency, baseCurrency)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return amount.MulRate(rate).Round(baseCurrency), rate, nil
+}
+
+// GetRate возвращает курс from -> to. Если прямой курс не задан, используется обратный
+func (s Service) GetRate(ctx context.Context, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	rate, err := s.repo.Get(ctx, from, to)
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.WithMessage(err, "get exchange rate")
+	}
+
+	rate, err = s.repo.Get(ctx, to, from)
+	if err == nil {
+		return 1 / rate.Rate, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.WithMessage(err, "get inverse exchange rate")
+	}
+
+	return 0, errors.WithMessagef(model.ErrExchangeRateNotFound, "%s/%s", from, to)
+}
+
+func (s Service) List(ctx context.Context) (domain.ExchangeRatesResponse, error) {
+	rates, err := s.repo.List(ctx)
+	if err != nil {
+		s.logger.Errorw("Failed to list exchange rates", "error", err)
+		return domain.ExchangeRatesResponse{}, errors.WithMessage(err, "list exchange rates")
+	}
+
+	items := make([]domain.ExchangeRateResponse, len(rates))
+	for i, rate := range rates {
+		items[i] = domain.ExchangeRateResponse{
+			FromCurrency: rate.FromCurrency,
+			ToCurrency:   rate.ToCurrency,
+			Rate:         rate.Rate,
+			UpdatedAt:    rate.UpdatedAt,
+		}
+	}
+
+	return domain.ExchangeRatesResponse{Rates: items}, nil
+}
+
+func (s Service) Update(ctx context.Context, req domain.ExchangeRatesUpdateRequest) error {
+	rates := make([]model.ExchangeRate, len(req.Rates))
+	for i, rate := range req.Rates {
+		rates[i] = model.ExchangeRate{
+			FromCurrency: strings.ToUpper(rate.FromCurrency),
+			ToCurrency:   strings.ToUpper(rate.ToCurrency),
+			Rate:         rate.Rate,
+		}
+	}
+
+	// Набор курсов загружается целиком или не загружается вовсе
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		return s.repo.Upsert(ctx, rates)
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to update exchange rates", "error", err)
+		return errors.WithMessage(err, "upsert exchange rates")
+	}
+
+	return nil
+}
+
+// LoadFile загружает курсы из JSON-файла в формате domain.ExchangeRatesUpdateRequest
+func (s Service) LoadFile(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.WithMessage(err, "read exchange rates file")
+	}
+
+	var req domain.ExchangeRatesUpdateRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errors.WithMessage(err, "parse exchange rates file")
+	}
+
+	for _, rate := range req.Rates {
+		if len(rate.FromCurrency) != 3 || len(rate.ToCurrency) != 3 || rate.Rate <= 0 {
+			return errors.Errorf("invalid exchange rate %s/%s: %g", rate.FromCurrency, rate.ToCurrency, rate.Rate)
+		}
+	}
+
+	if err := s.Update(ctx, req); err != nil {
+		return err
+	}
+
+	s.logger.Infow("Exchange rates loaded", "path", path, "count", len(req.Rates))
+
+	return nil
+}
@@ -0,0 +1,104 @@
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"testing"
+)
+
+// Mock репозитория курсов валют
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Get(ctx context.Context, fromCurrency, toCurrency string) (model.ExchangeRate, error) {
+	args := m.Called(ctx, fromCurrency, toCurrency)
+	return args.Get(0).(model.ExchangeRate), args.Error(1)
+}
+
+func (m *MockRepository) List(ctx context.Context) ([]model.ExchangeRate, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.ExchangeRate), args.Error(1)
+}
+
+func (m *MockRepository) Upsert(ctx context.Context, rates []model.ExchangeRate) error {
+	args := m.Called(ctx, rates)
+	return args.Error(0)
+}
+
+// Mock репозитория событий
+type MockEventRepo struct {
+	mock.Mock
+}
+
+func (m *MockEventRepo) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(model.Event), args.Error(1)
+}
+
+// Транзакция, выполняющая функцию без обращения к БД
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func setupService() (*Service, *MockRepository, *MockEventRepo) {
+	repo := new(MockRepository)
+	eventRepo := new(MockEventRepo)
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(repo, eventRepo, noTx{}, logger.Sugar())
+
+	return &service, repo, eventRepo
+}
+
+// Тест 1: пересчет по прямому курсу с округлением до копеек
+func TestConvert_DirectRate(t *testing.T) {
+	service, repo, eventRepo := setupService()
+	ctx := context.Background()
+
+	eventRepo.On("GetById", ctx, 1).Return(model.Event{EventId: 1, BaseCurrency: "RUB"}, nil)
+	repo.On("Get", ctx, "USD", "RUB").Return(model.ExchangeRate{FromCurrency: "USD", ToCurrency: "RUB", Rate: 90.123}, nil)
+
+	amount, rate, err := service.Convert(ctx, 1, model.MoneyFromFloat(10.01), "usd")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 90.123, rate)
+	assert.Equal(t, model.MoneyFromFloat(902.13), amount)
+}
+
+// Тест 2: если задан только обратный курс, используется он
+func TestGetRate_InverseRate(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+
+	repo.On("Get", ctx, "RUB", "EUR").Return(model.ExchangeRate{}, errors.WithMessage(sql.ErrNoRows, "get exchange rate"))
+	repo.On("Get", ctx, "EUR", "RUB").Return(model.ExchangeRate{FromCurrency: "EUR", ToCurrency: "RUB", Rate: 100}, nil)
+
+	rate, err := service.GetRate(ctx, "RUB", "EUR")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0.01, rate)
+}
+
+// Тест 3: одинаковые валюты не требуют курса, неизвестная пара — ошибка
+func TestGetRate_SameAndUnknown(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+
+	rate, err := service.GetRate(ctx, "RUB", "rub")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+	repo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+
+	repo.On("Get", ctx, mock.Anything, mock.Anything).Return(model.ExchangeRate{}, errors.WithMessage(sql.ErrNoRows, "get exchange rate"))
+
+	_, err = service.GetRate(ctx, "GBP", "RUB")
+	assert.ErrorIs(t, err, model.ErrExchangeRateNotFound)
+}
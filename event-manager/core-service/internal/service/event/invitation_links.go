@@ -0,0 +1,111 @@
+package event
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+)
+
+// InvitationLinks выпускает и проверяет подписанные ссылки ответа на приглашение. Токен ссылки —
+// ID участия, nonce приглашения и HMAC-SHA256 подпись от них
+type InvitationLinks struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+}
+
+func NewInvitationLinks(secret, baseURL string, ttl time.Duration) InvitationLinks {
+	return InvitationLinks{
+		secret:  []byte(secret),
+		baseURL: baseURL,
+		ttl:     ttl,
+	}
+}
+
+// newInvitation выпускает приглашение участника с новым nonce и сроком действия от now
+func (l InvitationLinks) newInvitation(participantId int, now time.Time) (model.EventInvitation, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return model.EventInvitation{}, errors.WithMessage(err, "generate invitation nonce")
+	}
+
+	return model.EventInvitation{
+		EventParticipantID: participantId,
+		Nonce:              hex.EncodeToString(nonce),
+		ExpiresAt:          now.Add(l.ttl),
+		SentAt:             now,
+	}, nil
+}
+
+// token подписывает приглашение
+func (l InvitationLinks) token(invitation model.EventInvitation) string {
+	payload := fmt.Sprintf("%d.%s", invitation.EventParticipantID, invitation.Nonce)
+	return payload + "." + l.sign(payload)
+}
+
+// parse проверяет подпись токена и возвращает ID участия и nonce приглашения
+func (l InvitationLinks) parse(token string) (int, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, "", model.ErrInvitationNotFound
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(l.sign(payload))) {
+		return 0, "", model.ErrInvitationNotFound
+	}
+
+	participantId, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", model.ErrInvitationNotFound
+	}
+
+	return participantId, parts[1], nil
+}
+
+// claimToken подписывает адрес, на который отправлено приглашение по адресу. Владелец адреса
+// получает токен в письме и предъявляет его, чтобы получить приглашения на этот адрес
+func (l InvitationLinks) claimToken(email string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(email))
+	return encoded + "." + l.sign("email:"+email)
+}
+
+// parseClaimToken проверяет подпись токена приглашения по адресу и возвращает адрес
+func (l InvitationLinks) parseClaimToken(token string) (string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", model.ErrInvitationNotFound
+	}
+
+	email, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", model.ErrInvitationNotFound
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(l.sign("email:"+string(email)))) {
+		return "", model.ErrInvitationNotFound
+	}
+
+	return string(email), nil
+}
+
+func (l InvitationLinks) sign(payload string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// urls возвращает ссылки принятия и отклонения приглашения
+func (l InvitationLinks) urls(invitation model.EventInvitation) (string, string) {
+	base := l.baseURL + "/" + l.token(invitation)
+	return base + "/accept", base + "/decline"
+}
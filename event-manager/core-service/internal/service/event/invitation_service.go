@@ -0,0 +1,274 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+)
+
+// AcceptInvitation подтверждает участие по подписанной ссылке из приглашения
+func (s Participant) AcceptInvitation(ctx context.Context, token string) (*domain.InvitationAnswerResponse, error) {
+	return s.answerInvitation(ctx, token, true)
+}
+
+// DeclineInvitation отклоняет участие по подписанной ссылке из приглашения
+func (s Participant) DeclineInvitation(ctx context.Context, token string) (*domain.InvitationAnswerResponse, error) {
+	return s.answerInvitation(ctx, token, false)
+}
+
+// GetInvitation возвращает событие приглашения по ссылке, не отвечая на него: ссылку может
+// открыть почтовый сканер, поэтому ответ отправляется отдельным запросом со страницы подтверждения
+func (s Participant) GetInvitation(ctx context.Context, token string) (*domain.InvitationPreviewResponse, error) {
+	id, err := s.checkInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	participant, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		s.logger.Errorw("Failed to get invited participant", "error", err, "id", id)
+		return nil, errors.WithMessage(err, "get participant")
+	}
+
+	event, err := s.eventRepo.GetById(ctx, participant.EventID)
+	if err != nil {
+		s.logger.Errorw("Failed to get invitation event", "error", err, "eventId", participant.EventID)
+		return nil, errors.WithMessage(err, "get event")
+	}
+
+	return &domain.InvitationPreviewResponse{
+		EventID:          event.EventId,
+		EventName:        event.Title,
+		StartDate:        event.StartDate,
+		WaitlistPosition: participant.WaitlistPosition,
+	}, nil
+}
+
+// checkInvitation проверяет подпись ссылки, ее актуальность и срок действия. Возвращает ID участия
+func (s Participant) checkInvitation(ctx context.Context, token string) (int, error) {
+	id, nonce, err := s.links.parse(token)
+	if err != nil {
+		return 0, err
+	}
+
+	invitation, err := s.invitationRepo.GetByParticipant(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if invitation.Nonce != nonce {
+		return 0, model.ErrInvitationNotFound
+	}
+	if invitation.Expired(time.Now()) {
+		return 0, model.ErrInvitationExpired
+	}
+
+	return id, nil
+}
+
+// answerInvitation проверяет ссылку и отвечает на приглашение. Ссылка действует до первого ответа,
+// истечения срока или повторной отправки приглашения
+func (s Participant) answerInvitation(ctx context.Context, token string, accept bool) (*domain.InvitationAnswerResponse, error) {
+	var id int
+	var resp domain.InvitationAnswerResponse
+	var promoted []model.User
+	var event model.Event
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.checkInvitation(ctx, token)
+		if err != nil {
+			return err
+		}
+
+		if accept {
+			event, err = s.confirm(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			participant, err := s.repo.GetById(ctx, id)
+			if err != nil {
+				return errors.WithMessage(err, "get participant")
+			}
+			resp.Status = domain.InvitationConfirmed
+			resp.WaitlistPosition = participant.WaitlistPosition
+		} else {
+			event, promoted, err = s.decline(ctx, id)
+			if err != nil {
+				return err
+			}
+			resp.Status = domain.InvitationDeclined
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, model.ErrInvitationNotFound) && !errors.Is(err, model.ErrInvitationExpired) {
+			s.logger.Errorw("Failed to answer invitation", "error", err, "id", id, "accept", accept)
+		}
+		return nil, err
+	}
+
+	s.notifyPromoted(ctx, event, promoted)
+
+	resp.EventID = event.EventId
+	resp.EventName = event.Title
+	return &resp, nil
+}
+
+// ListInvitations возвращает приглашения события, ожидающие ответа
+func (s Participant) ListInvitations(ctx context.Context, eventId int) (*domain.InvitationsResponse, error) {
+	invitations, err := s.invitationRepo.ListByEvent(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list invitations", "error", err, "eventId", eventId)
+		return nil, errors.WithMessage(err, "list invitations")
+	}
+
+	now := time.Now()
+	resp := make([]domain.InvitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		resp[i] = toInvitationResponse(invitation, now)
+	}
+
+	return &domain.InvitationsResponse{
+		Invitations: resp,
+		Total:       len(resp),
+	}, nil
+}
+
+// ResendInvitation отправляет приглашение повторно с новым сроком действия. Ссылки из прежних
+// писем перестают действовать
+func (s Participant) ResendInvitation(ctx context.Context, eventId, id int) (*domain.InvitationResponse, error) {
+	var invitation model.EventInvitation
+	var participant model.EventParticipant
+	var event model.Event
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		participant, err = s.getInvited(ctx, eventId, id)
+		if err != nil {
+			return err
+		}
+
+		event, err = s.eventRepo.GetByIdForUpdate(ctx, eventId)
+		if err != nil {
+			return errors.WithMessage(err, "get event")
+		}
+
+		invitation, err = s.links.newInvitation(id, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if err := s.invitationRepo.Save(ctx, invitation); err != nil {
+			return errors.WithMessage(err, "save invitation")
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, model.ErrInvitationNotFound) {
+			s.logger.Errorw("Failed to resend invitation", "error", err, "eventId", eventId, "id", id)
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, participant.UserID)
+	if err != nil {
+		s.logger.Errorw("Failed to get invited user", "error", err, "userID", participant.UserID)
+		return nil, errors.WithMessage(err, "get invited user")
+	}
+
+	if err := s.sendInvitation(ctx, event.Title, user.Email, participant, invitation); err != nil {
+		s.logger.Errorw("Failed to send invitation", "error", err, "eventId", eventId, "id", id)
+		return nil, err
+	}
+
+	resp := toInvitationResponse(model.PendingInvitation{
+		EventInvitation:  invitation,
+		EventID:          participant.EventID,
+		UserID:           user.UserId,
+		Username:         user.Username,
+		Email:            user.Email,
+		Role:             participant.Role,
+		WaitlistPosition: participant.WaitlistPosition,
+	}, invitation.SentAt)
+	return &resp, nil
+}
+
+// RevokeInvitation отзывает приглашение, ожидающее ответа: приглашенный удаляется из события,
+// а закрепленное за ним место занимает первый в листе ожидания
+func (s Participant) RevokeInvitation(ctx context.Context, eventId, id int) error {
+	var event model.Event
+	var promoted []model.User
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.getInvited(ctx, eventId, id); err != nil {
+			return err
+		}
+
+		var err error
+		event, promoted, err = s.remove(ctx, id)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, model.ErrInvitationNotFound) {
+			s.logger.Errorw("Failed to revoke invitation", "error", err, "eventId", eventId, "id", id)
+		}
+		return err
+	}
+
+	s.notifyPromoted(ctx, event, promoted)
+
+	return nil
+}
+
+// getInvited возвращает участника события, приглашение которого ожидает ответа
+func (s Participant) getInvited(ctx context.Context, eventId, id int) (model.EventParticipant, error) {
+	if _, err := s.invitationRepo.GetByParticipant(ctx, id); err != nil {
+		return model.EventParticipant{}, err
+	}
+
+	participant, err := s.repo.GetById(ctx, id)
+	if err != nil {
+		return model.EventParticipant{}, errors.WithMessage(err, "get participant")
+	}
+	if participant.EventID != eventId {
+		return model.EventParticipant{}, model.ErrInvitationNotFound
+	}
+
+	return participant, nil
+}
+
+// sendInvitation отправляет приглашенному письмо со ссылками принятия и отклонения приглашения
+func (s Participant) sendInvitation(ctx context.Context, eventName, email string, participant model.EventParticipant,
+	invitation model.EventInvitation) error {
+	acceptURL, declineURL := s.links.urls(invitation)
+
+	payload := map[string]any{
+		"event_name":  eventName,
+		"user_email":  email,
+		"accept_url":  acceptURL,
+		"decline_url": declineURL,
+		"expires_at":  invitation.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+	if participant.WaitlistPosition != nil {
+		payload["waitlist_position"] = *participant.WaitlistPosition
+	}
+
+	return s.publish(ctx, "participant_invited", payload)
+}
+
+func toInvitationResponse(invitation model.PendingInvitation, now time.Time) domain.InvitationResponse {
+	return domain.InvitationResponse{
+		ParticipantID:    invitation.EventParticipantID,
+		UserID:           invitation.UserID,
+		Username:         invitation.Username,
+		Email:            invitation.Email,
+		Role:             string(invitation.Role),
+		SentAt:           invitation.SentAt,
+		ExpiresAt:        invitation.ExpiresAt,
+		Expired:          invitation.Expired(now),
+		WaitlistPosition: invitation.WaitlistPosition,
+	}
+}
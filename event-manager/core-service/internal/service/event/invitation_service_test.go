@@ -0,0 +1,117 @@
+package event
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// invite приглашает пользователя в событие 1 и возвращает токены ссылок принятия и отклонения из письма
+func invite(t *testing.T, service *Participant, publisher *recordingPublisher, userId int) (string, string) {
+	_, err := service.Create(context.Background(), 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", UserID: userId})
+	assert.NoError(t, err)
+
+	data := publisher.messages[len(publisher.messages)-1]["data"].(map[string]any)
+	return linkToken(data["accept_url"].(string)), linkToken(data["decline_url"].(string))
+}
+
+func linkToken(url string) string {
+	parts := strings.Split(url, "/")
+	return parts[len(parts)-2]
+}
+
+// Тест 1: Приглашение принимается по ссылке один раз, поддельная ссылка не действует
+func TestAcceptInvitation(t *testing.T) {
+	service, store, invitations, publisher := setupParticipantService()
+	ctx := context.Background()
+
+	accept, decline := invite(t, service, publisher, 2)
+	assert.Equal(t, accept, decline)
+	participant, _ := store.GetById(ctx, 2)
+	assert.Nil(t, participant.IsConfirmed)
+
+	_, err := service.AcceptInvitation(ctx, accept[:len(accept)-1]+"x")
+	assert.ErrorIs(t, err, model.ErrInvitationNotFound)
+
+	// Открытие ссылки показывает приглашение, но не отвечает на него
+	preview, err := service.GetInvitation(ctx, accept)
+	assert.NoError(t, err)
+	assert.Equal(t, "Поход", preview.EventName)
+	participant, _ = store.GetById(ctx, 2)
+	assert.Nil(t, participant.IsConfirmed)
+	assert.Len(t, invitations, 1)
+
+	resp, err := service.AcceptInvitation(ctx, accept)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.InvitationAnswerResponse{EventID: 1, EventName: "Поход", Status: domain.InvitationConfirmed}, resp)
+	participant, _ = store.GetById(ctx, 2)
+	assert.Equal(t, ptrTo(true), participant.IsConfirmed)
+	assert.Empty(t, invitations)
+
+	_, err = service.DeclineInvitation(ctx, accept)
+	assert.ErrorIs(t, err, model.ErrInvitationNotFound)
+	_, err = service.GetInvitation(ctx, accept)
+	assert.ErrorIs(t, err, model.ErrInvitationNotFound)
+}
+
+// Тест 2: Повторная отправка заменяет ссылку, ссылка с истекшим сроком не действует
+func TestResendInvitation(t *testing.T) {
+	service, _, invitations, publisher := setupParticipantService()
+	ctx := context.Background()
+
+	old, _ := invite(t, service, publisher, 2)
+
+	resp, err := service.ResendInvitation(ctx, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "user2@example.com", resp.Email)
+	assert.False(t, resp.Expired)
+	assert.Len(t, publisher.messages, 2)
+	renewed := linkToken(publisher.messages[1]["data"].(map[string]any)["accept_url"].(string))
+	assert.NotEqual(t, old, renewed)
+
+	_, err = service.AcceptInvitation(ctx, old)
+	assert.ErrorIs(t, err, model.ErrInvitationNotFound)
+
+	invitation := invitations[2]
+	invitation.ExpiresAt = time.Now().Add(-time.Minute)
+	invitations[2] = invitation
+	_, err = service.AcceptInvitation(ctx, renewed)
+	assert.ErrorIs(t, err, model.ErrInvitationExpired)
+
+	_, err = service.ResendInvitation(ctx, 5, 2)
+	assert.ErrorIs(t, err, model.ErrInvitationNotFound)
+}
+
+// Тест 3: Отказ по ссылке и отзыв приглашения освобождают место для листа ожидания
+func TestDeclineAndRevokeInvitation(t *testing.T) {
+	service, store, invitations, publisher := setupParticipantService()
+	ctx := context.Background()
+
+	_, decline := invite(t, service, publisher, 2)
+	invite(t, service, publisher, 3)
+	invite(t, service, publisher, 4)
+	publisher.messages = nil
+
+	resp, err := service.DeclineInvitation(ctx, decline)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InvitationDeclined, resp.Status)
+	promoted, _ := store.GetById(ctx, 3)
+	assert.Nil(t, promoted.WaitlistPosition)
+	assert.Equal(t, "participant_promoted", publisher.messages[0]["event"])
+
+	// Место за приглашенным сохраняется до ответа, поэтому отзыв приглашения переводит следующего
+	assert.NoError(t, service.RevokeInvitation(ctx, 1, 3))
+	_, err = store.GetById(ctx, 3)
+	assert.Error(t, err)
+	promoted, _ = store.GetById(ctx, 4)
+	assert.Nil(t, promoted.WaitlistPosition)
+	assert.Len(t, publisher.messages, 2)
+	assert.Contains(t, invitations, 4)
+
+	assert.ErrorIs(t, service.RevokeInvitation(ctx, 1, 2), model.ErrInvitationNotFound)
+}
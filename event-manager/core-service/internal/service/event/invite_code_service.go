@@ -0,0 +1,239 @@
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+)
+
+// InviteCodeRepo коды приглашения в события
+type InviteCodeRepo interface {
+	Create(ctx context.Context, code model.EventInviteCode) (int, error)
+	GetById(ctx context.Context, id int) (model.EventInviteCode, error)
+	GetByCodeForUpdate(ctx context.Context, value string) (model.EventInviteCode, error)
+	RecordUse(ctx context.Context, id int, now time.Time) error
+	Revoke(ctx context.Context, id int, now time.Time) error
+	ListByEvent(ctx context.Context, eventId int) ([]model.EventInviteCode, error)
+}
+
+// CreateInviteCode выпускает код, по которому в событие может вступить любой зарегистрированный пользователь
+func (s Participant) CreateInviteCode(ctx context.Context, eventId, userId int, req domain.InviteCodeCreateRequest) (*domain.InviteCodeResponse, error) {
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, errors.WithMessage(model.ErrInvalidInviteCode, "expires_at must be in the future")
+	}
+
+	value, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	code := model.EventInviteCode{
+		EventID:   eventId,
+		Code:      value,
+		Role:      model.RoleParticipant,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: userId,
+		CreatedAt: now,
+	}
+	if req.Role != "" {
+		code.Role = model.ParticipantRole(req.Role)
+	}
+
+	code.InviteCodeID, err = s.inviteCodeRepo.Create(ctx, code)
+	if err != nil {
+		s.logger.Errorw("Failed to create invite code", "error", err, "eventId", eventId)
+		return nil, errors.WithMessage(err, "create invite code")
+	}
+
+	resp := toInviteCodeResponse(code, now)
+	return &resp, nil
+}
+
+// ListInviteCodes возвращает коды приглашения события со статистикой использования, новые первыми
+func (s Participant) ListInviteCodes(ctx context.Context, eventId int) (*domain.InviteCodesResponse, error) {
+	codes, err := s.inviteCodeRepo.ListByEvent(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list invite codes", "error", err, "eventId", eventId)
+		return nil, errors.WithMessage(err, "list invite codes")
+	}
+
+	now := time.Now()
+	resp := make([]domain.InviteCodeResponse, len(codes))
+	for i, code := range codes {
+		resp[i] = toInviteCodeResponse(code, now)
+	}
+
+	return &domain.InviteCodesResponse{
+		InviteCodes: resp,
+		Total:       len(resp),
+	}, nil
+}
+
+// RevokeInviteCode отзывает код приглашения. Вступившие по коду остаются участниками события
+func (s Participant) RevokeInviteCode(ctx context.Context, eventId, id int) error {
+	code, err := s.inviteCodeRepo.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if code.EventID != eventId {
+		return model.ErrInviteCodeNotFound
+	}
+
+	if err := s.inviteCodeRepo.Revoke(ctx, id, time.Now()); err != nil {
+		if !errors.Is(err, model.ErrInviteCodeNotFound) {
+			s.logger.Errorw("Failed to revoke invite code", "error", err, "eventId", eventId, "id", id)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// JoinByCode добавляет пользователя в событие по коду приглашения с ролью из кода. Участие сразу
+// подтверждено; если мест нет, пользователь попадает в лист ожидания
+func (s Participant) JoinByCode(ctx context.Context, userId int, value string) (*domain.EventParticipantResponse, error) {
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		s.logger.Errorw("Failed to get user for joining by code", "error", err, "userID", userId)
+		return nil, errors.WithMessage(err, "invalid user")
+	}
+
+	now := time.Now()
+	var participant model.EventParticipant
+
+	// Код и событие блокируются, чтобы одновременные вступления не превысили ограничения кода и события
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		code, err := s.inviteCodeRepo.GetByCodeForUpdate(ctx, strings.ToUpper(strings.TrimSpace(value)))
+		if err != nil {
+			return err
+		}
+		if code.RevokedAt != nil {
+			return model.ErrInviteCodeNotFound
+		}
+		if code.Expired(now) {
+			return model.ErrInviteCodeExpired
+		}
+		if code.Exhausted() {
+			return model.ErrInviteCodeExhausted
+		}
+
+		event, err := s.eventRepo.GetByIdForUpdate(ctx, code.EventID)
+		if err != nil {
+			return errors.WithMessage(err, "get event")
+		}
+		// В прошедшее, отмененное или архивное событие по коду не вступить
+		switch event.Status {
+		case model.EventStatusCompleted, model.EventStatusCancelled, model.EventStatusArchived:
+			return model.ErrInviteCodeExpired
+		}
+
+		_, err = s.repo.GetByEventAndUser(ctx, code.EventID, userId)
+		if err == nil {
+			return model.ErrUserAlreadyAnParticipant
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return errors.WithMessage(err, "check participant")
+		}
+
+		participant = model.EventParticipant{
+			EventID:     code.EventID,
+			UserID:      userId,
+			Role:        code.Role,
+			JoinedAt:    &now,
+			IsConfirmed: ptr(true),
+		}
+
+		free, err := s.hasFreeSlot(ctx, event)
+		if err != nil {
+			return err
+		}
+		if !free {
+			participant.WaitlistedAt = &now
+		}
+
+		participant.EventParticipantID, err = s.repo.Create(ctx, participant)
+		if err != nil {
+			return errors.WithMessage(err, "create participant")
+		}
+
+		if err := s.inviteCodeRepo.RecordUse(ctx, code.InviteCodeID, now); err != nil {
+			return errors.WithMessage(err, "record invite code use")
+		}
+
+		if participant.WaitlistedAt != nil {
+			created, err := s.repo.GetById(ctx, participant.EventParticipantID)
+			if err != nil {
+				return errors.WithMessage(err, "get waitlist position")
+			}
+			participant.WaitlistPosition = created.WaitlistPosition
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !isJoinRejected(err) {
+			s.logger.Errorw("Failed to join event by code", "error", err, "userID", userId)
+		}
+		return nil, err
+	}
+
+	resp := toParticipantResponse(participant, user)
+	return &resp, nil
+}
+
+// isJoinRejected сообщает, что вступление по коду отклонено, а не завершилось сбоем
+func isJoinRejected(err error) bool {
+	return errors.Is(err, model.ErrInviteCodeNotFound) || errors.Is(err, model.ErrInviteCodeExpired) ||
+		errors.Is(err, model.ErrInviteCodeExhausted) || errors.Is(err, model.ErrUserAlreadyAnParticipant)
+}
+
+// newInviteCode возвращает случайный код из 8 символов base32: его удобно продиктовать или ввести вручную
+func newInviteCode() (string, error) {
+	bytes := make([]byte, 5)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", errors.WithMessage(err, "generate invite code")
+	}
+
+	return base32.StdEncoding.EncodeToString(bytes), nil
+}
+
+func toInviteCodeResponse(code model.EventInviteCode, now time.Time) domain.InviteCodeResponse {
+	resp := domain.InviteCodeResponse{
+		Id:         code.InviteCodeID,
+		EventID:    code.EventID,
+		Code:       code.Code,
+		Role:       string(code.Role),
+		Status:     domain.InviteCodeActive,
+		MaxUses:    code.MaxUses,
+		UsesCount:  code.UsesCount,
+		ExpiresAt:  code.ExpiresAt,
+		CreatedBy:  code.CreatedBy,
+		CreatedAt:  code.CreatedAt,
+		LastUsedAt: code.LastUsedAt,
+		RevokedAt:  code.RevokedAt,
+	}
+	if code.MaxUses != nil {
+		remaining := max(*code.MaxUses-code.UsesCount, 0)
+		resp.RemainingUses = &remaining
+	}
+
+	switch {
+	case code.RevokedAt != nil:
+		resp.Status = domain.InviteCodeRevoked
+	case code.Expired(now):
+		resp.Status = domain.InviteCodeExpired
+	case code.Exhausted():
+		resp.Status = domain.InviteCodeExhausted
+	}
+
+	return resp
+}
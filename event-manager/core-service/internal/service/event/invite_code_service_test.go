@@ -0,0 +1,85 @@
+package event
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест 1: По коду вступают с ролью из кода, пока не исчерпан лимит; сверх мест — в лист ожидания
+func TestJoinByCode(t *testing.T) {
+	service, _, _, _ := setupParticipantService()
+	ctx := context.Background()
+
+	code, err := service.CreateInviteCode(ctx, 1, 1, domain.InviteCodeCreateRequest{Role: "admin", MaxUses: ptrTo(2)})
+	assert.NoError(t, err)
+	assert.Len(t, code.Code, 8)
+	assert.Equal(t, domain.InviteCodeActive, code.Status)
+	assert.Equal(t, ptrTo(2), code.RemainingUses)
+
+	joined, err := service.JoinByCode(ctx, 2, strings.ToLower(code.Code))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", joined.Role)
+	assert.Equal(t, ptrTo(true), joined.IsConfirmed)
+	assert.Nil(t, joined.WaitlistPosition)
+
+	_, err = service.JoinByCode(ctx, 2, code.Code)
+	assert.ErrorIs(t, err, model.ErrUserAlreadyAnParticipant)
+
+	waiting, err := service.JoinByCode(ctx, 3, code.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, ptrTo(1), waiting.WaitlistPosition)
+
+	_, err = service.JoinByCode(ctx, 4, code.Code)
+	assert.ErrorIs(t, err, model.ErrInviteCodeExhausted)
+
+	codes, err := service.ListInviteCodes(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, codes.Total)
+	assert.Equal(t, domain.InviteCodeExhausted, codes.InviteCodes[0].Status)
+	assert.Equal(t, 2, codes.InviteCodes[0].UsesCount)
+	assert.Equal(t, ptrTo(0), codes.InviteCodes[0].RemainingUses)
+	assert.NotNil(t, codes.InviteCodes[0].LastUsedAt)
+}
+
+// Тест 2: По истекшему и отозванному коду вступить нельзя, код чужого события не отзывается
+func TestJoinByCode_ExpiredAndRevoked(t *testing.T) {
+	service, _, _, _ := setupParticipantService()
+	ctx := context.Background()
+
+	_, err := service.CreateInviteCode(ctx, 1, 1, domain.InviteCodeCreateRequest{ExpiresAt: ptrTo(time.Now().Add(-time.Hour))})
+	assert.ErrorIs(t, err, model.ErrInvalidInviteCode)
+
+	expiring, err := service.CreateInviteCode(ctx, 1, 1, domain.InviteCodeCreateRequest{ExpiresAt: ptrTo(time.Now().Add(time.Hour))})
+	assert.NoError(t, err)
+	assert.Equal(t, "participant", expiring.Role)
+	revoked, err := service.CreateInviteCode(ctx, 1, 1, domain.InviteCodeCreateRequest{})
+	assert.NoError(t, err)
+	assert.Nil(t, revoked.RemainingUses)
+
+	codes := service.inviteCodeRepo.(inviteCodeStore)
+	expired := codes[expiring.Id]
+	expired.ExpiresAt = ptrTo(time.Now().Add(-time.Minute))
+	codes[expiring.Id] = expired
+
+	_, err = service.JoinByCode(ctx, 2, expiring.Code)
+	assert.ErrorIs(t, err, model.ErrInviteCodeExpired)
+
+	assert.ErrorIs(t, service.RevokeInviteCode(ctx, 5, revoked.Id), model.ErrInviteCodeNotFound)
+	assert.NoError(t, service.RevokeInviteCode(ctx, 1, revoked.Id))
+	assert.ErrorIs(t, service.RevokeInviteCode(ctx, 1, revoked.Id), model.ErrInviteCodeNotFound)
+
+	_, err = service.JoinByCode(ctx, 2, revoked.Code)
+	assert.ErrorIs(t, err, model.ErrInviteCodeNotFound)
+
+	list, err := service.ListInviteCodes(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InviteCodeRevoked, list.InviteCodes[0].Status)
+	assert.Equal(t, domain.InviteCodeExpired, list.InviteCodes[1].Status)
+	assert.Zero(t, list.InviteCodes[1].UsesCount)
+}
@@ -0,0 +1,222 @@
+package event
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+	defaultSort     = "start_date_desc"
+)
+
+// eventSorts допустимые значения параметра sort. По умолчанию при поиске — по релевантности,
+// без поисковой фразы — сначала поздние события
+var eventSorts = map[string]struct {
+	sortBy string
+	desc   bool
+}{
+	"start_date_desc": {model.EventSortStartDate, true},
+	"start_date_asc":  {model.EventSortStartDate, false},
+	"created_at_desc": {model.EventSortCreatedAt, true},
+	"created_at_asc":  {model.EventSortCreatedAt, false},
+	"title_asc":       {model.EventSortTitle, false},
+	"relevance":       {model.EventSortRelevance, true},
+}
+
+// List возвращает страницу событий по поисковой фразе и фильтрам. Если задан viewerId,
+// выбираются только события, в которых он участвует. NextCursor пуст, если страница последняя
+func (s Service) List(ctx context.Context, viewerId *int, req domain.EventListRequest) (*domain.EventsResponse, error) {
+	filter, err := eventFilter(req)
+	if err != nil {
+		return nil, err
+	}
+	filter.ViewerID = viewerId
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+
+	events, total, err := s.eventRepo.Search(ctx, filter)
+	if err != nil {
+		s.logger.Errorw("Failed to search events", "error", err, "query", filter.Query, "viewerId", viewerId)
+		return nil, errors.WithMessage(err, "search events")
+	}
+
+	var nextCursor string
+	if len(events) > limit {
+		events = events[:limit]
+		nextCursor, err = encodeCursor(eventSort(req), events[limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := s.listItems(ctx, events, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.EventsResponse{
+		Events:     items,
+		Total:      total,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// listItems возвращает события страницы. Если задан диапазон дат, серии разворачиваются
+// в повторения внутри него
+func (s Service) listItems(ctx context.Context, events []model.FoundEvent, req domain.EventListRequest) ([]domain.EventResponse, error) {
+	items := make([]domain.EventResponse, 0, len(events))
+	if req.DateFrom == nil || req.DateTo == nil {
+		for _, event := range events {
+			items = append(items, toEventResponse(event.Event))
+		}
+		return items, nil
+	}
+
+	seriesIds := make([]int, 0)
+	for _, event := range events {
+		if event.RecurrenceRule != nil {
+			seriesIds = append(seriesIds, event.EventId)
+		}
+	}
+
+	exceptions, err := s.exceptionRepo.ListByEvents(ctx, seriesIds)
+	if err != nil {
+		s.logger.Errorw("Failed to list event exceptions", "error", err, "eventIds", seriesIds)
+		return nil, errors.WithMessage(err, "list event exceptions")
+	}
+
+	bySeries := make(map[int][]model.EventException, len(seriesIds))
+	for _, exception := range exceptions {
+		bySeries[exception.EventID] = append(bySeries[exception.EventID], exception)
+	}
+
+	for _, event := range events {
+		if event.RecurrenceRule == nil {
+			items = append(items, toEventResponse(event.Event))
+			continue
+		}
+
+		occurrences, err := expand(event.Event, bySeries[event.EventId], *req.DateFrom, req.DateTo.AddDate(0, 0, 1), maxOccurrences)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, occurrences...)
+	}
+
+	return items, nil
+}
+
+// eventFilter переводит параметры запроса в фильтр репозитория
+func eventFilter(req domain.EventListRequest) (model.EventFilter, error) {
+	filter := model.EventFilter{
+		Query:         strings.TrimSpace(req.Query),
+		From:          req.DateFrom,
+		OrganizerID:   req.OrganizerID,
+		ParticipantID: req.ParticipantID,
+		SortBy:        model.EventSortStartDate,
+		Desc:          true,
+		Limit:         req.Limit,
+	}
+
+	if req.DateTo != nil {
+		// Дата окончания входит в диапазон целиком
+		to := req.DateTo.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	for _, status := range req.Status {
+		filter.Statuses = append(filter.Statuses, model.EventStatus(status))
+	}
+
+	sort := eventSort(req)
+	filter.SortBy = eventSorts[sort].sortBy
+	filter.Desc = eventSorts[sort].desc
+
+	if filter.Limit <= 0 {
+		filter.Limit = req.Size
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	filter.Limit = min(filter.Limit, maxPageSize)
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return model.EventFilter{}, err
+		}
+		if cursor.Sort != sort {
+			return model.EventFilter{}, errors.WithMessagef(model.ErrInvalidCursor, "cursor was issued for sort %s", cursor.Sort)
+		}
+		filter.After = &cursor
+	} else if req.Page > 1 {
+		filter.Offset = (req.Page - 1) * filter.Limit
+	}
+
+	return filter, nil
+}
+
+// eventSort возвращает порядок сортировки запроса с учетом значения по умолчанию
+func eventSort(req domain.EventListRequest) string {
+	query := strings.TrimSpace(req.Query)
+
+	sort := defaultSort
+	if query != "" {
+		sort = "relevance"
+	}
+	if _, ok := eventSorts[req.Sort]; ok {
+		sort = req.Sort
+	}
+	// Без поисковой фразы релевантность у всех событий одинакова
+	if sort == "relevance" && query == "" {
+		sort = defaultSort
+	}
+
+	return sort
+}
+
+func encodeCursor(sort string, event model.FoundEvent) (string, error) {
+	data, err := json.Marshal(model.EventCursor{
+		Sort:      sort,
+		StartDate: event.StartDate,
+		CreatedAt: event.CreatedAt,
+		Title:     event.Title,
+		Rank:      event.Rank,
+		EventID:   event.EventId,
+	})
+	if err != nil {
+		return "", errors.WithMessage(err, "encode cursor")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(value string) (model.EventCursor, error) {
+	var cursor model.EventCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, err.Error())
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, err.Error())
+	}
+	if _, ok := eventSorts[cursor.Sort]; !ok {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, "unknown cursor sort")
+	}
+	if cursor.EventID <= 0 || cursor.StartDate.Equal(time.Time{}) {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, "missing cursor position")
+	}
+
+	return cursor, nil
+}
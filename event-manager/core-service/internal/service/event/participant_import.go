@@ -0,0 +1,299 @@
+package event
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+)
+
+// EmailInvitationRepo приглашения по адресам, для которых еще нет пользователя
+type EmailInvitationRepo interface {
+	Save(ctx context.Context, invitation model.EventEmailInvitation) error
+	ListByEmail(ctx context.Context, email string) ([]model.EventEmailInvitation, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// invitedUser добавленный участник и приглашение, которое ему нужно отправить
+type invitedUser struct {
+	eventName   string
+	email       string
+	participant model.EventParticipant
+	invitation  model.EventInvitation
+}
+
+// ImportParticipants добавляет в событие пользователей из списка в одной транзакции. Найденные пользователи
+// получают приглашение участника, для неизвестных адресов сохраняется приглашение по адресу. Строки, которые
+// не удалось разобрать или найти, и уже участвующие пользователи не мешают добавлению остальных
+func (s Participant) ImportParticipants(ctx context.Context, eventId, invitedBy int, req domain.ParticipantImportRequest) (*domain.ParticipantImportResponse, error) {
+	resp := domain.ParticipantImportResponse{Results: make([]domain.ParticipantImportResult, len(req.Rows))}
+	var invited []invitedUser
+	var emails []string
+	var event model.Event
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		event, err = s.eventRepo.GetByIdForUpdate(ctx, eventId)
+		if err != nil {
+			return errors.WithMessage(err, "get event")
+		}
+		if event.Status.ReadOnly() {
+			return model.ErrEventArchived
+		}
+
+		now := time.Now()
+		emailInvited := make(map[string]bool)
+		for i, row := range req.Rows {
+			result := domain.ParticipantImportResult{
+				Row:      i + 1,
+				Username: strings.TrimSpace(row.Username),
+				Email:    strings.ToLower(strings.TrimSpace(row.Email)),
+			}
+
+			user, reason, err := s.resolveImportRow(ctx, result.Username, result.Email)
+			if err != nil {
+				return errors.WithMessagef(err, "resolve row %d", result.Row)
+			}
+
+			switch {
+			case reason != "":
+				result.Status = domain.ImportInvalid
+				result.Reason = reason
+				resp.Invalid++
+
+			case user == nil:
+				err := s.emailInvitationRepo.Save(ctx, model.EventEmailInvitation{
+					EventID:   eventId,
+					Email:     result.Email,
+					InvitedBy: invitedBy,
+					CreatedAt: now,
+				})
+				if err != nil {
+					return errors.WithMessage(err, "save email invitation")
+				}
+				if !emailInvited[result.Email] {
+					emailInvited[result.Email] = true
+					emails = append(emails, result.Email)
+				}
+				result.Status = domain.ImportInvitedByEmail
+				resp.InvitedByEmail++
+
+			default:
+				participant, invitation, err := s.addInvited(ctx, event, user.UserId, model.RoleParticipant, now)
+				if errors.Is(err, model.ErrUserAlreadyAnParticipant) {
+					result.Status = domain.ImportAlreadyParticipant
+					resp.AlreadyParticipant++
+					break
+				}
+				if err != nil {
+					return errors.WithMessagef(err, "add user %d", user.UserId)
+				}
+
+				invited = append(invited, invitedUser{
+					eventName:   event.Title,
+					email:       user.Email,
+					participant: participant,
+					invitation:  invitation,
+				})
+				result.Status = domain.ImportAdded
+				result.ParticipantID = &participant.EventParticipantID
+				result.WaitlistPosition = participant.WaitlistPosition
+				resp.Added++
+			}
+
+			resp.Results[i] = result
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, model.ErrEventArchived) {
+			s.logger.Errorw("Failed to import participants", "error", err, "eventId", eventId)
+		}
+		return nil, err
+	}
+
+	s.sendInvitations(ctx, invited)
+	for _, email := range emails {
+		err := s.publish(ctx, "participant_email_invited", map[string]any{
+			"event_id":    event.EventId,
+			"event_name":  event.Title,
+			"user_email":  email,
+			"claim_token": s.links.claimToken(email),
+		})
+		if err != nil {
+			s.logger.Errorw("Failed to send email invitation", "error", err, "eventId", eventId, "email", email)
+		}
+	}
+
+	return &resp, nil
+}
+
+// resolveImportRow ищет пользователя строки сначала по имени, затем по адресу. Возвращает причину,
+// по которой строка не может быть обработана, или nil без причины для адреса без пользователя
+func (s Participant) resolveImportRow(ctx context.Context, username, email string) (*model.User, string, error) {
+	if username == "" && email == "" {
+		return nil, "username or email is required", nil
+	}
+	if email != "" {
+		address, err := mail.ParseAddress(email)
+		if err != nil || address.Address != email {
+			return nil, "invalid email", nil
+		}
+	}
+
+	if username != "" {
+		user, err := s.userRepo.GetUserByUsername(ctx, username)
+		if err == nil {
+			return user, "", nil
+		}
+		if !errors.Is(err, model.ErrUserNotFound) {
+			return nil, "", errors.WithMessage(err, "get user by username")
+		}
+		if email == "" {
+			return nil, "user not found", nil
+		}
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", errors.WithMessage(err, "get user by email")
+	}
+
+	return user, "", nil
+}
+
+// ClaimEmailInvitations превращает приглашения по адресу в приглашения участника, на которые
+// пользователь отвечает по ссылкам из писем. Адрес берется из подписанного токена письма: адрес
+// в профиле не подтвержден и не доказывает владение им. Приглашения в завершенные события просто удаляются
+func (s Participant) ClaimEmailInvitations(ctx context.Context, userId int, token string) (*domain.EventParticipantsResponse, error) {
+	email, err := s.links.parseClaimToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, userId)
+	if err != nil {
+		s.logger.Errorw("Failed to get user for claiming invitations", "error", err, "userID", userId)
+		return nil, errors.WithMessage(err, "get user")
+	}
+
+	var invited []invitedUser
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		invitations, err := s.emailInvitationRepo.ListByEmail(ctx, email)
+		if err != nil {
+			return errors.WithMessage(err, "list email invitations")
+		}
+
+		now := time.Now()
+		for _, emailInvitation := range invitations {
+			event, err := s.eventRepo.GetByIdForUpdate(ctx, emailInvitation.EventID)
+			if err != nil {
+				return errors.WithMessage(err, "get event")
+			}
+
+			if !event.Status.ReadOnly() {
+				participant, invitation, err := s.addInvited(ctx, event, userId, model.RoleParticipant, now)
+				if err != nil && !errors.Is(err, model.ErrUserAlreadyAnParticipant) {
+					return errors.WithMessagef(err, "add to event %d", event.EventId)
+				}
+				if err == nil {
+					invited = append(invited, invitedUser{
+						eventName:   event.Title,
+						email:       user.Email,
+						participant: participant,
+						invitation:  invitation,
+					})
+				}
+			}
+
+			if err := s.emailInvitationRepo.Delete(ctx, emailInvitation.EmailInvitationID); err != nil {
+				return errors.WithMessage(err, "delete email invitation")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to claim email invitations", "error", err, "userID", userId)
+		return nil, err
+	}
+
+	s.sendInvitations(ctx, invited)
+
+	participants := make([]domain.EventParticipantResponse, len(invited))
+	for i, item := range invited {
+		participants[i] = toParticipantResponse(item.participant, user)
+	}
+
+	return &domain.EventParticipantsResponse{
+		Participants: participants,
+		Total:        len(participants),
+	}, nil
+}
+
+// InviteMembers приглашает пользователей в событие с указанными ролями, как при добавлении по одному:
+// участие ожидает ответа, а сверх ограничения пользователи попадают в лист ожидания в том же порядке.
+// Уже участвующие пользователи пропускаются
+func (s Participant) InviteMembers(ctx context.Context, eventId int, members []model.TemplateParticipant) error {
+	var invited []invitedUser
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		event, err := s.eventRepo.GetByIdForUpdate(ctx, eventId)
+		if err != nil {
+			return errors.WithMessage(err, "get event")
+		}
+
+		now := time.Now()
+		for _, member := range members {
+			user, err := s.userRepo.GetUserById(ctx, member.UserID)
+			if errors.Is(err, model.ErrUserNotFound) {
+				continue
+			}
+			if err != nil {
+				return errors.WithMessagef(err, "get user %d", member.UserID)
+			}
+
+			participant, invitation, err := s.addInvited(ctx, event, user.UserId, member.Role, now)
+			if errors.Is(err, model.ErrUserAlreadyAnParticipant) {
+				continue
+			}
+			if err != nil {
+				return errors.WithMessagef(err, "add user %d", user.UserId)
+			}
+
+			invited = append(invited, invitedUser{
+				eventName:   event.Title,
+				email:       user.Email,
+				participant: participant,
+				invitation:  invitation,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to invite members", "error", err, "eventId", eventId)
+		return err
+	}
+
+	s.sendInvitations(ctx, invited)
+
+	return nil
+}
+
+// sendInvitations отправляет приглашения добавленным участникам. Участники уже сохранены,
+// поэтому ошибки отправки только логируются
+func (s Participant) sendInvitations(ctx context.Context, invited []invitedUser) {
+	for _, item := range invited {
+		if err := s.sendInvitation(ctx, item.eventName, item.email, item.participant, item.invitation); err != nil {
+			s.logger.Errorw("Failed to send invitation", "error", err, "eventID", item.participant.EventID,
+				"userID", item.participant.UserID)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package event
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест 1: Каждая строка списка получает свой результат, неизвестные адреса приглашаются по адресу
+func TestImportParticipants(t *testing.T) {
+	service, store, invitations, publisher := setupParticipantService()
+	ctx := context.Background()
+
+	resp, err := service.ImportParticipants(ctx, 1, 1, domain.ParticipantImportRequest{Rows: []domain.ParticipantImportRow{
+		{Username: "user2"},
+		{Username: " user2 "},
+		{Email: "New@Example.com"},
+		{},
+		{Email: "not-an-email"},
+		{Username: "ghost"},
+		{Username: "user1"},
+		{Username: "ghost", Email: "user3@example.com"},
+		{Email: "new@example.com"},
+	}})
+	assert.NoError(t, err)
+
+	statuses := make([]string, len(resp.Results))
+	for i, result := range resp.Results {
+		statuses[i] = result.Status
+	}
+	assert.Equal(t, []string{
+		domain.ImportAdded, domain.ImportAlreadyParticipant, domain.ImportInvitedByEmail, domain.ImportInvalid,
+		domain.ImportInvalid, domain.ImportInvalid, domain.ImportAlreadyParticipant, domain.ImportAdded,
+		domain.ImportInvitedByEmail,
+	}, statuses)
+	assert.Equal(t, 2, resp.Added)
+	assert.Equal(t, 2, resp.AlreadyParticipant)
+	assert.Equal(t, 2, resp.InvitedByEmail)
+	assert.Equal(t, 3, resp.Invalid)
+	assert.Equal(t, "user not found", resp.Results[5].Reason)
+	assert.Equal(t, "new@example.com", resp.Results[2].Email)
+
+	// Мест на двоих: третий пользователь попадает в лист ожидания
+	assert.Equal(t, ptrTo(2), resp.Results[0].ParticipantID)
+	assert.Nil(t, resp.Results[0].WaitlistPosition)
+	assert.Equal(t, ptrTo(1), resp.Results[7].WaitlistPosition)
+	assert.Len(t, store.items, 3)
+	assert.Len(t, invitations, 2)
+
+	emailInvitations := service.emailInvitationRepo.(emailInvitationStore)
+	assert.Len(t, emailInvitations, 1)
+
+	assert.Len(t, publisher.messages, 3)
+	assert.Equal(t, "participant_invited", publisher.messages[0]["event"])
+	assert.Equal(t, "user3@example.com", publisher.messages[1]["data"].(map[string]any)["user_email"])
+	assert.Equal(t, "participant_email_invited", publisher.messages[2]["event"])
+	assert.Equal(t, map[string]any{"event_id": 1.0, "event_name": "Поход", "user_email": "new@example.com",
+		"claim_token": service.links.claimToken("new@example.com")}, publisher.messages[2]["data"])
+
+	store.events[1] = model.Event{EventId: 1, Status: model.EventStatusArchived}
+	_, err = service.ImportParticipants(ctx, 1, 1, domain.ParticipantImportRequest{Rows: []domain.ParticipantImportRow{{Username: "user4"}}})
+	assert.ErrorIs(t, err, model.ErrEventArchived)
+}
+
+// Тест 2: Зарегистрировавшийся пользователь с токеном из письма получает приглашения участника вместо приглашений по адресу
+func TestClaimEmailInvitations(t *testing.T) {
+	service, store, invitations, publisher := setupParticipantService()
+	ctx := context.Background()
+	store.events[2] = model.Event{EventId: 2, OrganizerID: 1, Title: "Сплав", Status: model.EventStatusArchived}
+
+	emailInvitations := service.emailInvitationRepo.(emailInvitationStore)
+	for _, eventId := range []int{1, 2} {
+		err := emailInvitations.Save(ctx, model.EventEmailInvitation{EventID: eventId, Email: email(5), InvitedBy: 1, CreatedAt: time.Now()})
+		assert.NoError(t, err)
+	}
+
+	// Токен другого адреса или без подписи не дает доступа к приглашениям
+	_, err := service.ClaimEmailInvitations(ctx, 5, service.links.claimToken(email(6)))
+	assert.NoError(t, err)
+	assert.Len(t, emailInvitations, 2)
+	forged, _, _ := strings.Cut(service.links.claimToken(email(5)), ".")
+	_, err = service.ClaimEmailInvitations(ctx, 5, forged+".signature")
+	assert.ErrorIs(t, err, model.ErrInvitationNotFound)
+
+	resp, err := service.ClaimEmailInvitations(ctx, 5, service.links.claimToken(email(5)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Total)
+	assert.Equal(t, 1, resp.Participants[0].EventID)
+	assert.Nil(t, resp.Participants[0].IsConfirmed)
+	assert.Empty(t, emailInvitations)
+	assert.Contains(t, invitations, resp.Participants[0].Id)
+
+	assert.Len(t, publisher.messages, 1)
+	assert.Equal(t, "participant_invited", publisher.messages[0]["event"])
+	assert.Equal(t, "user5@example.com", publisher.messages[0]["data"].(map[string]any)["user_email"])
+
+	resp, err = service.ClaimEmailInvitations(ctx, 5, service.links.claimToken(email(5)))
+	assert.NoError(t, err)
+	assert.Zero(t, resp.Total)
+}
+
+// Тест 3: Участники копии события приглашаются со своими ролями и ожидают ответа, сверх ограничения — в очереди
+func TestInviteMembers(t *testing.T) {
+	service, store, invitations, publisher := setupParticipantService()
+	ctx := context.Background()
+
+	err := service.InviteMembers(ctx, 1, []model.TemplateParticipant{
+		{UserID: 1, Role: model.RoleAdmin},
+		{UserID: 2, Role: model.RoleAdmin},
+		{UserID: 3, Role: model.RoleParticipant},
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, store.items, 3)
+	assert.Equal(t, model.RoleAdmin, store.items[1].Role)
+	assert.Nil(t, store.items[1].IsConfirmed)
+	assert.Nil(t, store.items[1].WaitlistedAt)
+	assert.NotNil(t, store.items[2].WaitlistedAt)
+	assert.Len(t, invitations, 2)
+	assert.Len(t, publisher.messages, 2)
+}
@@ -0,0 +1,160 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+)
+
+// ChangeRole назначает участника администратором или возвращает ему роль участника. Роль организатора
+// меняется только передачей события
+func (s Participant) ChangeRole(ctx context.Context, eventId, id int, req domain.EventParticipantUpdateRequest) (*domain.EventParticipantResponse, error) {
+	var participant model.EventParticipant
+	var event model.Event
+	changed := false
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		event, participant, err = s.getForRoleChange(ctx, eventId, id)
+		if err != nil {
+			return err
+		}
+		if participant.Role == model.RoleOrganizer {
+			return model.ErrOrganizerRoleChange
+		}
+
+		role := model.ParticipantRole(req.Role)
+		if participant.Role == role {
+			return nil
+		}
+
+		participant.Role = role
+		if err := s.repo.Update(ctx, participant); err != nil {
+			return errors.WithMessage(err, "update participant role")
+		}
+		changed = true
+
+		return nil
+	})
+	if err != nil {
+		if !isRoleChangeRejected(err) {
+			s.logger.Errorw("Failed to change participant role", "error", err, "eventId", eventId, "id", id)
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, participant.UserID)
+	if err != nil {
+		s.logger.Warnw("Failed to get participant user details", "error", err, "userID", participant.UserID)
+		user = &model.User{UserId: participant.UserID}
+	}
+
+	if changed {
+		s.notifyRoleChanged(ctx, event, participant.Role, user)
+	}
+
+	resp := toParticipantResponse(participant, user)
+	return &resp, nil
+}
+
+// TransferOwnership передает событие подтвердившему участие участнику: он становится организатором,
+// прежний организатор — администратором. Роли и организатор события меняются в одной транзакции
+func (s Participant) TransferOwnership(ctx context.Context, eventId int, req domain.OwnershipTransferRequest) error {
+	var event model.Event
+	var previous, next model.EventParticipant
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		event, next, err = s.getForRoleChange(ctx, eventId, req.ParticipantID)
+		if err != nil {
+			return err
+		}
+		if next.Role == model.RoleOrganizer || next.WaitlistedAt != nil || next.IsConfirmed == nil || !*next.IsConfirmed {
+			return model.ErrInvalidOwnershipTransfer
+		}
+
+		previous, err = s.repo.GetByEventAndUser(ctx, eventId, event.OrganizerID)
+		if err != nil {
+			return errors.WithMessage(err, "get organizer")
+		}
+
+		// Прежний организатор понижается первым: у события не может быть двух организаторов
+		previous.Role = model.RoleAdmin
+		if err := s.repo.Update(ctx, previous); err != nil {
+			return errors.WithMessage(err, "demote organizer")
+		}
+
+		next.Role = model.RoleOrganizer
+		if err := s.repo.Update(ctx, next); err != nil {
+			return errors.WithMessage(err, "promote organizer")
+		}
+
+		if _, err := s.eventRepo.UpdateOrganizer(ctx, eventId, next.UserID, time.Now()); err != nil {
+			return errors.WithMessage(err, "update event organizer")
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !isRoleChangeRejected(err) && !errors.Is(err, model.ErrInvalidOwnershipTransfer) {
+			s.logger.Errorw("Failed to transfer event ownership", "error", err, "eventId", eventId,
+				"participantId", req.ParticipantID)
+		}
+		return err
+	}
+
+	for _, participant := range []model.EventParticipant{next, previous} {
+		user, err := s.userRepo.GetUserById(ctx, participant.UserID)
+		if err != nil {
+			s.logger.Errorw("Failed to get user for role notification", "error", err, "userID", participant.UserID)
+			continue
+		}
+		s.notifyRoleChanged(ctx, event, participant.Role, user)
+	}
+
+	return nil
+}
+
+// getForRoleChange блокирует событие и возвращает его вместе с участником, роль которого меняется
+func (s Participant) getForRoleChange(ctx context.Context, eventId, id int) (model.Event, model.EventParticipant, error) {
+	event, err := s.eventRepo.GetByIdForUpdate(ctx, eventId)
+	if err != nil {
+		return model.Event{}, model.EventParticipant{}, errors.WithMessage(err, "get event")
+	}
+	if event.Status.ReadOnly() {
+		return model.Event{}, model.EventParticipant{}, model.ErrEventArchived
+	}
+
+	participant, err := s.repo.GetById(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && participant.EventID != eventId {
+		return model.Event{}, model.EventParticipant{}, model.ErrNotEventParticipant
+	}
+	if err != nil {
+		return model.Event{}, model.EventParticipant{}, errors.WithMessage(err, "get participant")
+	}
+
+	return event, participant, nil
+}
+
+// notifyRoleChanged сообщает участнику его новую роль. Роль уже сохранена, поэтому ошибка
+// уведомления только логируется
+func (s Participant) notifyRoleChanged(ctx context.Context, event model.Event, role model.ParticipantRole, user *model.User) {
+	err := s.publish(ctx, "participant_role_changed", map[string]any{
+		"event_id":   event.EventId,
+		"event_name": event.Title,
+		"role":       role,
+		"user_email": user.Email,
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to notify participant role change", "error", err, "eventId", event.EventId,
+			"userID", user.UserId)
+	}
+}
+
+// isRoleChangeRejected сообщает, что смена роли нарушает правила события, а не завершилась сбоем
+func isRoleChangeRejected(err error) bool {
+	return errors.Is(err, model.ErrNotEventParticipant) || errors.Is(err, model.ErrOrganizerRoleChange) ||
+		errors.Is(err, model.ErrEventArchived)
+}
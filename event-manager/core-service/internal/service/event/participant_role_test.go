@@ -0,0 +1,73 @@
+package event
+
+import (
+	"context"
+	"testing"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест 1: Смена роли уведомляет участника, роль организатора так не меняется
+func TestChangeRole(t *testing.T) {
+	service, store, _, publisher := setupParticipantService()
+	ctx := context.Background()
+	invite(t, service, publisher, 2)
+	publisher.messages = nil
+
+	resp, err := service.ChangeRole(ctx, 1, 2, domain.EventParticipantUpdateRequest{Role: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", resp.Role)
+	participant, _ := store.GetById(ctx, 2)
+	assert.Equal(t, model.RoleAdmin, participant.Role)
+	assert.Len(t, publisher.messages, 1)
+	assert.Equal(t, "participant_role_changed", publisher.messages[0]["event"])
+	assert.Equal(t, map[string]any{"event_id": 1.0, "event_name": "Поход", "role": "admin", "user_email": "user2@example.com"},
+		publisher.messages[0]["data"])
+
+	// Та же роль ничего не меняет и не отправляет повторное уведомление
+	_, err = service.ChangeRole(ctx, 1, 2, domain.EventParticipantUpdateRequest{Role: "admin"})
+	assert.NoError(t, err)
+	assert.Len(t, publisher.messages, 1)
+
+	_, err = service.ChangeRole(ctx, 1, 1, domain.EventParticipantUpdateRequest{Role: "participant"})
+	assert.ErrorIs(t, err, model.ErrOrganizerRoleChange)
+
+	_, err = service.ChangeRole(ctx, 5, 2, domain.EventParticipantUpdateRequest{Role: "participant"})
+	assert.ErrorIs(t, err, model.ErrNotEventParticipant)
+}
+
+// Тест 2: Событие передается только подтвердившему участие, организатор уходит только после передачи
+func TestTransferOwnership(t *testing.T) {
+	service, store, _, publisher := setupParticipantService()
+	ctx := context.Background()
+	invite(t, service, publisher, 2)
+	publisher.messages = nil
+
+	err := service.TransferOwnership(ctx, 1, domain.OwnershipTransferRequest{ParticipantID: 2})
+	assert.ErrorIs(t, err, model.ErrInvalidOwnershipTransfer)
+
+	_, err = service.Delete(ctx, 1, 1, 1, domain.ParticipantRemovalRequest{})
+	assert.ErrorIs(t, err, model.ErrOrganizerCannotLeave)
+
+	assert.NoError(t, service.ConfirmParticipation(ctx, 2))
+	assert.NoError(t, service.TransferOwnership(ctx, 1, domain.OwnershipTransferRequest{ParticipantID: 2}))
+
+	previous, _ := store.GetById(ctx, 1)
+	assert.Equal(t, model.RoleAdmin, previous.Role)
+	next, _ := store.GetById(ctx, 2)
+	assert.Equal(t, model.RoleOrganizer, next.Role)
+	assert.Equal(t, 2, store.events[1].OrganizerID)
+
+	assert.Len(t, publisher.messages, 2)
+	assert.Equal(t, "organizer", publisher.messages[0]["data"].(map[string]any)["role"])
+	assert.Equal(t, "user2@example.com", publisher.messages[0]["data"].(map[string]any)["user_email"])
+	assert.Equal(t, "admin", publisher.messages[1]["data"].(map[string]any)["role"])
+	assert.Equal(t, "user1@example.com", publisher.messages[1]["data"].(map[string]any)["user_email"])
+
+	// Новый организатор не может отказаться от участия, прежний может покинуть событие
+	assert.ErrorIs(t, service.DeclineParticipation(ctx, 2), model.ErrOrganizerCannotLeave)
+	_, err = service.Delete(ctx, 1, 1, 1, domain.ParticipantRemovalRequest{})
+	assert.NoError(t, err)
+}
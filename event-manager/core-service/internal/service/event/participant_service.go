@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
 	"github.com/pkg/errors"
+	"strings"
 	"time"
 
 	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
@@ -19,32 +20,88 @@ type ParticipantRepo interface {
 	Update(ctx context.Context, participant model.EventParticipant) error
 	Delete(ctx context.Context, id int) error
 	//DeleteByEventAndUser(ctx context.Context, eventId, userId int) error // no usages
-	ListByEvent(ctx context.Context, eventId, limit, offset int) ([]model.EventParticipant, error)
-	ListByUser(ctx context.Context, userId, limit, offset int) ([]model.EventParticipant, error)
+	Search(ctx context.Context, filter model.ParticipantFilter) ([]model.ParticipantDetails, int, error)
+	CountActive(ctx context.Context, eventId int) (int, error)
+	PromoteWaitlist(ctx context.Context, eventId int, now time.Time) ([]model.User, error)
+}
+
+// InvitationRepo приглашения участников, ожидающие ответа
+type InvitationRepo interface {
+	Save(ctx context.Context, invitation model.EventInvitation) error
+	GetByParticipant(ctx context.Context, participantId int) (model.EventInvitation, error)
+	Delete(ctx context.Context, participantId int) error
+	ListByEvent(ctx context.Context, eventId int) ([]model.PendingInvitation, error)
+}
+
+// ParticipantEventRepo блокирует событие на время изменения состава участников и передает его
+// новому организатору
+type ParticipantEventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+	GetByIdForUpdate(ctx context.Context, eventID int) (model.Event, error)
+	UpdateOrganizer(ctx context.Context, eventID, organizerID int, now time.Time) (int, error)
+}
+
+// TaskReleaser снимает удаляемого участника с незавершенных задач события
+type TaskReleaser interface {
+	ReleaseAssignee(ctx context.Context, eventId, userId int, reassignTo *int) (domain.RemovedTasksSummary, error)
+}
+
+// ShareReleaser оставляет за удаляемым участником его долги или делит их между остальными
+type ShareReleaser interface {
+	ReleaseShares(ctx context.Context, eventId, userId, changedBy int, redistribute bool) (domain.RemovedSharesSummary, error)
+}
+
+// RecurringReleaser исключает удаляемого участника из долей повторяющихся расходов события
+type RecurringReleaser interface {
+	ReleaseParticipant(ctx context.Context, eventId, userId int) error
 }
 
 type UserRepo interface {
 	GetUserById(ctx context.Context, id int) (*model.User, error)
 	ListUsers(ctx context.Context, limit, offset int) ([]model.User, int, error)
 	GetUserByUsername(ctx context.Context, username string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 }
 
 type Participant struct {
-	repo      ParticipantRepo
-	userRepo  UserRepo
-	notifyPbl NotifyPublisher
-	logger    *zap.SugaredLogger
+	repo                ParticipantRepo
+	userRepo            UserRepo
+	eventRepo           ParticipantEventRepo
+	invitationRepo      InvitationRepo
+	inviteCodeRepo      InviteCodeRepo
+	emailInvitationRepo EmailInvitationRepo
+	tasks               TaskReleaser
+	shares              ShareReleaser
+	recurring           RecurringReleaser
+	links               InvitationLinks
+	notifyPbl           NotifyPublisher
+	transactor          Transactor
+	logger              *zap.SugaredLogger
 }
 
-func NewParticipantService(repo ParticipantRepo, userRepo UserRepo, notifyPbl NotifyPublisher, logger *zap.SugaredLogger) Participant {
+func NewParticipantService(repo ParticipantRepo, userRepo UserRepo, eventRepo ParticipantEventRepo, invitationRepo InvitationRepo,
+	inviteCodeRepo InviteCodeRepo, emailInvitationRepo EmailInvitationRepo, tasks TaskReleaser, shares ShareReleaser,
+	recurring RecurringReleaser, links InvitationLinks, notifyPbl NotifyPublisher, transactor Transactor, logger *zap.SugaredLogger) Participant {
 	return Participant{
-		repo:      repo,
-		userRepo:  userRepo,
-		notifyPbl: notifyPbl,
-		logger:    logger,
+		repo:                repo,
+		userRepo:            userRepo,
+		eventRepo:           eventRepo,
+		invitationRepo:      invitationRepo,
+		inviteCodeRepo:      inviteCodeRepo,
+		emailInvitationRepo: emailInvitationRepo,
+		tasks:               tasks,
+		shares:              shares,
+		recurring:           recurring,
+		links:               links,
+		notifyPbl:           notifyPbl,
+		transactor:          transactor,
+		logger:              logger,
 	}
 }
 
+// Create приглашает пользователя в событие. Участие ожидает ответа на приглашение, но место
+// за приглашенным уже закреплено. Если мест нет, приглашенный попадает в лист ожидания.
+// В архивное событие участников не добавляют
 func (s Participant) Create(ctx context.Context, eventID int, req domain.EventParticipantCreateRequest) (*domain.EventParticipantResponse, error) {
 	var user *model.User
 	var err error
@@ -67,61 +124,87 @@ func (s Participant) Create(ctx context.Context, eventID int, req domain.EventPa
 		return nil, errors.New("either user_id or username must be provided")
 	}
 
-	_, err = s.repo.GetByEventAndUser(ctx, eventID, user.UserId)
+	var participant model.EventParticipant
+	var invitation model.EventInvitation
+
+	// Событие блокируется, чтобы одновременно добавленные участники не заняли больше мест, чем есть
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		event, err := s.eventRepo.GetByIdForUpdate(ctx, eventID)
+		if err != nil {
+			return errors.WithMessage(err, "get event")
+		}
+		if event.Status.ReadOnly() {
+			return model.ErrEventArchived
+		}
+
+		participant, invitation, err = s.addInvited(ctx, event, user.UserId, model.RoleParticipant, time.Now())
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, model.ErrUserAlreadyAnParticipant) && !errors.Is(err, model.ErrEventArchived) {
+			s.logger.Errorw("Failed to create participant", "error", err, "eventID", eventID, "userID", user.UserId)
+		}
+		return nil, err
+	}
+
+	// Ссылки приглашения действуют от имени пользователя, поэтому оно отправляется только на его адрес.
+	// Участник уже сохранен, поэтому ошибка уведомления только логируется
+	if err := s.sendInvitation(ctx, req.EventTitle, user.Email, participant, invitation); err != nil {
+		s.logger.Errorw("Failed to send invitation", "error", err, "eventID", eventID, "userID", user.UserId)
+	}
+
+	resp := toParticipantResponse(participant, user)
+	return &resp, nil
+}
+
+// addInvited добавляет пользователя в заблокированное событие с ролью role и приглашением, ожидающим ответа.
+// Место за приглашенным закрепляется сразу, а если мест нет, он попадает в лист ожидания
+func (s Participant) addInvited(ctx context.Context, event model.Event, userId int, role model.ParticipantRole, now time.Time) (model.EventParticipant, model.EventInvitation, error) {
+	_, err := s.repo.GetByEventAndUser(ctx, event.EventId, userId)
 	if err == nil {
-		return nil, model.ErrUserAlreadyAnParticipant
+		return model.EventParticipant{}, model.EventInvitation{}, model.ErrUserAlreadyAnParticipant
 	}
 	if !errors.Is(err, sql.ErrNoRows) {
-		s.logger.Errorw("Failed to check if participant exists", "error", err, "eventID", eventID, "userID", user.UserId)
-		return nil, errors.WithMessage(err, "check participant")
+		return model.EventParticipant{}, model.EventInvitation{}, errors.WithMessage(err, "check participant")
 	}
 
-	now := time.Now()
 	participant := model.EventParticipant{
-		EventID:     eventID,
-		UserID:      user.UserId,
-		Role:        model.RoleParticipant,
-		JoinedAt:    &now,
-		IsConfirmed: ptr(true), // Default to confirmed // todo
+		EventID:  event.EventId,
+		UserID:   userId,
+		Role:     role,
+		JoinedAt: &now,
 	}
 
-	id, err := s.repo.Create(ctx, participant)
+	free, err := s.hasFreeSlot(ctx, event)
 	if err != nil {
-		s.logger.Errorw("Failed to create participant", "error", err, "eventID", eventID, "userID", user.UserId)
-		return nil, errors.WithMessage(err, "create participant")
+		return model.EventParticipant{}, model.EventInvitation{}, err
 	}
-
-	emailToNotify := user.Email
-	if req.Email != "" {
-		emailToNotify = req.Email
+	if !free {
+		participant.WaitlistedAt = &now
 	}
 
-	data := map[string]any{
-		"event": "participant_added",
-		"data": map[string]any{
-			"event_name": req.EventTitle,
-			"user_email": emailToNotify,
-		},
+	participant.EventParticipantID, err = s.repo.Create(ctx, participant)
+	if err != nil {
+		return model.EventParticipant{}, model.EventInvitation{}, errors.WithMessage(err, "create participant")
 	}
 
-	bytes, err := json.Marshal(data)
+	invitation, err := s.links.newInvitation(participant.EventParticipantID, now)
 	if err != nil {
-		return nil, errors.WithMessage(err, "marshal participant notify")
+		return model.EventParticipant{}, model.EventInvitation{}, err
+	}
+	if err := s.invitationRepo.Save(ctx, invitation); err != nil {
+		return model.EventParticipant{}, model.EventInvitation{}, errors.WithMessage(err, "save invitation")
 	}
 
-	err = s.notifyPbl.Publish(ctx, bytes)
-	if err != nil {
-		return nil, errors.WithMessage(err, "publish participant notify")
+	if participant.WaitlistedAt != nil {
+		created, err := s.repo.GetById(ctx, participant.EventParticipantID)
+		if err != nil {
+			return model.EventParticipant{}, model.EventInvitation{}, errors.WithMessage(err, "get waitlist position")
+		}
+		participant.WaitlistPosition = created.WaitlistPosition
 	}
 
-	return &domain.EventParticipantResponse{
-		Id:          id,
-		EventID:     eventID,
-		User:        userToResponse(user),
-		Role:        string(participant.Role),
-		JoinedAt:    participant.JoinedAt,
-		IsConfirmed: participant.IsConfirmed,
-	}, nil
+	return participant, invitation, nil
 }
 
 func (s Participant) GetById(ctx context.Context, id int) (*domain.EventParticipantResponse, error) {
@@ -137,129 +220,374 @@ func (s Participant) GetById(ctx context.Context, id int) (*domain.EventParticip
 		user = &model.User{UserId: participant.UserID}
 	}
 
-	return &domain.EventParticipantResponse{
-		Id:          participant.EventParticipantID,
-		EventID:     participant.EventID,
-		User:        userToResponse(user),
-		Role:        string(participant.Role),
-		JoinedAt:    participant.JoinedAt,
-		IsConfirmed: participant.IsConfirmed,
-	}, nil
+	resp := toParticipantResponse(participant, user)
+	return &resp, nil
 }
 
-func (s Participant) Delete(ctx context.Context, id int) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
-		s.logger.Errorw("Failed to delete participant", "error", err, "id", id)
-		return errors.WithMessage(err, "failed to delete participant")
+// Delete удаляет участника из события от имени userId, а освободившееся место занимает первый в листе ожидания.
+// Незавершенные задачи участника переходят к другому участнику или остаются без исполнителя, а его неоплаченные
+// доли в чужих расходах остаются за ним или делятся между остальными участниками расходов — по выбору в req.
+// Все изменения выполняются в одной транзакции. Организатор не может покинуть событие, пока не передаст
+// его другому участнику
+func (s Participant) Delete(ctx context.Context, eventId, id, userId int, req domain.ParticipantRemovalRequest) (*domain.ParticipantRemovalResponse, error) {
+	var event model.Event
+	var promoted []model.User
+	var resp domain.ParticipantRemovalResponse
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		participant, err := s.repo.GetById(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && participant.EventID != eventId {
+			return model.ErrNotEventParticipant
+		}
+		if err != nil {
+			return errors.WithMessage(err, "get participant")
+		}
+
+		reassignTo, err := s.removalAssignee(ctx, participant, req)
+		if err != nil {
+			return err
+		}
+
+		event, promoted, err = s.remove(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		resp = domain.ParticipantRemovalResponse{Id: id, EventID: eventId, UserID: participant.UserID}
+		resp.Tasks, err = s.tasks.ReleaseAssignee(ctx, eventId, participant.UserID, reassignTo)
+		if err != nil {
+			return errors.WithMessage(err, "release tasks")
+		}
+
+		resp.Shares, err = s.shares.ReleaseShares(ctx, eventId, participant.UserID, userId,
+			req.Shares == domain.RemovalSharesRedistribute)
+		if err != nil {
+			return errors.WithMessage(err, "release expense shares")
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !isRemovalRejected(err) {
+			s.logger.Errorw("Failed to delete participant", "error", err, "id", id)
+		}
+		return nil, errors.WithMessage(err, "failed to delete participant")
 	}
 
-	return nil
+	s.notifyPromoted(ctx, event, promoted)
+
+	return &resp, nil
 }
 
-func (s Participant) ListByEvent(ctx context.Context, eventID int, page, size int) (*domain.EventParticipantsResponse, error) {
-	if page < 1 {
-		page = 1
+// removalAssignee проверяет политику задач и возвращает пользователя, к которому они переходят.
+// Задачи передаются только другому участнику, занявшему место и не отказавшемуся от участия
+func (s Participant) removalAssignee(ctx context.Context, participant model.EventParticipant, req domain.ParticipantRemovalRequest) (*int, error) {
+	if req.Tasks == domain.RemovalTasksUnassign && req.ReassignTo != nil {
+		return nil, errors.WithMessage(model.ErrInvalidRemovalPolicy, "reassign_to requires tasks=reassign")
+	}
+	if req.Tasks != domain.RemovalTasksReassign && req.ReassignTo == nil {
+		return nil, nil
 	}
-	if size < 1 {
-		size = 10
+	if req.ReassignTo == nil {
+		return nil, errors.WithMessage(model.ErrInvalidRemovalPolicy, "tasks=reassign requires reassign_to")
 	}
-	offset := (page - 1) * size
 
-	participants, err := s.repo.ListByEvent(ctx, eventID, size, offset)
+	assignee, err := s.repo.GetByEventAndUser(ctx, participant.EventID, *req.ReassignTo)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && (assignee.EventParticipantID == participant.EventParticipantID ||
+		assignee.WaitlistedAt != nil || assignee.IsConfirmed != nil && !*assignee.IsConfirmed) {
+		return nil, errors.WithMessagef(model.ErrInvalidRemovalPolicy, "user %d cannot take over tasks", *req.ReassignTo)
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "get assignee")
+	}
+
+	return req.ReassignTo, nil
+}
+
+// isRemovalRejected сообщает, что участника нельзя удалить по правилам события, а не из-за сбоя
+func isRemovalRejected(err error) bool {
+	return errors.Is(err, model.ErrOrganizerCannotLeave) || errors.Is(err, model.ErrNotEventParticipant) ||
+		errors.Is(err, model.ErrInvalidRemovalPolicy)
+}
+
+// remove удаляет участника в текущей транзакции и возвращает событие и пользователей, перешедших
+// из листа ожидания на освободившееся место
+func (s Participant) remove(ctx context.Context, id int) (model.Event, []model.User, error) {
+	participant, err := s.repo.GetById(ctx, id)
 	if err != nil {
-		s.logger.Errorw("Failed to list event participants", "error", err, "eventID", eventID, "page", page, "size", size)
+		return model.Event{}, nil, errors.WithMessage(err, "get participant")
+	}
+	if participant.Role == model.RoleOrganizer {
+		return model.Event{}, nil, model.ErrOrganizerCannotLeave
+	}
+
+	event, err := s.eventRepo.GetByIdForUpdate(ctx, participant.EventID)
+	if err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "get event")
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "delete participant")
+	}
+
+	if err := s.recurring.ReleaseParticipant(ctx, event.EventId, participant.UserID); err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "release recurring expenses")
+	}
+
+	promoted, err := s.repo.PromoteWaitlist(ctx, event.EventId, time.Now())
+	if err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "promote waitlist")
+	}
+
+	return event, promoted, nil
+}
+
+// ListByEvent возвращает участников события с данными пользователей
+func (s Participant) ListByEvent(ctx context.Context, eventId int, req domain.ParticipantListRequest) (*domain.EventParticipantsResponse, error) {
+	resp, err := s.search(ctx, model.ParticipantFilter{EventID: &eventId}, req)
+	if err != nil {
+		s.logger.Errorw("Failed to list event participants", "error", err, "eventID", eventId)
 		return nil, errors.WithMessage(err, "list participants")
 	}
 
-	participantResponses := make([]domain.EventParticipantResponse, len(participants))
-	for i, participant := range participants {
-		user, err := s.userRepo.GetUserById(ctx, participant.UserID)
-		if err != nil {
-			s.logger.Warnw("Failed to get participant user details", "error", err, "userID", participant.UserID)
-			user = &model.User{UserId: participant.UserID}
-		}
-		participantResponses[i] = domain.EventParticipantResponse{
-			Id:          participant.EventParticipantID,
-			EventID:     participant.EventID,
-			User:        userToResponse(user),
-			Role:        string(participant.Role),
-			JoinedAt:    participant.JoinedAt,
-			IsConfirmed: participant.IsConfirmed,
-		}
+	return resp, nil
+}
+
+// ListByUser возвращает участия пользователя в событиях вместе с названиями событий
+func (s Participant) ListByUser(ctx context.Context, userId int, req domain.ParticipantListRequest) (*domain.EventParticipantsResponse, error) {
+	resp, err := s.search(ctx, model.ParticipantFilter{UserID: &userId}, req)
+	if err != nil {
+		s.logger.Errorw("Failed to list user participations", "error", err, "userId", userId)
+		return nil, errors.WithMessage(err, "list participations")
 	}
 
-	return &domain.EventParticipantsResponse{
-		Participants: participantResponses,
-		Total:        len(participants),
-	}, nil
+	return resp, nil
 }
 
-func (s Participant) ListByUser(ctx context.Context, userId int, page, size int) (*domain.EventParticipantsResponse, error) {
-	if page < 1 {
-		page = 1
+// search дополняет фильтр параметрами запроса и возвращает страницу участников
+func (s Participant) search(ctx context.Context, filter model.ParticipantFilter, req domain.ParticipantListRequest) (*domain.EventParticipantsResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Size < 1 {
+		req.Size = 10
 	}
-	if size < 1 {
-		size = 10
+	filter.Limit = req.Size
+	filter.Offset = (req.Page - 1) * req.Size
+	filter.Status = req.Status
+	filter.Query = strings.TrimSpace(req.Query)
+	for _, role := range req.Role {
+		filter.Roles = append(filter.Roles, model.ParticipantRole(role))
 	}
-	offset := (page - 1) * size
 
-	participants, err := s.repo.ListByUser(ctx, userId, size, offset)
+	participants, total, err := s.repo.Search(ctx, filter)
 	if err != nil {
-		s.logger.Errorw("Failed to list user participations", "error", err, "userId", userId, "page", page, "size", size)
-		return nil, errors.WithMessage(err, "list participations")
+		return nil, err
 	}
 
 	participantResponses := make([]domain.EventParticipantResponse, len(participants))
 	for i, participant := range participants {
-		participantResponses[i] = domain.EventParticipantResponse{
-			Id:          participant.EventParticipantID,
-			EventID:     participant.EventID,
-			Role:        string(participant.Role),
-			JoinedAt:    participant.JoinedAt,
-			IsConfirmed: participant.IsConfirmed,
-		}
+		participantResponses[i] = toParticipantResponse(participant.EventParticipant, &participant.User)
+		participantResponses[i].EventTitle = participant.EventTitle
 	}
 
 	return &domain.EventParticipantsResponse{
 		Participants: participantResponses,
-		Total:        len(participants),
+		Total:        total,
 	}, nil
 }
 
+// ConfirmParticipation подтверждает участие и закрывает приглашение. Отказавшийся ранее участник,
+// которому не хватило места, возвращается в конец листа ожидания
 func (s Participant) ConfirmParticipation(ctx context.Context, id int) error {
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := s.confirm(ctx, id)
+		return err
+	})
+	if err != nil {
+		s.logger.Errorw("Failed to confirm participation", "error", err, "id", id)
+		return err
+	}
+
+	return nil
+}
+
+// DeclineParticipation отмечает отказ от участия и закрывает приглашение. Участник покидает лист
+// ожидания и снимается с незавершенных задач, а освободившееся место занимает первый в очереди
+func (s Participant) DeclineParticipation(ctx context.Context, id int) error {
+	var event model.Event
+	var promoted []model.User
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		event, promoted, err = s.decline(ctx, id)
+		return err
+	})
+	if err != nil {
+		if !errors.Is(err, model.ErrOrganizerCannotLeave) {
+			s.logger.Errorw("Failed to decline participation", "error", err, "id", id)
+		}
+		return err
+	}
+
+	s.notifyPromoted(ctx, event, promoted)
+
+	return nil
+}
+
+// confirm подтверждает участие в текущей транзакции и возвращает заблокированное событие
+func (s Participant) confirm(ctx context.Context, id int) (model.Event, error) {
 	participant, err := s.repo.GetById(ctx, id)
 	if err != nil {
-		s.logger.Errorw("Failed to get participant for confirmation", "error", err, "id", id)
-		return errors.WithMessage(err, "get participant")
+		return model.Event{}, errors.WithMessage(err, "get participant")
+	}
+
+	event, err := s.eventRepo.GetByIdForUpdate(ctx, participant.EventID)
+	if err != nil {
+		return model.Event{}, errors.WithMessage(err, "get event")
+	}
+
+	if participant.IsConfirmed != nil && !*participant.IsConfirmed {
+		free, err := s.hasFreeSlot(ctx, event)
+		if err != nil {
+			return model.Event{}, err
+		}
+		if !free {
+			now := time.Now()
+			participant.WaitlistedAt = &now
+		}
 	}
 
 	participant.IsConfirmed = ptr(true)
 
 	if err := s.repo.Update(ctx, participant); err != nil {
-		s.logger.Errorw("Failed to confirm participation", "error", err, "id", id)
-		return errors.WithMessage(err, "confirm participation")
+		return model.Event{}, errors.WithMessage(err, "confirm participation")
 	}
 
-	return nil
+	if err := s.invitationRepo.Delete(ctx, id); err != nil {
+		return model.Event{}, errors.WithMessage(err, "close invitation")
+	}
+
+	return event, nil
 }
 
-func (s Participant) DeclineParticipation(ctx context.Context, id int) error {
+// decline отмечает отказ в текущей транзакции, оставляет незавершенные задачи участника без исполнителя,
+// исключает его из повторяющихся расходов
+// и возвращает событие и пользователей, перешедших из листа ожидания на освободившееся место
+func (s Participant) decline(ctx context.Context, id int) (model.Event, []model.User, error) {
 	participant, err := s.repo.GetById(ctx, id)
 	if err != nil {
-		s.logger.Errorw("Failed to get participant for declining", "error", err, "id", id)
-		return errors.WithMessage(err, "failed to get participant")
+		return model.Event{}, nil, errors.WithMessage(err, "failed to get participant")
+	}
+	if participant.Role == model.RoleOrganizer {
+		return model.Event{}, nil, model.ErrOrganizerCannotLeave
+	}
+
+	event, err := s.eventRepo.GetByIdForUpdate(ctx, participant.EventID)
+	if err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "get event")
 	}
 
 	participant.IsConfirmed = ptr(false)
+	participant.WaitlistedAt = nil
 
 	if err := s.repo.Update(ctx, participant); err != nil {
-		s.logger.Errorw("Failed to decline participation", "error", err, "id", id)
-		return errors.WithMessage(err, "failed to decline participation")
+		return model.Event{}, nil, errors.WithMessage(err, "failed to decline participation")
+	}
+
+	if err := s.invitationRepo.Delete(ctx, id); err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "close invitation")
+	}
+
+	if _, err := s.tasks.ReleaseAssignee(ctx, event.EventId, participant.UserID, nil); err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "release tasks")
+	}
+
+	if err := s.recurring.ReleaseParticipant(ctx, event.EventId, participant.UserID); err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "release recurring expenses")
+	}
+
+	promoted, err := s.repo.PromoteWaitlist(ctx, event.EventId, time.Now())
+	if err != nil {
+		return model.Event{}, nil, errors.WithMessage(err, "promote waitlist")
+	}
+
+	return event, promoted, nil
+}
+
+// hasFreeSlot сообщает, есть ли свободное место в событии. Событие должно быть заблокировано
+// в текущей транзакции
+func (s Participant) hasFreeSlot(ctx context.Context, event model.Event) (bool, error) {
+	if event.MaxParticipants == nil {
+		return true, nil
+	}
+
+	count, err := s.repo.CountActive(ctx, event.EventId)
+	if err != nil {
+		return false, errors.WithMessage(err, "count participants")
+	}
+
+	return count < *event.MaxParticipants, nil
+}
+
+// notifyPromoted сообщает участникам, перешедшим из листа ожидания на освободившиеся места.
+// Состав участников уже сохранен, поэтому ошибка уведомления только логируется
+func (s Participant) notifyPromoted(ctx context.Context, event model.Event, users []model.User) {
+	if err := publishPromoted(ctx, s.notifyPbl, event, users); err != nil {
+		s.logger.Errorw("Failed to notify promoted participants", "error", err, "eventId", event.EventId)
+	}
+}
+
+func (s Participant) publish(ctx context.Context, event string, payload map[string]any) error {
+	bytes, err := json.Marshal(map[string]any{
+		"event": event,
+		"data":  payload,
+	})
+	if err != nil {
+		return errors.WithMessage(err, "marshal participant notify")
+	}
+
+	if err := s.notifyPbl.Publish(ctx, bytes); err != nil {
+		return errors.WithMessage(err, "publish participant notify")
+	}
+
+	return nil
+}
+
+// publishPromoted отправляет каждому пользователю, перешедшему из листа ожидания, уведомление о том,
+// что он стал участником события
+func publishPromoted(ctx context.Context, pbl NotifyPublisher, event model.Event, users []model.User) error {
+	for _, user := range users {
+		bytes, err := json.Marshal(map[string]any{
+			"event": "participant_promoted",
+			"data": map[string]any{
+				"event_id":   event.EventId,
+				"event_name": event.Title,
+				"user_email": user.Email,
+			},
+		})
+		if err != nil {
+			return errors.WithMessage(err, "marshal participant promoted notify")
+		}
+
+		if err := pbl.Publish(ctx, bytes); err != nil {
+			return errors.WithMessage(err, "publish participant promoted notify")
+		}
 	}
 
 	return nil
 }
 
+func toParticipantResponse(participant model.EventParticipant, user *model.User) domain.EventParticipantResponse {
+	return domain.EventParticipantResponse{
+		Id:               participant.EventParticipantID,
+		EventID:          participant.EventID,
+		User:             userToResponse(user),
+		Role:             string(participant.Role),
+		JoinedAt:         participant.JoinedAt,
+		IsConfirmed:      participant.IsConfirmed,
+		WaitlistPosition: participant.WaitlistPosition,
+	}
+}
+
 func userToResponse(user *model.User) domain.UserResponse {
 	return domain.UserResponse{
 		Id:        user.UserId,
@@ -0,0 +1,511 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// Участники и события в памяти
+type participantStore struct {
+	items  []model.EventParticipant
+	events map[int]model.Event
+}
+
+func (s *participantStore) Create(ctx context.Context, participant model.EventParticipant) (int, error) {
+	participant.EventParticipantID = len(s.items) + 1
+	s.items = append(s.items, participant)
+	return participant.EventParticipantID, nil
+}
+
+func (s *participantStore) GetById(ctx context.Context, id int) (model.EventParticipant, error) {
+	for _, item := range s.items {
+		if item.EventParticipantID == id {
+			item.WaitlistPosition = s.position(item)
+			return item, nil
+		}
+	}
+	return model.EventParticipant{}, sql.ErrNoRows
+}
+
+func (s *participantStore) GetByEventAndUser(ctx context.Context, eventId, userId int) (model.EventParticipant, error) {
+	for _, item := range s.items {
+		if item.EventID == eventId && item.UserID == userId {
+			return item, nil
+		}
+	}
+	return model.EventParticipant{}, sql.ErrNoRows
+}
+
+func (s *participantStore) Update(ctx context.Context, participant model.EventParticipant) error {
+	for i, item := range s.items {
+		if item.EventParticipantID == participant.EventParticipantID {
+			s.items[i] = participant
+		}
+	}
+	return nil
+}
+
+func (s *participantStore) Delete(ctx context.Context, id int) error {
+	kept := s.items[:0]
+	for _, item := range s.items {
+		if item.EventParticipantID != id {
+			kept = append(kept, item)
+		}
+	}
+	s.items = kept
+	return nil
+}
+
+func (s *participantStore) Search(ctx context.Context, filter model.ParticipantFilter) ([]model.ParticipantDetails, int, error) {
+	found := make([]model.ParticipantDetails, 0)
+	for _, item := range s.items {
+		username := fmt.Sprintf("user%d", item.UserID)
+		switch {
+		case filter.EventID != nil && item.EventID != *filter.EventID,
+			filter.UserID != nil && item.UserID != *filter.UserID,
+			len(filter.Roles) > 0 && !slices.Contains(filter.Roles, item.Role),
+			filter.Status == model.ParticipationWaitlisted && item.WaitlistedAt == nil,
+			filter.Status == model.ParticipationPending && (item.WaitlistedAt != nil || item.IsConfirmed != nil),
+			!strings.Contains(username, filter.Query):
+			continue
+		}
+		item.WaitlistPosition = s.position(item)
+		found = append(found, model.ParticipantDetails{
+			EventParticipant: item,
+			User:             model.User{UserId: item.UserID, Username: username, Email: email(item.UserID)},
+			EventTitle:       s.events[item.EventID].Title,
+		})
+	}
+
+	total := len(found)
+	found = found[min(filter.Offset, total):min(filter.Offset+filter.Limit, total)]
+	return found, total, nil
+}
+
+func (s *participantStore) CountActive(ctx context.Context, eventId int) (int, error) {
+	count := 0
+	for _, item := range s.items {
+		if item.EventID == eventId && item.WaitlistedAt == nil && (item.IsConfirmed == nil || *item.IsConfirmed) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *participantStore) PromoteWaitlist(ctx context.Context, eventId int, now time.Time) ([]model.User, error) {
+	active, _ := s.CountActive(ctx, eventId)
+	waiting := s.waitlist(eventId)
+
+	promoted := make([]model.User, 0)
+	for _, i := range waiting {
+		if limit := s.events[eventId].MaxParticipants; limit != nil && active >= *limit {
+			break
+		}
+		s.items[i].WaitlistedAt = nil
+		s.items[i].JoinedAt = &now
+		promoted = append(promoted, model.User{UserId: s.items[i].UserID, Email: email(s.items[i].UserID)})
+		active++
+	}
+	return promoted, nil
+}
+
+// eventStore события хранилища участников для чтения без блокировки
+type eventStore struct {
+	*participantStore
+}
+
+func (s eventStore) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	return s.events[eventID], nil
+}
+
+func (s *participantStore) GetByIdForUpdate(ctx context.Context, eventID int) (model.Event, error) {
+	return s.events[eventID], nil
+}
+
+func (s *participantStore) UpdateOrganizer(ctx context.Context, eventID, organizerID int, now time.Time) (int, error) {
+	event := s.events[eventID]
+	event.OrganizerID = organizerID
+	event.Version++
+	s.events[eventID] = event
+	return event.Version, nil
+}
+
+// waitlist индексы ожидающих участников события в порядке очереди
+func (s *participantStore) waitlist(eventId int) []int {
+	indexes := make([]int, 0)
+	for i, item := range s.items {
+		if item.EventID == eventId && item.WaitlistedAt != nil {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return s.items[indexes[a]].WaitlistedAt.Before(*s.items[indexes[b]].WaitlistedAt)
+	})
+	return indexes
+}
+
+func (s *participantStore) position(participant model.EventParticipant) *int {
+	for position, i := range s.waitlist(participant.EventID) {
+		if s.items[i].EventParticipantID == participant.EventParticipantID {
+			return ptrTo(position + 1)
+		}
+	}
+	return nil
+}
+
+// Приглашения в памяти
+type invitationStore map[int]model.EventInvitation
+
+func (s invitationStore) Save(ctx context.Context, invitation model.EventInvitation) error {
+	s[invitation.EventParticipantID] = invitation
+	return nil
+}
+
+func (s invitationStore) GetByParticipant(ctx context.Context, participantId int) (model.EventInvitation, error) {
+	invitation, ok := s[participantId]
+	if !ok {
+		return model.EventInvitation{}, model.ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+func (s invitationStore) Delete(ctx context.Context, participantId int) error {
+	delete(s, participantId)
+	return nil
+}
+
+func (s invitationStore) ListByEvent(ctx context.Context, eventId int) ([]model.PendingInvitation, error) {
+	return nil, nil
+}
+
+// Коды приглашения в памяти
+type inviteCodeStore map[int]model.EventInviteCode
+
+func (s inviteCodeStore) Create(ctx context.Context, code model.EventInviteCode) (int, error) {
+	code.InviteCodeID = len(s) + 1
+	s[code.InviteCodeID] = code
+	return code.InviteCodeID, nil
+}
+
+func (s inviteCodeStore) GetById(ctx context.Context, id int) (model.EventInviteCode, error) {
+	code, ok := s[id]
+	if !ok {
+		return model.EventInviteCode{}, model.ErrInviteCodeNotFound
+	}
+	return code, nil
+}
+
+func (s inviteCodeStore) GetByCodeForUpdate(ctx context.Context, value string) (model.EventInviteCode, error) {
+	for _, code := range s {
+		if code.Code == value {
+			return code, nil
+		}
+	}
+	return model.EventInviteCode{}, model.ErrInviteCodeNotFound
+}
+
+func (s inviteCodeStore) RecordUse(ctx context.Context, id int, now time.Time) error {
+	code := s[id]
+	code.UsesCount++
+	code.LastUsedAt = &now
+	s[id] = code
+	return nil
+}
+
+func (s inviteCodeStore) Revoke(ctx context.Context, id int, now time.Time) error {
+	code := s[id]
+	if code.RevokedAt != nil {
+		return model.ErrInviteCodeNotFound
+	}
+	code.RevokedAt = &now
+	s[id] = code
+	return nil
+}
+
+func (s inviteCodeStore) ListByEvent(ctx context.Context, eventId int) ([]model.EventInviteCode, error) {
+	codes := make([]model.EventInviteCode, 0)
+	for id := len(s); id > 0; id-- {
+		if s[id].EventID == eventId {
+			codes = append(codes, s[id])
+		}
+	}
+	return codes, nil
+}
+
+// Приглашения по адресу в памяти
+type emailInvitationStore map[string]model.EventEmailInvitation
+
+func (s emailInvitationStore) Save(ctx context.Context, invitation model.EventEmailInvitation) error {
+	key := fmt.Sprintf("%d/%s", invitation.EventID, invitation.Email)
+	invitation.EmailInvitationID = len(s) + 1
+	if saved, ok := s[key]; ok {
+		invitation.EmailInvitationID = saved.EmailInvitationID
+	}
+	s[key] = invitation
+	return nil
+}
+
+func (s emailInvitationStore) ListByEmail(ctx context.Context, address string) ([]model.EventEmailInvitation, error) {
+	invitations := make([]model.EventEmailInvitation, 0)
+	for _, invitation := range s {
+		if invitation.Email == address {
+			invitations = append(invitations, invitation)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].EmailInvitationID < invitations[j].EmailInvitationID
+	})
+	return invitations, nil
+}
+
+func (s emailInvitationStore) Delete(ctx context.Context, id int) error {
+	for key, invitation := range s {
+		if invitation.EmailInvitationID == id {
+			delete(s, key)
+		}
+	}
+	return nil
+}
+
+// Запоминает, как были сняты задачи, доли и повторяющиеся расходы удаленных участников
+type releaser struct {
+	reassignTo   map[int]*int
+	redistribute map[int]bool
+	recurring    []int
+}
+
+func (r *releaser) ReleaseAssignee(ctx context.Context, eventId, userId int, reassignTo *int) (domain.RemovedTasksSummary, error) {
+	r.reassignTo[userId] = reassignTo
+	return domain.RemovedTasksSummary{ReassignedTo: reassignTo}, nil
+}
+
+func (r *releaser) ReleaseShares(ctx context.Context, eventId, userId, changedBy int, redistribute bool) (domain.RemovedSharesSummary, error) {
+	r.redistribute[userId] = redistribute
+	return domain.RemovedSharesSummary{Currency: "RUB"}, nil
+}
+
+func (r *releaser) ReleaseParticipant(ctx context.Context, eventId, userId int) error {
+	r.recurring = append(r.recurring, userId)
+	return nil
+}
+
+// Пользователи с именами вида user<id> и адресами вида user<id>@example.com
+type userStore struct{}
+
+func (userStore) GetUserById(ctx context.Context, id int) (*model.User, error) {
+	return &model.User{UserId: id, Email: email(id)}, nil
+}
+
+func (userStore) ListUsers(ctx context.Context, limit, offset int) ([]model.User, int, error) {
+	return nil, 0, nil
+}
+
+func (userStore) GetUserByUsername(ctx context.Context, username string) (*model.User, error) {
+	var id int
+	if _, err := fmt.Sscanf(username, "user%d", &id); err != nil {
+		return nil, model.ErrUserNotFound
+	}
+	return &model.User{UserId: id, Username: username, Email: email(id)}, nil
+}
+
+func (userStore) GetUserByEmail(ctx context.Context, address string) (*model.User, error) {
+	var id int
+	if _, err := fmt.Sscanf(address, "user%d@example.com", &id); err != nil {
+		return nil, model.ErrUserNotFound
+	}
+	return &model.User{UserId: id, Email: email(id)}, nil
+}
+
+func email(userId int) string {
+	return fmt.Sprintf("user%d@example.com", userId)
+}
+
+// setupParticipantService событие 1 на двоих с организатором 1
+func setupParticipantService() (*Participant, *participantStore, invitationStore, *recordingPublisher) {
+	store := &participantStore{
+		items: []model.EventParticipant{{EventParticipantID: 1, EventID: 1, UserID: 1, Role: model.RoleOrganizer, IsConfirmed: ptrTo(true)}},
+		events: map[int]model.Event{
+			1: {EventId: 1, OrganizerID: 1, Title: "Поход", MaxParticipants: ptrTo(2)},
+		},
+	}
+	invitations := invitationStore{}
+	publisher := &recordingPublisher{}
+	logger, _ := zap.NewDevelopment()
+	links := NewInvitationLinks("secret", "https://example.com/api/v1/invitations", 24*time.Hour)
+
+	released := &releaser{reassignTo: map[int]*int{}, redistribute: map[int]bool{}}
+
+	service := NewParticipantService(store, userStore{}, eventStore{store}, invitations, inviteCodeStore{}, emailInvitationStore{}, released, released, released, links, publisher,
+		noTx{}, logger.Sugar())
+
+	return &service, store, invitations, publisher
+}
+
+// Тест 1: Сверх ограничения участники попадают в лист ожидания и узнают свое место в очереди
+func TestParticipantCreate_Waitlist(t *testing.T) {
+	service, _, _, publisher := setupParticipantService()
+	ctx := context.Background()
+
+	first, err := service.Create(ctx, 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", UserID: 2})
+	assert.NoError(t, err)
+	assert.Nil(t, first.WaitlistPosition)
+
+	second, err := service.Create(ctx, 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", UserID: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, ptrTo(1), second.WaitlistPosition)
+
+	third, err := service.Create(ctx, 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", UserID: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, ptrTo(2), third.WaitlistPosition)
+
+	_, err = service.Create(ctx, 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", UserID: 4})
+	assert.ErrorIs(t, err, model.ErrUserAlreadyAnParticipant)
+
+	assert.Len(t, publisher.messages, 3)
+	assert.Equal(t, "participant_invited", publisher.messages[2]["event"])
+	assert.NotContains(t, publisher.messages[0]["data"], "waitlist_position")
+	data := publisher.messages[2]["data"].(map[string]any)
+	assert.Equal(t, "user4@example.com", data["user_email"])
+	assert.Equal(t, 2.0, data["waitlist_position"])
+	assert.Regexp(t, `^https://example\.com/api/v1/invitations/4\.[0-9a-f]{32}\.[\w-]+/accept$`, data["accept_url"])
+}
+
+// Тест 2: Отказ и удаление участника освобождают место для первого в очереди, он получает уведомление.
+// Отказавшийся снимается со своих задач
+func TestParticipantDeclineAndDelete_PromoteWaitlist(t *testing.T) {
+	service, store, _, publisher := setupParticipantService()
+	ctx := context.Background()
+	for _, userId := range []int{2, 3, 4} {
+		_, err := service.Create(ctx, 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", UserID: userId})
+		assert.NoError(t, err)
+	}
+	publisher.messages = nil
+
+	assert.NoError(t, service.DeclineParticipation(ctx, 2))
+	released := service.tasks.(*releaser)
+	assert.Contains(t, released.reassignTo, 2)
+	assert.Nil(t, released.reassignTo[2])
+
+	promoted, _ := store.GetById(ctx, 3)
+	assert.Nil(t, promoted.WaitlistedAt)
+	waiting, _ := store.GetById(ctx, 4)
+	assert.Equal(t, ptrTo(1), waiting.WaitlistPosition)
+	assert.Len(t, publisher.messages, 1)
+	assert.Equal(t, "participant_promoted", publisher.messages[0]["event"])
+	assert.Equal(t, "user3@example.com", publisher.messages[0]["data"].(map[string]any)["user_email"])
+
+	// Отказавшийся снова подтверждает участие, но место уже занято
+	assert.NoError(t, service.ConfirmParticipation(ctx, 2))
+	declined, _ := store.GetById(ctx, 2)
+	assert.Equal(t, ptrTo(2), declined.WaitlistPosition)
+
+	_, err := service.Delete(ctx, 1, 3, 1, domain.ParticipantRemovalRequest{})
+	assert.NoError(t, err)
+	promoted, _ = store.GetById(ctx, 4)
+	assert.Nil(t, promoted.WaitlistPosition)
+	assert.Len(t, publisher.messages, 2)
+	assert.Equal(t, "user4@example.com", publisher.messages[1]["data"].(map[string]any)["user_email"])
+}
+
+// Тест 3: Задачи удаленного участника передаются только занявшему место участнику, долги делятся по выбору
+func TestParticipantDelete_RemovalPolicy(t *testing.T) {
+	service, store, _, publisher := setupParticipantService()
+	ctx := context.Background()
+	for _, userId := range []int{2, 3} {
+		invite(t, service, publisher, userId)
+	}
+	released := service.tasks.(*releaser)
+
+	_, err := service.Delete(ctx, 1, 2, 1, domain.ParticipantRemovalRequest{Tasks: domain.RemovalTasksReassign})
+	assert.ErrorIs(t, err, model.ErrInvalidRemovalPolicy)
+
+	// Третий участник в листе ожидания и не может принять задачи
+	_, err = service.Delete(ctx, 1, 2, 1, domain.ParticipantRemovalRequest{ReassignTo: ptrTo(3)})
+	assert.ErrorIs(t, err, model.ErrInvalidRemovalPolicy)
+	_, err = service.Delete(ctx, 1, 2, 1, domain.ParticipantRemovalRequest{Tasks: domain.RemovalTasksUnassign, ReassignTo: ptrTo(1)})
+	assert.ErrorIs(t, err, model.ErrInvalidRemovalPolicy)
+	_, err = service.Delete(ctx, 5, 2, 1, domain.ParticipantRemovalRequest{})
+	assert.ErrorIs(t, err, model.ErrNotEventParticipant)
+	assert.Len(t, store.items, 3)
+	assert.Empty(t, released.reassignTo)
+	assert.Empty(t, released.recurring)
+
+	resp, err := service.Delete(ctx, 1, 2, 1, domain.ParticipantRemovalRequest{
+		Tasks: domain.RemovalTasksReassign, ReassignTo: ptrTo(1), Shares: domain.RemovalSharesRedistribute,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.UserID)
+	assert.Equal(t, ptrTo(1), resp.Tasks.ReassignedTo)
+	assert.Equal(t, ptrTo(1), released.reassignTo[2])
+	assert.True(t, released.redistribute[2])
+
+	// Освободившееся место занял третий участник, по умолчанию его задачи остаются без исполнителя
+	_, err = service.Delete(ctx, 1, 3, 3, domain.ParticipantRemovalRequest{})
+	assert.NoError(t, err)
+	assert.Contains(t, released.reassignTo, 3)
+	assert.Nil(t, released.reassignTo[3])
+	assert.False(t, released.redistribute[3])
+	assert.Equal(t, []int{2, 3}, released.recurring)
+}
+
+// Тест 4: Список участников содержит данные пользователей и общее число участников под фильтром
+func TestParticipantListByEvent(t *testing.T) {
+	service, store, _, _ := setupParticipantService()
+	ctx := context.Background()
+	for _, userId := range []int{2, 3, 12} {
+		_, err := service.Create(ctx, 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", UserID: userId})
+		assert.NoError(t, err)
+	}
+	store.events[2] = model.Event{EventId: 2, OrganizerID: 2, Title: "Сплав"}
+	store.items = append(store.items, model.EventParticipant{EventParticipantID: 5, EventID: 2, UserID: 2, Role: model.RoleOrganizer})
+
+	resp, err := service.ListByEvent(ctx, 1, domain.ParticipantListRequest{Size: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, resp.Total)
+	assert.Len(t, resp.Participants, 2)
+	assert.Equal(t, "user1", resp.Participants[0].User.Username)
+	assert.Equal(t, "user1@example.com", resp.Participants[0].User.Email)
+
+	resp, err = service.ListByEvent(ctx, 1, domain.ParticipantListRequest{Status: model.ParticipationWaitlisted})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resp.Total)
+	assert.Equal(t, ptrTo(2), resp.Participants[1].WaitlistPosition)
+
+	resp, err = service.ListByEvent(ctx, 1, domain.ParticipantListRequest{Role: []string{"participant"}, Query: " user1 "})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Total)
+	assert.Equal(t, 12, resp.Participants[0].User.Id)
+
+	resp, err = service.ListByUser(ctx, 2, domain.ParticipantListRequest{Role: []string{"organizer"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Total)
+	assert.Equal(t, "Сплав", resp.Participants[0].EventTitle)
+	assert.Equal(t, "user2", resp.Participants[0].User.Username)
+}
+
+// Тест 5: Неизвестного пользователя и пользователя в архивном событии пригласить нельзя
+func TestParticipantCreate_Errors(t *testing.T) {
+	service, store, _, publisher := setupParticipantService()
+	ctx := context.Background()
+
+	_, err := service.Create(ctx, 1, domain.EventParticipantCreateRequest{EventTitle: "Поход", Username: "nobody"})
+	assert.ErrorIs(t, err, model.ErrUserNotFound)
+
+	store.events[2] = model.Event{EventId: 2, OrganizerID: 1, Title: "Сплав", Status: model.EventStatusArchived}
+	_, err = service.Create(ctx, 2, domain.EventParticipantCreateRequest{EventTitle: "Сплав", UserID: 2})
+	assert.ErrorIs(t, err, model.ErrEventArchived)
+
+	assert.Len(t, store.items, 1)
+	assert.Empty(t, publisher.messages)
+}
@@ -0,0 +1,548 @@
+package event
+
+import (
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/PabloPerdolie/event-manager/core-service/pkg/rrule"
+	"github.com/pkg/errors"
+	"sort"
+	"time"
+)
+
+// maxOccurrences ограничивает количество повторений одной серии в ответе
+const maxOccurrences = 500
+
+// ListOccurrences разворачивает серию в повторения, которые идут в окне хотя бы частично.
+// Измененные повторения возвращаются с изменениями, отмененные пропускаются
+func (s Service) ListOccurrences(ctx context.Context, id int, req domain.OccurrencesRequest) (domain.OccurrencesResponse, error) {
+	event, err := s.eventRepo.GetById(ctx, id)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for occurrences", "error", err, "id", id)
+		return domain.OccurrencesResponse{}, errors.WithMessage(err, "get event")
+	}
+
+	exceptions, err := s.exceptionRepo.ListByEvents(ctx, []int{id})
+	if err != nil {
+		s.logger.Errorw("Failed to list event exceptions", "error", err, "id", id)
+		return domain.OccurrencesResponse{}, errors.WithMessage(err, "list event exceptions")
+	}
+
+	// Дата окончания окна входит в него целиком
+	occurrences, err := expand(event, exceptions, req.From, req.To.AddDate(0, 0, 1), maxOccurrences)
+	if err != nil {
+		return domain.OccurrencesResponse{}, err
+	}
+
+	return domain.OccurrencesResponse{Occurrences: occurrences}, nil
+}
+
+// UpdateOccurrence изменяет одно повторение серии, повторения начиная с него или всю серию.
+// При изменении следующих повторений серия разделяется: прежняя заканчивается перед повторением,
+// а новая, с теми же участниками, начинается с него. Расходы и задачи остаются в прежней серии
+func (s Service) UpdateOccurrence(ctx context.Context, id, userId int, req domain.OccurrenceUpdateRequest) (*domain.EventResponse, error) {
+	event, rule, index, occurrence, err := s.getOccurrence(ctx, id, req.Version, req.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.RecurrenceRule != nil && req.Scope == domain.OccurrenceScopeThis {
+		return nil, errors.WithMessage(model.ErrInvalidEventRecurrence, "rule of a single occurrence cannot be changed")
+	}
+
+	var resp *domain.EventResponse
+	switch {
+	case req.Scope == domain.OccurrenceScopeThis:
+		resp, err = s.updateOneOccurrence(ctx, event, occurrence, req)
+	case req.Scope == domain.OccurrenceScopeAll || index == 0:
+		resp, err = s.updateSeries(ctx, event, occurrence, req)
+	default:
+		resp, err = s.splitSeries(ctx, event, rule, index, occurrence, req)
+	}
+	if err != nil && !errors.Is(err, model.ErrEventVersionConflict) && !errors.Is(err, model.ErrInvalidEventDates) &&
+		!errors.Is(err, model.ErrInvalidEventRecurrence) && !errors.Is(err, model.ErrOccurrenceNotFound) {
+		s.logger.Errorw("Failed to update event occurrence", "error", err, "id", id, "userId", userId, "scope", req.Scope)
+	}
+
+	return resp, err
+}
+
+// CancelOccurrence отменяет одно повторение серии или все повторения начиная с него
+func (s Service) CancelOccurrence(ctx context.Context, id, userId int, req domain.OccurrenceCancelRequest) (*domain.EventResponse, error) {
+	event, rule, index, occurrence, err := s.getOccurrence(ctx, id, req.Version, req.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Scope == domain.OccurrenceScopeFollowing && index == 0 {
+		return nil, errors.WithMessage(model.ErrInvalidEventRecurrence, "all occurrences cannot be cancelled, cancel the event instead")
+	}
+
+	event.UpdatedAt = time.Now()
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if req.Scope == domain.OccurrenceScopeThis {
+			exception, err := s.findException(ctx, event.EventId, occurrence)
+			if err != nil {
+				return err
+			}
+			exception.Cancelled = true
+			exception.UpdatedAt = event.UpdatedAt
+
+			if err := s.exceptionRepo.Upsert(ctx, exception); err != nil {
+				return errors.WithMessage(err, "cancel occurrence")
+			}
+		} else {
+			value := truncate(rule, index, occurrence).String()
+			event.RecurrenceRule = &value
+			if err := applyRecurrence(&event); err != nil {
+				return err
+			}
+
+			if err := s.exceptionRepo.DeleteFrom(ctx, event.EventId, occurrence); err != nil {
+				return errors.WithMessage(err, "delete cancelled exceptions")
+			}
+		}
+
+		var err error
+		event.Version, err = s.eventRepo.Update(ctx, event)
+		if err != nil {
+			return errors.WithMessage(err, "update event")
+		}
+
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, model.ErrEventVersionConflict) {
+			s.logger.Errorw("Failed to cancel event occurrence", "error", err, "id", id, "userId", userId, "scope", req.Scope)
+		}
+		return nil, err
+	}
+
+	details := map[string]any{"scope": req.Scope, "occurrence_date": occurrence}
+	if err := s.notifyUpdated(ctx, event, []string{"cancelled"}, details); err != nil {
+		s.logger.Errorw("Failed to notify occurrence cancel", "error", err, "id", id, "userId", userId)
+	}
+
+	resp := toEventResponse(event)
+	return &resp, nil
+}
+
+// getOccurrence возвращает серию, ее правило и номер повторения, начинающегося в occurrenceDate,
+// проверив, что серию можно изменять и клиент видел ее текущую версию
+func (s Service) getOccurrence(ctx context.Context, id, version int, occurrenceDate time.Time) (model.Event, rrule.Rule, int, time.Time, error) {
+	event, err := s.eventRepo.GetById(ctx, id)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for occurrence change", "error", err, "id", id)
+		return model.Event{}, rrule.Rule{}, 0, time.Time{}, errors.WithMessage(err, "get event")
+	}
+
+	if event.Status.ReadOnly() {
+		return model.Event{}, rrule.Rule{}, 0, time.Time{}, model.ErrEventArchived
+	}
+
+	if event.RecurrenceRule == nil {
+		return model.Event{}, rrule.Rule{}, 0, time.Time{}, model.ErrEventNotRecurring
+	}
+
+	if event.Version != version {
+		return model.Event{}, rrule.Rule{}, 0, time.Time{}, errors.WithMessagef(model.ErrEventVersionConflict, "current version is %d", event.Version)
+	}
+
+	rule, err := rrule.Parse(*event.RecurrenceRule)
+	if err != nil {
+		return model.Event{}, rrule.Rule{}, 0, time.Time{}, errors.WithMessage(err, "parse stored recurrence rule")
+	}
+
+	// Повторения вычисляются в часовом поясе серии, в нем же хранятся изменения
+	occurrence := occurrenceDate.In(event.StartDate.Location())
+	index, ok := rule.Index(event.StartDate, occurrence)
+	if !ok {
+		return model.Event{}, rrule.Rule{}, 0, time.Time{}, errors.WithMessagef(model.ErrOccurrenceNotFound, "no occurrence at %s", occurrenceDate)
+	}
+
+	return event, rule, index, occurrence, nil
+}
+
+// updateOneOccurrence сохраняет изменения повторения поверх его прежних изменений
+func (s Service) updateOneOccurrence(ctx context.Context, event model.Event, occurrence time.Time, req domain.OccurrenceUpdateRequest) (*domain.EventResponse, error) {
+	exception, err := s.findException(ctx, event.EventId, occurrence)
+	if err != nil {
+		return nil, err
+	}
+	if exception.Cancelled {
+		return nil, errors.WithMessage(model.ErrOccurrenceNotFound, "occurrence is cancelled")
+	}
+
+	current := applyException(event, occurrence, &exception)
+	changed := applyUpdate(&current, domain.EventUpdateRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		Location:    req.Location,
+	})
+	if !current.EndDate.After(current.StartDate) {
+		return nil, model.ErrInvalidEventDates
+	}
+
+	if len(changed) > 0 {
+		for _, field := range changed {
+			switch field {
+			case "title":
+				exception.Title = &current.Title
+			case "description":
+				exception.Description = &current.Description
+			case "location":
+				exception.Location = current.Location
+			case "start_date":
+				exception.StartDate = &current.StartDate
+			case "end_date":
+				exception.EndDate = &current.EndDate
+			}
+		}
+
+		event.UpdatedAt = time.Now()
+		exception.UpdatedAt = event.UpdatedAt
+		err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.exceptionRepo.Upsert(ctx, exception); err != nil {
+				return errors.WithMessage(err, "save occurrence")
+			}
+
+			// Версия серии защищает и ее повторения от одновременного изменения
+			var err error
+			event.Version, err = s.eventRepo.Update(ctx, event)
+			if err != nil {
+				return errors.WithMessage(err, "update event")
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		details := map[string]any{"scope": domain.OccurrenceScopeThis, "occurrence_date": occurrence}
+		if err := s.notifyUpdated(ctx, event, changed, details); err != nil {
+			s.logger.Errorw("Failed to notify occurrence update", "error", err, "id", event.EventId)
+		}
+	}
+
+	resp := occurrenceResponse(event, occurrence, &exception)
+	return &resp, nil
+}
+
+// updateSeries изменяет всю серию. Сдвиг времени повторения переносится на всю серию и ее изменения
+func (s Service) updateSeries(ctx context.Context, event model.Event, occurrence time.Time, req domain.OccurrenceUpdateRequest) (*domain.EventResponse, error) {
+	changed, shift, err := applySeriesUpdate(&event, occurrence, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		resp := toEventResponse(event)
+		return &resp, nil
+	}
+
+	event.UpdatedAt = time.Now()
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		event.Version, err = s.eventRepo.Update(ctx, event)
+		if err != nil {
+			return errors.WithMessage(err, "update event")
+		}
+
+		if shift != 0 {
+			if err := s.exceptionRepo.Move(ctx, event.EventId, event.EventId, time.Time{}, shift); err != nil {
+				return errors.WithMessage(err, "shift event exceptions")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{"scope": domain.OccurrenceScopeAll}
+	if err := s.notifyUpdated(ctx, event, changed, details); err != nil {
+		s.logger.Errorw("Failed to notify series update", "error", err, "id", event.EventId)
+	}
+
+	resp := toEventResponse(event)
+	return &resp, nil
+}
+
+// splitSeries заканчивает серию перед повторением с номером index и создает из оставшихся
+// повторений новую серию с изменениями запроса
+func (s Service) splitSeries(ctx context.Context, event model.Event, rule rrule.Rule, index int, occurrence time.Time, req domain.OccurrenceUpdateRequest) (*domain.EventResponse, error) {
+	tailRule := rule
+	if rule.Count > 0 {
+		tailRule.Count = rule.Count - index
+	}
+	tailValue := tailRule.String()
+
+	tail := event
+	tail.EventId = 0
+	tail.Version = 1
+	tail.StartDate = occurrence
+	tail.EndDate = occurrence.Add(event.EndDate.Sub(event.StartDate))
+	tail.RecurrenceRule = &tailValue
+	changed, shift, err := applySeriesUpdate(&tail, occurrence, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) == 0 {
+		resp := toEventResponse(event)
+		return &resp, nil
+	}
+
+	now := time.Now()
+	if tail.Status == model.EventStatusActive && tail.StartDate.After(now) {
+		tail.Status = model.EventStatusPlanned
+	}
+	tail.CreatedAt, tail.UpdatedAt = now, now
+
+	headValue := truncate(rule, index, occurrence).String()
+	event.RecurrenceRule = &headValue
+	if err := applyRecurrence(&event); err != nil {
+		return nil, err
+	}
+	event.UpdatedAt = now
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		event.Version, err = s.eventRepo.Update(ctx, event)
+		if err != nil {
+			return errors.WithMessage(err, "update event")
+		}
+
+		tail.EventId, err = s.eventRepo.Create(ctx, tail)
+		if err != nil {
+			return errors.WithMessage(err, "create series")
+		}
+
+		if err := s.participantRepo.CopyToEvent(ctx, event.EventId, tail.EventId); err != nil {
+			return errors.WithMessage(err, "copy series participants")
+		}
+
+		if err := s.exceptionRepo.Move(ctx, event.EventId, tail.EventId, occurrence, shift); err != nil {
+			return errors.WithMessage(err, "move event exceptions")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]any{"scope": domain.OccurrenceScopeFollowing, "occurrence_date": occurrence}
+	if err := s.notifyUpdated(ctx, tail, changed, details); err != nil {
+		s.logger.Errorw("Failed to notify series split", "error", err, "id", event.EventId, "newId", tail.EventId)
+	}
+
+	resp := toEventResponse(tail)
+	return &resp, nil
+}
+
+// applySeriesUpdate переносит изменения повторения, начинающегося в occurrence, на серию.
+// Возвращает измененные поля и сдвиг начала серии
+func applySeriesUpdate(event *model.Event, occurrence time.Time, req domain.OccurrenceUpdateRequest) ([]string, time.Duration, error) {
+	// Новое время повторения задает сдвиг серии и длительность повторений
+	start := occurrence
+	if req.StartDate != nil {
+		start = *req.StartDate
+	}
+	end := start.Add(event.EndDate.Sub(event.StartDate))
+	if req.EndDate != nil {
+		end = *req.EndDate
+	}
+	if !end.After(start) {
+		return nil, 0, model.ErrInvalidEventDates
+	}
+
+	shift := start.Sub(occurrence)
+	seriesStart := event.StartDate.Add(shift)
+	seriesEnd := seriesStart.Add(end.Sub(start))
+
+	changed := applyUpdate(event, domain.EventUpdateRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		StartDate:   &seriesStart,
+		EndDate:     &seriesEnd,
+		Location:    req.Location,
+	})
+
+	if req.RecurrenceRule != nil {
+		rule, err := rrule.Parse(*req.RecurrenceRule)
+		if err != nil {
+			return nil, 0, errors.WithMessage(model.ErrInvalidEventRecurrence, err.Error())
+		}
+		if value := rule.String(); value != *event.RecurrenceRule {
+			event.RecurrenceRule = &value
+			changed = append(changed, "recurrence_rule")
+		}
+	}
+
+	if err := applyRecurrence(event); err != nil {
+		return nil, 0, err
+	}
+
+	return changed, shift, nil
+}
+
+// applyRecurrence проверяет, что даты события задают первое повторение его правила, приводит правило
+// к каноническому виду и вычисляет окончание серии
+func applyRecurrence(event *model.Event) error {
+	if event.RecurrenceRule == nil {
+		end := event.EndDate
+		event.SeriesEnd = &end
+		return nil
+	}
+
+	rule, err := rrule.Parse(*event.RecurrenceRule)
+	if err != nil {
+		return errors.WithMessage(model.ErrInvalidEventRecurrence, err.Error())
+	}
+
+	first, ok := rule.First(event.StartDate)
+	if !ok || !first.Equal(event.StartDate) {
+		return errors.WithMessage(model.ErrInvalidEventRecurrence, "start date must be the first occurrence of the rule")
+	}
+
+	value := rule.String()
+	event.RecurrenceRule = &value
+	event.SeriesEnd = nil
+	if last, ok := rule.Last(event.StartDate); ok {
+		end := last.Add(event.EndDate.Sub(event.StartDate))
+		event.SeriesEnd = &end
+	}
+
+	return nil
+}
+
+// truncate возвращает правило, заканчивающееся перед повторением с номером index
+func truncate(rule rrule.Rule, index int, occurrence time.Time) rrule.Rule {
+	if rule.Count > 0 {
+		rule.Count = index
+		return rule
+	}
+
+	until := occurrence.Add(-time.Second)
+	rule.Until = &until
+	return rule
+}
+
+// findException возвращает сохраненное изменение повторения или пустое изменение, если его нет
+func (s Service) findException(ctx context.Context, eventId int, occurrence time.Time) (model.EventException, error) {
+	exceptions, err := s.exceptionRepo.ListByEvents(ctx, []int{eventId})
+	if err != nil {
+		return model.EventException{}, errors.WithMessage(err, "list event exceptions")
+	}
+
+	for _, exception := range exceptions {
+		if exception.OccurrenceDate.Equal(occurrence) {
+			return exception, nil
+		}
+	}
+
+	return model.EventException{EventID: eventId, OccurrenceDate: occurrence}, nil
+}
+
+// expand возвращает повторения события, которые идут в [from, to) хотя бы частично, не больше limit.
+// Обычное событие возвращается как есть
+func expand(event model.Event, exceptions []model.EventException, from, to time.Time, limit int) ([]domain.EventResponse, error) {
+	overlaps := func(start, end time.Time) bool {
+		return !end.Before(from) && start.Before(to)
+	}
+
+	if event.RecurrenceRule == nil {
+		if !overlaps(event.StartDate, event.EndDate) {
+			return []domain.EventResponse{}, nil
+		}
+		return []domain.EventResponse{toEventResponse(event)}, nil
+	}
+
+	rule, err := rrule.Parse(*event.RecurrenceRule)
+	if err != nil {
+		return nil, errors.WithMessage(err, "parse stored recurrence rule")
+	}
+
+	byDate := make(map[int64]*model.EventException, len(exceptions))
+	for i := range exceptions {
+		byDate[exceptions[i].OccurrenceDate.UnixNano()] = &exceptions[i]
+	}
+
+	duration := event.EndDate.Sub(event.StartDate)
+	dates := rule.Between(event.StartDate, from.Add(-duration), to, limit)
+
+	// Повторения, перенесенные в окно из-за его пределов
+	seen := make(map[int64]bool, len(dates))
+	for _, date := range dates {
+		seen[date.UnixNano()] = true
+	}
+	for _, exception := range exceptions {
+		if seen[exception.OccurrenceDate.UnixNano()] || exception.StartDate == nil {
+			continue
+		}
+		if _, ok := rule.Index(event.StartDate, exception.OccurrenceDate); ok {
+			dates = append(dates, exception.OccurrenceDate)
+		}
+	}
+
+	occurrences := make([]domain.EventResponse, 0, len(dates))
+	for _, date := range dates {
+		exception := byDate[date.UnixNano()]
+		if exception != nil && exception.Cancelled {
+			continue
+		}
+
+		occurrence := occurrenceResponse(event, date, exception)
+		if overlaps(occurrence.StartDate, occurrence.EndDate) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].StartDate.Before(occurrences[j].StartDate)
+	})
+	if len(occurrences) > limit {
+		occurrences = occurrences[:limit]
+	}
+
+	return occurrences, nil
+}
+
+// applyException возвращает событие со временем повторения occurrence и его изменениями
+func applyException(event model.Event, occurrence time.Time, exception *model.EventException) model.Event {
+	event.EndDate = occurrence.Add(event.EndDate.Sub(event.StartDate))
+	event.StartDate = occurrence
+	if exception == nil {
+		return event
+	}
+
+	if exception.Title != nil {
+		event.Title = *exception.Title
+	}
+	if exception.Description != nil {
+		event.Description = *exception.Description
+	}
+	if exception.Location != nil {
+		event.Location = exception.Location
+	}
+	if exception.StartDate != nil {
+		event.StartDate = *exception.StartDate
+	}
+	if exception.EndDate != nil {
+		event.EndDate = *exception.EndDate
+	}
+
+	return event
+}
+
+func occurrenceResponse(event model.Event, occurrence time.Time, exception *model.EventException) domain.EventResponse {
+	resp := toEventResponse(applyException(event, occurrence, exception))
+	resp.OccurrenceDate = &occurrence
+	resp.Modified = exception != nil && (exception.Title != nil || exception.Description != nil || exception.Location != nil ||
+		exception.StartDate != nil || exception.EndDate != nil)
+
+	return resp
+}
@@ -2,73 +2,129 @@ package event
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"slices"
+	"strings"
+	"time"
 )
 
+// participantRoles все роли участников события
+var participantRoles = []model.ParticipantRole{model.RoleOrganizer, model.RoleAdmin, model.RoleParticipant}
+
 type EventRepo interface {
 	Create(ctx context.Context, event model.Event) (int, error)
 	GetById(ctx context.Context, eventID int) (model.Event, error)
-	Update(ctx context.Context, event model.Event) error
+	Update(ctx context.Context, event model.Event) (int, error)
+	UpdateStatus(ctx context.Context, eventID int, from, to model.EventStatus, now time.Time) (int, error)
+	StartDue(ctx context.Context, now time.Time) ([]int, error)
+	FinishDue(ctx context.Context, now time.Time) ([]int, error)
 	Delete(ctx context.Context, eventID int) error
-	List(ctx context.Context, limit, offset int) ([]model.Event, error)
-	ListByOrganizer(ctx context.Context, organizerID, limit, offset int) ([]model.Event, error)
-	ListByParticipant(ctx context.Context, participantID, limit, offset int) ([]model.Event, error)
+	Search(ctx context.Context, filter model.EventFilter) ([]model.FoundEvent, int, error)
 }
 
 type EventParticipantRepo interface {
 	Create(ctx context.Context, participant model.EventParticipant) (int, error)
+	CopyToEvent(ctx context.Context, fromEventId, toEventId int) error
+	ListEventUsers(ctx context.Context, eventId int, roles []model.ParticipantRole) ([]model.User, error)
+	PromoteWaitlist(ctx context.Context, eventId int, now time.Time) ([]model.User, error)
+}
+
+// EventExceptionRepo изменения и отмены отдельных повторений серий
+type EventExceptionRepo interface {
+	Upsert(ctx context.Context, exception model.EventException) error
+	ListByEvents(ctx context.Context, eventIds []int) ([]model.EventException, error)
+	Move(ctx context.Context, fromEventId, toEventId int, from time.Time, shift time.Duration) error
+	DeleteFrom(ctx context.Context, eventId int, from time.Time) error
 }
 
 type NotifyPublisher interface {
 	Publish(ctx context.Context, data []byte) error
 }
 
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type Service struct {
 	eventRepo       EventRepo
 	participantRepo EventParticipantRepo
+	exceptionRepo   EventExceptionRepo
 	notifyPbl       NotifyPublisher
+	transactor      Transactor
 	logger          *zap.SugaredLogger
 }
 
-func NewService(eventRepo EventRepo, participantRepo EventParticipantRepo, notifyPbl NotifyPublisher, logger *zap.SugaredLogger) Service {
+func NewService(eventRepo EventRepo, participantRepo EventParticipantRepo, exceptionRepo EventExceptionRepo, notifyPbl NotifyPublisher, transactor Transactor, logger *zap.SugaredLogger) Service {
 	return Service{
 		eventRepo:       eventRepo,
 		participantRepo: participantRepo,
+		exceptionRepo:   exceptionRepo,
 		notifyPbl:       notifyPbl,
+		transactor:      transactor,
 		logger:          logger,
 	}
 }
 
 func (s Service) Create(ctx context.Context, userId int, req domain.EventCreateRequest) (int, error) {
-	event := model.Event{
-		Title:       req.Title,
-		Description: req.Description,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
-		Location:    &req.Location,
-		OrganizerID: userId,
+	baseCurrency := strings.ToUpper(req.BaseCurrency)
+	if baseCurrency == "" {
+		baseCurrency = model.DefaultCurrency
 	}
 
-	id, err := s.eventRepo.Create(ctx, event)
-	if err != nil {
-		s.logger.Errorw("Failed to create event", "error", err, "userId", userId)
-		return 0, errors.WithMessage(err, "create event")
+	status := model.EventStatus(req.Status)
+	if status == "" {
+		status = model.EventStatusPlanned
 	}
 
-	participant := model.EventParticipant{
-		EventID:     id,
-		UserID:      userId,
-		IsConfirmed: ptr(true),
-		Role:        model.RoleOrganizer,
+	event := model.Event{
+		Title:           req.Title,
+		Description:     req.Description,
+		StartDate:       req.StartDate,
+		EndDate:         req.EndDate,
+		Location:        &req.Location,
+		Status:          status,
+		BaseCurrency:    baseCurrency,
+		OrganizerID:     userId,
+		MaxParticipants: req.MaxParticipants,
+	}
+	if req.RecurrenceRule != "" {
+		event.RecurrenceRule = &req.RecurrenceRule
 	}
+	if err := applyRecurrence(&event); err != nil {
+		return 0, err
+	}
+
+	// Событие без организатора-участника недоступно никому, поэтому создаем их атомарно
+	var id int
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.eventRepo.Create(ctx, event)
+		if err != nil {
+			s.logger.Errorw("Failed to create event", "error", err, "userId", userId)
+			return errors.WithMessage(err, "create event")
+		}
 
-	_, err = s.participantRepo.Create(ctx, participant)
+		participant := model.EventParticipant{
+			EventID:     id,
+			UserID:      userId,
+			IsConfirmed: ptr(true),
+			Role:        model.RoleOrganizer,
+		}
+
+		_, err = s.participantRepo.Create(ctx, participant)
+		if err != nil {
+			s.logger.Errorw("Failed to add creator as participant", "error", err, "eventId", id, "userId", userId)
+			return errors.WithMessage(err, "add creator as participant")
+		}
+
+		return nil
+	})
 	if err != nil {
-		s.logger.Errorw("Failed to add creator as participant", "error", err, "eventId", id, "userId", userId)
-		// We don't return an error here, as the event was already created successfully // todo
+		return 0, err
 	}
 
 	return id, nil
@@ -81,140 +137,265 @@ func (s Service) GetById(ctx context.Context, id int) (*domain.EventResponse, er
 		return nil, errors.WithMessage(err, "get event")
 	}
 
-	return &domain.EventResponse{
-		Id:          event.EventId,
-		Title:       event.Title,
-		Description: event.Description,
-		StartDate:   event.StartDate,
-		EndDate:     event.EndDate,
-		Location:    *event.Location,
-		CreatedBy:   event.OrganizerID,
-		CreatedAt:   event.CreatedAt,
-	}, nil
+	resp := toEventResponse(event)
+	return &resp, nil
 }
 
-func (s Service) Update(ctx context.Context, id int, req domain.EventUpdateRequest) error {
+// Update изменяет событие, если клиент прислал его текущую версию, и уведомляет участников об измененных полях.
+// Запрос без изменений версию не увеличивает
+func (s Service) Update(ctx context.Context, id, userId int, req domain.EventUpdateRequest) (*domain.EventResponse, error) {
 	event, err := s.eventRepo.GetById(ctx, id)
 	if err != nil {
 		s.logger.Errorw("Failed to get event for update", "error", err, "id", id)
-		return errors.WithMessage(err, "get event")
+		return nil, errors.WithMessage(err, "get event")
 	}
 
-	if req.Title != nil {
-		event.Title = *req.Title
+	if event.Status.ReadOnly() {
+		return nil, model.ErrEventArchived
 	}
 
-	if req.Description != nil {
-		event.Description = *req.Description
+	if event.Version != req.Version {
+		return nil, errors.WithMessagef(model.ErrEventVersionConflict, "current version is %d", event.Version)
 	}
 
-	if req.StartDate != nil {
-		event.StartDate = *req.StartDate
+	previousStart := event.StartDate
+	changed := applyUpdate(&event, req)
+	if (req.StartDate != nil || req.EndDate != nil) && !event.EndDate.After(event.StartDate) {
+		return nil, model.ErrInvalidEventDates
 	}
 
-	if req.EndDate != nil {
-		event.EndDate = *req.EndDate
+	if len(changed) > 0 {
+		if err := applyRecurrence(&event); err != nil {
+			return nil, err
+		}
+
+		// Изменения повторений серии сдвигаются вместе с ее началом
+		shift := event.StartDate.Sub(previousStart)
+		event.UpdatedAt = time.Now()
+		var promoted []model.User
+		err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			event.Version, err = s.eventRepo.Update(ctx, event)
+			if err != nil {
+				return errors.WithMessage(err, "update event")
+			}
+
+			if event.RecurrenceRule != nil && shift != 0 {
+				if err := s.exceptionRepo.Move(ctx, id, id, time.Time{}, shift); err != nil {
+					return errors.WithMessage(err, "shift event exceptions")
+				}
+			}
+
+			// Обновление события блокирует его запись, поэтому места распределяются без гонок
+			// с добавлением участников. При уменьшении ограничения свободных мест нет и никто не переводится
+			if slices.Contains(changed, "max_participants") {
+				promoted, err = s.participantRepo.PromoteWaitlist(ctx, id, event.UpdatedAt)
+				if err != nil {
+					return errors.WithMessage(err, "promote waitlist")
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			if !errors.Is(err, model.ErrEventVersionConflict) {
+				s.logger.Errorw("Failed to update event", "error", err, "id", id)
+			}
+			return nil, err
+		}
+
+		// Событие уже сохранено, поэтому ошибка уведомления только логируется
+		if err := s.notifyUpdated(ctx, event, changed, nil); err != nil {
+			s.logger.Errorw("Failed to notify event update", "error", err, "id", id, "userId", userId)
+		}
+		if err := publishPromoted(ctx, s.notifyPbl, event, promoted); err != nil {
+			s.logger.Errorw("Failed to notify promoted participants", "error", err, "id", id)
+		}
 	}
 
-	if req.Location != nil {
-		event.Location = req.Location
+	resp := toEventResponse(event)
+	return &resp, nil
+}
+
+// ChangeStatus переводит событие в другой статус, если переход из текущего статуса допустим
+func (s Service) ChangeStatus(ctx context.Context, id int, req domain.EventStatusRequest) (*domain.EventResponse, error) {
+	event, err := s.eventRepo.GetById(ctx, id)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for status change", "error", err, "id", id)
+		return nil, errors.WithMessage(err, "get event")
 	}
 
-	if err := s.eventRepo.Update(ctx, event); err != nil {
-		s.logger.Errorw("Failed to update event", "error", err, "id", id)
-		return errors.WithMessage(err, "update event")
+	to := model.EventStatus(req.Status)
+	if !event.Status.CanTransitionTo(to) {
+		return nil, errors.WithMessagef(model.ErrInvalidEventTransition, "%s -> %s", event.Status, to)
 	}
 
-	return nil
+	event.UpdatedAt = time.Now()
+	event.Version, err = s.eventRepo.UpdateStatus(ctx, id, event.Status, to, event.UpdatedAt)
+	if err != nil {
+		if !errors.Is(err, model.ErrEventVersionConflict) {
+			s.logger.Errorw("Failed to update event status", "error", err, "id", id, "status", to)
+		}
+		return nil, errors.WithMessage(err, "update event status")
+	}
+	event.Status = to
+
+	resp := toEventResponse(event)
+	return &resp, nil
 }
 
-func (s Service) Delete(ctx context.Context, id int) error {
-	_, err := s.eventRepo.GetById(ctx, id)
+// AdvanceStatuses делает активными начавшиеся запланированные события и завершает закончившиеся активные.
+// Событие, которое успело и начаться, и закончиться, проходит оба перехода за один запуск
+func (s Service) AdvanceStatuses(ctx context.Context, now time.Time) error {
+	started, err := s.eventRepo.StartDue(ctx, now)
 	if err != nil {
-		s.logger.Errorw("Failed to get event for deletion", "error", err, "id", id)
-		return errors.WithMessage(err, "get event")
+		return errors.WithMessage(err, "start due events")
 	}
 
-	if err := s.eventRepo.Delete(ctx, id); err != nil {
-		s.logger.Errorw("Failed to delete event", "error", err, "id", id)
-		return errors.WithMessage(err, "delete event")
+	finished, err := s.eventRepo.FinishDue(ctx, now)
+	if err != nil {
+		return errors.WithMessage(err, "finish due events")
+	}
+
+	if len(started) > 0 || len(finished) > 0 {
+		s.logger.Infow("Event statuses advanced", "started", started, "finished", finished)
 	}
 
 	return nil
 }
 
-func (s Service) List(ctx context.Context, page, size int) (*domain.EventsResponse, error) {
-	if page < 1 {
-		page = 1
+// RunStatusUpdates запускает обновление статусов событий по датам каждые interval до отмены ctx
+func (s Service) RunStatusUpdates(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.AdvanceStatuses(ctx, time.Now()); err != nil {
+			s.logger.Errorw("Failed to advance event statuses", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
-	if size < 1 {
-		size = 10
+}
+
+// applyUpdate переносит в событие заданные поля запроса и возвращает имена полей, значение которых изменилось
+func applyUpdate(event *model.Event, req domain.EventUpdateRequest) []string {
+	changed := make([]string, 0)
+
+	if req.Title != nil && *req.Title != event.Title {
+		event.Title = *req.Title
+		changed = append(changed, "title")
 	}
 
-	offset := (page - 1) * size
-	events, err := s.eventRepo.List(ctx, size, offset)
-	if err != nil {
-		s.logger.Errorw("Failed to list events", "error", err, "page", page, "size", size)
-		return nil, errors.WithMessage(err, "list events")
+	if req.Description != nil && *req.Description != event.Description {
+		event.Description = *req.Description
+		changed = append(changed, "description")
 	}
 
-	return convertToEventsResponse(events), nil
-}
+	if req.StartDate != nil && !req.StartDate.Equal(event.StartDate) {
+		event.StartDate = *req.StartDate
+		changed = append(changed, "start_date")
+	}
 
-func (s Service) ListByOrganizer(ctx context.Context, organizerId int, page, size int) (*domain.EventsResponse, error) {
-	if page < 1 {
-		page = 1
+	if req.EndDate != nil && !req.EndDate.Equal(event.EndDate) {
+		event.EndDate = *req.EndDate
+		changed = append(changed, "end_date")
 	}
-	if size < 1 {
-		size = 10
+
+	if req.Location != nil && (event.Location == nil || *req.Location != *event.Location) {
+		event.Location = req.Location
+		changed = append(changed, "location")
 	}
 
-	offset := (page - 1) * size
-	events, err := s.eventRepo.ListByOrganizer(ctx, organizerId, size, offset)
-	if err != nil {
-		s.logger.Errorw("Failed to list organizer events", "error", err, "organizerId", organizerId, "page", page, "size", size)
-		return nil, errors.WithMessage(err, "list organizer events")
+	if req.MaxParticipants != nil {
+		var limit *int
+		if *req.MaxParticipants > 0 {
+			limit = req.MaxParticipants
+		}
+		if (limit == nil) != (event.MaxParticipants == nil) || limit != nil && *limit != *event.MaxParticipants {
+			event.MaxParticipants = limit
+			changed = append(changed, "max_participants")
+		}
 	}
 
-	return convertToEventsResponse(events), nil
+	return changed
 }
 
-func (s Service) ListByParticipant(ctx context.Context, participantId int, page, size int) (*domain.EventsResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if size < 1 {
-		size = 10
+// notifyUpdated отправляет каждому участнику события список измененных полей. details дополняет
+// данные уведомления, например повторением серии, к которому относится изменение
+func (s Service) notifyUpdated(ctx context.Context, event model.Event, changed []string, details map[string]any) error {
+	users, err := s.participantRepo.ListEventUsers(ctx, event.EventId, participantRoles)
+	if err != nil {
+		return errors.WithMessage(err, "list event participants")
 	}
 
-	offset := (page - 1) * size
-	events, err := s.eventRepo.ListByParticipant(ctx, participantId, size, offset)
-	if err != nil {
-		s.logger.Errorw("Failed to list participant events", "error", err, "participantId", participantId, "page", page, "size", size)
-		return nil, errors.WithMessage(err, "list participant events")
+	for _, user := range users {
+		payload := map[string]any{
+			"event_id":       event.EventId,
+			"event_name":     event.Title,
+			"changed_fields": changed,
+			"user_email":     user.Email,
+		}
+		for key, value := range details {
+			payload[key] = value
+		}
+
+		data := map[string]any{
+			"event": "event_updated",
+			"data":  payload,
+		}
+
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return errors.WithMessage(err, "marshal event updated notify")
+		}
+
+		if err := s.notifyPbl.Publish(ctx, bytes); err != nil {
+			return errors.WithMessage(err, "publish event updated notify")
+		}
 	}
 
-	return convertToEventsResponse(events), nil
+	return nil
 }
 
-func convertToEventsResponse(events []model.Event) *domain.EventsResponse {
-	eventResponses := make([]domain.EventResponse, len(events))
+func (s Service) Delete(ctx context.Context, id int) error {
+	_, err := s.eventRepo.GetById(ctx, id)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for deletion", "error", err, "id", id)
+		return errors.WithMessage(err, "get event")
+	}
 
-	for i, event := range events {
-		eventResponses[i] = domain.EventResponse{
-			Id:          event.EventId,
-			Title:       event.Title,
-			Description: event.Description,
-			StartDate:   event.StartDate,
-			EndDate:     event.EndDate,
-			Location:    *event.Location,
-			CreatedBy:   event.OrganizerID,
-			CreatedAt:   event.CreatedAt,
-		}
+	if err := s.eventRepo.Delete(ctx, id); err != nil {
+		s.logger.Errorw("Failed to delete event", "error", err, "id", id)
+		return errors.WithMessage(err, "delete event")
 	}
 
-	return &domain.EventsResponse{
-		Events: eventResponses,
-		Total:  len(events),
+	return nil
+}
+
+func toEventResponse(event model.Event) domain.EventResponse {
+	resp := domain.EventResponse{
+		Id:              event.EventId,
+		Title:           event.Title,
+		Description:     event.Description,
+		StartDate:       event.StartDate,
+		EndDate:         event.EndDate,
+		Location:        *event.Location,
+		Status:          string(event.Status),
+		BaseCurrency:    event.BaseCurrency,
+		CreatedBy:       event.OrganizerID,
+		Version:         event.Version,
+		CreatedAt:       event.CreatedAt,
+		UpdatedAt:       event.UpdatedAt,
+		MaxParticipants: event.MaxParticipants,
 	}
+	if event.RecurrenceRule != nil {
+		resp.RecurrenceRule = *event.RecurrenceRule
+	}
+
+	return resp
 }
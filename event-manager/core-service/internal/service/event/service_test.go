@@ -0,0 +1,638 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// Mock репозитория событий
+type MockEventRepo struct {
+	mock.Mock
+}
+
+func (m *MockEventRepo) Create(ctx context.Context, event model.Event) (int, error) {
+	args := m.Called(ctx, event)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockEventRepo) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(model.Event), args.Error(1)
+}
+
+func (m *MockEventRepo) Update(ctx context.Context, event model.Event) (int, error) {
+	args := m.Called(ctx, event)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockEventRepo) UpdateStatus(ctx context.Context, eventID int, from, to model.EventStatus, now time.Time) (int, error) {
+	args := m.Called(ctx, eventID, from, to, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockEventRepo) StartDue(ctx context.Context, now time.Time) ([]int, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockEventRepo) FinishDue(ctx context.Context, now time.Time) ([]int, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockEventRepo) Delete(ctx context.Context, eventID int) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+func (m *MockEventRepo) Search(ctx context.Context, filter model.EventFilter) ([]model.FoundEvent, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.FoundEvent), args.Int(1), args.Error(2)
+}
+
+// Участники события
+type participants struct{}
+
+func (participants) Create(ctx context.Context, participant model.EventParticipant) (int, error) {
+	return 1, nil
+}
+
+func (participants) CopyToEvent(ctx context.Context, fromEventId, toEventId int) error {
+	return nil
+}
+
+func (participants) ListEventUsers(ctx context.Context, eventId int, roles []model.ParticipantRole) ([]model.User, error) {
+	return []model.User{{UserId: 1, Email: "org@example.com"}, {UserId: 2, Email: "guest@example.com"}}, nil
+}
+
+func (participants) PromoteWaitlist(ctx context.Context, eventId int, now time.Time) ([]model.User, error) {
+	return []model.User{{UserId: 3, Email: "waiting@example.com"}}, nil
+}
+
+// Изменения повторений серий в памяти
+type exceptionStore struct {
+	items []model.EventException
+}
+
+func (s *exceptionStore) Upsert(ctx context.Context, exception model.EventException) error {
+	for i, item := range s.items {
+		if item.EventID == exception.EventID && item.OccurrenceDate.Equal(exception.OccurrenceDate) {
+			s.items[i] = exception
+			return nil
+		}
+	}
+	s.items = append(s.items, exception)
+	return nil
+}
+
+func (s *exceptionStore) ListByEvents(ctx context.Context, eventIds []int) ([]model.EventException, error) {
+	result := make([]model.EventException, 0)
+	for _, item := range s.items {
+		for _, id := range eventIds {
+			if item.EventID == id {
+				result = append(result, item)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *exceptionStore) Move(ctx context.Context, fromEventId, toEventId int, from time.Time, shift time.Duration) error {
+	for i, item := range s.items {
+		if item.EventID == fromEventId && !item.OccurrenceDate.Before(from) {
+			s.items[i].EventID = toEventId
+			s.items[i].OccurrenceDate = item.OccurrenceDate.Add(shift)
+		}
+	}
+	return nil
+}
+
+func (s *exceptionStore) DeleteFrom(ctx context.Context, eventId int, from time.Time) error {
+	kept := s.items[:0]
+	for _, item := range s.items {
+		if item.EventID != eventId || item.OccurrenceDate.Before(from) {
+			kept = append(kept, item)
+		}
+	}
+	s.items = kept
+	return nil
+}
+
+// Публикатор, запоминающий отправленные уведомления
+type recordingPublisher struct {
+	messages []map[string]any
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, data []byte) error {
+	var msg map[string]any
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+// Транзакции без БД: функция выполняется сразу
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+var (
+	start = time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	end   = time.Date(2025, 7, 3, 18, 0, 0, 0, time.UTC)
+)
+
+func setupService() (*Service, *MockEventRepo, *recordingPublisher) {
+	service, repo, publisher, _ := setupSeriesService()
+	return service, repo, publisher
+}
+
+func setupSeriesService() (*Service, *MockEventRepo, *recordingPublisher, *exceptionStore) {
+	repo := new(MockEventRepo)
+	publisher := &recordingPublisher{}
+	exceptions := &exceptionStore{}
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(repo, participants{}, exceptions, publisher, noTx{}, logger.Sugar())
+
+	return &service, repo, publisher, exceptions
+}
+
+func storedEvent() model.Event {
+	location := "Казань"
+	return model.Event{
+		EventId: 1, OrganizerID: 1, Title: "Поход", StartDate: start, EndDate: end,
+		Location: &location, Status: model.EventStatusPlanned, BaseCurrency: "RUB", Version: 3,
+	}
+}
+
+// storedSeries встречи по четвергам с 3 июля 2025 года, 4 раза
+func storedSeries() model.Event {
+	event := storedEvent()
+	event.StartDate = time.Date(2025, 7, 3, 19, 0, 0, 0, time.UTC)
+	event.EndDate = time.Date(2025, 7, 3, 21, 0, 0, 0, time.UTC)
+	event.RecurrenceRule = ptrTo("FREQ=WEEKLY;BYDAY=TH;COUNT=4")
+	event.SeriesEnd = ptrTo(time.Date(2025, 7, 24, 21, 0, 0, 0, time.UTC))
+	return event
+}
+
+// thursday начало повторения серии storedSeries в июльский четверг day
+func thursday(day int) time.Time {
+	return time.Date(2025, 7, day, 19, 0, 0, 0, time.UTC)
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+// Тест 1: Измененные поля сохраняются с новой версией, каждый участник получает их список
+func TestUpdate_Success(t *testing.T) {
+	service, repo, publisher := setupService()
+	ctx := context.Background()
+	newEnd := end.AddDate(0, 0, 1)
+
+	repo.On("GetById", ctx, 1).Return(storedEvent(), nil)
+	repo.On("Update", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return event.Version == 3 && event.Title == "Сплав" && event.EndDate.Equal(newEnd) && *event.Location == "Казань"
+	})).Return(4, nil)
+
+	resp, err := service.Update(ctx, 1, 1, domain.EventUpdateRequest{
+		Version:  3,
+		Title:    ptrTo("Сплав"),
+		EndDate:  &newEnd,
+		Location: ptrTo("Казань"),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, resp.Version)
+	assert.Equal(t, "Сплав", resp.Title)
+	assert.Len(t, publisher.messages, 2)
+	assert.Equal(t, "event_updated", publisher.messages[0]["event"])
+	data := publisher.messages[1]["data"].(map[string]any)
+	assert.Equal(t, "guest@example.com", data["user_email"])
+	assert.Equal(t, []any{"title", "end_date"}, data["changed_fields"])
+}
+
+// Тест 2: Запрос с устаревшей версией отклоняется без сохранения
+func TestUpdate_StaleVersion(t *testing.T) {
+	service, repo, publisher := setupService()
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 1).Return(storedEvent(), nil)
+
+	_, err := service.Update(ctx, 1, 1, domain.EventUpdateRequest{Version: 2, Title: ptrTo("Сплав")})
+
+	assert.ErrorIs(t, err, model.ErrEventVersionConflict)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	assert.Empty(t, publisher.messages)
+}
+
+// Тест 3: Конфликт при сохранении, если событие изменили между чтением и записью
+func TestUpdate_ConcurrentChange(t *testing.T) {
+	service, repo, publisher := setupService()
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 1).Return(storedEvent(), nil)
+	repo.On("Update", ctx, mock.AnythingOfType("model.Event")).Return(0, model.ErrEventVersionConflict)
+
+	_, err := service.Update(ctx, 1, 1, domain.EventUpdateRequest{Version: 3, Title: ptrTo("Сплав")})
+
+	assert.ErrorIs(t, err, model.ErrEventVersionConflict)
+	assert.Empty(t, publisher.messages)
+}
+
+// Тест 4: Дата окончания раньше даты начала отклоняется
+func TestUpdate_InvalidDates(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+	newStart := end.Add(time.Hour)
+
+	repo.On("GetById", ctx, 1).Return(storedEvent(), nil)
+
+	_, err := service.Update(ctx, 1, 1, domain.EventUpdateRequest{Version: 3, StartDate: &newStart})
+
+	assert.ErrorIs(t, err, model.ErrInvalidEventDates)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// Тест 5: Запрос без изменений не увеличивает версию и не рассылает уведомления
+func TestUpdate_NoChanges(t *testing.T) {
+	service, repo, publisher := setupService()
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 1).Return(storedEvent(), nil)
+
+	resp, err := service.Update(ctx, 1, 1, domain.EventUpdateRequest{Version: 3, Title: ptrTo("Поход"), StartDate: ptrTo(start)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, resp.Version)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	assert.Empty(t, publisher.messages)
+}
+
+// Тест 6: Архивное событие не изменяется
+func TestUpdate_Archived(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+	event := storedEvent()
+	event.Status = model.EventStatusArchived
+
+	repo.On("GetById", ctx, 1).Return(event, nil)
+
+	_, err := service.Update(ctx, 1, 1, domain.EventUpdateRequest{Version: 3, Title: ptrTo("Сплав")})
+
+	assert.ErrorIs(t, err, model.ErrEventArchived)
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// Тест 7: Допустимый переход сохраняется, если статус не изменили параллельно
+func TestChangeStatus_Success(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 1).Return(storedEvent(), nil)
+	repo.On("UpdateStatus", ctx, 1, model.EventStatusPlanned, model.EventStatusCancelled, mock.AnythingOfType("time.Time")).Return(4, nil)
+
+	resp, err := service.ChangeStatus(ctx, 1, domain.EventStatusRequest{Status: "cancelled"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "cancelled", resp.Status)
+	assert.Equal(t, 4, resp.Version)
+}
+
+// Тест 8: Недопустимый переход отклоняется без сохранения
+func TestChangeStatus_InvalidTransition(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 1).Return(storedEvent(), nil)
+
+	_, err := service.ChangeStatus(ctx, 1, domain.EventStatusRequest{Status: "archived"})
+
+	assert.ErrorIs(t, err, model.ErrInvalidEventTransition)
+	repo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Тест 9: Планировщик сначала начинает события, затем завершает, чтобы прошедшее событие прошло оба перехода
+func TestAdvanceStatuses(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+	now := time.Date(2025, 7, 2, 12, 0, 0, 0, time.UTC)
+
+	var order []string
+	repo.On("StartDue", ctx, now).Run(func(mock.Arguments) { order = append(order, "start") }).Return([]int{1, 2}, nil)
+	repo.On("FinishDue", ctx, now).Run(func(mock.Arguments) { order = append(order, "finish") }).Return([]int{2}, nil)
+
+	err := service.AdvanceStatuses(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"start", "finish"}, order)
+}
+
+// Тест 10: Допустимые переходы жизненного цикла
+func TestEventStatusTransitions(t *testing.T) {
+	assert.True(t, model.EventStatusDraft.CanTransitionTo(model.EventStatusPlanned))
+	assert.True(t, model.EventStatusActive.CanTransitionTo(model.EventStatusCompleted))
+	assert.True(t, model.EventStatusCancelled.CanTransitionTo(model.EventStatusArchived))
+	assert.False(t, model.EventStatusDraft.CanTransitionTo(model.EventStatusActive))
+	assert.False(t, model.EventStatusCompleted.CanTransitionTo(model.EventStatusActive))
+	assert.False(t, model.EventStatusArchived.CanTransitionTo(model.EventStatusPlanned))
+}
+
+// Тест 11: Поисковая фраза сортирует по релевантности, конец диапазона дат включается целиком,
+// Total — количество всех найденных событий, а не размер страницы
+func TestList_Search(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+	viewer := 5
+	dateTo := time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC)
+
+	found := make([]model.FoundEvent, 3)
+	for i := range found {
+		found[i] = model.FoundEvent{Event: storedEvent(), Rank: 0.5 - float64(i)/10}
+		found[i].EventId = i + 1
+	}
+
+	repo.On("Search", ctx, mock.MatchedBy(func(filter model.EventFilter) bool {
+		return filter.Query == "поход" && filter.SortBy == model.EventSortRelevance && filter.Desc &&
+			*filter.ViewerID == viewer && filter.To.Equal(dateTo.AddDate(0, 0, 1)) &&
+			len(filter.Statuses) == 1 && filter.Statuses[0] == model.EventStatusPlanned && filter.Limit == 3
+	})).Return(found, 7, nil)
+
+	resp, err := service.List(ctx, &viewer, domain.EventListRequest{
+		Query: " поход ", DateTo: &dateTo, Status: []string{"planned"}, Limit: 2,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Events, 2)
+	assert.Equal(t, 7, resp.Total)
+	assert.NotEmpty(t, resp.NextCursor)
+
+	cursor, err := decodeCursor(resp.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cursor.EventID)
+	assert.Equal(t, 0.4, cursor.Rank)
+	assert.Equal(t, "relevance", cursor.Sort)
+}
+
+// Тест 12: Курсор следующей страницы передается в репозиторий, на последней странице курсора нет
+func TestList_NextPage(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+
+	cursor, err := encodeCursor("title_asc", model.FoundEvent{Event: storedEvent()})
+	assert.NoError(t, err)
+
+	repo.On("Search", ctx, mock.MatchedBy(func(filter model.EventFilter) bool {
+		return filter.After != nil && filter.After.EventID == 1 && filter.After.StartDate.Equal(start) &&
+			filter.SortBy == model.EventSortTitle && !filter.Desc && filter.Offset == 0
+	})).Return([]model.FoundEvent{{Event: storedEvent()}}, 1, nil)
+
+	resp, err := service.List(ctx, nil, domain.EventListRequest{Sort: "title_asc", Cursor: cursor, Page: 3})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Events, 1)
+	assert.Empty(t, resp.NextCursor)
+}
+
+// Тест 13: Без поисковой фразы сортировка по релевантности заменяется сортировкой по дате начала,
+// а страница без курсора задает смещение
+func TestList_RelevanceWithoutQuery(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+
+	repo.On("Search", ctx, mock.MatchedBy(func(filter model.EventFilter) bool {
+		return filter.SortBy == model.EventSortStartDate && filter.Desc && filter.Offset == 10 && filter.Limit == 6
+	})).Return([]model.FoundEvent{}, 0, nil)
+
+	resp, err := service.List(ctx, nil, domain.EventListRequest{Sort: "relevance", Page: 3, Size: 5})
+
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Events)
+	assert.Equal(t, 0, resp.Total)
+}
+
+// Тест 14: Поврежденный курсор и курсор, выданный для другого порядка сортировки, отклоняются без запроса к БД
+func TestList_InvalidCursor(t *testing.T) {
+	service, repo, _ := setupService()
+
+	_, err := service.List(context.Background(), nil, domain.EventListRequest{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, model.ErrInvalidCursor)
+
+	cursor, err := encodeCursor("title_asc", model.FoundEvent{Event: storedEvent()})
+	assert.NoError(t, err)
+	_, err = service.List(context.Background(), nil, domain.EventListRequest{Sort: "start_date_asc", Cursor: cursor})
+	assert.ErrorIs(t, err, model.ErrInvalidCursor)
+
+	repo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
+}
+
+// Тест 15: Серия создается с правилом в каноническом виде и окончанием последнего повторения,
+// правило, которому не соответствует дата начала, отклоняется
+func TestCreate_Recurring(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+	req := domain.EventCreateRequest{
+		Title: "Митап", StartDate: thursday(3), EndDate: thursday(3).Add(2 * time.Hour), RecurrenceRule: "freq=weekly;byday=th;count=4",
+	}
+
+	repo.On("Create", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return *event.RecurrenceRule == "FREQ=WEEKLY;BYDAY=TH;COUNT=4" && event.SeriesEnd.Equal(thursday(24).Add(2*time.Hour))
+	})).Return(5, nil)
+
+	id, err := service.Create(ctx, 1, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, id)
+
+	req.RecurrenceRule = "FREQ=WEEKLY;BYDAY=FR"
+	_, err = service.Create(ctx, 1, req)
+
+	assert.ErrorIs(t, err, model.ErrInvalidEventRecurrence)
+	repo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Тест 16: Повторения в окне учитывают отмененные и перенесенные, в том числе перенесенные в окно извне
+func TestListOccurrences(t *testing.T) {
+	service, repo, _, exceptions := setupSeriesService()
+	ctx := context.Background()
+	movedStart := time.Date(2025, 7, 16, 10, 0, 0, 0, time.UTC)
+
+	exceptions.items = []model.EventException{
+		{EventID: 1, OccurrenceDate: thursday(10), Cancelled: true},
+		{EventID: 1, OccurrenceDate: thursday(24), StartDate: &movedStart, EndDate: ptrTo(movedStart.Add(time.Hour)), Title: ptrTo("Итоги")},
+	}
+	repo.On("GetById", ctx, 1).Return(storedSeries(), nil)
+
+	resp, err := service.ListOccurrences(ctx, 1, domain.OccurrencesRequest{
+		From: time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 7, 17, 0, 0, 0, 0, time.UTC),
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Occurrences, 2)
+	assert.Equal(t, "Итоги", resp.Occurrences[0].Title)
+	assert.True(t, resp.Occurrences[0].Modified)
+	assert.Equal(t, thursday(24), *resp.Occurrences[0].OccurrenceDate)
+	assert.Equal(t, thursday(17), resp.Occurrences[1].StartDate)
+	assert.False(t, resp.Occurrences[1].Modified)
+}
+
+// Тест 17: Изменение одного повторения сохраняется отдельно от серии, версия серии увеличивается,
+// уведомление указывает повторение
+func TestUpdateOccurrence_This(t *testing.T) {
+	service, repo, publisher, exceptions := setupSeriesService()
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 1).Return(storedSeries(), nil)
+	repo.On("Update", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return event.Title == "Поход" && event.StartDate.Equal(thursday(3))
+	})).Return(4, nil)
+
+	resp, err := service.UpdateOccurrence(ctx, 1, 1, domain.OccurrenceUpdateRequest{
+		OccurrenceDate: thursday(17), Scope: "this", Version: 3, Location: ptrTo("Онлайн"),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, resp.Version)
+	assert.Equal(t, "Онлайн", resp.Location)
+	assert.Equal(t, thursday(17), resp.StartDate)
+	assert.Len(t, exceptions.items, 1)
+	assert.Equal(t, "Онлайн", *exceptions.items[0].Location)
+	assert.Nil(t, exceptions.items[0].Title)
+
+	data := publisher.messages[0]["data"].(map[string]any)
+	assert.Equal(t, "this", data["scope"])
+	assert.Equal(t, []any{"location"}, data["changed_fields"])
+}
+
+// Тест 18: Изменение следующих повторений заканчивает серию перед повторением и создает новую серию
+// с оставшимися повторениями, сдвинутыми на новое время; изменения повторений переходят в новую серию
+func TestUpdateOccurrence_Following(t *testing.T) {
+	service, repo, _, exceptions := setupSeriesService()
+	ctx := context.Background()
+	exceptions.items = []model.EventException{
+		{EventID: 1, OccurrenceDate: thursday(10), Title: ptrTo("Старое")},
+		{EventID: 1, OccurrenceDate: thursday(24), Title: ptrTo("Итоги")},
+	}
+
+	repo.On("GetById", ctx, 1).Return(storedSeries(), nil)
+	repo.On("Update", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return *event.RecurrenceRule == "FREQ=WEEKLY;BYDAY=TH;COUNT=2" && event.SeriesEnd.Equal(thursday(10).Add(2*time.Hour))
+	})).Return(4, nil)
+	repo.On("Create", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return *event.RecurrenceRule == "FREQ=WEEKLY;BYDAY=TH;COUNT=2" && event.StartDate.Equal(thursday(17).Add(time.Hour)) &&
+			event.EndDate.Equal(thursday(17).Add(3*time.Hour)) && event.Title == "Поход"
+	})).Return(9, nil)
+
+	resp, err := service.UpdateOccurrence(ctx, 1, 1, domain.OccurrenceUpdateRequest{
+		OccurrenceDate: thursday(17), Scope: "following", Version: 3, StartDate: ptrTo(thursday(17).Add(time.Hour)),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9, resp.Id)
+	assert.Equal(t, thursday(17).Add(time.Hour), resp.StartDate)
+	assert.Equal(t, 1, exceptions.items[0].EventID)
+	assert.Equal(t, 9, exceptions.items[1].EventID)
+	assert.Equal(t, thursday(24).Add(time.Hour), exceptions.items[1].OccurrenceDate)
+}
+
+// Тест 19: Изменение всей серии сдвигает ее начало и изменения повторений, новое правило проверяется
+func TestUpdateOccurrence_All(t *testing.T) {
+	service, repo, _, exceptions := setupSeriesService()
+	ctx := context.Background()
+	exceptions.items = []model.EventException{{EventID: 1, OccurrenceDate: thursday(24), Cancelled: true}}
+	friday := thursday(18).Add(-time.Hour)
+
+	repo.On("GetById", ctx, 1).Return(storedSeries(), nil)
+
+	_, err := service.UpdateOccurrence(ctx, 1, 1, domain.OccurrenceUpdateRequest{
+		OccurrenceDate: thursday(17), Scope: "all", Version: 3, StartDate: &friday,
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidEventRecurrence)
+
+	repo.On("Update", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return event.StartDate.Equal(thursday(4).Add(-time.Hour)) && *event.RecurrenceRule == "FREQ=WEEKLY;BYDAY=FR;COUNT=4"
+	})).Return(4, nil)
+
+	resp, err := service.UpdateOccurrence(ctx, 1, 1, domain.OccurrenceUpdateRequest{
+		OccurrenceDate: thursday(17), Scope: "all", Version: 3, StartDate: &friday, RecurrenceRule: ptrTo("FREQ=WEEKLY;BYDAY=FR;COUNT=4"),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=FR;COUNT=4", resp.RecurrenceRule)
+	assert.Equal(t, thursday(25).Add(-time.Hour), exceptions.items[0].OccurrenceDate)
+}
+
+// Тест 20: Отмена повторения сохраняется как изменение, отмена следующих укорачивает серию,
+// а отменить так все повторения и несуществующее повторение нельзя
+func TestCancelOccurrence(t *testing.T) {
+	service, repo, _, exceptions := setupSeriesService()
+	ctx := context.Background()
+
+	repo.On("GetById", ctx, 1).Return(storedSeries(), nil)
+	repo.On("Update", ctx, mock.AnythingOfType("model.Event")).Return(4, nil).Once()
+
+	_, err := service.CancelOccurrence(ctx, 1, 1, domain.OccurrenceCancelRequest{OccurrenceDate: thursday(10), Scope: "this", Version: 3})
+	assert.NoError(t, err)
+	assert.True(t, exceptions.items[0].Cancelled)
+
+	repo.On("Update", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return *event.RecurrenceRule == "FREQ=WEEKLY;BYDAY=TH;COUNT=1"
+	})).Return(5, nil).Once()
+
+	resp, err := service.CancelOccurrence(ctx, 1, 1, domain.OccurrenceCancelRequest{OccurrenceDate: thursday(10), Scope: "following", Version: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, resp.Version)
+	assert.Empty(t, exceptions.items)
+
+	_, err = service.CancelOccurrence(ctx, 1, 1, domain.OccurrenceCancelRequest{OccurrenceDate: thursday(3), Scope: "following", Version: 3})
+	assert.ErrorIs(t, err, model.ErrInvalidEventRecurrence)
+
+	_, err = service.CancelOccurrence(ctx, 1, 1, domain.OccurrenceCancelRequest{OccurrenceDate: thursday(31), Scope: "this", Version: 3})
+	assert.ErrorIs(t, err, model.ErrOccurrenceNotFound)
+}
+
+// Тест 21: Увеличение ограничения участников переводит ожидающих на места и сообщает им об этом
+func TestUpdate_MaxParticipants(t *testing.T) {
+	service, repo, publisher := setupService()
+	ctx := context.Background()
+	event := storedEvent()
+	event.MaxParticipants = ptrTo(2)
+
+	repo.On("GetById", ctx, 1).Return(event, nil)
+	repo.On("Update", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return event.EventId == 1 && *event.MaxParticipants == 3
+	})).Return(4, nil)
+
+	resp, err := service.Update(ctx, 1, 1, domain.EventUpdateRequest{Version: 3, MaxParticipants: ptrTo(3)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ptrTo(3), resp.MaxParticipants)
+	assert.Len(t, publisher.messages, 3)
+	assert.Equal(t, []any{"max_participants"}, publisher.messages[0]["data"].(map[string]any)["changed_fields"])
+	assert.Equal(t, "participant_promoted", publisher.messages[2]["event"])
+	assert.Equal(t, "waiting@example.com", publisher.messages[2]["data"].(map[string]any)["user_email"])
+
+	// Повторное то же ограничение ничего не меняет, 0 снимает ограничение
+	repo.On("GetById", ctx, 2).Return(model.Event{EventId: 2, Location: ptrTo(""), Version: 1, MaxParticipants: ptrTo(3)}, nil)
+	resp, err = service.Update(ctx, 2, 1, domain.EventUpdateRequest{Version: 1, MaxParticipants: ptrTo(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, resp.Version)
+
+	repo.On("Update", ctx, mock.MatchedBy(func(event model.Event) bool {
+		return event.EventId == 2 && event.MaxParticipants == nil
+	})).Return(2, nil)
+	resp, err = service.Update(ctx, 2, 1, domain.EventUpdateRequest{Version: 1, MaxParticipants: ptrTo(0)})
+	assert.NoError(t, err)
+	assert.Nil(t, resp.MaxParticipants)
+}
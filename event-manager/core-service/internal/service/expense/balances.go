@@ -0,0 +1,86 @@
+package expense
+
+import (
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// GetUserBalances собирает долги пользователя по всем событиям, в которых он участвует.
+// Суммы каждого события указаны в его базовой валюте, итоги считаются отдельно по валютам
+func (s Service) GetUserBalances(ctx context.Context, userId int) (domain.UserBalancesResponse, error) {
+	rows, err := s.expenseShareRepo.GetUserCounterpartyBalances(ctx, userId)
+	if err != nil {
+		s.logger.Errorw("Failed to get user balances", "error", err, "userId", userId)
+		return domain.UserBalancesResponse{}, errors.WithMessage(err, "failed to get user balances")
+	}
+
+	resp := domain.UserBalancesResponse{
+		UserID: userId,
+		Totals: make([]domain.CurrencyBalanceResponse, 0),
+		Events: make([]domain.EventBalanceResponse, 0),
+	}
+
+	// Строки отсортированы по событиям, поэтому события собираются последовательно
+	totals := make(map[string]*domain.BalanceTotals)
+	for _, row := range rows {
+		if len(resp.Events) == 0 || resp.Events[len(resp.Events)-1].EventID != row.EventID {
+			resp.Events = append(resp.Events, domain.EventBalanceResponse{
+				EventID:        row.EventID,
+				EventTitle:     row.EventTitle,
+				Currency:       row.Currency,
+				Counterparties: make([]domain.CounterpartyBalanceResponse, 0),
+			})
+		}
+		event := &resp.Events[len(resp.Events)-1]
+
+		counterparty := counterpartyTotals(row)
+		event.Counterparties = append(event.Counterparties, domain.CounterpartyBalanceResponse{
+			UserID:        row.CounterpartyID,
+			Username:      row.Username,
+			BalanceTotals: counterparty,
+		})
+		event.BalanceTotals = addTotals(event.BalanceTotals, counterparty)
+
+		if totals[row.Currency] == nil {
+			totals[row.Currency] = &domain.BalanceTotals{}
+		}
+		*totals[row.Currency] = addTotals(*totals[row.Currency], counterparty)
+	}
+
+	for currency, total := range totals {
+		resp.Totals = append(resp.Totals, domain.CurrencyBalanceResponse{
+			Currency:      currency,
+			BalanceTotals: *total,
+		})
+	}
+	sort.Slice(resp.Totals, func(i, j int) bool {
+		return resp.Totals[i].Currency < resp.Totals[j].Currency
+	})
+
+	return resp, nil
+}
+
+// counterpartyTotals считает итог с участником: неоплаченные доли в обе стороны
+// плюс платежи, не закрывшие ни одной доли
+func counterpartyTotals(row model.CounterpartyBalance) domain.BalanceTotals {
+	return domain.BalanceTotals{
+		OwedToUser: row.OwedToUser,
+		UserOwes:   row.UserOwes,
+		PaidToUser: row.PaidToUser,
+		PaidByUser: row.PaidByUser,
+		Balance:    row.OwedToUser - row.UserOwes + row.Transfers,
+	}
+}
+
+func addTotals(a, b domain.BalanceTotals) domain.BalanceTotals {
+	return domain.BalanceTotals{
+		OwedToUser: a.OwedToUser + b.OwedToUser,
+		UserOwes:   a.UserOwes + b.UserOwes,
+		PaidToUser: a.PaidToUser + b.PaidToUser,
+		PaidByUser: a.PaidByUser + b.PaidByUser,
+		Balance:    a.Balance + b.Balance,
+	}
+}
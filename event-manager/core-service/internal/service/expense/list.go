@@ -0,0 +1,177 @@
+package expense
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	defaultSort     = "created_at_desc"
+)
+
+// expenseSorts допустимые значения параметра sort. По умолчанию — сначала новые
+var expenseSorts = map[string]struct {
+	sortBy string
+	desc   bool
+}{
+	"created_at_desc": {model.ExpenseSortCreatedAt, true},
+	"created_at_asc":  {model.ExpenseSortCreatedAt, false},
+	"amount_desc":     {model.ExpenseSortAmount, true},
+	"amount_asc":      {model.ExpenseSortAmount, false},
+}
+
+// ListExpenses возвращает страницу расходов события по фильтрам. Доли всех расходов страницы
+// загружаются одним запросом. NextCursor пуст, если страница последняя
+func (s Service) ListExpenses(ctx context.Context, req domain.ExpenseListRequest) (domain.ExpensesResponse, error) {
+	filter, err := expenseFilter(req)
+	if err != nil {
+		return domain.ExpensesResponse{}, err
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+
+	expenses, total, err := s.expenseRepo.ListExpenses(ctx, filter)
+	if err != nil {
+		s.logger.Errorw("Failed to list expenses", "error", err, "eventId", req.EventID)
+		return domain.ExpensesResponse{}, errors.WithMessage(err, "failed to list expenses")
+	}
+
+	var nextCursor string
+	if len(expenses) > limit {
+		expenses = expenses[:limit]
+		nextCursor, err = encodeCursor(expenseSort(req), expenses[limit-1])
+		if err != nil {
+			return domain.ExpensesResponse{}, err
+		}
+	}
+
+	ids := make([]int, len(expenses))
+	for i, expense := range expenses {
+		ids[i] = expense.ExpenseID
+	}
+
+	shares, err := s.expenseShareRepo.ListExpenseSharesByExpenseIds(ctx, ids)
+	if err != nil {
+		s.logger.Errorw("Failed to list expense shares", "error", err, "eventId", req.EventID)
+		return domain.ExpensesResponse{}, errors.WithMessage(err, "failed to list expense shares")
+	}
+
+	sharesByExpense := make(map[int][]model.ExpenseShare, len(expenses))
+	for _, share := range shares {
+		sharesByExpense[share.ExpenseID] = append(sharesByExpense[share.ExpenseID], share)
+	}
+
+	items := make([]domain.ExpenseResponse, len(expenses))
+	for i, expense := range expenses {
+		expenseShares := sharesByExpense[expense.ExpenseID]
+		if expenseShares == nil {
+			expenseShares = []model.ExpenseShare{}
+		}
+		items[i] = toExpenseResponse(expense, expenseShares)
+	}
+
+	return domain.ExpensesResponse{
+		Items:      items,
+		TotalCount: total,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+// expenseSort возвращает порядок сортировки запроса с учетом значения по умолчанию
+func expenseSort(req domain.ExpenseListRequest) string {
+	if _, ok := expenseSorts[req.Sort]; ok {
+		return req.Sort
+	}
+	return defaultSort
+}
+
+// expenseFilter переводит параметры запроса в фильтр репозитория. Курсор, выданный для другого порядка
+// сортировки, отклоняется
+func expenseFilter(req domain.ExpenseListRequest) (model.ExpenseFilter, error) {
+	filter := model.ExpenseFilter{
+		EventID:       req.EventID,
+		CreatedBy:     req.CreatedBy,
+		ParticipantID: req.ParticipantID,
+		CategoryID:    req.CategoryID,
+		Currency:      strings.ToUpper(req.Currency),
+		CreatedFrom:   req.DateFrom,
+		Limit:         req.Limit,
+	}
+
+	if req.DateTo != nil {
+		// Дата окончания входит в диапазон целиком
+		to := req.DateTo.AddDate(0, 0, 1)
+		filter.CreatedTo = &to
+	}
+
+	if req.Status != "" {
+		paid := req.Status == "paid"
+		filter.Paid = &paid
+	}
+
+	sort := expenseSort(req)
+	filter.SortBy = expenseSorts[sort].sortBy
+	filter.Desc = expenseSorts[sort].desc
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	filter.Limit = min(filter.Limit, maxPageSize)
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return model.ExpenseFilter{}, err
+		}
+		if cursor.Sort != sort {
+			return model.ExpenseFilter{}, errors.WithMessagef(model.ErrInvalidCursor, "cursor was issued for sort %s", cursor.Sort)
+		}
+		filter.After = &cursor
+	}
+
+	return filter, nil
+}
+
+func encodeCursor(sort string, expense model.Expense) (string, error) {
+	data, err := json.Marshal(model.ExpenseCursor{
+		Sort:       sort,
+		CreatedAt:  expense.CreatedAt,
+		BaseAmount: expense.BaseAmount,
+		ExpenseID:  expense.ExpenseID,
+	})
+	if err != nil {
+		return "", errors.WithMessage(err, "encode cursor")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(value string) (model.ExpenseCursor, error) {
+	var cursor model.ExpenseCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, err.Error())
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, err.Error())
+	}
+	if _, ok := expenseSorts[cursor.Sort]; !ok {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, "unknown cursor sort")
+	}
+	if cursor.ExpenseID <= 0 || cursor.CreatedAt.Equal(time.Time{}) {
+		return cursor, errors.WithMessage(model.ErrInvalidCursor, "missing cursor position")
+	}
+
+	return cursor, nil
+}
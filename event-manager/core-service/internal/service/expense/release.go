@@ -0,0 +1,145 @@
+package expense
+
+import (
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+)
+
+// ReleaseShares разбирается с неоплаченными долями пользователя в чужих расходах события, когда
+// он покидает событие. Без redistribute долги остаются за пользователем. С redistribute каждая доля
+// делится между неоплаченными долями остальных участников расхода пропорционально их суммам,
+// а доля, которую не с кем разделить, остается за пользователем. Изменения долей записываются
+// в журнал расхода от имени changedBy
+func (s Service) ReleaseShares(ctx context.Context, eventId, userId, changedBy int, redistribute bool) (domain.RemovedSharesSummary, error) {
+	summary := domain.RemovedSharesSummary{
+		Policy:        domain.RemovalSharesKeep,
+		Kept:          make([]int, 0),
+		Redistributed: make([]int, 0),
+	}
+	if redistribute {
+		summary.Policy = domain.RemovalSharesRedistribute
+	}
+
+	baseCurrency, err := s.converter.BaseCurrency(ctx, eventId)
+	if err != nil {
+		return summary, errors.WithMessage(err, "get base currency")
+	}
+	summary.Currency = baseCurrency
+
+	released, err := s.expenseShareRepo.ListUnpaidSharesByUser(ctx, eventId, userId)
+	if err != nil {
+		return summary, errors.WithMessage(err, "list unpaid shares")
+	}
+
+	for _, share := range released {
+		if redistribute {
+			ok, err := s.redistributeShare(ctx, share, baseCurrency, changedBy)
+			if err != nil {
+				return summary, err
+			}
+			if ok {
+				summary.Redistributed = append(summary.Redistributed, share.ExpenseID)
+				summary.RedistributedAmount += share.BaseAmount
+				continue
+			}
+		}
+
+		summary.Kept = append(summary.Kept, share.ShareID)
+		summary.KeptAmount += share.BaseAmount
+	}
+
+	return summary, nil
+}
+
+// redistributeShare переносит долю на остальных участников расхода и удаляет ее.
+// Возвращает false, если у расхода нет других неоплаченных долей
+func (s Service) redistributeShare(ctx context.Context, released model.ExpenseShare, baseCurrency string, changedBy int) (bool, error) {
+	expense, err := s.expenseRepo.GetExpenseById(ctx, released.ExpenseID)
+	if err != nil {
+		return false, errors.WithMessage(err, "get expense")
+	}
+
+	oldShares, err := s.expenseShareRepo.ListExpenseSharesByExpenseId(ctx, released.ExpenseID)
+	if err != nil {
+		return false, errors.WithMessage(err, "list expense shares")
+	}
+
+	shares, recipients := spreadShare(*expense, oldShares, released, baseCurrency)
+	if len(recipients) == 0 {
+		return false, nil
+	}
+
+	for _, i := range recipients {
+		if err := s.expenseShareRepo.UpdateExpenseShare(ctx, shares[i]); err != nil {
+			return false, errors.WithMessage(err, "update expense share")
+		}
+	}
+	if err := s.expenseShareRepo.DeleteExpenseShare(ctx, released.ShareID); err != nil {
+		return false, errors.WithMessage(err, "delete expense share")
+	}
+
+	_, err = s.historyRepo.Create(ctx, model.ExpenseHistory{
+		ExpenseID: released.ExpenseID,
+		ChangedBy: changedBy,
+		Changes:   expenseChanges(*expense, *expense, oldShares, shares),
+	})
+	if err != nil {
+		return false, errors.WithMessage(err, "create expense history")
+	}
+
+	return true, nil
+}
+
+// spreadShare делит сумму доли released между неоплаченными долями остальных участников расхода
+// пропорционально их суммам. Суммы в валюте расхода и в базовой валюте распределяются отдельно,
+// чтобы итоги расхода в обеих валютах не изменились. Процент и точная сумма получателей пересчитываются,
+// веса долей остаются прежними. Возвращает доли без released и индексы измененных
+func spreadShare(expense model.Expense, oldShares []model.ExpenseShare, released model.ExpenseShare, baseCurrency string) ([]model.ExpenseShare, []int) {
+	shares := make([]model.ExpenseShare, 0, len(oldShares))
+	recipients := make([]int, 0)
+	weights := make([]float64, 0)
+	for _, share := range oldShares {
+		if share.ShareID == released.ShareID {
+			continue
+		}
+		if !share.IsPaid && share.Amount > 0 {
+			recipients = append(recipients, len(shares))
+			weights = append(weights, float64(share.Amount))
+		}
+		shares = append(shares, share)
+	}
+	if len(recipients) == 0 {
+		return shares, recipients
+	}
+
+	unit := model.MinorUnit(expense.Currency)
+	baseUnit := model.MinorUnit(baseCurrency)
+	amounts := distribute(int64(released.Amount/unit), weights)
+	baseAmounts := distribute(int64(released.BaseAmount/baseUnit), weights)
+
+	var sumWeights float64
+	for _, w := range weights {
+		sumWeights += w
+	}
+
+	for i, idx := range recipients {
+		share := &shares[idx]
+		share.Amount += model.Money(amounts[i]) * unit
+		share.BaseAmount += model.Money(baseAmounts[i]) * baseUnit
+
+		switch expense.SplitMethod {
+		case model.SplitMethodPercent:
+			if share.SplitValue != nil && released.SplitValue != nil {
+				value := *share.SplitValue + *released.SplitValue*weights[i]/sumWeights
+				share.SplitValue = &value
+			}
+		case model.SplitMethodExact:
+			value := share.Amount.Float64()
+			share.SplitValue = &value
+		}
+	}
+
+	return shares, recipients
+}
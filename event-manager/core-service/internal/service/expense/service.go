@@ -6,183 +6,498 @@ import (
 	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"math"
+	"strings"
+	"time"
 )
 
 type ExpenseRepository interface {
 	CreateExpense(ctx context.Context, expense model.Expense) (int, error)
 	GetExpenseById(ctx context.Context, id int) (*model.Expense, error)
-	ListExpensesByEventId(ctx context.Context, eventId int, limit, offset int) ([]model.Expense, int, error)
-	GetEventTotalExpenses(ctx context.Context, eventId int) (float64, error)
+	UpdateExpense(ctx context.Context, expense model.Expense) error
+	ListExpenses(ctx context.Context, filter model.ExpenseFilter) ([]model.Expense, int, error)
+	GetEventTotalExpenses(ctx context.Context, eventId int) (model.Money, error)
 	DeleteExpense(ctx context.Context, id int) error
 }
 
 type ExpenseShareRepository interface {
 	CreateExpenseShare(ctx context.Context, share model.ExpenseShare) (int, error)
 	ListExpenseSharesByExpenseId(ctx context.Context, expenseId int) ([]model.ExpenseShare, error)
+	ListExpenseSharesByExpenseIds(ctx context.Context, expenseIds []int) ([]model.ExpenseShare, error)
+	GetExpenseShareById(ctx context.Context, id int) (*model.ExpenseShare, error)
 	UpdateExpenseSharePaidStatus(ctx context.Context, shareId int, isPaid bool) error
+	UpdateExpenseShare(ctx context.Context, share model.ExpenseShare) error
+	DeleteExpenseShare(ctx context.Context, shareId int) error
 	DeleteExpenseSharesByExpenseId(ctx context.Context, expenseId int) error
 	GetEventBalanceReport(ctx context.Context, eventId int) ([]domain.UserBalance, error)
+	GetUserCounterpartyBalances(ctx context.Context, userId int) ([]model.CounterpartyBalance, error)
+	ListUnpaidSharesByUser(ctx context.Context, eventId, userId int) ([]model.ExpenseShare, error)
+}
+
+type ExpenseHistoryRepository interface {
+	Create(ctx context.Context, history model.ExpenseHistory) (int, error)
+	ListByExpense(ctx context.Context, expenseId int) ([]model.ExpenseHistory, error)
+}
+
+// AttachmentService вложения расхода, файлы которых удаляются вместе с ним
+type AttachmentService interface {
+	ListByExpense(ctx context.Context, expenseId int) ([]model.Attachment, error)
+	RemoveFiles(ctx context.Context, attachments []model.Attachment)
+}
+
+// BudgetService категории и лимиты расходов события
+type BudgetService interface {
+	CheckCategory(ctx context.Context, eventId, categoryId int) error
+	NotifyBudget(ctx context.Context, eventId int)
+}
+
+type CurrencyConverter interface {
+	BaseCurrency(ctx context.Context, eventId int) (string, error)
+	Convert(ctx context.Context, eventId int, amount model.Money, currency string) (model.Money, float64, error)
+}
+
+type EventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type Service struct {
 	expenseRepo      ExpenseRepository
 	expenseShareRepo ExpenseShareRepository
+	historyRepo      ExpenseHistoryRepository
+	attachments      AttachmentService
+	budget           BudgetService
+	converter        CurrencyConverter
+	eventRepo        EventRepo
+	transactor       Transactor
 	logger           *zap.SugaredLogger
 }
 
-func NewService(expenseRepo ExpenseRepository, expenseShareRepo ExpenseShareRepository, logger *zap.SugaredLogger) Service {
+func NewService(expenseRepo ExpenseRepository, expenseShareRepo ExpenseShareRepository, historyRepo ExpenseHistoryRepository, attachments AttachmentService, budget BudgetService, converter CurrencyConverter, eventRepo EventRepo, transactor Transactor, logger *zap.SugaredLogger) Service {
 	return Service{
 		expenseRepo:      expenseRepo,
 		expenseShareRepo: expenseShareRepo,
+		historyRepo:      historyRepo,
+		attachments:      attachments,
+		budget:           budget,
+		converter:        converter,
+		eventRepo:        eventRepo,
+		transactor:       transactor,
 		logger:           logger,
 	}
 }
 
 func (s Service) CreateExpense(ctx context.Context, req domain.ExpenseCreateRequest) (int, error) {
+	currency := strings.ToUpper(req.Currency)
+	amount := req.Amount.Round(currency)
+	if amount <= 0 {
+		return 0, errors.WithMessagef(model.ErrInvalidExpenseSplit, "amount is less than the minor unit of %s", currency)
+	}
+
+	// Проверяем корректность разделения до создания расхода
+	hasShares := len(req.UserIDs) > 0 || len(req.Shares) > 0
+	if hasShares {
+		if _, err := splitExpense(amount, currency, req.SplitMethod, req.UserIDs, req.Shares); err != nil {
+			return 0, errors.WithMessage(err, "split expense")
+		}
+	}
+
+	if req.CategoryID != nil {
+		if err := s.budget.CheckCategory(ctx, req.EventID, *req.CategoryID); err != nil {
+			return 0, errors.WithMessage(err, "check expense category")
+		}
+	}
+
 	expense := model.Expense{
 		EventID:     req.EventID,
 		Description: req.Description,
-		Amount:      req.Amount,
-		Currency:    req.Currency,
+		Amount:      amount,
+		Currency:    currency,
 		CreatedBy:   req.CreatedBy,
 		SplitMethod: req.SplitMethod,
+		CategoryID:  req.CategoryID,
+	}
+
+	id, err := s.createExpense(ctx, expense, req.UserIDs, req.Shares)
+	if err != nil {
+		return 0, err
+	}
+
+	s.budget.NotifyBudget(ctx, req.EventID)
+
+	return id, nil
+}
+
+// CreateOccurrence создает расход повторяющегося расхода за дату date. Лимиты бюджета не проверяются:
+// это делает вызывающий после фиксации транзакции
+func (s Service) CreateOccurrence(ctx context.Context, recurring model.RecurringExpense, date time.Time) (int, error) {
+	weights := make([]domain.ExpenseShareRequest, len(recurring.Split.Shares))
+	for i, share := range recurring.Split.Shares {
+		weights[i] = domain.ExpenseShareRequest{UserID: share.UserID, Value: share.Value}
+	}
+
+	expense := model.Expense{
+		EventID:     recurring.EventID,
+		Description: recurring.Description,
+		Amount:      recurring.Amount,
+		Currency:    recurring.Currency,
+		CreatedBy:   recurring.CreatedBy,
+		SplitMethod: recurring.SplitMethod,
+		CategoryID:  recurring.CategoryID,
+		RecurringID: &recurring.RecurringID,
+		Occurrence:  &date,
+	}
+
+	return s.createExpense(ctx, expense, recurring.Split.UserIDs, weights)
+}
+
+// ValidateSplit проверяет, что расход с такой суммой можно разделить между участниками указанным методом
+func (s Service) ValidateSplit(amount model.Money, currency, splitMethod string, userIds []int, weights []domain.ExpenseShareRequest) error {
+	if _, err := splitExpense(amount, currency, splitMethod, userIds, weights); err != nil {
+		return errors.WithMessage(err, "split expense")
+	}
+
+	return nil
+}
+
+// createExpense конвертирует сумму расхода в базовую валюту события и сохраняет расход вместе с долями.
+// В архивное событие расходы не добавляются
+func (s Service) createExpense(ctx context.Context, expense model.Expense, userIds []int, weights []domain.ExpenseShareRequest) (int, error) {
+	if err := s.checkWritable(ctx, expense.EventID); err != nil {
+		return 0, err
 	}
 
-	id, err := s.expenseRepo.CreateExpense(ctx, expense)
+	// Фиксируем курс на момент создания, чтобы последующие изменения курсов не меняли балансы
+	var err error
+	expense.BaseAmount, expense.ExchangeRate, err = s.converter.Convert(ctx, expense.EventID, expense.Amount, expense.Currency)
 	if err != nil {
-		s.logger.Errorw("Failed to create expense", "error", err, "eventId", req.EventID)
-		return 0, errors.WithMessage(err, "create expense")
+		s.logger.Errorw("Failed to convert expense amount", "error", err, "eventId", expense.EventID, "currency", expense.Currency)
+		return 0, errors.WithMessage(err, "convert expense amount")
 	}
 
-	// Создаем доли расходов для участников
-	if len(req.UserIDs) > 0 {
-		err = s.CreateExpenseShares(ctx, id, req.UserIDs, req.SplitMethod)
+	// Расход и его доли создаются атомарно
+	var id int
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.expenseRepo.CreateExpense(ctx, expense)
+		if err != nil {
+			s.logger.Errorw("Failed to create expense", "error", err, "eventId", expense.EventID)
+			return errors.WithMessage(err, "create expense")
+		}
+
+		if len(userIds) == 0 && len(weights) == 0 {
+			return nil
+		}
+
+		err = s.CreateExpenseShares(ctx, id, userIds, expense.SplitMethod, weights)
 		if err != nil {
 			s.logger.Errorw("Failed to create expense shares", "error", err, "expenseId", id)
-			// Мы не возвращаем ошибку, так как расход уже создан успешно
+			return errors.WithMessage(err, "create expense shares")
 		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return id, nil
 }
 
-func (s Service) CreateExpenseShares(ctx context.Context, expenseId int, userIds []int, splitMethod string) error {
+// checkWritable возвращает model.ErrEventArchived, если расходы события доступны только для чтения
+func (s Service) checkWritable(ctx context.Context, eventId int) error {
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for expense", "error", err, "eventId", eventId)
+		return errors.WithMessage(err, "get event")
+	}
+	if event.Status.ReadOnly() {
+		return model.ErrEventArchived
+	}
+
+	return nil
+}
+
+// CheckExpenseWritable возвращает model.ErrEventArchived, если событие расхода доступно только для чтения.
+// Используется перед изменением вложений расхода
+func (s Service) CheckExpenseWritable(ctx context.Context, expenseId int) error {
+	expense, err := s.expenseRepo.GetExpenseById(ctx, expenseId)
+	if err != nil {
+		s.logger.Errorw("Failed to get expense", "error", err, "id", expenseId)
+		return errors.WithMessage(err, "failed to get expense")
+	}
+
+	return s.checkWritable(ctx, expense.EventID)
+}
+
+// CreateExpenseShares пересоздает доли расхода в одной транзакции. Для метода equal используются userIds,
+// для percent, exact и shares — веса участников из weights
+func (s Service) CreateExpenseShares(ctx context.Context, expenseId int, userIds []int, splitMethod string, weights []domain.ExpenseShareRequest) error {
 	// Получаем расход для определения суммы для разделения
 	expense, err := s.expenseRepo.GetExpenseById(ctx, expenseId)
 	if err != nil {
 		return errors.WithMessage(err, "failed to get expense for creating shares")
 	}
 
-	// Удаляем существующие доли для этого расхода
-	err = s.expenseShareRepo.DeleteExpenseSharesByExpenseId(ctx, expenseId)
+	shares, err := splitExpense(expense.Amount, expense.Currency, splitMethod, userIds, weights)
 	if err != nil {
-		return errors.WithMessage(err, "failed to delete existing expense shares")
+		return errors.WithMessage(err, "split expense")
 	}
 
-	// Создаем доли в зависимости от метода разделения
-	switch splitMethod {
-	case model.SplitMethodEqual, model.SplitMethodPercent:
-		// Для equal и percent (пока) реализуем как равное разделение
-		if len(userIds) == 0 {
-			return errors.New("cannot split expense with no participants")
+	baseCurrency, err := s.converter.BaseCurrency(ctx, expense.EventID)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get event base currency")
+	}
+	convertShares(shares, expense.BaseAmount, baseCurrency)
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// Удаляем существующие доли для этого расхода
+		err := s.expenseShareRepo.DeleteExpenseSharesByExpenseId(ctx, expenseId)
+		if err != nil {
+			return errors.WithMessage(err, "failed to delete existing expense shares")
 		}
 
-		shareAmount := expense.Amount / float64(len(userIds))
-		// Округляем до 2 знаков после запятой
-		shareAmount = math.Round(shareAmount*100) / 100
+		for _, share := range shares {
+			share.ExpenseID = expenseId
 
-		for _, userId := range userIds {
-			share := model.ExpenseShare{
-				ExpenseID: expenseId,
-				UserID:    userId,
-				Amount:    shareAmount,
-				IsPaid:    false,
+			_, err := s.expenseShareRepo.CreateExpenseShare(ctx, share)
+			if err != nil {
+				return errors.WithMessagef(err, "failed to create expense share for user %d", share.UserID)
 			}
+		}
 
-			_, err := s.expenseShareRepo.CreateExpenseShare(ctx, share)
+		return nil
+	})
+}
+
+// UpdateExpense редактирует расход и пересчитывает его доли, если изменились сумма, валюта, метод
+// разделения или участники. Оплаченная доля сохраняет статус, если ее сумма в базовой валюте не изменилась;
+// правка, уменьшающая или удаляющая оплаченную долю, отклоняется. Изменения записываются в журнал расхода.
+// Расходы архивного события не редактируются
+func (s Service) UpdateExpense(ctx context.Context, id, userId int, req domain.ExpenseUpdateRequest) (*domain.ExpenseResponse, error) {
+	var resp domain.ExpenseResponse
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		expense, err := s.expenseRepo.GetExpenseById(ctx, id)
+		if err != nil {
+			return errors.WithMessage(err, "get expense")
+		}
+		if err := s.checkWritable(ctx, expense.EventID); err != nil {
+			return err
+		}
+
+		oldShares, err := s.expenseShareRepo.ListExpenseSharesByExpenseId(ctx, id)
+		if err != nil {
+			s.logger.Errorw("Failed to list expense shares for update", "error", err, "expenseId", id)
+			return errors.WithMessage(err, "list expense shares")
+		}
+
+		updated := *expense
+		if req.Description != nil {
+			updated.Description = *req.Description
+		}
+		if req.Amount != nil {
+			updated.Amount = *req.Amount
+		}
+		if req.Currency != nil {
+			updated.Currency = strings.ToUpper(*req.Currency)
+		}
+		if req.SplitMethod != nil {
+			updated.SplitMethod = *req.SplitMethod
+		}
+		if req.CategoryID != nil && *req.CategoryID == 0 {
+			updated.CategoryID = nil
+		} else if req.CategoryID != nil {
+			if err := s.budget.CheckCategory(ctx, expense.EventID, *req.CategoryID); err != nil {
+				return errors.WithMessage(err, "check expense category")
+			}
+			updated.CategoryID = req.CategoryID
+		}
+
+		updated.Amount = updated.Amount.Round(updated.Currency)
+		if updated.Amount <= 0 {
+			return errors.WithMessagef(model.ErrInvalidExpenseSplit, "amount is less than the minor unit of %s", updated.Currency)
+		}
+
+		baseCurrency, err := s.converter.BaseCurrency(ctx, expense.EventID)
+		if err != nil {
+			return errors.WithMessage(err, "get event base currency")
+		}
+
+		// При смене валюты берется текущий курс, иначе сохраняется курс, зафиксированный при создании
+		if updated.Currency != expense.Currency {
+			updated.BaseAmount, updated.ExchangeRate, err = s.converter.Convert(ctx, expense.EventID, updated.Amount, updated.Currency)
 			if err != nil {
-				s.logger.Warnw("Failed to create expense share", "error", err, "expenseId", expenseId, "userId", userId)
-				// Продолжаем, даже если одна доля не создалась
+				s.logger.Errorw("Failed to convert expense amount", "error", err, "expenseId", id, "currency", updated.Currency)
+				return errors.WithMessage(err, "convert expense amount")
+			}
+		} else {
+			updated.BaseAmount = updated.Amount.MulRate(expense.ExchangeRate).Round(baseCurrency)
+		}
+
+		shares := oldShares
+		resplit := req.Amount != nil || req.Currency != nil || req.SplitMethod != nil || req.UserIDs != nil || req.Shares != nil
+		if resplit && (len(oldShares) > 0 || req.UserIDs != nil || req.Shares != nil) {
+			userIds, weights := splitParticipants(req, updated.SplitMethod, expense.SplitMethod, oldShares)
+			shares, err = splitExpense(updated.Amount, updated.Currency, updated.SplitMethod, userIds, weights)
+			if err != nil {
+				return errors.WithMessage(err, "split expense")
+			}
+			convertShares(shares, updated.BaseAmount, baseCurrency)
+
+			if err := s.saveShares(ctx, id, oldShares, shares); err != nil {
+				return err
 			}
 		}
 
-	case model.SplitMethodExact:
-		// Для точных сумм нам нужны дополнительные данные
-		// Пока возвращаем ошибку
-		return errors.New("exact split method requires additional data")
+		changes := expenseChanges(*expense, updated, oldShares, shares)
+		if len(changes) > 0 {
+			if err := s.expenseRepo.UpdateExpense(ctx, updated); err != nil {
+				s.logger.Errorw("Failed to update expense", "error", err, "expenseId", id)
+				return errors.WithMessage(err, "update expense")
+			}
 
-	default:
-		return errors.Errorf("unsupported split method: %s", splitMethod)
+			_, err := s.historyRepo.Create(ctx, model.ExpenseHistory{
+				ExpenseID: id,
+				ChangedBy: userId,
+				Changes:   changes,
+			})
+			if err != nil {
+				s.logger.Errorw("Failed to record expense history", "error", err, "expenseId", id)
+				return errors.WithMessage(err, "create expense history")
+			}
+		}
+
+		resp = toExpenseResponse(updated, shares)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	s.budget.NotifyBudget(ctx, resp.EventID)
+
+	return &resp, nil
 }
 
-func (s Service) ListExpensesByEvent(ctx context.Context, eventId int, page, size int) (domain.ExpensesResponse, error) {
-	offset := (page - 1) * size
-	expenses, total, err := s.expenseRepo.ListExpensesByEventId(ctx, eventId, size, offset)
+// saveShares заменяет доли расхода пересчитанными, сохраняя id и статус оплаты совпадающих долей
+func (s Service) saveShares(ctx context.Context, expenseId int, oldShares, shares []model.ExpenseShare) error {
+	deleted, err := mergeShares(oldShares, shares)
 	if err != nil {
-		s.logger.Errorw("Failed to list expenses by event", "error", err, "eventId", eventId)
-		return domain.ExpensesResponse{}, errors.WithMessage(err, "failed to list expenses")
+		return err
 	}
 
-	// Преобразуем в ответ
-	expenseResponses := make([]domain.ExpenseResponse, len(expenses))
-	for i, expense := range expenses {
-		// Получаем доли для каждого расхода
-		shares, err := s.expenseShareRepo.ListExpenseSharesByExpenseId(ctx, expense.ExpenseID)
+	for _, shareId := range deleted {
+		if err := s.expenseShareRepo.DeleteExpenseShare(ctx, shareId); err != nil {
+			return errors.WithMessagef(err, "failed to delete expense share %d", shareId)
+		}
+	}
+
+	for i := range shares {
+		shares[i].ExpenseID = expenseId
+		if shares[i].ShareID != 0 {
+			if err := s.expenseShareRepo.UpdateExpenseShare(ctx, shares[i]); err != nil {
+				return errors.WithMessagef(err, "failed to update expense share for user %d", shares[i].UserID)
+			}
+			continue
+		}
+
+		id, err := s.expenseShareRepo.CreateExpenseShare(ctx, shares[i])
 		if err != nil {
-			s.logger.Warnw("Failed to get expense shares", "error", err, "expenseId", expense.ExpenseID)
-			shares = []model.ExpenseShare{}
+			return errors.WithMessagef(err, "failed to create expense share for user %d", shares[i].UserID)
 		}
+		shares[i].ShareID = id
+	}
+
+	return nil
+}
 
-		expenseResponses[i] = domain.ExpenseResponse{
-			ExpenseID:   expense.ExpenseID,
-			EventID:     expense.EventID,
-			Description: expense.Description,
-			Amount:      expense.Amount,
-			Currency:    expense.Currency,
-			CreatedBy:   expense.CreatedBy,
-			SplitMethod: expense.SplitMethod,
-			CreatedAt:   expense.CreatedAt,
-			Shares:      shares,
+func (s Service) GetExpenseHistory(ctx context.Context, expenseId int) (domain.ExpenseHistoryResponse, error) {
+	history, err := s.historyRepo.ListByExpense(ctx, expenseId)
+	if err != nil {
+		s.logger.Errorw("Failed to list expense history", "error", err, "expenseId", expenseId)
+		return domain.ExpenseHistoryResponse{}, errors.WithMessage(err, "failed to list expense history")
+	}
+
+	items := make([]domain.ExpenseHistoryEntry, len(history))
+	for i, entry := range history {
+		items[i] = domain.ExpenseHistoryEntry{
+			HistoryID: entry.HistoryID,
+			ChangedBy: entry.ChangedBy,
+			ChangedAt: entry.ChangedAt,
+			Changes:   entry.Changes,
 		}
 	}
 
-	return domain.ExpensesResponse{
-		Items:      expenseResponses,
-		TotalCount: total,
+	return domain.ExpenseHistoryResponse{
+		ExpenseID: expenseId,
+		Items:     items,
 	}, nil
 }
 
+// DeleteExpense удаляет расход вместе с долями и вложениями. Расходы архивного события не удаляются
 func (s Service) DeleteExpense(ctx context.Context, id int) error {
 	// Сначала проверяем, существует ли расход
-	_, err := s.expenseRepo.GetExpenseById(ctx, id)
+	expense, err := s.expenseRepo.GetExpenseById(ctx, id)
 	if err != nil {
 		s.logger.Errorw("Failed to get expense for deletion", "error", err, "id", id)
 		return errors.WithMessage(err, "failed to get expense")
 	}
-
-	// Удаляем все доли расхода
-	err = s.expenseShareRepo.DeleteExpenseSharesByExpenseId(ctx, id)
-	if err != nil {
-		s.logger.Errorw("Failed to delete expense shares", "error", err, "expenseId", id)
-		// Продолжаем, так как хотим попробовать удалить сам расход
+	if err := s.checkWritable(ctx, expense.EventID); err != nil {
+		return err
 	}
 
-	// Удаляем расход
-	err = s.expenseRepo.DeleteExpense(ctx, id)
+	// Доли, вложения и сам расход удаляются атомарно, а файлы вложений — после фиксации транзакции
+	var attachments []model.Attachment
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		attachments, err = s.attachments.ListByExpense(ctx, id)
+		if err != nil {
+			s.logger.Errorw("Failed to list expense attachments", "error", err, "expenseId", id)
+			return errors.WithMessage(err, "failed to list expense attachments")
+		}
+
+		err = s.expenseShareRepo.DeleteExpenseSharesByExpenseId(ctx, id)
+		if err != nil {
+			s.logger.Errorw("Failed to delete expense shares", "error", err, "expenseId", id)
+			return errors.WithMessage(err, "failed to delete expense shares")
+		}
+
+		err = s.expenseRepo.DeleteExpense(ctx, id)
+		if err != nil {
+			s.logger.Errorw("Failed to delete expense", "error", err, "id", id)
+			return errors.WithMessage(err, "failed to delete expense")
+		}
+
+		return nil
+	})
 	if err != nil {
-		s.logger.Errorw("Failed to delete expense", "error", err, "id", id)
-		return errors.WithMessage(err, "failed to delete expense")
+		return err
 	}
 
+	s.attachments.RemoveFiles(ctx, attachments)
+	s.budget.NotifyBudget(ctx, expense.EventID)
+
 	return nil
 }
 
+// UpdateExpenseSharePaidStatus меняет статус оплаты доли. Доли расходов архивного события не меняются
 func (s Service) UpdateExpenseSharePaidStatus(ctx context.Context, shareId int, isPaid bool) error {
-	err := s.expenseShareRepo.UpdateExpenseSharePaidStatus(ctx, shareId, isPaid)
+	share, err := s.expenseShareRepo.GetExpenseShareById(ctx, shareId)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get expense share")
+	}
+
+	expense, err := s.expenseRepo.GetExpenseById(ctx, share.ExpenseID)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get expense")
+	}
+	if err := s.checkWritable(ctx, expense.EventID); err != nil {
+		return err
+	}
+
+	err = s.expenseShareRepo.UpdateExpenseSharePaidStatus(ctx, shareId, isPaid)
 	if err != nil {
 		s.logger.Errorw("Failed to update expense share paid status", "error", err, "shareId", shareId)
 		return errors.WithMessage(err, "failed to update expense share paid status")
@@ -206,77 +521,34 @@ func (s Service) GetEventBalanceReport(ctx context.Context, eventId int) (domain
 		return domain.BalanceReportResponse{}, errors.WithMessage(err, "failed to get event balance report")
 	}
 
-	// Преобразуем в доменную модель и добавляем дополнительную информацию
-	userBalances := make([]domain.UserBalance, len(balances))
-
-	for i, balance := range balances {
-		// Базовая информация о балансе
-		userBalances[i] = domain.UserBalance{
-			UserID:   balance.UserID,
-			Username: balance.Username,
-			Balance:  balance.Balance,
-		}
-
-		// Получаем список всех долей пользователя в этом событии
-		shares, err := s.getDetailedUserShares(ctx, eventId, balance.UserID)
-		if err != nil {
-			// Логируем ошибку, но продолжаем выполнение
-			s.logger.Warnw("Failed to get detailed shares for user", "error", err, "eventId", eventId, "userId", balance.UserID)
-		} else {
-			// Добавляем статистику по долям
-			userBalances[i].PaidAmount = shares.PaidAmount
-			userBalances[i].UnpaidAmount = shares.UnpaidAmount
-			userBalances[i].TotalDue = shares.TotalDue
-		}
+	currency, err := s.converter.BaseCurrency(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event base currency for report", "error", err, "eventId", eventId)
+		return domain.BalanceReportResponse{}, errors.WithMessage(err, "failed to get event base currency")
 	}
 
 	return domain.BalanceReportResponse{
 		EventID:      eventId,
+		Currency:     currency,
 		TotalAmount:  totalAmount,
-		UserBalances: userBalances,
+		UserBalances: balances,
 	}, nil
 }
 
-// Дополнительная структура для хранения детальной информации о долях пользователя
-type userShareDetails struct {
-	PaidAmount   float64
-	UnpaidAmount float64
-	TotalDue     float64
-}
-
-// Вспомогательный метод для получения детальной информации о долях пользователя
-func (s Service) getDetailedUserShares(ctx context.Context, eventId, userId int) (userShareDetails, error) {
-	// Получаем все расходы события
-	expenses, _, err := s.expenseRepo.ListExpensesByEventId(ctx, eventId, 1000, 0)
-	if err != nil {
-		return userShareDetails{}, errors.WithMessage(err, "list expenses by event")
-	}
-
-	var result userShareDetails
-
-	// Обрабатываем каждый расход
-	for _, expense := range expenses {
-		// Получаем доли для этого расхода
-		shares, err := s.expenseShareRepo.ListExpenseSharesByExpenseId(ctx, expense.ExpenseID)
-		if err != nil {
-			s.logger.Warnw("Failed to get expense shares", "error", err, "expenseId", expense.ExpenseID)
-			continue
-		}
-
-		// Находим долю текущего пользователя
-		for _, share := range shares {
-			if share.UserID == userId {
-				// Считаем оплаченные и неоплаченные суммы
-				if share.IsPaid {
-					result.PaidAmount += share.Amount
-				} else {
-					result.UnpaidAmount += share.Amount
-				}
-				result.TotalDue += share.Amount
-				break
-			}
-		}
+func toExpenseResponse(expense model.Expense, shares []model.ExpenseShare) domain.ExpenseResponse {
+	return domain.ExpenseResponse{
+		ExpenseID:    expense.ExpenseID,
+		EventID:      expense.EventID,
+		Description:  expense.Description,
+		Amount:       expense.Amount,
+		Currency:     expense.Currency,
+		ExchangeRate: expense.ExchangeRate,
+		BaseAmount:   expense.BaseAmount,
+		CreatedBy:    expense.CreatedBy,
+		SplitMethod:  expense.SplitMethod,
+		CategoryID:   expense.CategoryID,
+		RecurringID:  expense.RecurringID,
+		CreatedAt:    expense.CreatedAt,
+		Shares:       shares,
 	}
-
-	return result, nil
 }
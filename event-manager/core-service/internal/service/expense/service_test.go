@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	"testing"
+	"time"
 )
 
 // Mock репозитория расходов
@@ -26,14 +27,19 @@ func (m *MockExpenseRepository) GetExpenseById(ctx context.Context, id int) (*mo
 	return args.Get(0).(*model.Expense), args.Error(1)
 }
 
-func (m *MockExpenseRepository) ListExpensesByEventId(ctx context.Context, eventId int, limit, offset int) ([]model.Expense, int, error) {
-	args := m.Called(ctx, eventId, limit, offset)
+func (m *MockExpenseRepository) ListExpenses(ctx context.Context, filter model.ExpenseFilter) ([]model.Expense, int, error) {
+	args := m.Called(ctx, filter)
 	return args.Get(0).([]model.Expense), args.Int(1), args.Error(2)
 }
 
-func (m *MockExpenseRepository) GetEventTotalExpenses(ctx context.Context, eventId int) (float64, error) {
+func (m *MockExpenseRepository) GetEventTotalExpenses(ctx context.Context, eventId int) (model.Money, error) {
 	args := m.Called(ctx, eventId)
-	return args.Get(0).(float64), args.Error(1)
+	return args.Get(0).(model.Money), args.Error(1)
+}
+
+func (m *MockExpenseRepository) UpdateExpense(ctx context.Context, expense model.Expense) error {
+	args := m.Called(ctx, expense)
+	return args.Error(0)
 }
 
 func (m *MockExpenseRepository) DeleteExpense(ctx context.Context, id int) error {
@@ -56,30 +62,139 @@ func (m *MockExpenseShareRepository) ListExpenseSharesByExpenseId(ctx context.Co
 	return args.Get(0).([]model.ExpenseShare), args.Error(1)
 }
 
+func (m *MockExpenseShareRepository) ListExpenseSharesByExpenseIds(ctx context.Context, expenseIds []int) ([]model.ExpenseShare, error) {
+	args := m.Called(ctx, expenseIds)
+	return args.Get(0).([]model.ExpenseShare), args.Error(1)
+}
+
+func (m *MockExpenseShareRepository) GetExpenseShareById(ctx context.Context, id int) (*model.ExpenseShare, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*model.ExpenseShare), args.Error(1)
+}
+
 func (m *MockExpenseShareRepository) UpdateExpenseSharePaidStatus(ctx context.Context, shareId int, isPaid bool) error {
 	args := m.Called(ctx, shareId, isPaid)
 	return args.Error(0)
 }
 
+func (m *MockExpenseShareRepository) UpdateExpenseShare(ctx context.Context, share model.ExpenseShare) error {
+	args := m.Called(ctx, share)
+	return args.Error(0)
+}
+
+func (m *MockExpenseShareRepository) DeleteExpenseShare(ctx context.Context, shareId int) error {
+	args := m.Called(ctx, shareId)
+	return args.Error(0)
+}
+
 func (m *MockExpenseShareRepository) DeleteExpenseSharesByExpenseId(ctx context.Context, expenseId int) error {
 	args := m.Called(ctx, expenseId)
 	return args.Error(0)
 }
 
+func (m *MockExpenseShareRepository) GetUserCounterpartyBalances(ctx context.Context, userId int) ([]model.CounterpartyBalance, error) {
+	args := m.Called(ctx, userId)
+	return args.Get(0).([]model.CounterpartyBalance), args.Error(1)
+}
+
+func (m *MockExpenseShareRepository) ListUnpaidSharesByUser(ctx context.Context, eventId, userId int) ([]model.ExpenseShare, error) {
+	args := m.Called(ctx, eventId, userId)
+	return args.Get(0).([]model.ExpenseShare), args.Error(1)
+}
+
 func (m *MockExpenseShareRepository) GetEventBalanceReport(ctx context.Context, eventId int) ([]domain.UserBalance, error) {
 	args := m.Called(ctx, eventId)
 	return args.Get(0).([]domain.UserBalance), args.Error(1)
 }
 
+// Mock журнала изменений расходов
+type MockExpenseHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockExpenseHistoryRepository) Create(ctx context.Context, history model.ExpenseHistory) (int, error) {
+	args := m.Called(ctx, history)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockExpenseHistoryRepository) ListByExpense(ctx context.Context, expenseId int) ([]model.ExpenseHistory, error) {
+	args := m.Called(ctx, expenseId)
+	return args.Get(0).([]model.ExpenseHistory), args.Error(1)
+}
+
+// Конвертер с фиксированными курсами к рублю — базовой валюте всех событий в тестах
+type fixedRates map[string]float64
+
+func (r fixedRates) BaseCurrency(ctx context.Context, eventId int) (string, error) {
+	return "RUB", nil
+}
+
+func (r fixedRates) Convert(ctx context.Context, eventId int, amount model.Money, currency string) (model.Money, float64, error) {
+	rate, ok := r[currency]
+	if !ok {
+		return 0, 0, model.ErrExchangeRateNotFound
+	}
+
+	return amount.MulRate(rate), rate, nil
+}
+
+// money переводит сумму в рублях в model.Money
+func money(amount float64) model.Money {
+	return model.MoneyFromFloat(amount)
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+// Расходы без вложений
+type noAttachments struct{}
+
+func (noAttachments) ListByExpense(ctx context.Context, expenseId int) ([]model.Attachment, error) {
+	return nil, nil
+}
+
+func (noAttachments) RemoveFiles(ctx context.Context, attachments []model.Attachment) {}
+
+// Бюджет без категорий и лимитов
+type noBudget struct{}
+
+func (noBudget) CheckCategory(ctx context.Context, eventId, categoryId int) error {
+	return model.ErrCategoryNotFound
+}
+
+func (noBudget) NotifyBudget(ctx context.Context, eventId int) {}
+
+// Событие в заданном статусе
+type eventStatus model.EventStatus
+
+func (s eventStatus) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	return model.Event{EventId: eventID, Status: model.EventStatus(s)}, nil
+}
+
+// Транзакция, выполняющая функцию без обращения к БД
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func setupService() (*Service, *MockExpenseRepository, *MockExpenseShareRepository) {
+	service, expenseRepo, expenseShareRepo, _ := setupServiceWithHistory()
+
+	return service, expenseRepo, expenseShareRepo
+}
+
+func setupServiceWithHistory() (*Service, *MockExpenseRepository, *MockExpenseShareRepository, *MockExpenseHistoryRepository) {
 	expenseRepo := new(MockExpenseRepository)
 	expenseShareRepo := new(MockExpenseShareRepository)
+	historyRepo := new(MockExpenseHistoryRepository)
 	logger, _ := zap.NewDevelopment()
 	sugar := logger.Sugar()
 
-	service := NewService(expenseRepo, expenseShareRepo, sugar)
+	service := NewService(expenseRepo, expenseShareRepo, historyRepo, noAttachments{}, noBudget{}, fixedRates{"RUB": 1, "USD": 90.5}, eventStatus(model.EventStatusPlanned), noTx{}, sugar)
 
-	return &service, expenseRepo, expenseShareRepo
+	return &service, expenseRepo, expenseShareRepo, historyRepo
 }
 
 // Тест 1: Успешное создание долей расходов с методом разделения Equal
@@ -90,7 +205,7 @@ func TestCreateExpenseShares_Equal_Success(t *testing.T) {
 	expenseID := 1
 	expense := &model.Expense{
 		ExpenseID: expenseID,
-		Amount:    100.0,
+		Amount:    money(100),
 	}
 	userIDs := []int{1, 2, 3, 4}
 
@@ -99,7 +214,7 @@ func TestCreateExpenseShares_Equal_Success(t *testing.T) {
 	expenseShareRepo.On("DeleteExpenseSharesByExpenseId", ctx, expenseID).Return(nil)
 
 	// Для каждого пользователя настраиваем создание доли
-	expectedAmount := 25.0 // 100 / 4 = 25
+	expectedAmount := money(25) // 100 / 4 = 25
 	for _, userID := range userIDs {
 		expectedShare := model.ExpenseShare{
 			ExpenseID: expenseID,
@@ -116,7 +231,7 @@ func TestCreateExpenseShares_Equal_Success(t *testing.T) {
 	}
 
 	// Действие
-	err := service.CreateExpenseShares(ctx, expenseID, userIDs, model.SplitMethodEqual)
+	err := service.CreateExpenseShares(ctx, expenseID, userIDs, model.SplitMethodEqual, nil)
 
 	// Проверка
 	assert.NoError(t, err)
@@ -132,33 +247,31 @@ func TestCreateExpenseShares_Percent_Success(t *testing.T) {
 	expenseID := 1
 	expense := &model.Expense{
 		ExpenseID: expenseID,
-		Amount:    100.0,
+		Amount:    money(100),
+	}
+	weights := []domain.ExpenseShareRequest{
+		{UserID: 1, Value: 60},
+		{UserID: 2, Value: 40},
 	}
-	userIDs := []int{1, 2}
 
 	// Настраиваем моки
 	expenseRepo.On("GetExpenseById", ctx, expenseID).Return(expense, nil)
 	expenseShareRepo.On("DeleteExpenseSharesByExpenseId", ctx, expenseID).Return(nil)
 
 	// Для каждого пользователя настраиваем создание доли
-	expectedAmount := 50.0 // 100 / 2 = 50
-	for _, userID := range userIDs {
-		expectedShare := model.ExpenseShare{
-			ExpenseID: expenseID,
-			UserID:    userID,
-			Amount:    expectedAmount,
-			IsPaid:    false,
-		}
+	expectedAmounts := map[int]model.Money{1: money(60), 2: money(40)}
+	for userID, expectedAmount := range expectedAmounts {
 		expenseShareRepo.On("CreateExpenseShare", ctx, mock.MatchedBy(func(share model.ExpenseShare) bool {
-			return share.ExpenseID == expectedShare.ExpenseID &&
-				share.UserID == expectedShare.UserID &&
-				share.Amount == expectedShare.Amount &&
-				share.IsPaid == expectedShare.IsPaid
+			return share.ExpenseID == expenseID &&
+				share.UserID == userID &&
+				share.Amount == expectedAmount &&
+				share.SplitValue != nil &&
+				!share.IsPaid
 		})).Return(userID, nil).Once()
 	}
 
 	// Действие
-	err := service.CreateExpenseShares(ctx, expenseID, userIDs, model.SplitMethodPercent)
+	err := service.CreateExpenseShares(ctx, expenseID, nil, model.SplitMethodPercent, weights)
 
 	// Проверка
 	assert.NoError(t, err)
@@ -166,30 +279,34 @@ func TestCreateExpenseShares_Percent_Success(t *testing.T) {
 	expenseShareRepo.AssertExpectations(t)
 }
 
-// Тест 3: Ошибка при использовании метода разделения Exact (пока не поддерживается полностью)
-func TestCreateExpenseShares_Exact_NotSupported(t *testing.T) {
+// Тест 3: Ошибка, если точные суммы не сходятся с суммой расхода
+func TestCreateExpenseShares_Exact_SumMismatch(t *testing.T) {
 	// Подготовка
 	service, expenseRepo, expenseShareRepo := setupService()
 	ctx := context.Background()
 	expenseID := 1
 	expense := &model.Expense{
 		ExpenseID: expenseID,
-		Amount:    100.0,
+		Amount:    money(100),
+	}
+	weights := []domain.ExpenseShareRequest{
+		{UserID: 1, Value: 30},
+		{UserID: 2, Value: 30},
+		{UserID: 3, Value: 30},
 	}
-	userIDs := []int{1, 2, 3}
 
 	// Настраиваем моки
 	expenseRepo.On("GetExpenseById", ctx, expenseID).Return(expense, nil)
-	expenseShareRepo.On("DeleteExpenseSharesByExpenseId", ctx, expenseID).Return(nil)
 
 	// Действие
-	err := service.CreateExpenseShares(ctx, expenseID, userIDs, model.SplitMethodExact)
+	err := service.CreateExpenseShares(ctx, expenseID, nil, model.SplitMethodExact, weights)
 
 	// Проверка
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "exact split method requires additional data")
+	assert.ErrorIs(t, err, model.ErrInvalidExpenseSplit)
+	assert.Contains(t, err.Error(), "exact amounts sum to 90.00, expected 100.00")
 	expenseRepo.AssertExpectations(t)
-	expenseShareRepo.AssertExpectations(t)
+	// Существующие доли не должны удаляться, если разделение некорректно
+	expenseShareRepo.AssertNotCalled(t, "DeleteExpenseSharesByExpenseId", ctx, expenseID)
 }
 
 // Тест 4: Ошибка при попытке разделить расход без участников
@@ -200,22 +317,385 @@ func TestCreateExpenseShares_NoParticipants(t *testing.T) {
 	expenseID := 1
 	expense := &model.Expense{
 		ExpenseID: expenseID,
-		Amount:    100.0,
+		Amount:    money(100),
 	}
 	userIDs := []int{} // Пустой список пользователей
 
 	// Настраиваем моки
 	expenseRepo.On("GetExpenseById", ctx, expenseID).Return(expense, nil)
-	expenseShareRepo.On("DeleteExpenseSharesByExpenseId", ctx, expenseID).Return(nil)
 
 	// Действие
-	err := service.CreateExpenseShares(ctx, expenseID, userIDs, model.SplitMethodEqual)
+	err := service.CreateExpenseShares(ctx, expenseID, userIDs, model.SplitMethodEqual, nil)
 
 	// Проверка
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cannot split expense with no participants")
 	expenseRepo.AssertExpectations(t)
-	expenseShareRepo.AssertExpectations(t)
+	expenseShareRepo.AssertNotCalled(t, "DeleteExpenseSharesByExpenseId", ctx, expenseID)
+}
+
+// Тест 5: Остаток от деления раздается по копейке, сумма долей равна сумме расхода
+func TestSplitExpense_Equal_DistributesRemainder(t *testing.T) {
+	shares, err := splitExpense(money(100), "RUB", model.SplitMethodEqual, []int{1, 2, 3}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, money(33.34), shares[0].Amount)
+	assert.Equal(t, money(33.33), shares[1].Amount)
+	assert.Equal(t, money(33.33), shares[2].Amount)
+}
+
+// Тест 6: Разделение по количеству долей
+func TestSplitExpense_Shares(t *testing.T) {
+	weights := []domain.ExpenseShareRequest{
+		{UserID: 1, Value: 2},
+		{UserID: 2, Value: 1},
+		{UserID: 3, Value: 1},
+	}
+
+	shares, err := splitExpense(money(10.01), "RUB", model.SplitMethodShares, nil, weights)
+
+	assert.NoError(t, err)
+	assert.Equal(t, money(5.01), shares[0].Amount)
+	assert.Equal(t, money(2.5), shares[1].Amount)
+	assert.Equal(t, money(2.5), shares[2].Amount)
+}
+
+// Тест 7: Точные суммы сохраняются без изменений
+func TestSplitExpense_Exact_Success(t *testing.T) {
+	weights := []domain.ExpenseShareRequest{
+		{UserID: 1, Value: 70.5},
+		{UserID: 2, Value: 29.5},
+	}
+
+	shares, err := splitExpense(money(100), "RUB", model.SplitMethodExact, nil, weights)
+
+	assert.NoError(t, err)
+	assert.Equal(t, money(70.5), shares[0].Amount)
+	assert.Equal(t, money(29.5), shares[1].Amount)
+}
+
+// Тест 8: Ошибки валидации процентов и повторяющихся участников
+func TestSplitExpense_ValidationErrors(t *testing.T) {
+	_, err := splitExpense(money(100), "RUB", model.SplitMethodPercent, nil, []domain.ExpenseShareRequest{
+		{UserID: 1, Value: 50},
+		{UserID: 2, Value: 40},
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidExpenseSplit)
+
+	_, err = splitExpense(money(100), "RUB", model.SplitMethodEqual, []int{1, 1}, nil)
+	assert.ErrorIs(t, err, model.ErrInvalidExpenseSplit)
+
+	_, err = splitExpense(money(100), "RUB", model.SplitMethodPercent, nil, nil)
+	assert.ErrorIs(t, err, model.ErrInvalidExpenseSplit)
+}
+
+// Тест 9: Расход в иностранной валюте сохраняется с курсом и суммой в базовой валюте
+func TestCreateExpense_ConvertsToBaseCurrency(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, _ := setupService()
+	ctx := context.Background()
+
+	expenseRepo.On("CreateExpense", ctx, mock.MatchedBy(func(expense model.Expense) bool {
+		return expense.Amount == money(10) &&
+			expense.Currency == "USD" &&
+			expense.ExchangeRate == 90.5 &&
+			expense.BaseAmount == money(905)
+	})).Return(1, nil)
+
+	// Действие
+	id, err := service.CreateExpense(ctx, domain.ExpenseCreateRequest{
+		EventID:     1,
+		Description: "Такси",
+		Amount:      money(10),
+		Currency:    "usd",
+		SplitMethod: model.SplitMethodEqual,
+		CreatedBy:   1,
+	})
+
+	// Проверка
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+	expenseRepo.AssertExpectations(t)
+}
+
+// Тест 10: Расход не создается, если курс валюты неизвестен
+func TestCreateExpense_UnknownCurrency(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, _ := setupService()
+
+	// Действие
+	_, err := service.CreateExpense(context.Background(), domain.ExpenseCreateRequest{
+		EventID:     1,
+		Amount:      money(10),
+		Currency:    "GBP",
+		SplitMethod: model.SplitMethodEqual,
+	})
+
+	// Проверка
+	assert.ErrorIs(t, err, model.ErrExchangeRateNotFound)
+	expenseRepo.AssertNotCalled(t, "CreateExpense", mock.Anything, mock.Anything)
+}
+
+// Тест 11: Доли в базовой валюте в сумме дают сумму расхода в базовой валюте
+func TestConvertShares(t *testing.T) {
+	shares, err := splitExpense(money(10), "USD", model.SplitMethodEqual, []int{1, 2, 3}, nil)
+	assert.NoError(t, err)
+
+	convertShares(shares, money(905), "RUB")
+
+	// 3.34, 3.33 и 3.33 USD по курсу 90.5
+	assert.Equal(t, money(302.27), shares[0].BaseAmount)
+	assert.Equal(t, money(301.37), shares[1].BaseAmount)
+	assert.Equal(t, money(301.36), shares[2].BaseAmount)
+}
+
+// Тест 12: Ошибка создания доли возвращается, а не пропускается
+func TestCreateExpense_ShareError(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, expenseShareRepo := setupService()
+	ctx := context.Background()
+	shareErr := errors.New("insert failed")
+
+	expenseRepo.On("CreateExpense", ctx, mock.AnythingOfType("model.Expense")).Return(1, nil)
+	expenseRepo.On("GetExpenseById", ctx, 1).Return(&model.Expense{ExpenseID: 1, Amount: money(100), Currency: "RUB", BaseAmount: money(100)}, nil)
+	expenseShareRepo.On("DeleteExpenseSharesByExpenseId", ctx, 1).Return(nil)
+	expenseShareRepo.On("CreateExpenseShare", ctx, mock.MatchedBy(func(share model.ExpenseShare) bool {
+		return share.UserID == 1
+	})).Return(1, nil)
+	expenseShareRepo.On("CreateExpenseShare", ctx, mock.MatchedBy(func(share model.ExpenseShare) bool {
+		return share.UserID == 2
+	})).Return(0, shareErr)
+
+	// Действие
+	_, err := service.CreateExpense(ctx, domain.ExpenseCreateRequest{
+		EventID:     1,
+		Amount:      money(100),
+		Currency:    "RUB",
+		SplitMethod: model.SplitMethodEqual,
+		UserIDs:     []int{1, 2, 3},
+	})
+
+	// Проверка
+	assert.ErrorIs(t, err, shareErr)
+	expenseShareRepo.AssertNumberOfCalls(t, "CreateExpenseShare", 2)
+}
+
+// Тест 13: Расход не удаляется, если не удалось удалить его доли
+func TestDeleteExpense_SharesError(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, expenseShareRepo := setupService()
+	ctx := context.Background()
+	deleteErr := errors.New("delete failed")
+
+	expenseRepo.On("GetExpenseById", ctx, 1).Return(&model.Expense{ExpenseID: 1}, nil)
+	expenseShareRepo.On("DeleteExpenseSharesByExpenseId", ctx, 1).Return(deleteErr)
+
+	// Действие
+	err := service.DeleteExpense(ctx, 1)
+
+	// Проверка
+	assert.ErrorIs(t, err, deleteErr)
+	expenseRepo.AssertNotCalled(t, "DeleteExpense", mock.Anything, mock.Anything)
+}
+
+// Тест 14: Оплаченная доля с неизменной суммой остается оплаченной, выросшая — становится неоплаченной,
+// а доля выбывшего участника удаляется
+func TestMergeShares_PreservesPaidStatus(t *testing.T) {
+	oldShares := []model.ExpenseShare{
+		{ShareID: 10, UserID: 1, BaseAmount: money(50), IsPaid: true},
+		{ShareID: 11, UserID: 2, BaseAmount: money(30), IsPaid: true},
+		{ShareID: 12, UserID: 3, BaseAmount: money(20)},
+	}
+	shares := []model.ExpenseShare{
+		{UserID: 1, BaseAmount: money(50)},
+		{UserID: 2, BaseAmount: money(40)},
+		{UserID: 4, BaseAmount: money(10)},
+	}
+
+	deleted, err := mergeShares(oldShares, shares)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{12}, deleted)
+	assert.Equal(t, 10, shares[0].ShareID)
+	assert.True(t, shares[0].IsPaid)
+	assert.Equal(t, 11, shares[1].ShareID)
+	assert.False(t, shares[1].IsPaid)
+	assert.Equal(t, 0, shares[2].ShareID)
+}
+
+// Тест 15: Правка, уменьшающая оплаченную долю, отклоняется и ничего не сохраняет
+func TestUpdateExpense_PaidShareReduced(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, expenseShareRepo, historyRepo := setupServiceWithHistory()
+	ctx := context.Background()
+	amount := money(60)
+
+	expenseRepo.On("GetExpenseById", ctx, 1).Return(&model.Expense{
+		ExpenseID: 1, EventID: 1, Amount: money(100), Currency: "RUB", ExchangeRate: 1, BaseAmount: money(100), SplitMethod: model.SplitMethodEqual,
+	}, nil)
+	expenseShareRepo.On("ListExpenseSharesByExpenseId", ctx, 1).Return([]model.ExpenseShare{
+		{ShareID: 10, ExpenseID: 1, UserID: 1, Amount: money(50), BaseAmount: money(50), IsPaid: true},
+		{ShareID: 11, ExpenseID: 1, UserID: 2, Amount: money(50), BaseAmount: money(50)},
+	}, nil)
+
+	// Действие
+	_, err := service.UpdateExpense(ctx, 1, 1, domain.ExpenseUpdateRequest{Amount: &amount})
+
+	// Проверка
+	assert.ErrorIs(t, err, model.ErrPaidShareReduced)
+	expenseRepo.AssertNotCalled(t, "UpdateExpense", mock.Anything, mock.Anything)
+	expenseShareRepo.AssertNotCalled(t, "UpdateExpenseShare", mock.Anything, mock.Anything)
+	historyRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Тест 16: Новый участник получает долю, оплаченная доля не меняется, изменения записываются в журнал
+func TestUpdateExpense_AddParticipant(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, expenseShareRepo, historyRepo := setupServiceWithHistory()
+	ctx := context.Background()
+	amount := money(150)
+	userIds := []int{1, 2, 3}
+
+	expenseRepo.On("GetExpenseById", ctx, 1).Return(&model.Expense{
+		ExpenseID: 1, EventID: 1, Amount: money(100), Currency: "RUB", ExchangeRate: 1, BaseAmount: money(100), SplitMethod: model.SplitMethodEqual,
+	}, nil)
+	expenseShareRepo.On("ListExpenseSharesByExpenseId", ctx, 1).Return([]model.ExpenseShare{
+		{ShareID: 10, ExpenseID: 1, UserID: 1, Amount: money(50), BaseAmount: money(50), IsPaid: true},
+		{ShareID: 11, ExpenseID: 1, UserID: 2, Amount: money(50), BaseAmount: money(50)},
+	}, nil)
+	expenseShareRepo.On("UpdateExpenseShare", ctx, mock.AnythingOfType("model.ExpenseShare")).Return(nil)
+	expenseShareRepo.On("CreateExpenseShare", ctx, mock.AnythingOfType("model.ExpenseShare")).Return(12, nil)
+	expenseRepo.On("UpdateExpense", ctx, mock.MatchedBy(func(expense model.Expense) bool {
+		return expense.Amount == money(150) && expense.BaseAmount == money(150)
+	})).Return(nil)
+	historyRepo.On("Create", ctx, mock.MatchedBy(func(history model.ExpenseHistory) bool {
+		return history.ExpenseID == 1 && history.ChangedBy == 2 && len(history.Changes) == 3 &&
+			history.Changes[0].Field == "amount" && history.Changes[1].Field == "base_amount" && history.Changes[2].Field == "shares"
+	})).Return(1, nil)
+
+	// Действие
+	resp, err := service.UpdateExpense(ctx, 1, 2, domain.ExpenseUpdateRequest{Amount: &amount, UserIDs: &userIds})
+
+	// Проверка
+	assert.NoError(t, err)
+	assert.Len(t, resp.Shares, 3)
+	assert.True(t, resp.Shares[0].IsPaid)
+	assert.Equal(t, 12, resp.Shares[2].ShareID)
+	expenseShareRepo.AssertNumberOfCalls(t, "UpdateExpenseShare", 2)
+	expenseShareRepo.AssertNotCalled(t, "DeleteExpenseShare", mock.Anything, mock.Anything)
+	historyRepo.AssertExpectations(t)
+}
+
+// Тест 17: Доли страницы загружаются одним запросом, курсор указывает на последний расход страницы
+func TestListExpenses_NextCursorAndShares(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, expenseShareRepo := setupService()
+	ctx := context.Background()
+	createdAt := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	expenseRepo.On("ListExpenses", ctx, mock.MatchedBy(func(filter model.ExpenseFilter) bool {
+		return filter.EventID == 1 && filter.Limit == 3 && filter.SortBy == model.ExpenseSortAmount && !filter.Desc
+	})).Return([]model.Expense{
+		{ExpenseID: 5, BaseAmount: money(10), CreatedAt: createdAt},
+		{ExpenseID: 3, BaseAmount: money(20), CreatedAt: createdAt},
+		{ExpenseID: 4, BaseAmount: money(30), CreatedAt: createdAt},
+	}, 7, nil)
+	expenseShareRepo.On("ListExpenseSharesByExpenseIds", ctx, []int{5, 3}).Return([]model.ExpenseShare{
+		{ShareID: 1, ExpenseID: 3, UserID: 1},
+		{ShareID: 2, ExpenseID: 3, UserID: 2},
+	}, nil)
+
+	// Действие
+	resp, err := service.ListExpenses(ctx, domain.ExpenseListRequest{EventID: 1, Sort: "amount_asc", Limit: 2})
+
+	// Проверка
+	assert.NoError(t, err)
+	assert.Equal(t, 7, resp.TotalCount)
+	assert.Len(t, resp.Items, 2)
+	assert.Empty(t, resp.Items[0].Shares)
+	assert.Len(t, resp.Items[1].Shares, 2)
+	expenseShareRepo.AssertNotCalled(t, "ListExpenseSharesByExpenseId", mock.Anything, mock.Anything)
+
+	cursor, err := decodeCursor(resp.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cursor.ExpenseID)
+	assert.Equal(t, money(20), cursor.BaseAmount)
+	assert.Equal(t, "amount_asc", cursor.Sort)
+
+	// Курсор другого порядка сортировки отклоняется
+	_, err = expenseFilter(domain.ExpenseListRequest{EventID: 1, Cursor: resp.NextCursor})
+	assert.ErrorIs(t, err, model.ErrInvalidCursor)
+
+	filter, err := expenseFilter(domain.ExpenseListRequest{EventID: 1, Sort: "amount_asc", Cursor: resp.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, filter.After.ExpenseID)
+}
+
+// Тест 18: Параметры запроса переводятся в фильтр, некорректный курсор отклоняется
+func TestExpenseFilter(t *testing.T) {
+	dateTo := time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC)
+
+	filter, err := expenseFilter(domain.ExpenseListRequest{EventID: 1, Currency: "usd", DateTo: &dateTo, Status: "unpaid", Limit: 500})
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", filter.Currency)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), *filter.CreatedTo)
+	assert.False(t, *filter.Paid)
+	assert.Equal(t, model.ExpenseSortCreatedAt, filter.SortBy)
+	assert.True(t, filter.Desc)
+	assert.Equal(t, maxPageSize, filter.Limit)
+
+	_, err = expenseFilter(domain.ExpenseListRequest{EventID: 1, Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, model.ErrInvalidCursor)
+}
+
+// Тест 19: В архивное событие расход не добавляется
+func TestCreateExpense_ArchivedEvent(t *testing.T) {
+	// Подготовка
+	expenseRepo := new(MockExpenseRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewService(expenseRepo, new(MockExpenseShareRepository), new(MockExpenseHistoryRepository), noAttachments{}, noBudget{},
+		fixedRates{"RUB": 1}, eventStatus(model.EventStatusArchived), noTx{}, logger.Sugar())
+
+	// Действие
+	_, err := service.CreateExpense(context.Background(), domain.ExpenseCreateRequest{
+		EventID:     1,
+		Amount:      money(10),
+		Currency:    "RUB",
+		SplitMethod: model.SplitMethodEqual,
+	})
+
+	// Проверка
+	assert.ErrorIs(t, err, model.ErrEventArchived)
+	expenseRepo.AssertNotCalled(t, "CreateExpense", mock.Anything, mock.Anything)
+}
+
+// Тест 20: Расходы, доли и вложения архивного события не редактируются и не удаляются
+func TestArchivedEvent_ExpenseReadOnly(t *testing.T) {
+	// Подготовка
+	expenseRepo := new(MockExpenseRepository)
+	expenseShareRepo := new(MockExpenseShareRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewService(expenseRepo, expenseShareRepo, new(MockExpenseHistoryRepository), noAttachments{}, noBudget{},
+		fixedRates{"RUB": 1}, eventStatus(model.EventStatusArchived), noTx{}, logger.Sugar())
+	ctx := context.Background()
+
+	expenseRepo.On("GetExpenseById", ctx, 1).Return(&model.Expense{ExpenseID: 1, EventID: 1, Currency: "RUB"}, nil)
+	expenseShareRepo.On("GetExpenseShareById", ctx, 5).Return(&model.ExpenseShare{ShareID: 5, ExpenseID: 1}, nil)
+
+	// Действие
+	_, updateErr := service.UpdateExpense(ctx, 1, 1, domain.ExpenseUpdateRequest{Description: ptrTo("Обед")})
+	deleteErr := service.DeleteExpense(ctx, 1)
+	paidErr := service.UpdateExpenseSharePaidStatus(ctx, 5, true)
+	attachmentErr := service.CheckExpenseWritable(ctx, 1)
+
+	// Проверка
+	assert.ErrorIs(t, updateErr, model.ErrEventArchived)
+	assert.ErrorIs(t, deleteErr, model.ErrEventArchived)
+	assert.ErrorIs(t, paidErr, model.ErrEventArchived)
+	assert.ErrorIs(t, attachmentErr, model.ErrEventArchived)
+	expenseRepo.AssertNotCalled(t, "UpdateExpense", mock.Anything, mock.Anything)
+	expenseRepo.AssertNotCalled(t, "DeleteExpense", mock.Anything, mock.Anything)
+	expenseShareRepo.AssertNotCalled(t, "UpdateExpenseSharePaidStatus", mock.Anything, mock.Anything, mock.Anything)
 }
 
 // Тест 1: Успешное получение отчета о балансе с непустым списком пользователей
@@ -226,13 +706,13 @@ func TestGetEventBalanceReport_Success(t *testing.T) {
 	eventID := 1
 
 	// Настраиваем моки
-	totalAmount := 300.0
+	totalAmount := money(300)
 	expenseRepo.On("GetEventTotalExpenses", ctx, eventID).Return(totalAmount, nil)
 
 	userBalances := []domain.UserBalance{
-		{UserID: 1, Username: "user1", Balance: 100.0},
-		{UserID: 2, Username: "user2", Balance: -50.0},
-		{UserID: 3, Username: "user3", Balance: -50.0},
+		{UserID: 1, Username: "user1", Balance: money(100)},
+		{UserID: 2, Username: "user2", Balance: money(-50)},
+		{UserID: 3, Username: "user3", Balance: money(-50)},
 	}
 	expenseShareRepo.On("GetEventBalanceReport", ctx, eventID).Return(userBalances, nil)
 
@@ -242,6 +722,7 @@ func TestGetEventBalanceReport_Success(t *testing.T) {
 	// Проверка
 	assert.NoError(t, err)
 	assert.Equal(t, eventID, report.EventID)
+	assert.Equal(t, "RUB", report.Currency)
 	assert.Equal(t, totalAmount, report.TotalAmount)
 	assert.Equal(t, len(userBalances), len(report.UserBalances))
 
@@ -263,7 +744,7 @@ func TestGetEventBalanceReport_ErrorOnGetTotalExpenses(t *testing.T) {
 	eventID := 1
 
 	// Настраиваем мок для ошибки при получении общей суммы
-	expenseRepo.On("GetEventTotalExpenses", ctx, eventID).Return(0.0, errors.New("database error"))
+	expenseRepo.On("GetEventTotalExpenses", ctx, eventID).Return(model.Money(0), errors.New("database error"))
 
 	// Действие
 	_, err := service.GetEventBalanceReport(ctx, eventID)
@@ -285,7 +766,7 @@ func TestGetEventBalanceReport_ErrorOnGetUserBalances(t *testing.T) {
 	eventID := 1
 
 	// Настраиваем моки
-	totalAmount := 300.0
+	totalAmount := money(300)
 	expenseRepo.On("GetEventTotalExpenses", ctx, eventID).Return(totalAmount, nil)
 	expenseShareRepo.On("GetEventBalanceReport", ctx, eventID).Return([]domain.UserBalance{}, errors.New("user balance error"))
 
@@ -308,7 +789,7 @@ func TestGetEventBalanceReport_EmptyUserBalances(t *testing.T) {
 	eventID := 1
 
 	// Настраиваем моки
-	totalAmount := 0.0
+	totalAmount := model.Money(0)
 	expenseRepo.On("GetEventTotalExpenses", ctx, eventID).Return(totalAmount, nil)
 
 	// Пустой список балансов пользователей
@@ -327,3 +808,115 @@ func TestGetEventBalanceReport_EmptyUserBalances(t *testing.T) {
 	expenseRepo.AssertExpectations(t)
 	expenseShareRepo.AssertExpectations(t)
 }
+
+// Тест 5: Балансы пользователя группируются по событиям, итоги считаются отдельно по валютам
+func TestGetUserBalances_GroupsByEventAndCurrency(t *testing.T) {
+	// Подготовка
+	service, _, expenseShareRepo := setupService()
+	ctx := context.Background()
+	userID := 1
+
+	rows := []model.CounterpartyBalance{
+		{EventID: 10, EventTitle: "Поход", Currency: "RUB", CounterpartyID: 2, Username: "anna", OwedToUser: 50000, PaidToUser: 10000},
+		{EventID: 10, EventTitle: "Поход", Currency: "RUB", CounterpartyID: 3, Username: "oleg", UserOwes: 20000, PaidByUser: 5000, Transfers: 5000},
+		{EventID: 11, EventTitle: "Дача", Currency: "RUB", CounterpartyID: 2, Username: "anna", UserOwes: 10000},
+		{EventID: 12, EventTitle: "Trip", Currency: "EUR", CounterpartyID: 4, Username: "max", OwedToUser: 1500},
+	}
+	expenseShareRepo.On("GetUserCounterpartyBalances", ctx, userID).Return(rows, nil)
+
+	// Действие
+	resp, err := service.GetUserBalances(ctx, userID)
+
+	// Проверка
+	assert.NoError(t, err)
+	assert.Len(t, resp.Events, 3)
+
+	hike := resp.Events[0]
+	assert.Equal(t, 10, hike.EventID)
+	assert.Len(t, hike.Counterparties, 2)
+	assert.Equal(t, model.Money(50000), hike.Counterparties[0].Balance)
+	assert.Equal(t, model.Money(-15000), hike.Counterparties[1].Balance)
+	assert.Equal(t, model.Money(35000), hike.Balance)
+	assert.Equal(t, model.Money(10000), hike.PaidToUser)
+	assert.Equal(t, model.Money(5000), hike.PaidByUser)
+
+	assert.Equal(t, []domain.CurrencyBalanceResponse{
+		{Currency: "EUR", BalanceTotals: domain.BalanceTotals{OwedToUser: 1500, Balance: 1500}},
+		{Currency: "RUB", BalanceTotals: domain.BalanceTotals{OwedToUser: 50000, UserOwes: 30000, PaidToUser: 10000, PaidByUser: 5000, Balance: 25000}},
+	}, resp.Totals)
+
+	expenseShareRepo.AssertExpectations(t)
+}
+
+// Тест 6: Долг покинувшего событие делится между неоплаченными долями остальных, а долг,
+// который не с кем разделить, остается за ним
+func TestReleaseShares_Redistribute(t *testing.T) {
+	// Подготовка
+	service, expenseRepo, expenseShareRepo, historyRepo := setupServiceWithHistory()
+	ctx := context.Background()
+
+	expenseShareRepo.On("ListUnpaidSharesByUser", ctx, 1, 3).Return([]model.ExpenseShare{
+		{ShareID: 13, ExpenseID: 1, UserID: 3, Amount: money(10), BaseAmount: money(10), SplitValue: ptrTo(10.0)},
+		{ShareID: 22, ExpenseID: 2, UserID: 3, Amount: money(15), BaseAmount: money(15)},
+	}, nil)
+	expenseRepo.On("GetExpenseById", ctx, 1).Return(&model.Expense{
+		ExpenseID: 1, EventID: 1, Amount: money(100), Currency: "RUB", BaseAmount: money(100), SplitMethod: model.SplitMethodPercent,
+	}, nil)
+	expenseShareRepo.On("ListExpenseSharesByExpenseId", ctx, 1).Return([]model.ExpenseShare{
+		{ShareID: 10, ExpenseID: 1, UserID: 1, Amount: money(40), BaseAmount: money(40), SplitValue: ptrTo(40.0)},
+		{ShareID: 11, ExpenseID: 1, UserID: 2, Amount: money(30), BaseAmount: money(30), SplitValue: ptrTo(30.0), IsPaid: true},
+		{ShareID: 12, ExpenseID: 1, UserID: 4, Amount: money(20), BaseAmount: money(20), SplitValue: ptrTo(20.0)},
+		{ShareID: 13, ExpenseID: 1, UserID: 3, Amount: money(10), BaseAmount: money(10), SplitValue: ptrTo(10.0)},
+	}, nil)
+	expenseRepo.On("GetExpenseById", ctx, 2).Return(&model.Expense{
+		ExpenseID: 2, EventID: 1, Amount: money(30), Currency: "RUB", BaseAmount: money(30), SplitMethod: model.SplitMethodEqual,
+	}, nil)
+	expenseShareRepo.On("ListExpenseSharesByExpenseId", ctx, 2).Return([]model.ExpenseShare{
+		{ShareID: 21, ExpenseID: 2, UserID: 1, Amount: money(15), BaseAmount: money(15), IsPaid: true},
+		{ShareID: 22, ExpenseID: 2, UserID: 3, Amount: money(15), BaseAmount: money(15)},
+	}, nil)
+	expenseShareRepo.On("UpdateExpenseShare", ctx, mock.MatchedBy(func(share model.ExpenseShare) bool {
+		return share.ShareID == 10 && share.Amount == money(46.67) && share.BaseAmount == money(46.67)
+	})).Return(nil)
+	expenseShareRepo.On("UpdateExpenseShare", ctx, mock.MatchedBy(func(share model.ExpenseShare) bool {
+		return share.ShareID == 12 && share.Amount == money(23.33) && share.BaseAmount == money(23.33)
+	})).Return(nil)
+	expenseShareRepo.On("DeleteExpenseShare", ctx, 13).Return(nil)
+	historyRepo.On("Create", ctx, mock.MatchedBy(func(history model.ExpenseHistory) bool {
+		return history.ExpenseID == 1 && history.ChangedBy == 1 && len(history.Changes) == 1 && history.Changes[0].Field == "shares"
+	})).Return(1, nil)
+
+	// Действие
+	summary, err := service.ReleaseShares(ctx, 1, 3, 1, true)
+
+	// Проверка
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RemovedSharesSummary{
+		Policy:              domain.RemovalSharesRedistribute,
+		Currency:            "RUB",
+		Kept:                []int{22},
+		KeptAmount:          money(15),
+		Redistributed:       []int{1},
+		RedistributedAmount: money(10),
+	}, summary)
+	expenseShareRepo.AssertExpectations(t)
+	expenseShareRepo.AssertNotCalled(t, "DeleteExpenseShare", ctx, 22)
+	historyRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+// Тест 7: Проценты получателей пересчитываются так, чтобы в сумме остались 100
+func TestSpreadShare_Percent(t *testing.T) {
+	expense := model.Expense{Currency: "RUB", SplitMethod: model.SplitMethodPercent}
+	released := model.ExpenseShare{ShareID: 3, Amount: money(10), BaseAmount: money(10), SplitValue: ptrTo(10.0)}
+	shares, recipients := spreadShare(expense, []model.ExpenseShare{
+		{ShareID: 1, Amount: money(60), BaseAmount: money(60), SplitValue: ptrTo(60.0)},
+		{ShareID: 2, Amount: money(30), BaseAmount: money(30), SplitValue: ptrTo(30.0)},
+		released,
+	}, released, "RUB")
+
+	assert.Equal(t, []int{0, 1}, recipients)
+	assert.Len(t, shares, 2)
+	assert.Equal(t, money(100), shares[0].Amount+shares[1].Amount)
+	assert.InDelta(t, 100, *shares[0].SplitValue+*shares[1].SplitValue, percentTolerance)
+	assert.InDelta(t, 66.666667, *shares[0].SplitValue, 1e-5)
+}
@@ -0,0 +1,176 @@
+package expense
+
+import (
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"math"
+	"sort"
+)
+
+// percentTolerance допустимая погрешность суммы процентов
+const percentTolerance = 1e-6
+
+// splitExpense рассчитывает доли расхода. Все вычисления ведутся в минимальных единицах
+// валюты расхода, поэтому сумма долей всегда равна сумме расхода.
+// Для equal участники берутся из userIds (или из weights, если userIds пуст),
+// для percent, exact и shares — из weights.
+func splitExpense(amount model.Money, currency string, splitMethod string, userIds []int, weights []domain.ExpenseShareRequest) ([]model.ExpenseShare, error) {
+	unit := model.MinorUnit(currency)
+	total := int64(amount.Round(currency) / unit)
+
+	switch splitMethod {
+	case model.SplitMethodEqual:
+		if len(userIds) == 0 {
+			for _, w := range weights {
+				userIds = append(userIds, w.UserID)
+			}
+		}
+		if len(userIds) == 0 {
+			return nil, errors.WithMessage(model.ErrInvalidExpenseSplit, "cannot split expense with no participants")
+		}
+		if err := checkUniqueUsers(userIds); err != nil {
+			return nil, err
+		}
+
+		units := make([]float64, len(userIds))
+		for i := range units {
+			units[i] = 1
+		}
+
+		return buildShares(userIds, distribute(total, units), unit, nil), nil
+
+	case model.SplitMethodPercent, model.SplitMethodExact, model.SplitMethodShares:
+		if len(weights) == 0 {
+			return nil, errors.WithMessagef(model.ErrInvalidExpenseSplit, "split method %s requires shares", splitMethod)
+		}
+
+		ids := make([]int, len(weights))
+		values := make([]float64, len(weights))
+		for i, w := range weights {
+			if w.Value <= 0 {
+				return nil, errors.WithMessagef(model.ErrInvalidExpenseSplit, "share value for user %d must be positive", w.UserID)
+			}
+			ids[i] = w.UserID
+			values[i] = w.Value
+		}
+		if err := checkUniqueUsers(ids); err != nil {
+			return nil, err
+		}
+
+		var amounts []int64
+		switch splitMethod {
+		case model.SplitMethodPercent:
+			var sum float64
+			for _, v := range values {
+				sum += v
+			}
+			if math.Abs(sum-100) > percentTolerance {
+				return nil, errors.WithMessagef(model.ErrInvalidExpenseSplit, "percentages sum to %g, expected 100", sum)
+			}
+			amounts = distribute(total, values)
+
+		case model.SplitMethodExact:
+			var sum int64
+			amounts = make([]int64, len(values))
+			for i, v := range values {
+				amounts[i] = int64(model.MoneyFromFloat(v).Round(currency) / unit)
+				sum += amounts[i]
+			}
+			if sum != total {
+				return nil, errors.WithMessagef(model.ErrInvalidExpenseSplit, "exact amounts sum to %s, expected %s", model.Money(sum)*unit, model.Money(total)*unit)
+			}
+
+		case model.SplitMethodShares:
+			amounts = distribute(total, values)
+		}
+
+		return buildShares(ids, amounts, unit, values), nil
+
+	default:
+		return nil, errors.WithMessagef(model.ErrInvalidExpenseSplit, "unsupported split method: %s", splitMethod)
+	}
+}
+
+// distribute делит total пропорционально весам методом наибольшего остатка:
+// каждому достается округленная вниз часть, а оставшиеся копейки раздаются
+// по одной участникам с наибольшей дробной частью, при равенстве — в порядке следования
+func distribute(total int64, weights []float64) []int64 {
+	var sumWeights float64
+	for _, w := range weights {
+		sumWeights += w
+	}
+
+	result := make([]int64, len(weights))
+	fractions := make([]float64, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		exact := float64(total) * w / sumWeights
+		result[i] = int64(math.Floor(exact))
+		fractions[i] = exact - float64(result[i])
+		allocated += result[i]
+	}
+
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return fractions[order[a]] > fractions[order[b]]
+	})
+
+	for i := int64(0); i < total-allocated; i++ {
+		result[order[int(i)%len(order)]]++
+	}
+
+	return result
+}
+
+// convertShares рассчитывает доли в базовой валюте, распределяя baseAmount пропорционально
+// долям в валюте расхода, чтобы их сумма совпадала с суммой расхода в базовой валюте
+func convertShares(shares []model.ExpenseShare, baseAmount model.Money, baseCurrency string) {
+	weights := make([]float64, len(shares))
+	var sum float64
+	for i, share := range shares {
+		weights[i] = float64(share.Amount)
+		sum += weights[i]
+	}
+	if sum == 0 {
+		return
+	}
+
+	unit := model.MinorUnit(baseCurrency)
+	for i, amount := range distribute(int64(baseAmount.Round(baseCurrency)/unit), weights) {
+		shares[i].BaseAmount = model.Money(amount) * unit
+	}
+}
+
+// buildShares собирает доли из сумм, выраженных в минимальных единицах валюты размером unit
+func buildShares(userIds []int, amounts []int64, unit model.Money, values []float64) []model.ExpenseShare {
+	shares := make([]model.ExpenseShare, len(userIds))
+	for i, userId := range userIds {
+		shares[i] = model.ExpenseShare{
+			UserID: userId,
+			Amount: model.Money(amounts[i]) * unit,
+			IsPaid: false,
+		}
+		if values != nil {
+			value := values[i]
+			shares[i].SplitValue = &value
+		}
+	}
+
+	return shares
+}
+
+func checkUniqueUsers(userIds []int) error {
+	seen := make(map[int]bool, len(userIds))
+	for _, id := range userIds {
+		if seen[id] {
+			return errors.WithMessagef(model.ErrInvalidExpenseSplit, "duplicate user %d in split", id)
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package expense
+
+import (
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"slices"
+	"sort"
+)
+
+// splitParticipants возвращает участников и веса для пересчета долей. Если в запросе их нет,
+// берутся текущие доли расхода; веса переносятся, только если метод разделения не меняется
+func splitParticipants(req domain.ExpenseUpdateRequest, splitMethod, oldSplitMethod string, oldShares []model.ExpenseShare) ([]int, []domain.ExpenseShareRequest) {
+	if req.UserIDs != nil || req.Shares != nil {
+		var userIds []int
+		var weights []domain.ExpenseShareRequest
+		if req.UserIDs != nil {
+			userIds = *req.UserIDs
+		}
+		if req.Shares != nil {
+			weights = *req.Shares
+		}
+		return userIds, weights
+	}
+
+	if splitMethod == model.SplitMethodEqual {
+		userIds := make([]int, len(oldShares))
+		for i, share := range oldShares {
+			userIds[i] = share.UserID
+		}
+		return userIds, nil
+	}
+
+	if splitMethod != oldSplitMethod {
+		return nil, nil
+	}
+
+	weights := make([]domain.ExpenseShareRequest, 0, len(oldShares))
+	for _, share := range oldShares {
+		if share.SplitValue == nil {
+			return nil, nil
+		}
+		weights = append(weights, domain.ExpenseShareRequest{UserID: share.UserID, Value: *share.SplitValue})
+	}
+
+	return nil, weights
+}
+
+// mergeShares сопоставляет пересчитанные доли с текущими по участникам: совпавшая доля получает id текущей,
+// а оплаченная сохраняет статус, если ее сумма в базовой валюте не изменилась. Выросшая оплаченная доля
+// становится неоплаченной, а погасивший ее платеж учитывается в балансе как перевод.
+// Возвращает id долей, которые нужно удалить, или ErrPaidShareReduced, если оплаченная доля уменьшается или удаляется
+func mergeShares(oldShares, shares []model.ExpenseShare) ([]int, error) {
+	byUser := make(map[int]model.ExpenseShare, len(oldShares))
+	for _, share := range oldShares {
+		byUser[share.UserID] = share
+	}
+
+	for i := range shares {
+		old, ok := byUser[shares[i].UserID]
+		if !ok {
+			continue
+		}
+		delete(byUser, shares[i].UserID)
+
+		shares[i].ShareID = old.ShareID
+		if !old.IsPaid {
+			continue
+		}
+		if shares[i].BaseAmount < old.BaseAmount {
+			return nil, errors.WithMessagef(model.ErrPaidShareReduced, "share of user %d would drop from %s to %s",
+				old.UserID, old.BaseAmount, shares[i].BaseAmount)
+		}
+		if shares[i].BaseAmount == old.BaseAmount {
+			shares[i].IsPaid = true
+			shares[i].PaidAt = old.PaidAt
+		}
+	}
+
+	deleted := make([]int, 0, len(byUser))
+	for _, old := range oldShares {
+		if _, ok := byUser[old.UserID]; !ok {
+			continue
+		}
+		if old.IsPaid {
+			return nil, errors.WithMessagef(model.ErrPaidShareReduced, "paid share of user %d cannot be removed", old.UserID)
+		}
+		deleted = append(deleted, old.ShareID)
+	}
+
+	return deleted, nil
+}
+
+// expenseChanges перечисляет поля, отличающиеся у расхода до и после редактирования
+func expenseChanges(old, updated model.Expense, oldShares, shares []model.ExpenseShare) model.ExpenseChanges {
+	changes := make(model.ExpenseChanges, 0)
+	add := func(field string, oldValue, newValue any) {
+		if oldValue != newValue {
+			changes = append(changes, model.ExpenseChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	add("description", old.Description, updated.Description)
+	add("amount", old.Amount, updated.Amount)
+	add("currency", old.Currency, updated.Currency)
+	add("exchange_rate", old.ExchangeRate, updated.ExchangeRate)
+	add("base_amount", old.BaseAmount, updated.BaseAmount)
+	add("split_method", old.SplitMethod, updated.SplitMethod)
+	add("category_id", categoryValue(old.CategoryID), categoryValue(updated.CategoryID))
+
+	oldSnapshot, newSnapshot := shareSnapshots(oldShares), shareSnapshots(shares)
+	if !slices.Equal(oldSnapshot, newSnapshot) {
+		changes = append(changes, model.ExpenseChange{Field: "shares", OldValue: oldSnapshot, NewValue: newSnapshot})
+	}
+
+	return changes
+}
+
+// categoryValue возвращает id категории для сравнения и записи в журнал, nil — расход без категории
+func categoryValue(categoryId *int) any {
+	if categoryId == nil {
+		return nil
+	}
+	return *categoryId
+}
+
+// shareSnapshots возвращает состояние долей, упорядоченное по участникам
+func shareSnapshots(shares []model.ExpenseShare) []model.ExpenseShareSnapshot {
+	snapshots := make([]model.ExpenseShareSnapshot, len(shares))
+	for i, share := range shares {
+		snapshots[i] = model.ExpenseShareSnapshot{
+			UserID:     share.UserID,
+			Amount:     share.Amount,
+			BaseAmount: share.BaseAmount,
+			IsPaid:     share.IsPaid,
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].UserID < snapshots[j].UserID
+	})
+
+	return snapshots
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+)
+
+// locale заголовки и форматы выгрузки на одном языке
+type locale struct {
+	expensesTitle  string
+	sharesTitle    string
+	balancesTitle  string
+	expenseHeaders []string
+	shareHeaders   []string
+	balanceHeaders []string
+	total          string
+	yes            string
+	no             string
+	splitMethods   map[string]string
+	csvComma       rune   // Разделитель полей CSV: для русской локали Excel ожидает точку с запятой
+	decimal        byte   // Разделитель дробной части в CSV
+	timeLayout     string // Формат даты и времени в CSV
+	xlsxTimeFormat string // Формат даты и времени в XLSX
+}
+
+var locales = map[string]locale{
+	domain.ExportLangRu: {
+		expensesTitle: "Расходы",
+		sharesTitle:   "Доли",
+		balancesTitle: "Балансы",
+		expenseHeaders: []string{
+			"ID", "Дата (UTC)", "Описание", "Категория", "Автор", "Сумма", "Валюта",
+			"Курс", "Сумма в валюте события", "Валюта события", "Способ разделения",
+		},
+		shareHeaders: []string{
+			"ID расхода", "Расход", "Участник", "Сумма", "Валюта",
+			"Сумма в валюте события", "Валюта события", "Оплачено", "Дата оплаты (UTC)",
+		},
+		balanceHeaders: []string{
+			"Участник", "Оплачено", "Не оплачено", "Всего к оплате", "Баланс", "Валюта события",
+		},
+		total: "Итого",
+		yes:   "да",
+		no:    "нет",
+		splitMethods: map[string]string{
+			model.SplitMethodEqual:   "поровну",
+			model.SplitMethodPercent: "проценты",
+			model.SplitMethodExact:   "точные суммы",
+			model.SplitMethodShares:  "доли",
+		},
+		csvComma:       ';',
+		decimal:        ',',
+		timeLayout:     "02.01.2006 15:04",
+		xlsxTimeFormat: "dd.mm.yyyy hh:mm",
+	},
+	domain.ExportLangEn: {
+		expensesTitle: "Expenses",
+		sharesTitle:   "Shares",
+		balancesTitle: "Balances",
+		expenseHeaders: []string{
+			"ID", "Date (UTC)", "Description", "Category", "Created by", "Amount", "Currency",
+			"Exchange rate", "Base amount", "Base currency", "Split method",
+		},
+		shareHeaders: []string{
+			"Expense ID", "Expense", "Participant", "Amount", "Currency",
+			"Base amount", "Base currency", "Paid", "Paid at (UTC)",
+		},
+		balanceHeaders: []string{
+			"Participant", "Paid", "Unpaid", "Total due", "Balance", "Base currency",
+		},
+		total: "Total",
+		yes:   "yes",
+		no:    "no",
+		splitMethods: map[string]string{
+			model.SplitMethodEqual:   "equal",
+			model.SplitMethodPercent: "percent",
+			model.SplitMethodExact:   "exact",
+			model.SplitMethodShares:  "shares",
+		},
+		csvComma:       ',',
+		decimal:        '.',
+		timeLayout:     "2006-01-02 15:04",
+		xlsxTimeFormat: "yyyy-mm-dd hh:mm",
+	},
+}
+
+func (l locale) bool(v bool) string {
+	if v {
+		return l.yes
+	}
+	return l.no
+}
+
+func (l locale) splitMethod(method string) string {
+	if name, ok := l.splitMethods[method]; ok {
+		return name
+	}
+	return method
+}
@@ -0,0 +1,230 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type ExpenseService interface {
+	ListExpenses(ctx context.Context, req domain.ExpenseListRequest) (domain.ExpensesResponse, error)
+	GetEventBalanceReport(ctx context.Context, eventId int) (domain.BalanceReportResponse, error)
+}
+
+type CategoryService interface {
+	ListCategories(ctx context.Context, eventId int) (domain.CategoriesResponse, error)
+}
+
+// pageSize количество расходов, загружаемых за один запрос при выгрузке
+const pageSize = 100
+
+type Service struct {
+	expenses   ExpenseService
+	categories CategoryService
+	logger     *zap.SugaredLogger
+}
+
+func NewService(expenses ExpenseService, categories CategoryService, logger *zap.SugaredLogger) Service {
+	return Service{
+		expenses:   expenses,
+		categories: categories,
+		logger:     logger,
+	}
+}
+
+// Export записывает в w расходы события, их доли и балансы участников в формате format.
+// Расходы читаются постранично и пишутся сразу, поэтому выгрузка не держит событие в памяти целиком.
+// Справочники загружаются до первой записи: ошибка при их загрузке возвращается, пока в w ничего не записано
+func (s Service) Export(ctx context.Context, eventId int, format, lang string, w io.Writer) error {
+	l, ok := locales[lang]
+	if !ok {
+		l = locales[domain.ExportLangRu]
+	}
+
+	report, err := s.expenses.GetEventBalanceReport(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get balance report for export", "error", err, "eventId", eventId)
+		return errors.WithMessage(err, "get balance report")
+	}
+
+	categories, err := s.categories.ListCategories(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list categories for export", "error", err, "eventId", eventId)
+		return errors.WithMessage(err, "list categories")
+	}
+
+	e := exporter{
+		Service:    s,
+		eventId:    eventId,
+		locale:     l,
+		currency:   report.Currency,
+		usernames:  make(map[int]string, len(report.UserBalances)),
+		categories: make(map[int]string, len(categories.Items)),
+	}
+	for _, balance := range report.UserBalances {
+		e.usernames[balance.UserID] = balance.Username
+	}
+	for _, category := range categories.Items {
+		e.categories[category.CategoryID] = category.Name
+	}
+
+	switch format {
+	case domain.ExportFormatXLSX:
+		e.table = newXLSXTable(w, l)
+	default:
+		e.table, err = newCSVTable(w, l)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := e.write(ctx, report); err != nil {
+		s.logger.Errorw("Failed to export expenses", "error", err, "eventId", eventId)
+		return errors.WithMessage(err, "export expenses")
+	}
+
+	return nil
+}
+
+// exporter состояние одной выгрузки
+type exporter struct {
+	Service
+	table      table
+	eventId    int
+	locale     locale
+	currency   string // Базовая валюта события
+	usernames  map[int]string
+	categories map[int]string
+}
+
+func (e exporter) write(ctx context.Context, report domain.BalanceReportResponse) error {
+	if err := e.table.Section(e.locale.expensesTitle, e.locale.expenseHeaders); err != nil {
+		return err
+	}
+	err := e.eachPage(ctx, func(expense domain.ExpenseResponse) error {
+		return e.table.Row(
+			intCell(expense.ExpenseID),
+			timeCell(&expense.CreatedAt),
+			textCell(expense.Description),
+			textCell(e.category(expense.CategoryID)),
+			textCell(e.username(expense.CreatedBy)),
+			moneyCell(expense.Amount, expense.Currency),
+			textCell(expense.Currency),
+			rateCell(expense.ExchangeRate),
+			moneyCell(expense.BaseAmount, e.currency),
+			textCell(e.currency),
+			textCell(e.locale.splitMethod(expense.SplitMethod)),
+		)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Листы XLSX пишутся по очереди, поэтому доли читаются вторым проходом по страницам расходов
+	if err := e.table.Section(e.locale.sharesTitle, e.locale.shareHeaders); err != nil {
+		return err
+	}
+	err = e.eachPage(ctx, func(expense domain.ExpenseResponse) error {
+		for _, share := range expense.Shares {
+			err := e.table.Row(
+				intCell(expense.ExpenseID),
+				textCell(expense.Description),
+				textCell(e.username(share.UserID)),
+				moneyCell(share.Amount, expense.Currency),
+				textCell(expense.Currency),
+				moneyCell(share.BaseAmount, e.currency),
+				textCell(e.currency),
+				textCell(e.locale.bool(share.IsPaid)),
+				timeCell(share.PaidAt),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := e.table.Section(e.locale.balancesTitle, e.locale.balanceHeaders); err != nil {
+		return err
+	}
+	var paid, unpaid, due model.Money
+	for _, balance := range report.UserBalances {
+		err := e.table.Row(
+			textCell(balance.Username),
+			moneyCell(balance.PaidAmount, e.currency),
+			moneyCell(balance.UnpaidAmount, e.currency),
+			moneyCell(balance.TotalDue, e.currency),
+			moneyCell(balance.Balance, e.currency),
+			textCell(e.currency),
+		)
+		if err != nil {
+			return err
+		}
+		paid += balance.PaidAmount
+		unpaid += balance.UnpaidAmount
+		due += balance.TotalDue
+	}
+	err = e.table.Row(
+		textCell(e.locale.total),
+		moneyCell(paid, e.currency),
+		moneyCell(unpaid, e.currency),
+		moneyCell(due, e.currency),
+		textCell(""),
+		textCell(e.currency),
+	)
+	if err != nil {
+		return err
+	}
+
+	return e.table.Close()
+}
+
+// eachPage обходит расходы события от старых к новым вместе с долями
+func (e exporter) eachPage(ctx context.Context, fn func(expense domain.ExpenseResponse) error) error {
+	req := domain.ExpenseListRequest{
+		EventID: e.eventId,
+		Sort:    "created_at_asc",
+		Limit:   pageSize,
+	}
+
+	for {
+		page, err := e.expenses.ListExpenses(ctx, req)
+		if err != nil {
+			return errors.WithMessage(err, "list expenses")
+		}
+
+		for _, expense := range page.Items {
+			if err := fn(expense); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		req.Cursor = page.NextCursor
+	}
+}
+
+// username имя участника; пользователь, покинувший событие, выводится по id
+func (e exporter) username(userId int) string {
+	if name, ok := e.usernames[userId]; ok {
+		return name
+	}
+	return fmt.Sprintf("#%d", userId)
+}
+
+func (e exporter) category(categoryId *int) string {
+	if categoryId == nil {
+		return ""
+	}
+	return e.categories[*categoryId]
+}
@@ -0,0 +1,199 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Mock сервиса расходов
+type MockExpenseService struct {
+	mock.Mock
+}
+
+func (m *MockExpenseService) ListExpenses(ctx context.Context, req domain.ExpenseListRequest) (domain.ExpensesResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(domain.ExpensesResponse), args.Error(1)
+}
+
+func (m *MockExpenseService) GetEventBalanceReport(ctx context.Context, eventId int) (domain.BalanceReportResponse, error) {
+	args := m.Called(ctx, eventId)
+	return args.Get(0).(domain.BalanceReportResponse), args.Error(1)
+}
+
+// Mock сервиса категорий
+type MockCategoryService struct {
+	mock.Mock
+}
+
+func (m *MockCategoryService) ListCategories(ctx context.Context, eventId int) (domain.CategoriesResponse, error) {
+	args := m.Called(ctx, eventId)
+	return args.Get(0).(domain.CategoriesResponse), args.Error(1)
+}
+
+func setupService() (*Service, *MockExpenseService, *MockCategoryService) {
+	expenses := new(MockExpenseService)
+	categories := new(MockCategoryService)
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(expenses, categories, logger.Sugar())
+
+	return &service, expenses, categories
+}
+
+// setupEvent настраивает событие с двумя страницами расходов: ужин в рублях и отель в иенах
+func setupEvent(ctx context.Context, expenses *MockExpenseService, categories *MockCategoryService) {
+	paidAt := time.Date(2025, 6, 2, 9, 30, 0, 0, time.UTC)
+	food := 3
+
+	expenses.On("GetEventBalanceReport", ctx, 1).Return(domain.BalanceReportResponse{
+		EventID:     1,
+		Currency:    "RUB",
+		TotalAmount: 1234567,
+		UserBalances: []domain.UserBalance{
+			{UserID: 1, Username: "anna", Balance: 500000, PaidAmount: 117283, UnpaidAmount: 0, TotalDue: 117283},
+			{UserID: 2, Username: "boris", Balance: -500000, PaidAmount: 0, UnpaidAmount: 117284, TotalDue: 117284},
+		},
+	}, nil)
+	categories.On("ListCategories", ctx, 1).Return(domain.CategoriesResponse{
+		Items: []domain.CategoryResponse{{CategoryID: food, EventID: 1, Name: "Еда"}},
+	}, nil)
+
+	expenses.On("ListExpenses", ctx, domain.ExpenseListRequest{EventID: 1, Sort: "created_at_asc", Limit: pageSize}).
+		Return(domain.ExpensesResponse{
+			Items: []domain.ExpenseResponse{{
+				ExpenseID: 10, CreatedBy: 1, Description: "Ужин; кафе", Amount: 234567, Currency: "RUB",
+				ExchangeRate: 1, BaseAmount: 234567, SplitMethod: model.SplitMethodEqual, CategoryID: &food,
+				CreatedAt: time.Date(2025, 6, 1, 19, 5, 0, 0, time.UTC),
+				Shares: []model.ExpenseShare{
+					{ExpenseID: 10, UserID: 1, Amount: 117283, BaseAmount: 117283, IsPaid: true, PaidAt: &paidAt},
+					{ExpenseID: 10, UserID: 2, Amount: 117284, BaseAmount: 117284},
+				},
+			}},
+			TotalCount: 2,
+			NextCursor: "page2",
+		}, nil)
+	expenses.On("ListExpenses", ctx, domain.ExpenseListRequest{EventID: 1, Sort: "created_at_asc", Limit: pageSize, Cursor: "page2"}).
+		Return(domain.ExpensesResponse{
+			Items: []domain.ExpenseResponse{{
+				ExpenseID: 11, CreatedBy: 7, Description: "Hotel", Amount: 1500000, Currency: "JPY",
+				ExchangeRate: 0.6, BaseAmount: 900000, SplitMethod: model.SplitMethodExact,
+				CreatedAt: time.Date(2025, 6, 3, 8, 0, 0, 0, time.UTC),
+			}},
+			TotalCount: 2,
+		}, nil)
+}
+
+// Тест 1: Русская выгрузка CSV — BOM, точка с запятой, десятичная запятая, знаки валюты и все страницы расходов
+func TestExport_CSVRussian(t *testing.T) {
+	service, expenses, categories := setupService()
+	ctx := context.Background()
+	setupEvent(ctx, expenses, categories)
+
+	var buf bytes.Buffer
+	err := service.Export(ctx, 1, domain.ExportFormatCSV, domain.ExportLangRu, &buf)
+
+	assert.NoError(t, err)
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "\uFEFFРасходы\n"))
+	assert.Contains(t, out, "ID;Дата (UTC);Описание;Категория;Автор;Сумма;Валюта;")
+	assert.Contains(t, out, `10;01.06.2025 19:05;"Ужин; кафе";Еда;anna;2345,67;RUB;1;2345,67;RUB;поровну`)
+	// Иены записываются без дробной части, автор, покинувший событие, — по id
+	assert.Contains(t, out, "11;03.06.2025 08:00;Hotel;;#7;15000;JPY;0,6;9000,00;RUB;точные суммы")
+	assert.Contains(t, out, "\n\nДоли\n")
+	assert.Contains(t, out, `10;"Ужин; кафе";anna;1172,83;RUB;1172,83;RUB;да;02.06.2025 09:30`)
+	assert.Contains(t, out, `10;"Ужин; кафе";boris;1172,84;RUB;1172,84;RUB;нет;`)
+	assert.Contains(t, out, "boris;0,00;1172,84;1172,84;-5000,00;RUB")
+	assert.Contains(t, out, "Итого;1172,83;1172,84;2345,67;;RUB")
+	// Каждая страница читается дважды: для расходов и для долей
+	expenses.AssertNumberOfCalls(t, "ListExpenses", 4)
+}
+
+// Тест 2: Английская выгрузка CSV — запятая между полями и точка в дробной части
+func TestExport_CSVEnglish(t *testing.T) {
+	service, expenses, categories := setupService()
+	ctx := context.Background()
+	setupEvent(ctx, expenses, categories)
+
+	var buf bytes.Buffer
+	err := service.Export(ctx, 1, domain.ExportFormatCSV, domain.ExportLangEn, &buf)
+
+	assert.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "ID,Date (UTC),Description,Category,Created by,Amount,Currency,")
+	assert.Contains(t, out, "10,2025-06-01 19:05,Ужин; кафе,Еда,anna,2345.67,RUB,1,2345.67,RUB,equal")
+	assert.Contains(t, out, "Total,1172.83,1172.84,2345.67,,RUB")
+}
+
+// Тест 3: XLSX — разделы на отдельных листах, суммы числами с форматом валюты
+func TestExport_XLSX(t *testing.T) {
+	service, expenses, categories := setupService()
+	ctx := context.Background()
+	setupEvent(ctx, expenses, categories)
+
+	var buf bytes.Buffer
+	err := service.Export(ctx, 1, domain.ExportFormatXLSX, domain.ExportLangEn, &buf)
+	assert.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Expenses" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Shares" sheetId="2" r:id="rId2"/>`)
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Balances" sheetId="3" r:id="rId3"/>`)
+	assert.Contains(t, files["xl/styles.xml"], `formatCode="#,##0.00\ &#34;RUB&#34;"`)
+	assert.Contains(t, files["xl/styles.xml"], `formatCode="#,##0\ &#34;JPY&#34;"`)
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], "<v>2345.67</v>")
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], "<v>15000</v>")
+	assert.Contains(t, files["xl/worksheets/sheet1.xml"], "Ужин; кафе")
+	assert.Contains(t, files["xl/worksheets/sheet3.xml"], "<v>-5000</v>")
+}
+
+// Тест 4: Ошибка загрузки справочников возвращается до записи файла
+func TestExport_ReportError(t *testing.T) {
+	service, expenses, _ := setupService()
+	ctx := context.Background()
+	reportErr := errors.New("db is down")
+
+	expenses.On("GetEventBalanceReport", ctx, 1).Return(domain.BalanceReportResponse{}, reportErr)
+
+	var buf bytes.Buffer
+	err := service.Export(ctx, 1, domain.ExportFormatCSV, domain.ExportLangRu, &buf)
+
+	assert.ErrorIs(t, err, reportErr)
+	assert.Zero(t, buf.Len())
+	expenses.AssertNotCalled(t, "ListExpenses", mock.Anything, mock.Anything)
+}
+
+// Тест 5: Текст, похожий на формулу, экранируется в CSV, а отрицательные суммы остаются числами
+func TestCSVTable_EscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	table, err := newCSVTable(&buf, locales[domain.ExportLangEn])
+	assert.NoError(t, err)
+
+	err = table.Row(textCell("=HYPERLINK(\"http://evil\")"), textCell("+1"), textCell("-2"), textCell("@SUM(A1)"),
+		textCell("\tcmd"), textCell("\rcmd"), textCell("plain"), textCell(""), moneyCell(-150000, "RUB"))
+	assert.NoError(t, err)
+	assert.NoError(t, table.Close())
+
+	assert.Equal(t, "\uFEFF\"'=HYPERLINK(\"\"http://evil\"\")\",'+1,'-2,'@SUM(A1),'\tcmd,\"'\rcmd\",plain,,-1500.00\n", buf.String())
+}
@@ -0,0 +1,217 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/PabloPerdolie/event-manager/core-service/pkg/xlsx"
+	"github.com/pkg/errors"
+)
+
+type cellKind int
+
+const (
+	kindText cellKind = iota
+	kindInt
+	kindRate
+	kindMoney
+	kindTime
+)
+
+// cell значение ячейки выгрузки. Форматирование зависит от формата файла и языка
+type cell struct {
+	kind     cellKind
+	text     string
+	number   float64
+	money    model.Money
+	currency string
+	time     time.Time
+}
+
+func textCell(s string) cell {
+	return cell{kind: kindText, text: s}
+}
+
+func intCell(v int) cell {
+	return cell{kind: kindInt, number: float64(v)}
+}
+
+func rateCell(v float64) cell {
+	return cell{kind: kindRate, number: v}
+}
+
+func moneyCell(m model.Money, currency string) cell {
+	return cell{kind: kindMoney, money: m, currency: currency}
+}
+
+// timeCell дата и время в UTC, пустая ячейка для nil
+func timeCell(t *time.Time) cell {
+	if t == nil {
+		return textCell("")
+	}
+	return cell{kind: kindTime, time: t.UTC()}
+}
+
+// table пишет выгрузку по разделам: каждый раздел начинается с заголовков столбцов
+type table interface {
+	Section(title string, headers []string) error
+	Row(cells ...cell) error
+	Close() error
+}
+
+// csvTable записывает разделы в один CSV-файл через пустую строку, каждый раздел — с названием.
+// Файл начинается с BOM, чтобы Excel распознал UTF-8
+type csvTable struct {
+	w        *csv.Writer
+	locale   locale
+	sections int
+}
+
+func newCSVTable(w io.Writer, l locale) (*csvTable, error) {
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return nil, errors.WithMessage(err, "write bom")
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = l.csvComma
+
+	return &csvTable{
+		w:      cw,
+		locale: l,
+	}, nil
+}
+
+func (t *csvTable) Section(title string, headers []string) error {
+	if t.sections > 0 {
+		if err := t.w.Write(nil); err != nil {
+			return err
+		}
+	}
+	t.sections++
+
+	if err := t.w.Write([]string{title}); err != nil {
+		return err
+	}
+	return t.w.Write(headers)
+}
+
+func (t *csvTable) Row(cells ...cell) error {
+	record := make([]string, len(cells))
+	for i, c := range cells {
+		record[i] = t.format(c)
+	}
+
+	return t.w.Write(record)
+}
+
+func (t *csvTable) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+func (t *csvTable) format(c cell) string {
+	switch c.kind {
+	case kindInt:
+		return strconv.FormatFloat(c.number, 'f', 0, 64)
+	case kindRate:
+		return t.decimal(strconv.FormatFloat(c.number, 'f', -1, 64))
+	case kindMoney:
+		return t.decimal(formatMoney(c.money, c.currency))
+	case kindTime:
+		return c.time.Format(t.locale.timeLayout)
+	default:
+		return escapeFormula(c.text)
+	}
+}
+
+// escapeFormula экранирует текст, который табличный редактор принял бы за формулу: описания,
+// категории и имена пользователей задают участники события
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (t *csvTable) decimal(s string) string {
+	if t.locale.decimal == '.' {
+		return s
+	}
+	return strings.Replace(s, ".", string(t.locale.decimal), 1)
+}
+
+// formatMoney записывает сумму с числом знаков после запятой, принятым в валюте
+func formatMoney(m model.Money, currency string) string {
+	s := m.Round(currency).String()
+	if model.CurrencyDigits(currency) == 0 {
+		s, _, _ = strings.Cut(s, ".")
+	}
+
+	return s
+}
+
+// xlsxTable записывает каждый раздел на отдельный лист книги. Суммы записываются числами
+// с форматом валюты, даты — датами Excel
+type xlsxTable struct {
+	w      *xlsx.Writer
+	locale locale
+}
+
+func newXLSXTable(w io.Writer, l locale) *xlsxTable {
+	return &xlsxTable{
+		w:      xlsx.NewWriter(w),
+		locale: l,
+	}
+}
+
+func (t *xlsxTable) Section(title string, headers []string) error {
+	if err := t.w.AddSheet(title); err != nil {
+		return err
+	}
+
+	cells := make([]xlsx.Cell, len(headers))
+	for i, header := range headers {
+		cells[i] = xlsx.Header(header)
+	}
+
+	return t.w.WriteRow(cells...)
+}
+
+func (t *xlsxTable) Row(cells ...cell) error {
+	row := make([]xlsx.Cell, len(cells))
+	for i, c := range cells {
+		switch c.kind {
+		case kindInt:
+			row[i] = xlsx.Number(c.number, 0)
+		case kindRate:
+			row[i] = xlsx.Number(c.number, t.w.NumberFormat("0.######"))
+		case kindMoney:
+			row[i] = xlsx.Number(c.money.Round(c.currency).Float64(), t.w.NumberFormat(moneyFormat(c.currency)))
+		case kindTime:
+			row[i] = xlsx.Time(c.time, t.w.NumberFormat(t.locale.xlsxTimeFormat))
+		default:
+			row[i] = xlsx.String(c.text)
+		}
+	}
+
+	return t.w.WriteRow(row...)
+}
+
+func (t *xlsxTable) Close() error {
+	return t.w.Close()
+}
+
+// moneyFormat числовой формат Excel для сумм в валюте: разряды, знаки после запятой и код валюты
+func moneyFormat(currency string) string {
+	format := "#,##0"
+	if digits := model.CurrencyDigits(currency); digits > 0 {
+		format += "." + strings.Repeat("0", digits)
+	}
+
+	return fmt.Sprintf(`%s\ "%s"`, format, strings.ToUpper(currency))
+}
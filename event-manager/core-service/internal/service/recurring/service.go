@@ -0,0 +1,388 @@
+package recurring
+
+import (
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, recurring model.RecurringExpense) (int, error)
+	GetById(ctx context.Context, id int) (model.RecurringExpense, error)
+	GetByIdForUpdate(ctx context.Context, id int) (model.RecurringExpense, error)
+	ListByEvent(ctx context.Context, eventId int) ([]model.RecurringExpense, error)
+	ListDueIds(ctx context.Context, date time.Time) ([]int, error)
+	UpdateNextDate(ctx context.Context, id int, nextDate time.Time) error
+	UpdateSplit(ctx context.Context, id int, splitMethod string, split model.RecurringSplit) error
+	Delete(ctx context.Context, id int) error
+}
+
+// ExpenseService создание расходов по правилу
+type ExpenseService interface {
+	ValidateSplit(amount model.Money, currency, splitMethod string, userIds []int, weights []domain.ExpenseShareRequest) error
+	CreateOccurrence(ctx context.Context, recurring model.RecurringExpense, date time.Time) (int, error)
+}
+
+type BudgetService interface {
+	CheckCategory(ctx context.Context, eventId, categoryId int) error
+	NotifyBudget(ctx context.Context, eventId int)
+}
+
+type EventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Service struct {
+	repo       Repository
+	expenses   ExpenseService
+	budget     BudgetService
+	eventRepo  EventRepo
+	transactor Transactor
+	logger     *zap.SugaredLogger
+}
+
+func NewService(repo Repository, expenses ExpenseService, budget BudgetService, eventRepo EventRepo, transactor Transactor, logger *zap.SugaredLogger) Service {
+	return Service{
+		repo:       repo,
+		expenses:   expenses,
+		budget:     budget,
+		eventRepo:  eventRepo,
+		transactor: transactor,
+		logger:     logger,
+	}
+}
+
+// Create сохраняет правило повторяющегося расхода в пределах дат события и в той же транзакции
+// создает расходы за уже наступившие даты
+func (s Service) Create(ctx context.Context, eventId, userId int, req domain.RecurringExpenseCreateRequest) (*domain.RecurringExpenseResponse, error) {
+	currency := strings.ToUpper(req.Currency)
+	amount := req.Amount.Round(currency)
+	if amount <= 0 {
+		return nil, errors.WithMessagef(model.ErrInvalidExpenseSplit, "amount is less than the minor unit of %s", currency)
+	}
+
+	if err := s.expenses.ValidateSplit(amount, currency, req.SplitMethod, req.UserIDs, req.Shares); err != nil {
+		return nil, err
+	}
+
+	if req.CategoryID != nil {
+		if err := s.budget.CheckCategory(ctx, eventId, *req.CategoryID); err != nil {
+			return nil, errors.WithMessage(err, "check expense category")
+		}
+	}
+
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get event")
+	}
+	if event.Status.ReadOnly() {
+		return nil, model.ErrEventArchived
+	}
+
+	start, end, err := recurrenceRange(event, req.StartDate, req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	recurring := model.RecurringExpense{
+		EventID:     eventId,
+		CreatedBy:   userId,
+		CategoryID:  req.CategoryID,
+		Description: req.Description,
+		Amount:      amount,
+		Currency:    currency,
+		SplitMethod: req.SplitMethod,
+		Split:       toSplit(req.UserIDs, req.Shares),
+		Frequency:   req.Frequency,
+		NextDate:    start,
+		EndDate:     end,
+	}
+
+	var created int
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		recurring.RecurringID, err = s.repo.Create(ctx, recurring)
+		if err != nil {
+			s.logger.Errorw("Failed to create recurring expense", "error", err, "eventId", eventId)
+			return errors.WithMessage(err, "create recurring expense")
+		}
+
+		_, created, err = s.generateLocked(ctx, recurring.RecurringID, today(time.Now()))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if created > 0 {
+		s.budget.NotifyBudget(ctx, eventId)
+	}
+
+	recurring, err = s.repo.GetById(ctx, recurring.RecurringID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get recurring expense")
+	}
+
+	resp := toResponse(recurring)
+	return &resp, nil
+}
+
+func (s Service) List(ctx context.Context, eventId int) (domain.RecurringExpensesResponse, error) {
+	recurring, err := s.repo.ListByEvent(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list recurring expenses", "error", err, "eventId", eventId)
+		return domain.RecurringExpensesResponse{}, errors.WithMessage(err, "list recurring expenses")
+	}
+
+	items := make([]domain.RecurringExpenseResponse, len(recurring))
+	for i, r := range recurring {
+		items[i] = toResponse(r)
+	}
+
+	return domain.RecurringExpensesResponse{Items: items}, nil
+}
+
+// Delete останавливает повторяющийся расход. Уже созданные расходы не удаляются
+func (s Service) Delete(ctx context.Context, eventId, recurringId int) error {
+	recurring, err := s.repo.GetById(ctx, recurringId)
+	if err != nil {
+		return errors.WithMessage(err, "get recurring expense")
+	}
+	if recurring.EventID != eventId {
+		return errors.WithMessage(model.ErrRecurringExpenseNotFound, "recurring expense belongs to another event")
+	}
+
+	if err := s.repo.Delete(ctx, recurringId); err != nil {
+		s.logger.Errorw("Failed to delete recurring expense", "error", err, "recurringId", recurringId)
+		return errors.WithMessage(err, "delete recurring expense")
+	}
+
+	return nil
+}
+
+// ReleaseParticipant исключает пользователя из долей правил события, чтобы новые расходы на него
+// не создавались. Доли процентом и точной суммой становятся весами оставшихся участников, и сумма
+// правила делится между ними в прежней пропорции. Правила, созданные пользователем или оставшиеся
+// без участников, останавливаются. Вызывается в транзакции удаления участника
+func (s Service) ReleaseParticipant(ctx context.Context, eventId, userId int) error {
+	rules, err := s.repo.ListByEvent(ctx, eventId)
+	if err != nil {
+		return errors.WithMessage(err, "list recurring expenses")
+	}
+
+	for _, rule := range rules {
+		// Правило блокируется, чтобы планировщик не создал расход по прежним долям
+		rule, err := s.repo.GetByIdForUpdate(ctx, rule.RecurringID)
+		if err != nil {
+			return errors.WithMessage(err, "get recurring expense")
+		}
+
+		split, splitMethod, changed := withoutUser(rule.Split, rule.SplitMethod, userId)
+		switch {
+		case rule.CreatedBy == userId || changed && len(split.UserIDs) == 0 && len(split.Shares) == 0:
+			if err := s.repo.Delete(ctx, rule.RecurringID); err != nil {
+				return errors.WithMessage(err, "delete recurring expense")
+			}
+		case changed:
+			if err := s.repo.UpdateSplit(ctx, rule.RecurringID, splitMethod, split); err != nil {
+				return errors.WithMessage(err, "update recurring expense split")
+			}
+		}
+	}
+
+	return nil
+}
+
+// GenerateDue создает расходы всех правил за даты до now включительно. Ошибка одного правила
+// не останавливает обработку остальных, оно будет обработано при следующем запуске
+func (s Service) GenerateDue(ctx context.Context, now time.Time) error {
+	date := today(now)
+	ids, err := s.repo.ListDueIds(ctx, date)
+	if err != nil {
+		return errors.WithMessage(err, "list due recurring expenses")
+	}
+
+	for _, id := range ids {
+		if err := s.generate(ctx, id, date); err != nil {
+			s.logger.Errorw("Failed to generate recurring expense", "error", err, "recurringId", id)
+		}
+	}
+
+	return nil
+}
+
+// Run запускает планировщик, который создает расходы по правилам каждые interval до отмены ctx
+func (s Service) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.GenerateDue(ctx, time.Now()); err != nil {
+			s.logger.Errorw("Failed to generate recurring expenses", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generate создает расходы правила за все даты до date в одной транзакции и проверяет лимиты бюджета после нее
+func (s Service) generate(ctx context.Context, recurringId int, date time.Time) error {
+	var eventId, created int
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		eventId, created, err = s.generateLocked(ctx, recurringId, date)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if created > 0 {
+		s.budget.NotifyBudget(ctx, eventId)
+	}
+
+	return nil
+}
+
+// generateLocked создает расходы правила за даты с NextDate до date и окончания правила или события
+// и сдвигает дату следующего расхода. Возвращает событие правила и число созданных расходов.
+// Вызывается в транзакции, правило блокируется до ее завершения
+func (s Service) generateLocked(ctx context.Context, recurringId int, date time.Time) (int, int, error) {
+	recurring, err := s.repo.GetByIdForUpdate(ctx, recurringId)
+	if err != nil {
+		return 0, 0, errors.WithMessage(err, "get recurring expense")
+	}
+
+	event, err := s.eventRepo.GetById(ctx, recurring.EventID)
+	if err != nil {
+		return 0, 0, errors.WithMessage(err, "get event")
+	}
+
+	end := today(event.EndDate)
+	if recurring.EndDate != nil && recurring.EndDate.Before(end) {
+		end = *recurring.EndDate
+	}
+
+	created := 0
+	next := recurring.NextDate
+	for ; !next.After(date) && !next.After(end); next = recurring.Step(next) {
+		if _, err := s.expenses.CreateOccurrence(ctx, recurring, next); err != nil {
+			return 0, 0, errors.WithMessagef(err, "create expense for %s", next.Format(time.DateOnly))
+		}
+		created++
+	}
+
+	if created == 0 {
+		return recurring.EventID, 0, nil
+	}
+
+	if err := s.repo.UpdateNextDate(ctx, recurringId, next); err != nil {
+		return 0, 0, err
+	}
+
+	return recurring.EventID, created, nil
+}
+
+// recurrenceRange определяет даты первого и последнего расхода. Даты по умолчанию берутся из события,
+// явно заданные даты должны лежать в его пределах
+func recurrenceRange(event model.Event, startDate, endDate *time.Time) (time.Time, *time.Time, error) {
+	eventStart, eventEnd := today(event.StartDate), today(event.EndDate)
+
+	start := eventStart
+	if startDate != nil {
+		start = today(*startDate)
+	}
+
+	var end *time.Time
+	if endDate != nil {
+		date := today(*endDate)
+		end = &date
+	}
+
+	switch {
+	case start.Before(eventStart) || start.After(eventEnd):
+		return time.Time{}, nil, errors.WithMessage(model.ErrInvalidRecurrence, "start date is outside of the event dates")
+	case end != nil && end.After(eventEnd):
+		return time.Time{}, nil, errors.WithMessage(model.ErrInvalidRecurrence, "end date is after the event end")
+	case end != nil && end.Before(start):
+		return time.Time{}, nil, errors.WithMessage(model.ErrInvalidRecurrence, "end date is before start date")
+	}
+
+	return start, end, nil
+}
+
+// today отбрасывает время, оставляя календарную дату в UTC
+func today(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// withoutUser возвращает доли правила без пользователя и метод разделения для оставшихся.
+// changed = false, если пользователя в долях не было
+func withoutUser(split model.RecurringSplit, splitMethod string, userId int) (model.RecurringSplit, string, bool) {
+	var result model.RecurringSplit
+	changed := false
+	for _, id := range split.UserIDs {
+		if id == userId {
+			changed = true
+			continue
+		}
+		result.UserIDs = append(result.UserIDs, id)
+	}
+	for _, share := range split.Shares {
+		if share.UserID == userId {
+			changed = true
+			continue
+		}
+		result.Shares = append(result.Shares, share)
+	}
+
+	if changed && (splitMethod == model.SplitMethodPercent || splitMethod == model.SplitMethodExact) {
+		splitMethod = model.SplitMethodShares
+	}
+
+	return result, splitMethod, changed
+}
+
+func toSplit(userIds []int, weights []domain.ExpenseShareRequest) model.RecurringSplit {
+	split := model.RecurringSplit{UserIDs: userIds}
+	for _, w := range weights {
+		split.Shares = append(split.Shares, model.RecurringShare{UserID: w.UserID, Value: w.Value})
+	}
+
+	return split
+}
+
+func toResponse(recurring model.RecurringExpense) domain.RecurringExpenseResponse {
+	resp := domain.RecurringExpenseResponse{
+		RecurringID: recurring.RecurringID,
+		EventID:     recurring.EventID,
+		CreatedBy:   recurring.CreatedBy,
+		CategoryID:  recurring.CategoryID,
+		Description: recurring.Description,
+		Amount:      recurring.Amount,
+		Currency:    recurring.Currency,
+		SplitMethod: recurring.SplitMethod,
+		UserIDs:     recurring.Split.UserIDs,
+		Frequency:   recurring.Frequency,
+		NextDate:    recurring.NextDate,
+		EndDate:     recurring.EndDate,
+		CreatedAt:   recurring.CreatedAt,
+	}
+	for _, share := range recurring.Split.Shares {
+		resp.Shares = append(resp.Shares, domain.ExpenseShareRequest{UserID: share.UserID, Value: share.Value})
+	}
+
+	return resp
+}
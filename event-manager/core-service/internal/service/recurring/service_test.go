@@ -0,0 +1,206 @@
+package recurring
+
+import (
+	"context"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// Mock репозитория повторяющихся расходов
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, recurring model.RecurringExpense) (int, error) {
+	args := m.Called(ctx, recurring)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) GetById(ctx context.Context, id int) (model.RecurringExpense, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.RecurringExpense), args.Error(1)
+}
+
+func (m *MockRepository) GetByIdForUpdate(ctx context.Context, id int) (model.RecurringExpense, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.RecurringExpense), args.Error(1)
+}
+
+func (m *MockRepository) ListByEvent(ctx context.Context, eventId int) ([]model.RecurringExpense, error) {
+	args := m.Called(ctx, eventId)
+	return args.Get(0).([]model.RecurringExpense), args.Error(1)
+}
+
+func (m *MockRepository) ListDueIds(ctx context.Context, date time.Time) ([]int, error) {
+	args := m.Called(ctx, date)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (m *MockRepository) UpdateNextDate(ctx context.Context, id int, nextDate time.Time) error {
+	args := m.Called(ctx, id, nextDate)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateSplit(ctx context.Context, id int, splitMethod string, split model.RecurringSplit) error {
+	args := m.Called(ctx, id, splitMethod, split)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Сервис расходов, запоминающий даты созданных расходов
+type recordingExpenses struct {
+	dates []time.Time
+}
+
+func (e *recordingExpenses) ValidateSplit(amount model.Money, currency, splitMethod string, userIds []int, weights []domain.ExpenseShareRequest) error {
+	return nil
+}
+
+func (e *recordingExpenses) CreateOccurrence(ctx context.Context, recurring model.RecurringExpense, date time.Time) (int, error) {
+	e.dates = append(e.dates, date)
+	return len(e.dates), nil
+}
+
+// Бюджет без лимитов
+type noBudget struct{}
+
+func (noBudget) CheckCategory(ctx context.Context, eventId, categoryId int) error {
+	return nil
+}
+
+func (noBudget) NotifyBudget(ctx context.Context, eventId int) {}
+
+// Событие с 1 по 10 июня
+type juneEvent struct{}
+
+func (juneEvent) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	return model.Event{
+		EventId:   eventID,
+		StartDate: date(2025, 6, 1).Add(10 * time.Hour),
+		EndDate:   date(2025, 6, 10).Add(18 * time.Hour),
+	}, nil
+}
+
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func setupService() (*Service, *MockRepository, *recordingExpenses) {
+	repo := new(MockRepository)
+	expenses := &recordingExpenses{}
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(repo, expenses, noBudget{}, juneEvent{}, noTx{}, logger.Sugar())
+
+	return &service, repo, expenses
+}
+
+// Тест 1: Планировщик создает расходы за все пропущенные дни до текущей даты и сдвигает дату следующего
+func TestGenerateDue_CatchUp(t *testing.T) {
+	service, repo, expenses := setupService()
+	ctx := context.Background()
+	now := date(2025, 6, 4).Add(15 * time.Hour)
+
+	repo.On("ListDueIds", ctx, date(2025, 6, 4)).Return([]int{7}, nil)
+	repo.On("GetByIdForUpdate", ctx, 7).Return(model.RecurringExpense{
+		RecurringID: 7, EventID: 1, Frequency: model.FrequencyDaily, NextDate: date(2025, 6, 2),
+	}, nil)
+	repo.On("UpdateNextDate", ctx, 7, date(2025, 6, 5)).Return(nil)
+
+	err := service.GenerateDue(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{date(2025, 6, 2), date(2025, 6, 3), date(2025, 6, 4)}, expenses.dates)
+	repo.AssertExpectations(t)
+}
+
+// Тест 2: Еженедельные расходы не создаются после окончания события
+func TestGenerateDue_StopsAtEventEnd(t *testing.T) {
+	service, repo, expenses := setupService()
+	ctx := context.Background()
+	now := date(2025, 7, 1)
+
+	repo.On("ListDueIds", ctx, now).Return([]int{7}, nil)
+	repo.On("GetByIdForUpdate", ctx, 7).Return(model.RecurringExpense{
+		RecurringID: 7, EventID: 1, Frequency: model.FrequencyWeekly, NextDate: date(2025, 6, 1),
+	}, nil)
+	repo.On("UpdateNextDate", ctx, 7, date(2025, 6, 15)).Return(nil)
+
+	err := service.GenerateDue(ctx, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{date(2025, 6, 1), date(2025, 6, 8)}, expenses.dates)
+	repo.AssertExpectations(t)
+}
+
+// Тест 3: Даты правила по умолчанию берутся из события и должны лежать в его пределах
+func TestRecurrenceRange(t *testing.T) {
+	event, _ := juneEvent{}.GetById(context.Background(), 1)
+
+	start, end, err := recurrenceRange(event, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, date(2025, 6, 1), start)
+	assert.Nil(t, end)
+
+	startDate, endDate := date(2025, 6, 3).Add(9*time.Hour), date(2025, 6, 5)
+	start, end, err = recurrenceRange(event, &startDate, &endDate)
+	assert.NoError(t, err)
+	assert.Equal(t, date(2025, 6, 3), start)
+	assert.Equal(t, date(2025, 6, 5), *end)
+
+	lateEnd := date(2025, 6, 11)
+	_, _, err = recurrenceRange(event, nil, &lateEnd)
+	assert.ErrorIs(t, err, model.ErrInvalidRecurrence)
+
+	_, _, err = recurrenceRange(event, &endDate, &startDate)
+	assert.ErrorIs(t, err, model.ErrInvalidRecurrence)
+}
+
+// Тест 4: Удаленный участник исключается из долей правил, правила без участников и созданные им останавливаются
+func TestReleaseParticipant(t *testing.T) {
+	service, repo, _ := setupService()
+	ctx := context.Background()
+
+	rules := []model.RecurringExpense{
+		{RecurringID: 1, EventID: 1, CreatedBy: 1, SplitMethod: model.SplitMethodEqual, Split: model.RecurringSplit{UserIDs: []int{1, 2, 3}}},
+		{RecurringID: 2, EventID: 1, CreatedBy: 1, SplitMethod: model.SplitMethodPercent, Split: model.RecurringSplit{
+			Shares: []model.RecurringShare{{UserID: 1, Value: 50}, {UserID: 2, Value: 30}, {UserID: 3, Value: 20}},
+		}},
+		{RecurringID: 3, EventID: 1, CreatedBy: 1, SplitMethod: model.SplitMethodEqual, Split: model.RecurringSplit{UserIDs: []int{2}}},
+		{RecurringID: 4, EventID: 1, CreatedBy: 2, SplitMethod: model.SplitMethodEqual, Split: model.RecurringSplit{UserIDs: []int{1, 3}}},
+		{RecurringID: 5, EventID: 1, CreatedBy: 1, SplitMethod: model.SplitMethodEqual, Split: model.RecurringSplit{UserIDs: []int{1, 3}}},
+	}
+	repo.On("ListByEvent", ctx, 1).Return(rules, nil)
+	for _, rule := range rules {
+		repo.On("GetByIdForUpdate", ctx, rule.RecurringID).Return(rule, nil)
+	}
+	repo.On("UpdateSplit", ctx, 1, model.SplitMethodEqual, model.RecurringSplit{UserIDs: []int{1, 3}}).Return(nil)
+	// Проценты становятся весами, чтобы оставшиеся участники делили всю сумму в прежней пропорции
+	repo.On("UpdateSplit", ctx, 2, model.SplitMethodShares, model.RecurringSplit{
+		Shares: []model.RecurringShare{{UserID: 1, Value: 50}, {UserID: 3, Value: 20}},
+	}).Return(nil)
+	repo.On("Delete", ctx, 3).Return(nil)
+	repo.On("Delete", ctx, 4).Return(nil)
+
+	err := service.ReleaseParticipant(ctx, 1, 2)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "UpdateSplit", ctx, 5, mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "Delete", ctx, 5)
+}
@@ -8,7 +8,7 @@ import (
 )
 
 type ParticipantService interface {
-	ListByEvent(ctx context.Context, eventID int, page, size int) (*domain.EventParticipantsResponse, error)
+	ListByEvent(ctx context.Context, eventId int, req domain.ParticipantListRequest) (*domain.EventParticipantsResponse, error)
 }
 
 type EventService interface {
@@ -24,25 +24,37 @@ type CommentsRepo interface {
 }
 
 type ExpenseService interface {
-	ListExpensesByEvent(ctx context.Context, eventId int, page, size int) (domain.ExpensesResponse, error)
+	ListExpenses(ctx context.Context, req domain.ExpenseListRequest) (domain.ExpensesResponse, error)
 	GetEventBalanceReport(ctx context.Context, eventId int) (domain.BalanceReportResponse, error)
 }
 
+type SettlementService interface {
+	GetPlan(ctx context.Context, eventId int) (domain.SettlementPlanResponse, error)
+}
+
+type BudgetService interface {
+	GetReport(ctx context.Context, eventId int) (domain.BudgetReportResponse, error)
+}
+
 type Service struct {
 	taskService        TaskService
 	participantService ParticipantService
 	eventService       EventService
 	commentsRepo       CommentsRepo
 	expenseService     ExpenseService
+	settlementService  SettlementService
+	budgetService      BudgetService
 }
 
-func NewService(taskService TaskService, participantService ParticipantService, eventService EventService, commentsRepo CommentsRepo, expenseService ExpenseService) Service {
+func NewService(taskService TaskService, participantService ParticipantService, eventService EventService, commentsRepo CommentsRepo, expenseService ExpenseService, settlementService SettlementService, budgetService BudgetService) Service {
 	return Service{
 		taskService:        taskService,
 		participantService: participantService,
 		eventService:       eventService,
 		commentsRepo:       commentsRepo,
 		expenseService:     expenseService,
+		settlementService:  settlementService,
+		budgetService:      budgetService,
 	}
 }
 
@@ -57,7 +69,7 @@ func (s Service) GetEventSummary(ctx context.Context, eventId int) (*domain.Even
 		return nil, errors.WithMessage(err, "get tasks by event id")
 	}
 
-	participants, err := s.participantService.ListByEvent(ctx, eventId, 1, 100) // todo delete pagination
+	participants, err := s.participantService.ListByEvent(ctx, eventId, domain.ParticipantListRequest{Size: 100}) // todo delete pagination
 	if err != nil {
 		return nil, errors.WithMessage(err, "get participants by event id")
 	}
@@ -67,7 +79,8 @@ func (s Service) GetEventSummary(ctx context.Context, eventId int) (*domain.Even
 		return nil, errors.WithMessage(err, "get comments by event id")
 	}
 
-	expenses, err := s.expenseService.ListExpensesByEvent(ctx, eventId, 1, 100)
+	// Первая страница расходов, остальные доступны через GET /expenses по next_cursor
+	expenses, err := s.expenseService.ListExpenses(ctx, domain.ExpenseListRequest{EventID: eventId, Limit: 100})
 	if err != nil {
 		return nil, errors.WithMessage(err, "get expenses by event id")
 	}
@@ -77,6 +90,16 @@ func (s Service) GetEventSummary(ctx context.Context, eventId int) (*domain.Even
 		return nil, errors.WithMessage(err, "get balance report by event id")
 	}
 
+	settlements, err := s.settlementService.GetPlan(ctx, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get settlement plan by event id")
+	}
+
+	budget, err := s.budgetService.GetReport(ctx, eventId)
+	if err != nil {
+		return nil, errors.WithMessage(err, "get budget report by event id")
+	}
+
 	return &domain.EventData{
 		EventParticipants: *participants,
 		EventData:         *event,
@@ -84,5 +107,7 @@ func (s Service) GetEventSummary(ctx context.Context, eventId int) (*domain.Even
 		Comments:          *comments,
 		Expenses:          expenses,
 		BalanceReport:     balanceReport,
+		Settlements:       settlements,
+		Budget:            budget,
 	}, nil
 }
@@ -0,0 +1,240 @@
+package settlement
+
+import (
+	"context"
+	"database/sql"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, settlement model.Settlement) (int, error)
+	ListByEvent(ctx context.Context, eventId int) ([]model.Settlement, error)
+}
+
+type ExpenseShareRepository interface {
+	GetEventBalanceReport(ctx context.Context, eventId int) ([]domain.UserBalance, error)
+	ListUnpaidSharesBetween(ctx context.Context, eventId, debtorId, creditorId int) ([]model.ExpenseShare, error)
+	HasUnpaidShares(ctx context.Context, eventId, userId int) (bool, error)
+	MarkSharesPaidBySettlement(ctx context.Context, settlementId int, shareIds []int) error
+}
+
+type ParticipantRepo interface {
+	GetByEventAndUser(ctx context.Context, eventId, userId int) (model.EventParticipant, error)
+}
+
+type EventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type CurrencyService interface {
+	BaseCurrency(ctx context.Context, eventId int) (string, error)
+}
+
+type Service struct {
+	repo             Repository
+	expenseShareRepo ExpenseShareRepository
+	participantRepo  ParticipantRepo
+	eventRepo        EventRepo
+	currencyService  CurrencyService
+	transactor       Transactor
+	logger           *zap.SugaredLogger
+}
+
+func NewService(repo Repository, expenseShareRepo ExpenseShareRepository, participantRepo ParticipantRepo, eventRepo EventRepo, currencyService CurrencyService, transactor Transactor, logger *zap.SugaredLogger) Service {
+	return Service{
+		repo:             repo,
+		expenseShareRepo: expenseShareRepo,
+		participantRepo:  participantRepo,
+		eventRepo:        eventRepo,
+		currencyService:  currencyService,
+		transactor:       transactor,
+		logger:           logger,
+	}
+}
+
+// checkParty проверяет сторону платежа: участника события или бывшего участника, у которого
+// остались неоплаченные доли или долги других по его расходам
+func (s Service) checkParty(ctx context.Context, eventId, userId int) error {
+	_, err := s.participantRepo.GetByEventAndUser(ctx, eventId, userId)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return errors.WithMessage(err, "get participant")
+	}
+
+	open, err := s.expenseShareRepo.HasUnpaidShares(ctx, eventId, userId)
+	if err != nil {
+		return errors.WithMessage(err, "check unpaid shares")
+	}
+	if !open {
+		return errors.WithMessagef(model.ErrInvalidSettlement, "user %d is not a participant of event", userId)
+	}
+
+	return nil
+}
+
+// GetPlan возвращает минимальный набор переводов, погашающий все долги события, и уже проведенные платежи
+func (s Service) GetPlan(ctx context.Context, eventId int) (domain.SettlementPlanResponse, error) {
+	balances, err := s.expenseShareRepo.GetEventBalanceReport(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get balances for settlement plan", "error", err, "eventId", eventId)
+		return domain.SettlementPlanResponse{}, errors.WithMessage(err, "get event balances")
+	}
+
+	settlements, err := s.repo.ListByEvent(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list event settlements", "error", err, "eventId", eventId)
+		return domain.SettlementPlanResponse{}, errors.WithMessage(err, "list settlements")
+	}
+
+	payments := make([]domain.SettlementResponse, len(settlements))
+	for i, settlement := range settlements {
+		payments[i] = toResponse(settlement, nil)
+	}
+
+	currency, err := s.currencyService.BaseCurrency(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event base currency", "error", err, "eventId", eventId)
+		return domain.SettlementPlanResponse{}, errors.WithMessage(err, "get base currency")
+	}
+
+	return domain.SettlementPlanResponse{
+		EventID:   eventId,
+		Currency:  currency,
+		Transfers: simplifyDebts(balances),
+		Payments:  payments,
+	}, nil
+}
+
+// Record регистрирует платеж FromUserID -> ToUserID в базовой валюте события и отмечает оплаченными
+// неоплаченные доли плательщика в расходах получателя, начиная с самых старых, пока их полностью покрывает сумма платежа.
+// В архивном событии платежи не регистрируются
+func (s Service) Record(ctx context.Context, eventId, userId int, req domain.SettlementCreateRequest) (*domain.SettlementResponse, error) {
+	if req.FromUserID == req.ToUserID {
+		return nil, errors.WithMessage(model.ErrInvalidSettlement, "payer and receiver must differ")
+	}
+
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for settlement", "error", err, "eventId", eventId)
+		return nil, errors.WithMessage(err, "get event")
+	}
+	if event.Status.ReadOnly() {
+		return nil, model.ErrEventArchived
+	}
+
+	for _, id := range []int{req.FromUserID, req.ToUserID} {
+		if err := s.checkParty(ctx, eventId, id); err != nil {
+			return nil, err
+		}
+	}
+
+	settlement := model.Settlement{
+		EventID:    eventId,
+		FromUserID: req.FromUserID,
+		ToUserID:   req.ToUserID,
+		Amount:     req.Amount,
+		CreatedBy:  userId,
+		CreatedAt:  time.Now(),
+	}
+
+	// Платеж и отметки об оплате долей сохраняются атомарно
+	paidShareIds := make([]int, 0)
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.repo.Create(ctx, settlement)
+		if err != nil {
+			s.logger.Errorw("Failed to create settlement", "error", err, "eventId", eventId)
+			return errors.WithMessage(err, "create settlement")
+		}
+		settlement.SettlementID = id
+
+		shares, err := s.expenseShareRepo.ListUnpaidSharesBetween(ctx, eventId, req.FromUserID, req.ToUserID)
+		if err != nil {
+			s.logger.Errorw("Failed to list unpaid shares for settlement", "error", err, "settlementId", id)
+			return errors.WithMessage(err, "list unpaid shares")
+		}
+
+		remaining := req.Amount
+		for _, share := range shares {
+			if share.BaseAmount > remaining {
+				continue
+			}
+			remaining -= share.BaseAmount
+			paidShareIds = append(paidShareIds, share.ShareID)
+		}
+
+		if err := s.expenseShareRepo.MarkSharesPaidBySettlement(ctx, id, paidShareIds); err != nil {
+			s.logger.Errorw("Failed to mark shares paid by settlement", "error", err, "settlementId", id)
+			return errors.WithMessage(err, "mark shares paid")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toResponse(settlement, paidShareIds)
+	return &resp, nil
+}
+
+// simplifyDebts жадно сводит балансы к минимальному числу переводов (min cash flow):
+// на каждом шаге участник с наибольшим долгом платит участнику с наибольшим требованием
+func simplifyDebts(balances []domain.UserBalance) []domain.SettlementTransfer {
+	amounts := make([]model.Money, len(balances))
+	for i, balance := range balances {
+		amounts[i] = balance.Balance
+	}
+
+	transfers := make([]domain.SettlementTransfer, 0)
+	for {
+		creditor, debtor := -1, -1
+		for i, amount := range amounts {
+			if amount > 0 && (creditor == -1 || amount > amounts[creditor]) {
+				creditor = i
+			}
+			if amount < 0 && (debtor == -1 || amount < amounts[debtor]) {
+				debtor = i
+			}
+		}
+		if creditor == -1 || debtor == -1 {
+			break
+		}
+
+		amount := min(amounts[creditor], -amounts[debtor])
+		amounts[creditor] -= amount
+		amounts[debtor] += amount
+
+		transfers = append(transfers, domain.SettlementTransfer{
+			FromUserID:   balances[debtor].UserID,
+			FromUsername: balances[debtor].Username,
+			ToUserID:     balances[creditor].UserID,
+			ToUsername:   balances[creditor].Username,
+			Amount:       amount,
+		})
+	}
+
+	return transfers
+}
+
+func toResponse(settlement model.Settlement, paidShareIds []int) domain.SettlementResponse {
+	return domain.SettlementResponse{
+		Id:           settlement.SettlementID,
+		EventID:      settlement.EventID,
+		FromUserID:   settlement.FromUserID,
+		ToUserID:     settlement.ToUserID,
+		Amount:       settlement.Amount,
+		CreatedBy:    settlement.CreatedBy,
+		CreatedAt:    settlement.CreatedAt,
+		PaidShareIDs: paidShareIds,
+	}
+}
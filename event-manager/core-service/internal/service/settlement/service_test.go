@@ -0,0 +1,238 @@
+package settlement
+
+import (
+	"context"
+	"database/sql"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"testing"
+)
+
+// Mock репозитория платежей
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, settlement model.Settlement) (int, error) {
+	args := m.Called(ctx, settlement)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockRepository) ListByEvent(ctx context.Context, eventId int) ([]model.Settlement, error) {
+	args := m.Called(ctx, eventId)
+	return args.Get(0).([]model.Settlement), args.Error(1)
+}
+
+// Mock репозитория долей расходов
+type MockExpenseShareRepository struct {
+	mock.Mock
+}
+
+func (m *MockExpenseShareRepository) GetEventBalanceReport(ctx context.Context, eventId int) ([]domain.UserBalance, error) {
+	args := m.Called(ctx, eventId)
+	return args.Get(0).([]domain.UserBalance), args.Error(1)
+}
+
+func (m *MockExpenseShareRepository) ListUnpaidSharesBetween(ctx context.Context, eventId, debtorId, creditorId int) ([]model.ExpenseShare, error) {
+	args := m.Called(ctx, eventId, debtorId, creditorId)
+	return args.Get(0).([]model.ExpenseShare), args.Error(1)
+}
+
+func (m *MockExpenseShareRepository) HasUnpaidShares(ctx context.Context, eventId, userId int) (bool, error) {
+	args := m.Called(ctx, eventId, userId)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockExpenseShareRepository) MarkSharesPaidBySettlement(ctx context.Context, settlementId int, shareIds []int) error {
+	args := m.Called(ctx, settlementId, shareIds)
+	return args.Error(0)
+}
+
+// Mock репозитория участников
+type MockParticipantRepo struct {
+	mock.Mock
+}
+
+func (m *MockParticipantRepo) GetByEventAndUser(ctx context.Context, eventId, userId int) (model.EventParticipant, error) {
+	args := m.Called(ctx, eventId, userId)
+	return args.Get(0).(model.EventParticipant), args.Error(1)
+}
+
+// Валюта всех событий в тестах
+type rubCurrency struct{}
+
+func (rubCurrency) BaseCurrency(ctx context.Context, eventId int) (string, error) {
+	return "RUB", nil
+}
+
+// Событие в заданном статусе
+type eventStatus model.EventStatus
+
+func (s eventStatus) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	return model.Event{EventId: eventID, Status: model.EventStatus(s)}, nil
+}
+
+// money переводит сумму в рублях в model.Money
+func money(amount float64) model.Money {
+	return model.MoneyFromFloat(amount)
+}
+
+// Транзакция, выполняющая функцию без обращения к БД
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func setupService() (*Service, *MockRepository, *MockExpenseShareRepository, *MockParticipantRepo) {
+	repo := new(MockRepository)
+	expenseShareRepo := new(MockExpenseShareRepository)
+	participantRepo := new(MockParticipantRepo)
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(repo, expenseShareRepo, participantRepo, eventStatus(model.EventStatusPlanned), rubCurrency{}, noTx{}, logger.Sugar())
+
+	return &service, repo, expenseShareRepo, participantRepo
+}
+
+// Тест 1: сведение балансов к минимальному числу переводов
+func TestSimplifyDebts(t *testing.T) {
+	balances := []domain.UserBalance{
+		{UserID: 1, Username: "alice", Balance: money(60)},
+		{UserID: 2, Username: "bob", Balance: money(10)},
+		{UserID: 3, Username: "carol", Balance: money(-30)},
+		{UserID: 4, Username: "dave", Balance: money(-40)},
+	}
+
+	transfers := simplifyDebts(balances)
+
+	assert.Equal(t, []domain.SettlementTransfer{
+		{FromUserID: 4, FromUsername: "dave", ToUserID: 1, ToUsername: "alice", Amount: money(40)},
+		{FromUserID: 3, FromUsername: "carol", ToUserID: 1, ToUsername: "alice", Amount: money(20)},
+		{FromUserID: 3, FromUsername: "carol", ToUserID: 2, ToUsername: "bob", Amount: money(10)},
+	}, transfers)
+}
+
+// Тест 2: при нулевых балансах переводы не нужны
+func TestSimplifyDebts_Settled(t *testing.T) {
+	transfers := simplifyDebts([]domain.UserBalance{
+		{UserID: 1, Balance: 0},
+		{UserID: 2, Balance: 0},
+	})
+
+	assert.Empty(t, transfers)
+}
+
+// Тест 3: платеж отмечает оплаченными только полностью покрытые доли
+func TestRecord_MarksCoveredShares(t *testing.T) {
+	service, repo, expenseShareRepo, participantRepo := setupService()
+	ctx := context.Background()
+
+	participantRepo.On("GetByEventAndUser", ctx, 1, 2).Return(model.EventParticipant{}, nil)
+	participantRepo.On("GetByEventAndUser", ctx, 1, 3).Return(model.EventParticipant{}, nil)
+	repo.On("Create", ctx, mock.MatchedBy(func(s model.Settlement) bool {
+		return s.EventID == 1 && s.FromUserID == 2 && s.ToUserID == 3 && s.Amount == money(50) && s.CreatedBy == 2
+	})).Return(7, nil)
+	expenseShareRepo.On("ListUnpaidSharesBetween", ctx, 1, 2, 3).Return([]model.ExpenseShare{
+		{ShareID: 10, Amount: money(30), BaseAmount: money(30)},
+		{ShareID: 11, Amount: money(25), BaseAmount: money(25)},
+		{ShareID: 12, Amount: money(20), BaseAmount: money(20)},
+	}, nil)
+	expenseShareRepo.On("MarkSharesPaidBySettlement", ctx, 7, []int{10, 12}).Return(nil)
+
+	resp, err := service.Record(ctx, 1, 2, domain.SettlementCreateRequest{FromUserID: 2, ToUserID: 3, Amount: money(50)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, resp.Id)
+	assert.Equal(t, []int{10, 12}, resp.PaidShareIDs)
+	expenseShareRepo.AssertExpectations(t)
+}
+
+// Тест 4: платеж самому себе отклоняется
+func TestRecord_SameUser(t *testing.T) {
+	service, repo, _, _ := setupService()
+
+	_, err := service.Record(context.Background(), 1, 2, domain.SettlementCreateRequest{FromUserID: 2, ToUserID: 2, Amount: money(10)})
+
+	assert.True(t, errors.Is(err, model.ErrInvalidSettlement))
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Тест 5: получатель не участвует в событии
+func TestRecord_NotParticipant(t *testing.T) {
+	service, repo, expenseShareRepo, participantRepo := setupService()
+	ctx := context.Background()
+
+	participantRepo.On("GetByEventAndUser", ctx, 1, 2).Return(model.EventParticipant{}, nil)
+	participantRepo.On("GetByEventAndUser", ctx, 1, 9).Return(model.EventParticipant{}, sql.ErrNoRows)
+	expenseShareRepo.On("HasUnpaidShares", ctx, 1, 9).Return(false, nil)
+
+	_, err := service.Record(ctx, 1, 2, domain.SettlementCreateRequest{FromUserID: 2, ToUserID: 9, Amount: money(10)})
+
+	assert.True(t, errors.Is(err, model.ErrInvalidSettlement))
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Тест 6: в архивном событии платеж не регистрируется
+func TestRecord_ArchivedEvent(t *testing.T) {
+	repo := new(MockRepository)
+	logger, _ := zap.NewDevelopment()
+	service := NewService(repo, new(MockExpenseShareRepository), new(MockParticipantRepo), eventStatus(model.EventStatusArchived), rubCurrency{}, noTx{}, logger.Sugar())
+
+	_, err := service.Record(context.Background(), 1, 2, domain.SettlementCreateRequest{FromUserID: 2, ToUserID: 3, Amount: money(10)})
+
+	assert.True(t, errors.Is(err, model.ErrEventArchived))
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// Тест 7: должник и кредитор, удаленные из события с shares=keep, остаются в плане и могут рассчитаться
+func TestRecord_RemovedParticipantsWithKeptShares(t *testing.T) {
+	service, repo, expenseShareRepo, participantRepo := setupService()
+	ctx := context.Background()
+
+	// Пользователь 2 должен удаленному кредитору 3, удаленный должник 4 должен пользователю 2
+	expenseShareRepo.On("GetEventBalanceReport", ctx, 1).Return([]domain.UserBalance{
+		{UserID: 3, Username: "carol", Balance: money(30)},
+		{UserID: 2, Username: "bob", Balance: money(-10)},
+		{UserID: 4, Username: "dave", Balance: money(-20)},
+	}, nil)
+	repo.On("ListByEvent", ctx, 1).Return([]model.Settlement{}, nil)
+
+	plan, err := service.GetPlan(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.SettlementTransfer{
+		{FromUserID: 4, FromUsername: "dave", ToUserID: 3, ToUsername: "carol", Amount: money(20)},
+		{FromUserID: 2, FromUsername: "bob", ToUserID: 3, ToUsername: "carol", Amount: money(10)},
+	}, plan.Transfers)
+
+	participantRepo.On("GetByEventAndUser", ctx, 1, 2).Return(model.EventParticipant{}, nil)
+	participantRepo.On("GetByEventAndUser", ctx, 1, 3).Return(model.EventParticipant{}, sql.ErrNoRows)
+	participantRepo.On("GetByEventAndUser", ctx, 1, 4).Return(model.EventParticipant{}, sql.ErrNoRows)
+	expenseShareRepo.On("HasUnpaidShares", ctx, 1, 3).Return(true, nil)
+	expenseShareRepo.On("HasUnpaidShares", ctx, 1, 4).Return(true, nil)
+	repo.On("Create", ctx, mock.Anything).Return(8, nil).Once()
+	repo.On("Create", ctx, mock.Anything).Return(9, nil).Once()
+	expenseShareRepo.On("ListUnpaidSharesBetween", ctx, 1, 2, 3).Return([]model.ExpenseShare{
+		{ShareID: 20, Amount: money(10), BaseAmount: money(10)},
+	}, nil)
+	expenseShareRepo.On("ListUnpaidSharesBetween", ctx, 1, 4, 2).Return([]model.ExpenseShare{
+		{ShareID: 21, Amount: money(20), BaseAmount: money(20)},
+	}, nil)
+	expenseShareRepo.On("MarkSharesPaidBySettlement", ctx, 8, []int{20}).Return(nil)
+	expenseShareRepo.On("MarkSharesPaidBySettlement", ctx, 9, []int{21}).Return(nil)
+
+	// Текущий участник платит удаленному кредитору
+	toCreditor, err := service.Record(ctx, 1, 2, domain.SettlementCreateRequest{FromUserID: 2, ToUserID: 3, Amount: money(10)})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{20}, toCreditor.PaidShareIDs)
+
+	// Удаленный должник возвращает долг текущему участнику
+	fromDebtor, err := service.Record(ctx, 1, 2, domain.SettlementCreateRequest{FromUserID: 4, ToUserID: 2, Amount: money(20)})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{21}, fromDebtor.PaidShareIDs)
+	expenseShareRepo.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"database/sql"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
 	"github.com/pkg/errors"
 	"time"
@@ -27,18 +28,27 @@ type AssignmentRepository interface {
 	Delete(ctx context.Context, id int) error
 	ListByTask(ctx context.Context, taskId, limit, offset int) ([]model.TaskAssignment, error)
 	ListByUser(ctx context.Context, userId, limit, offset int) ([]model.TaskAssignment, error)
+	ListOpenByEventAndUser(ctx context.Context, eventId, userId int) ([]model.TaskAssignment, error)
+	Reassign(ctx context.Context, id, userId int) error
+	Unassign(ctx context.Context, id int) error
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type Service struct {
 	taskRepo       Repository
 	assignmentRepo AssignmentRepository
+	transactor     Transactor
 	logger         *zap.SugaredLogger
 }
 
-func NewService(taskRepo Repository, assignmentRepo AssignmentRepository, logger *zap.SugaredLogger) Service {
+func NewService(taskRepo Repository, assignmentRepo AssignmentRepository, transactor Transactor, logger *zap.SugaredLogger) Service {
 	return Service{
 		taskRepo:       taskRepo,
 		assignmentRepo: assignmentRepo,
+		transactor:     transactor,
 		logger:         logger,
 	}
 }
@@ -52,27 +62,40 @@ func (s Service) Create(ctx context.Context, req domain.TaskCreateRequest) (*dom
 		StoryPoints: req.StoryPoints,
 		Priority:    req.Priority,
 		Status:      string(domain.TaskStatusPending),
+		DueDate:     req.DueDate,
 		CreatedAt:   time.Now(),
 	}
 
-	id, err := s.taskRepo.Create(ctx, task)
-	if err != nil {
-		s.logger.Errorw("Failed to create task", "error", err, "eventId", req.EventId)
-		return nil, errors.WithMessage(err, "create task")
-	}
+	// Задача и ее назначение создаются атомарно
+	var id int
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.taskRepo.Create(ctx, task)
+		if err != nil {
+			s.logger.Errorw("Failed to create task", "error", err, "eventId", req.EventId)
+			return errors.WithMessage(err, "create task")
+		}
+
+		if req.AssignedTo == nil {
+			return nil
+		}
 
-	if req.AssignedTo != nil {
 		assignment := model.TaskAssignment{
 			TaskId:     id,
 			UserId:     *req.AssignedTo,
 			AssignedAt: time.Now(),
 		}
 
-		_, err := s.assignmentRepo.Create(ctx, assignment)
+		_, err = s.assignmentRepo.Create(ctx, assignment)
 		if err != nil {
-			s.logger.Warnw("Failed to create task assignment", "error", err, "taskId", id, "userId", req.AssignedTo)
-			// Continue even if one assignment fails
+			s.logger.Errorw("Failed to create task assignment", "error", err, "taskId", id, "userId", req.AssignedTo)
+			return errors.WithMessage(err, "create task assignment")
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &domain.TaskResponse{
@@ -85,6 +108,7 @@ func (s Service) Create(ctx context.Context, req domain.TaskCreateRequest) (*dom
 		StoryPoints: task.StoryPoints,
 		Priority:    task.Priority,
 		Status:      domain.TaskStatus(task.Status),
+		DueDate:     task.DueDate,
 		CreatedAt:   task.CreatedAt,
 	}, nil
 }
@@ -112,6 +136,14 @@ func (s Service) Update(ctx context.Context, id int, req domain.TaskUpdateReques
 		task.Status = string(*req.Status)
 	}
 
+	if req.DueDate != nil {
+		task.DueDate = req.DueDate
+	}
+
+	if req.ClearDueDate {
+		task.DueDate = nil
+	}
+
 	if err := s.taskRepo.Update(ctx, task); err != nil {
 		s.logger.Errorw("Failed to update task", "error", err, "id", id)
 		return errors.WithMessage(err, "update task")
@@ -251,6 +283,7 @@ func (s Service) convertToTasksResponse(ctx context.Context, tasks []model.Task)
 			ParentID:    task.ParentId,
 			Priority:    task.Priority,
 			Status:      domain.TaskStatus(task.Status),
+			DueDate:     task.DueDate,
 			CreatedAt:   task.CreatedAt,
 		}
 
@@ -273,3 +306,46 @@ func (s Service) convertToTasksResponse(ctx context.Context, tasks []model.Task)
 		Total: len(tasks),
 	}
 }
+
+// ReleaseAssignee снимает пользователя с незавершенных задач события. Если задан reassignTo, задачи
+// переходят к нему; с задач, на которые он уже назначен, прежний исполнитель просто снимается
+func (s Service) ReleaseAssignee(ctx context.Context, eventId, userId int, reassignTo *int) (domain.RemovedTasksSummary, error) {
+	summary := domain.RemovedTasksSummary{
+		Policy:       domain.RemovalTasksUnassign,
+		ReassignedTo: reassignTo,
+		Reassigned:   make([]int, 0),
+		Unassigned:   make([]int, 0),
+	}
+	if reassignTo != nil {
+		summary.Policy = domain.RemovalTasksReassign
+	}
+
+	assignments, err := s.assignmentRepo.ListOpenByEventAndUser(ctx, eventId, userId)
+	if err != nil {
+		return summary, errors.WithMessage(err, "list open assignments")
+	}
+
+	for _, assignment := range assignments {
+		if reassignTo == nil {
+			if err := s.assignmentRepo.Unassign(ctx, assignment.TaskAssignmentID); err != nil {
+				return summary, errors.WithMessage(err, "unassign task")
+			}
+			summary.Unassigned = append(summary.Unassigned, assignment.TaskId)
+			continue
+		}
+
+		_, err := s.assignmentRepo.GetByTaskAndUser(ctx, assignment.TaskId, *reassignTo)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = s.assignmentRepo.Reassign(ctx, assignment.TaskAssignmentID, *reassignTo)
+		case err == nil:
+			err = s.assignmentRepo.Unassign(ctx, assignment.TaskAssignmentID)
+		}
+		if err != nil {
+			return summary, errors.WithMessage(err, "reassign task")
+		}
+		summary.Reassigned = append(summary.Reassigned, assignment.TaskId)
+	}
+
+	return summary, nil
+}
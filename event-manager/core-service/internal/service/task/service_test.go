@@ -2,6 +2,7 @@ package task
 
 import (
 	"context"
+	"database/sql"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
 	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
 	"github.com/pkg/errors"
@@ -87,13 +88,35 @@ func (m *MockAssignmentRepository) ListByUser(ctx context.Context, userId, limit
 	return args.Get(0).([]model.TaskAssignment), args.Error(1)
 }
 
+func (m *MockAssignmentRepository) ListOpenByEventAndUser(ctx context.Context, eventId, userId int) ([]model.TaskAssignment, error) {
+	args := m.Called(ctx, eventId, userId)
+	return args.Get(0).([]model.TaskAssignment), args.Error(1)
+}
+
+func (m *MockAssignmentRepository) Reassign(ctx context.Context, id, userId int) error {
+	args := m.Called(ctx, id, userId)
+	return args.Error(0)
+}
+
+func (m *MockAssignmentRepository) Unassign(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// Транзакция, выполняющая функцию без обращения к БД
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 func setupTaskService() (*Service, *MockTaskRepository, *MockAssignmentRepository) {
 	taskRepo := new(MockTaskRepository)
 	assignmentRepo := new(MockAssignmentRepository)
 	logger, _ := zap.NewDevelopment()
 	sugar := logger.Sugar()
 
-	service := NewService(taskRepo, assignmentRepo, sugar)
+	service := NewService(taskRepo, assignmentRepo, noTx{}, sugar)
 
 	return &service, taskRepo, assignmentRepo
 }
@@ -237,8 +260,8 @@ func TestCreate_Error_TaskCreation(t *testing.T) {
 	taskRepo.AssertExpectations(t)
 }
 
-// Тест 4: Успешное создание задачи с ошибкой при создании назначения
-func TestCreate_Success_WithAssignmentError(t *testing.T) {
+// Тест 4: Ошибка при создании назначения откатывает создание задачи
+func TestCreate_Error_Assignment(t *testing.T) {
 	// Подготовка
 	service, taskRepo, assignmentRepo := setupTaskService()
 	ctx := context.Background()
@@ -267,10 +290,10 @@ func TestCreate_Success_WithAssignmentError(t *testing.T) {
 	// Действие
 	resp, err := service.Create(ctx, req)
 
-	// Проверка - задача должна быть создана успешно, несмотря на ошибку назначения
-	assert.NoError(t, err)
-	assert.NotNil(t, resp)
-	assert.Equal(t, taskID, resp.Id)
+	// Проверка - ошибка назначения возвращается, задача не создается
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, assignmentError)
+	assert.Nil(t, resp)
 
 	taskRepo.AssertExpectations(t)
 	assignmentRepo.AssertExpectations(t)
@@ -443,6 +466,26 @@ func TestUpdate_Error_UpdateTask(t *testing.T) {
 	taskRepo.AssertExpectations(t)
 }
 
+// Тест 5: Срок задачи снимается флагом clear_due_date
+func TestUpdate_ClearDueDate(t *testing.T) {
+	// Подготовка
+	service, taskRepo, _ := setupTaskService()
+	ctx := context.Background()
+	dueDate := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	taskRepo.On("GetById", ctx, 1).Return(model.Task{TaskId: 1, Title: "Title", DueDate: &dueDate}, nil)
+	taskRepo.On("Update", ctx, mock.MatchedBy(func(task model.Task) bool {
+		return task.TaskId == 1 && task.Title == "Title" && task.DueDate == nil
+	})).Return(nil)
+
+	// Действие
+	err := service.Update(ctx, 1, domain.TaskUpdateRequest{ClearDueDate: true})
+
+	// Проверка
+	assert.NoError(t, err)
+	taskRepo.AssertExpectations(t)
+}
+
 // Тесты для методов получения списка задач
 
 // Тест 1: Успешное получение списка задач по событию
@@ -621,3 +664,34 @@ func TestListByUser_Error(t *testing.T) {
 
 	taskRepo.AssertExpectations(t)
 }
+
+// Тесты для метода ReleaseAssignee
+
+// Тест 1: Задачи покинувшего событие переходят к другому участнику, повторно его не назначают
+func TestReleaseAssignee_Reassign(t *testing.T) {
+	// Подготовка
+	service, _, assignmentRepo := setupTaskService()
+	ctx := context.Background()
+	reassignTo := 7
+
+	// Настраиваем моки
+	assignmentRepo.On("ListOpenByEventAndUser", ctx, 1, 5).Return([]model.TaskAssignment{
+		{TaskAssignmentID: 10, TaskId: 100, UserId: 5},
+		{TaskAssignmentID: 11, TaskId: 101, UserId: 5},
+	}, nil)
+	assignmentRepo.On("GetByTaskAndUser", ctx, 100, reassignTo).Return(model.TaskAssignment{}, sql.ErrNoRows)
+	assignmentRepo.On("GetByTaskAndUser", ctx, 101, reassignTo).Return(model.TaskAssignment{TaskAssignmentID: 12}, nil)
+	assignmentRepo.On("Reassign", ctx, 10, reassignTo).Return(nil)
+	assignmentRepo.On("Unassign", ctx, 11).Return(nil)
+
+	// Действие
+	summary, err := service.ReleaseAssignee(ctx, 1, 5, &reassignTo)
+
+	// Проверка
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RemovalTasksReassign, summary.Policy)
+	assert.Equal(t, []int{100, 101}, summary.Reassigned)
+	assert.Empty(t, summary.Unassigned)
+
+	assignmentRepo.AssertExpectations(t)
+}
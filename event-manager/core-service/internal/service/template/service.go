@@ -0,0 +1,446 @@
+package template
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/PabloPerdolie/event-manager/core-service/pkg/rrule"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type TemplateRepo interface {
+	Create(ctx context.Context, template model.EventTemplate) (int, error)
+	GetById(ctx context.Context, id int) (model.EventTemplate, error)
+	ListByOwner(ctx context.Context, ownerId int) ([]model.EventTemplate, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type EventRepo interface {
+	GetById(ctx context.Context, eventID int) (model.Event, error)
+}
+
+// EventCreator создает событие вместе с организатором и проверяет правило повторения
+type EventCreator interface {
+	Create(ctx context.Context, userId int, req domain.EventCreateRequest) (int, error)
+}
+
+type ParticipantRepo interface {
+	ListAllByEvent(ctx context.Context, eventId int) ([]model.EventParticipant, error)
+}
+
+// MemberInviter приглашает участников в созданное событие: участие ожидает их ответа
+type MemberInviter interface {
+	InviteMembers(ctx context.Context, eventId int, members []model.TemplateParticipant) error
+}
+
+type TaskRepo interface {
+	Create(ctx context.Context, task model.Task) (int, error)
+	ListAllByEvent(ctx context.Context, eventId int) ([]model.Task, error)
+}
+
+type BudgetRepo interface {
+	CreateCategory(ctx context.Context, category model.ExpenseCategory) (int, error)
+	ListCategorySpending(ctx context.Context, eventId int) ([]model.CategorySpending, error)
+	GetEventBudget(ctx context.Context, eventId int) (model.EventBudget, error)
+	SetEventBudget(ctx context.Context, eventId int, total *model.Money) error
+}
+
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type Service struct {
+	templateRepo    TemplateRepo
+	eventRepo       EventRepo
+	events          EventCreator
+	participantRepo ParticipantRepo
+	inviter         MemberInviter
+	taskRepo        TaskRepo
+	budgetRepo      BudgetRepo
+	transactor      Transactor
+	logger          *zap.SugaredLogger
+}
+
+func NewService(templateRepo TemplateRepo, eventRepo EventRepo, events EventCreator, participantRepo ParticipantRepo,
+	inviter MemberInviter, taskRepo TaskRepo, budgetRepo BudgetRepo, transactor Transactor, logger *zap.SugaredLogger) Service {
+	return Service{
+		templateRepo:    templateRepo,
+		eventRepo:       eventRepo,
+		events:          events,
+		participantRepo: participantRepo,
+		inviter:         inviter,
+		taskRepo:        taskRepo,
+		budgetRepo:      budgetRepo,
+		transactor:      transactor,
+		logger:          logger,
+	}
+}
+
+// Save сохраняет событие как шаблон пользователя userId. Названия шаблонов пользователя не повторяются
+func (s Service) Save(ctx context.Context, eventId, userId int, req domain.TemplateCreateRequest) (*domain.TemplateResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.WithMessage(model.ErrInvalidTemplate, "template name is empty")
+	}
+
+	template := model.EventTemplate{
+		OwnerID:       userId,
+		Name:          name,
+		SourceEventID: &eventId,
+		CreatedAt:     time.Now(),
+	}
+
+	// Снимок читается в транзакции, чтобы задачи, участники и бюджет относились к одному состоянию события
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		content, err := s.snapshot(ctx, eventId)
+		if err != nil {
+			return err
+		}
+		template.Content = content
+
+		template.TemplateID, err = s.templateRepo.Create(ctx, template)
+		if err != nil {
+			s.logger.Errorw("Failed to create event template", "error", err, "eventId", eventId, "userId", userId)
+			return errors.WithMessage(err, "create event template")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toTemplateResponse(template)
+	return &resp, nil
+}
+
+// List возвращает шаблоны пользователя
+func (s Service) List(ctx context.Context, userId int) (*domain.TemplatesResponse, error) {
+	templates, err := s.templateRepo.ListByOwner(ctx, userId)
+	if err != nil {
+		s.logger.Errorw("Failed to list event templates", "error", err, "userId", userId)
+		return nil, errors.WithMessage(err, "list event templates")
+	}
+
+	resp := &domain.TemplatesResponse{Templates: make([]domain.TemplateResponse, len(templates))}
+	for i, template := range templates {
+		resp.Templates[i] = toTemplateResponse(template)
+	}
+
+	return resp, nil
+}
+
+// Delete удаляет шаблон пользователя. События, созданные из шаблона, не меняются
+func (s Service) Delete(ctx context.Context, id, userId int) error {
+	if _, err := s.getOwned(ctx, id, userId); err != nil {
+		return err
+	}
+
+	if err := s.templateRepo.Delete(ctx, id); err != nil {
+		s.logger.Errorw("Failed to delete event template", "error", err, "id", id)
+		return errors.WithMessage(err, "delete event template")
+	}
+
+	return nil
+}
+
+// CreateEvent создает событие из шаблона пользователя. Пользователь становится организатором события
+func (s Service) CreateEvent(ctx context.Context, id, userId int, req domain.EventCopyRequest) (int, error) {
+	template, err := s.getOwned(ctx, id, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	var eventId int
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		eventId, err = s.instantiate(ctx, userId, template.Content, req)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.inviteMembers(ctx, eventId, userId, template.Content.Participants)
+
+	return eventId, nil
+}
+
+// Clone создает копию события со сдвигом дат. Пользователь становится организатором копии
+func (s Service) Clone(ctx context.Context, eventId, userId int, req domain.EventCopyRequest) (int, error) {
+	var cloneId int
+	var content model.TemplateContent
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		content, err = s.snapshot(ctx, eventId)
+		if err != nil {
+			return err
+		}
+
+		cloneId, err = s.instantiate(ctx, userId, content, req)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.inviteMembers(ctx, cloneId, userId, content.Participants)
+
+	return cloneId, nil
+}
+
+// inviteMembers приглашает участников снимка в созданное событие. Создатель уже добавлен организатором,
+// остальные сохраняют роли, а организатор снимка становится администратором: организатор события один.
+// Событие уже создано, поэтому ошибка приглашения только логируется — участников можно пригласить вручную
+func (s Service) inviteMembers(ctx context.Context, eventId, userId int, participants []model.TemplateParticipant) {
+	members := make([]model.TemplateParticipant, 0, len(participants))
+	for _, participant := range participants {
+		if participant.UserID == userId {
+			continue
+		}
+		if participant.Role == model.RoleOrganizer {
+			participant.Role = model.RoleAdmin
+		}
+		members = append(members, participant)
+	}
+	if len(members) == 0 {
+		return
+	}
+
+	if err := s.inviter.InviteMembers(ctx, eventId, members); err != nil {
+		s.logger.Errorw("Failed to invite event members", "error", err, "eventId", eventId)
+	}
+}
+
+// getOwned возвращает шаблон, если он принадлежит пользователю. Чужие шаблоны считаются не найденными
+func (s Service) getOwned(ctx context.Context, id, userId int) (model.EventTemplate, error) {
+	template, err := s.templateRepo.GetById(ctx, id)
+	if err != nil {
+		if !errors.Is(err, model.ErrTemplateNotFound) {
+			s.logger.Errorw("Failed to get event template", "error", err, "id", id)
+		}
+		return model.EventTemplate{}, errors.WithMessage(err, "get event template")
+	}
+	if template.OwnerID != userId {
+		return model.EventTemplate{}, errors.WithMessage(model.ErrTemplateNotFound, "get event template")
+	}
+
+	return template, nil
+}
+
+// snapshot собирает снимок события: задачи с иерархией, роли участников, общий лимит и категории расходов
+func (s Service) snapshot(ctx context.Context, eventId int) (model.TemplateContent, error) {
+	event, err := s.eventRepo.GetById(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event for template", "error", err, "eventId", eventId)
+		return model.TemplateContent{}, errors.WithMessage(err, "get event")
+	}
+
+	content := model.TemplateContent{
+		Title:           event.Title,
+		Description:     event.Description,
+		Location:        event.Location,
+		BaseCurrency:    event.BaseCurrency,
+		StartDate:       event.StartDate,
+		EndDate:         event.EndDate,
+		RecurrenceRule:  event.RecurrenceRule,
+		MaxParticipants: event.MaxParticipants,
+	}
+
+	tasks, err := s.taskRepo.ListAllByEvent(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list event tasks for template", "error", err, "eventId", eventId)
+		return model.TemplateContent{}, errors.WithMessage(err, "list event tasks")
+	}
+	content.Tasks = templateTasks(tasks)
+
+	participants, err := s.participantRepo.ListAllByEvent(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list event participants for template", "error", err, "eventId", eventId)
+		return model.TemplateContent{}, errors.WithMessage(err, "list event participants")
+	}
+	content.Participants = make([]model.TemplateParticipant, len(participants))
+	for i, participant := range participants {
+		content.Participants[i] = model.TemplateParticipant{UserID: participant.UserID, Role: participant.Role}
+	}
+
+	budget, err := s.budgetRepo.GetEventBudget(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to get event budget for template", "error", err, "eventId", eventId)
+		return model.TemplateContent{}, errors.WithMessage(err, "get event budget")
+	}
+	content.TotalBudget = budget.TotalBudget
+
+	categories, err := s.budgetRepo.ListCategorySpending(ctx, eventId)
+	if err != nil {
+		s.logger.Errorw("Failed to list expense categories for template", "error", err, "eventId", eventId)
+		return model.TemplateContent{}, errors.WithMessage(err, "list expense categories")
+	}
+	content.Categories = make([]model.TemplateCategory, len(categories))
+	for i, category := range categories {
+		content.Categories[i] = model.TemplateCategory{Name: category.Name, Budget: category.Budget}
+	}
+
+	return content, nil
+}
+
+// templateTasks упорядочивает задачи так, чтобы родитель шел раньше подзадач. Задача, родитель которой
+// не входит в событие, становится корневой
+func templateTasks(tasks []model.Task) []model.TemplateTask {
+	known := make(map[int]bool, len(tasks))
+	for _, task := range tasks {
+		known[task.TaskId] = true
+	}
+
+	children := make(map[int][]model.Task)
+	roots := make([]model.Task, 0)
+	for _, task := range tasks {
+		if task.ParentId != nil && known[*task.ParentId] && *task.ParentId != task.TaskId {
+			children[*task.ParentId] = append(children[*task.ParentId], task)
+			continue
+		}
+		roots = append(roots, task)
+	}
+
+	result := make([]model.TemplateTask, 0, len(tasks))
+	var visit func(task model.Task, parentKey *int)
+	visit = func(task model.Task, parentKey *int) {
+		result = append(result, model.TemplateTask{
+			Key:         task.TaskId,
+			ParentKey:   parentKey,
+			Title:       task.Title,
+			Description: task.Description,
+			StoryPoints: task.StoryPoints,
+			Priority:    task.Priority,
+			DueDate:     task.DueDate,
+		})
+
+		key := task.TaskId
+		for _, child := range children[task.TaskId] {
+			visit(child, &key)
+		}
+	}
+	for _, root := range roots {
+		visit(root, nil)
+	}
+
+	return result
+}
+
+// instantiate создает событие по снимку, сдвигая даты к req.StartDate. Вызывается в транзакции
+func (s Service) instantiate(ctx context.Context, userId int, content model.TemplateContent, req domain.EventCopyRequest) (int, error) {
+	shift := req.StartDate.Sub(content.StartDate)
+
+	eventReq := domain.EventCreateRequest{
+		Title:           content.Title,
+		Description:     content.Description,
+		StartDate:       content.StartDate.Add(shift),
+		EndDate:         content.EndDate.Add(shift),
+		BaseCurrency:    content.BaseCurrency,
+		Status:          string(model.EventStatusPlanned),
+		MaxParticipants: content.MaxParticipants,
+	}
+	if req.Title != "" {
+		eventReq.Title = req.Title
+	}
+	if content.Location != nil {
+		eventReq.Location = *content.Location
+	}
+	if content.RecurrenceRule != nil {
+		rule, err := shiftRule(*content.RecurrenceRule, shift)
+		if err != nil {
+			return 0, err
+		}
+		eventReq.RecurrenceRule = rule
+	}
+
+	eventId, err := s.events.Create(ctx, userId, eventReq)
+	if err != nil {
+		return 0, errors.WithMessage(err, "create event")
+	}
+
+	now := time.Now()
+	taskIds := make(map[int]int, len(content.Tasks))
+	for _, templateTask := range content.Tasks {
+		task := model.Task{
+			EventId:     eventId,
+			Title:       templateTask.Title,
+			Description: templateTask.Description,
+			StoryPoints: templateTask.StoryPoints,
+			Priority:    templateTask.Priority,
+			Status:      string(domain.TaskStatusPending),
+			CreatedAt:   now,
+		}
+		if templateTask.ParentKey != nil {
+			if parentId, ok := taskIds[*templateTask.ParentKey]; ok {
+				task.ParentId = &parentId
+			}
+		}
+		if templateTask.DueDate != nil {
+			due := templateTask.DueDate.Add(shift)
+			task.DueDate = &due
+		}
+
+		taskIds[templateTask.Key], err = s.taskRepo.Create(ctx, task)
+		if err != nil {
+			s.logger.Errorw("Failed to copy event task", "error", err, "eventId", eventId, "title", templateTask.Title)
+			return 0, errors.WithMessage(err, "copy event task")
+		}
+	}
+
+	if content.TotalBudget != nil {
+		if err := s.budgetRepo.SetEventBudget(ctx, eventId, content.TotalBudget); err != nil {
+			s.logger.Errorw("Failed to copy event budget", "error", err, "eventId", eventId)
+			return 0, errors.WithMessage(err, "copy event budget")
+		}
+	}
+
+	for _, category := range content.Categories {
+		_, err := s.budgetRepo.CreateCategory(ctx, model.ExpenseCategory{
+			EventID: eventId,
+			Name:    category.Name,
+			Budget:  category.Budget,
+		})
+		if err != nil {
+			s.logger.Errorw("Failed to copy expense category", "error", err, "eventId", eventId, "name", category.Name)
+			return 0, errors.WithMessage(err, "copy expense category")
+		}
+	}
+
+	return eventId, nil
+}
+
+// shiftRule сдвигает UNTIL правила повторения вместе с датами события, чтобы число повторений не изменилось
+func shiftRule(value string, shift time.Duration) (string, error) {
+	rule, err := rrule.Parse(value)
+	if err != nil {
+		return "", errors.WithMessage(model.ErrInvalidEventRecurrence, err.Error())
+	}
+
+	if rule.Until != nil {
+		until := rule.Until.Add(shift)
+		rule.Until = &until
+	}
+
+	return rule.String(), nil
+}
+
+func toTemplateResponse(template model.EventTemplate) domain.TemplateResponse {
+	content := template.Content
+	return domain.TemplateResponse{
+		Id:                template.TemplateID,
+		Name:              template.Name,
+		SourceEventId:     template.SourceEventID,
+		Title:             content.Title,
+		Duration:          content.EndDate.Sub(content.StartDate).String(),
+		RecurrenceRule:    content.RecurrenceRule,
+		TasksCount:        len(content.Tasks),
+		ParticipantsCount: len(content.Participants),
+		CategoriesCount:   len(content.Categories),
+		TotalBudget:       content.TotalBudget,
+		CreatedAt:         template.CreatedAt,
+	}
+}
@@ -0,0 +1,288 @@
+package template
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/PabloPerdolie/event-manager/core-service/internal/domain"
+	"github.com/PabloPerdolie/event-manager/core-service/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// Мок репозитория событий
+type MockEventRepo struct {
+	mock.Mock
+}
+
+func (m *MockEventRepo) GetById(ctx context.Context, eventID int) (model.Event, error) {
+	args := m.Called(ctx, eventID)
+	return args.Get(0).(model.Event), args.Error(1)
+}
+
+// Мок сервиса создания событий
+type MockEventCreator struct {
+	mock.Mock
+}
+
+func (m *MockEventCreator) Create(ctx context.Context, userId int, req domain.EventCreateRequest) (int, error) {
+	args := m.Called(ctx, userId, req)
+	return args.Int(0), args.Error(1)
+}
+
+// Хранилище шаблонов в памяти
+type templateStore map[int]model.EventTemplate
+
+func (s templateStore) Create(ctx context.Context, template model.EventTemplate) (int, error) {
+	template.TemplateID = len(s) + 1
+	s[template.TemplateID] = template
+	return template.TemplateID, nil
+}
+
+func (s templateStore) GetById(ctx context.Context, id int) (model.EventTemplate, error) {
+	template, ok := s[id]
+	if !ok {
+		return model.EventTemplate{}, model.ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+func (s templateStore) ListByOwner(ctx context.Context, ownerId int) ([]model.EventTemplate, error) {
+	templates := make([]model.EventTemplate, 0)
+	for _, template := range s {
+		if template.OwnerID == ownerId {
+			templates = append(templates, template)
+		}
+	}
+	return templates, nil
+}
+
+func (s templateStore) Delete(ctx context.Context, id int) error {
+	delete(s, id)
+	return nil
+}
+
+// eventStore участники и бюджеты событий в памяти
+type eventStore struct {
+	participants []model.EventParticipant
+	budgets      map[int]*model.Money
+	categories   []model.ExpenseCategory
+}
+
+// InviteMembers добавляет приглашенных участников, ожидающих ответа
+func (s *eventStore) InviteMembers(ctx context.Context, eventId int, members []model.TemplateParticipant) error {
+	for _, member := range members {
+		s.participants = append(s.participants, model.EventParticipant{
+			EventParticipantID: len(s.participants) + 1,
+			EventID:            eventId,
+			UserID:             member.UserID,
+			Role:               member.Role,
+		})
+	}
+	return nil
+}
+
+func (s *eventStore) ListAllByEvent(ctx context.Context, eventId int) ([]model.EventParticipant, error) {
+	result := make([]model.EventParticipant, 0)
+	for _, participant := range s.participants {
+		if participant.EventID == eventId {
+			result = append(result, participant)
+		}
+	}
+	return result, nil
+}
+
+func (s *eventStore) CreateCategory(ctx context.Context, category model.ExpenseCategory) (int, error) {
+	category.CategoryID = len(s.categories) + 1
+	s.categories = append(s.categories, category)
+	return category.CategoryID, nil
+}
+
+func (s *eventStore) ListCategorySpending(ctx context.Context, eventId int) ([]model.CategorySpending, error) {
+	result := make([]model.CategorySpending, 0)
+	for _, category := range s.categories {
+		if category.EventID == eventId {
+			result = append(result, model.CategorySpending{ExpenseCategory: category})
+		}
+	}
+	return result, nil
+}
+
+func (s *eventStore) GetEventBudget(ctx context.Context, eventId int) (model.EventBudget, error) {
+	return model.EventBudget{EventID: eventId, TotalBudget: s.budgets[eventId]}, nil
+}
+
+func (s *eventStore) SetEventBudget(ctx context.Context, eventId int, total *model.Money) error {
+	s.budgets[eventId] = total
+	return nil
+}
+
+// taskStore задачи событий в памяти
+type taskStore struct {
+	tasks []model.Task
+}
+
+func (s *taskStore) Create(ctx context.Context, task model.Task) (int, error) {
+	task.TaskId = 100 + len(s.tasks)
+	s.tasks = append(s.tasks, task)
+	return task.TaskId, nil
+}
+
+func (s *taskStore) ListAllByEvent(ctx context.Context, eventId int) ([]model.Task, error) {
+	result := make([]model.Task, 0)
+	for _, task := range s.tasks {
+		if task.EventId == eventId {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
+// noTx выполняет функцию без транзакции
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func setupService() (*Service, *MockEventRepo, *MockEventCreator, templateStore, *eventStore, *taskStore) {
+	eventRepo := new(MockEventRepo)
+	creator := new(MockEventCreator)
+	templates := templateStore{}
+	events := &eventStore{budgets: map[int]*model.Money{}}
+	tasks := &taskStore{}
+	logger, _ := zap.NewDevelopment()
+
+	service := NewService(templates, eventRepo, creator, events, events, tasks, events, noTx{}, logger.Sugar())
+
+	return &service, eventRepo, creator, templates, events, tasks
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+// sourceEvent событие 5 организатора 1 с подзадачей, участниками и бюджетом
+func sourceEvent(events *eventStore, tasks *taskStore) model.Event {
+	// Подзадача создана раньше родителя: после копирования она все равно должна ссылаться на новый родитель
+	tasks.tasks = []model.Task{
+		{TaskId: 11, EventId: 5, ParentId: ptrTo(12), Title: "Купить уголь", StoryPoints: ptrTo(2), Status: "completed",
+			DueDate: ptrTo(time.Date(2025, 7, 4, 12, 0, 0, 0, time.UTC))},
+		{TaskId: 12, EventId: 5, Title: "Подготовить мангал", Priority: ptrTo("high"), Status: "in_progress"},
+	}
+	events.participants = []model.EventParticipant{
+		{EventID: 5, UserID: 1, Role: model.RoleOrganizer},
+		{EventID: 5, UserID: 2, Role: model.RoleAdmin},
+		{EventID: 5, UserID: 3, Role: model.RoleParticipant},
+	}
+	events.budgets[5] = ptrTo(model.MoneyFromFloat(5000))
+	events.categories = []model.ExpenseCategory{{EventID: 5, Name: "Еда", Budget: ptrTo(model.MoneyFromFloat(3000))}}
+
+	return model.Event{
+		EventId: 5, OrganizerID: 1, Title: "Шашлыки", Description: "На даче", Location: ptrTo("Дача"),
+		StartDate: time.Date(2025, 7, 5, 10, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 7, 5, 20, 0, 0, 0, time.UTC),
+		BaseCurrency: "RUB", Status: model.EventStatusCompleted,
+	}
+}
+
+// Тест 1: Копия события сдвигает даты и сроки, сохраняет иерархию задач, роли участников и бюджет
+func TestClone(t *testing.T) {
+	service, eventRepo, creator, _, events, tasks := setupService()
+	ctx := context.Background()
+
+	eventRepo.On("GetById", ctx, 5).Return(sourceEvent(events, tasks), nil)
+	creator.On("Create", ctx, 2, domain.EventCreateRequest{
+		Title: "Шашлыки", Description: "На даче", Location: "Дача", BaseCurrency: "RUB", Status: "planned",
+		StartDate: time.Date(2025, 8, 2, 10, 0, 0, 0, time.UTC), EndDate: time.Date(2025, 8, 2, 20, 0, 0, 0, time.UTC),
+	}).Return(9, nil)
+
+	id, err := service.Clone(ctx, 5, 2, domain.EventCopyRequest{StartDate: time.Date(2025, 8, 2, 10, 0, 0, 0, time.UTC)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9, id)
+
+	copied, _ := tasks.ListAllByEvent(ctx, 9)
+	assert.Len(t, copied, 2)
+	assert.Equal(t, "Подготовить мангал", copied[0].Title)
+	assert.Nil(t, copied[0].ParentId)
+	assert.Equal(t, "pending", copied[0].Status)
+	assert.Equal(t, "Купить уголь", copied[1].Title)
+	assert.Equal(t, ptrTo(copied[0].TaskId), copied[1].ParentId)
+	assert.Equal(t, ptrTo(time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)), copied[1].DueDate)
+	assert.Equal(t, ptrTo(2), copied[1].StoryPoints)
+
+	// Создатель уже организатор копии, прежний организатор становится администратором.
+	// Участники копии приглашаются и еще не подтвердили участие
+	participants, _ := events.ListAllByEvent(ctx, 9)
+	assert.Equal(t, []model.EventParticipant{
+		{EventParticipantID: 4, EventID: 9, UserID: 1, Role: model.RoleAdmin},
+		{EventParticipantID: 5, EventID: 9, UserID: 3, Role: model.RoleParticipant},
+	}, participants)
+
+	assert.Equal(t, ptrTo(model.MoneyFromFloat(5000)), events.budgets[9])
+	categories, _ := events.ListCategorySpending(ctx, 9)
+	assert.Len(t, categories, 1)
+	assert.Equal(t, "Еда", categories[0].Name)
+}
+
+// Тест 2: Событие из шаблона создается только владельцем шаблона, UNTIL серии сдвигается вместе с датами
+func TestCreateEvent_FromTemplate(t *testing.T) {
+	service, eventRepo, creator, templates, events, tasks := setupService()
+	ctx := context.Background()
+
+	event := sourceEvent(events, tasks)
+	event.RecurrenceRule = ptrTo("FREQ=WEEKLY;UNTIL=20250726T235959Z")
+	eventRepo.On("GetById", ctx, 5).Return(event, nil)
+
+	saved, err := service.Save(ctx, 5, 1, domain.TemplateCreateRequest{Name: "Летние шашлыки"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, saved.TasksCount)
+	assert.Equal(t, 3, saved.ParticipantsCount)
+	assert.Equal(t, "10h0m0s", saved.Duration)
+	assert.Equal(t, ptrTo(5), templates[saved.Id].SourceEventID)
+
+	_, err = service.CreateEvent(ctx, saved.Id, 2, domain.EventCopyRequest{StartDate: time.Date(2026, 6, 6, 10, 0, 0, 0, time.UTC)})
+	assert.ErrorIs(t, err, model.ErrTemplateNotFound)
+
+	creator.On("Create", ctx, 1, mock.MatchedBy(func(req domain.EventCreateRequest) bool {
+		return req.Title == "Шашлыки 2026" && req.RecurrenceRule == "FREQ=WEEKLY;UNTIL=20260627T235959Z" &&
+			req.StartDate.Equal(time.Date(2026, 6, 6, 10, 0, 0, 0, time.UTC))
+	})).Return(10, nil)
+
+	id, err := service.CreateEvent(ctx, saved.Id, 1, domain.EventCopyRequest{
+		StartDate: time.Date(2026, 6, 6, 10, 0, 0, 0, time.UTC),
+		Title:     "Шашлыки 2026",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, id)
+	participants, _ := events.ListAllByEvent(ctx, 10)
+	assert.Len(t, participants, 2)
+	copied, _ := tasks.ListAllByEvent(ctx, 10)
+	assert.Equal(t, ptrTo(time.Date(2026, 6, 5, 12, 0, 0, 0, time.UTC)), copied[1].DueDate)
+}
+
+// Тест 3: Чужой шаблон нельзя удалить
+func TestDelete_OtherOwner(t *testing.T) {
+	service, _, _, templates, _, _ := setupService()
+	ctx := context.Background()
+	templates[1] = model.EventTemplate{TemplateID: 1, OwnerID: 1}
+
+	assert.ErrorIs(t, service.Delete(ctx, 1, 2), model.ErrTemplateNotFound)
+	assert.Len(t, templates, 1)
+
+	assert.NoError(t, service.Delete(ctx, 1, 1))
+	assert.Empty(t, templates)
+}
+
+// Тест 4: Шаблон с пустым названием не сохраняется
+func TestSave_BlankName(t *testing.T) {
+	service, _, _, templates, _, _ := setupService()
+
+	_, err := service.Save(context.Background(), 1, 1, domain.TemplateCreateRequest{Name: "   "})
+
+	assert.ErrorIs(t, err, model.ErrInvalidTemplate)
+	assert.Empty(t, templates)
+}
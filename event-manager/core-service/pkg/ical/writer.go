@@ -0,0 +1,109 @@
+// Package ical записывает календари в формате iCalendar (RFC 5545): экранирует текстовые значения
+// и переносит длинные строки
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineLength максимальная длина строки в октетах без учета CRLF
+const maxLineLength = 75
+
+const dateTimeFormat = "20060102T150405Z"
+
+// Writer записывает строки календаря потоком. Первая ошибка записи запоминается и возвращается из Close,
+// последующие вызовы ничего не пишут
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Begin открывает компонент, например VCALENDAR или VEVENT
+func (w *Writer) Begin(component string) {
+	w.line("BEGIN:" + component)
+}
+
+// End закрывает компонент
+func (w *Writer) End(component string) {
+	w.line("END:" + component)
+}
+
+// Prop записывает свойство с уже отформатированным значением. name может содержать параметры,
+// например "REFRESH-INTERVAL;VALUE=DURATION"
+func (w *Writer) Prop(name, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text записывает текстовое свойство, экранируя спецсимволы
+func (w *Writer) Text(name, value string) {
+	w.Prop(name, EscapeText(value))
+}
+
+// Time записывает дату и время в UTC
+func (w *Writer) Time(name string, t time.Time) {
+	w.Prop(name, FormatTime(t))
+}
+
+// Times записывает список дат и времени в UTC одним свойством, например EXDATE
+func (w *Writer) Times(name string, times []time.Time) {
+	values := make([]string, len(times))
+	for i, t := range times {
+		values[i] = FormatTime(t)
+	}
+	w.Prop(name, strings.Join(values, ","))
+}
+
+// Close дописывает буфер. Writer, переданный в NewWriter, не закрывается
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// line записывает строку, перенося ее по 75 октетов без разрыва символов UTF-8
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+
+	b := &strings.Builder{}
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Пробел в начале строки продолжения входит в ее длину
+		limit = maxLineLength - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+
+	_, w.err = w.w.WriteString(b.String())
+}
+
+// FormatTime форматирует время в UTC, например 20250703T190000Z
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// EscapeText экранирует обратную косую черту, точку с запятой, запятую и переводы строк
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,50 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Тест 1: Спецсимволы текстовых значений экранируются, время записывается в UTC
+func TestWriter_TextAndTime(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+
+	w.Begin("VEVENT")
+	w.Text("SUMMARY", "Встреча; план, итоги\nи C:\\docs")
+	w.Time("DTSTART", time.Date(2025, 7, 3, 22, 0, 0, 0, time.FixedZone("MSK", 3*60*60)))
+	w.Times("EXDATE", []time.Time{time.Date(2025, 7, 10, 19, 0, 0, 0, time.UTC), time.Date(2025, 7, 17, 19, 0, 0, 0, time.UTC)})
+	w.End("VEVENT")
+
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "BEGIN:VEVENT\r\n"+
+		"SUMMARY:Встреча\\; план\\, итоги\\nи C:\\\\docs\r\n"+
+		"DTSTART:20250703T190000Z\r\n"+
+		"EXDATE:20250710T190000Z,20250717T190000Z\r\n"+
+		"END:VEVENT\r\n", buf.String())
+}
+
+// Тест 2: Длинные строки переносятся по 75 октетов без разрыва многобайтовых символов
+func TestWriter_Folding(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+
+	w.Text("DESCRIPTION", strings.Repeat("я", 100))
+
+	assert.NoError(t, w.Close())
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	assert.Greater(t, len(lines), 1)
+	unfolded := lines[0]
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineLength)
+		assert.True(t, strings.HasPrefix(line, "DESCRIPTION:") || strings.HasPrefix(line, " "))
+	}
+	for _, line := range lines[1:] {
+		unfolded += line[1:]
+	}
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("я", 100), unfolded)
+}
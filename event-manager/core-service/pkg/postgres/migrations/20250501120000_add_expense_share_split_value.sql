@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE expense_share ADD COLUMN split_value FLOAT;
+
+-- +goose Down
+ALTER TABLE expense_share DROP COLUMN split_value;
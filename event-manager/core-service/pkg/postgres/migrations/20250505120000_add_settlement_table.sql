@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE settlement
+(
+    settlement_id SERIAL PRIMARY KEY,
+    event_id      INT       NOT NULL,
+    from_user_id  INT       NOT NULL,
+    to_user_id    INT       NOT NULL,
+    amount        FLOAT     NOT NULL,
+    created_by    INT       NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES events (event_id) ON DELETE CASCADE,
+    FOREIGN KEY (from_user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (to_user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Доля, погашенная платежом, ссылается на него
+ALTER TABLE expense_share ADD COLUMN settlement_id INT REFERENCES settlement (settlement_id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE expense_share DROP COLUMN settlement_id;
+
+DROP TABLE settlement;
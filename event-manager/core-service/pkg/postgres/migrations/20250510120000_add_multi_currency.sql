@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE events ADD COLUMN base_currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Курсы валют, загружаемые из файла или через API администратора
+CREATE TABLE exchange_rate
+(
+    from_currency VARCHAR(3)     NOT NULL,
+    to_currency   VARCHAR(3)     NOT NULL,
+    rate          NUMERIC(18, 8) NOT NULL CHECK (rate > 0),
+    updated_at    TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (from_currency, to_currency)
+);
+
+-- Курс на момент создания расхода и суммы в базовой валюте события
+ALTER TABLE expense ADD COLUMN exchange_rate NUMERIC(18, 8) NOT NULL DEFAULT 1;
+ALTER TABLE expense ADD COLUMN base_amount FLOAT;
+UPDATE expense SET base_amount = amount;
+ALTER TABLE expense ALTER COLUMN base_amount SET NOT NULL;
+
+ALTER TABLE expense_share ADD COLUMN base_amount FLOAT;
+UPDATE expense_share SET base_amount = amount;
+ALTER TABLE expense_share ALTER COLUMN base_amount SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE expense_share DROP COLUMN base_amount;
+
+ALTER TABLE expense DROP COLUMN base_amount;
+ALTER TABLE expense DROP COLUMN exchange_rate;
+
+DROP TABLE exchange_rate;
+
+ALTER TABLE events DROP COLUMN base_currency;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Денежные суммы хранятся с фиксированной точкой, существующие значения округляются до копеек
+ALTER TABLE expense
+    ALTER COLUMN amount TYPE NUMERIC(14, 2) USING round(amount::numeric, 2),
+    ALTER COLUMN base_amount TYPE NUMERIC(14, 2) USING round(base_amount::numeric, 2);
+
+ALTER TABLE expense_share
+    ALTER COLUMN amount TYPE NUMERIC(14, 2) USING round(amount::numeric, 2),
+    ALTER COLUMN base_amount TYPE NUMERIC(14, 2) USING round(base_amount::numeric, 2);
+
+ALTER TABLE settlement
+    ALTER COLUMN amount TYPE NUMERIC(14, 2) USING round(amount::numeric, 2);
+
+-- +goose Down
+ALTER TABLE settlement
+    ALTER COLUMN amount TYPE FLOAT USING amount::float;
+
+ALTER TABLE expense_share
+    ALTER COLUMN amount TYPE FLOAT USING amount::float,
+    ALTER COLUMN base_amount TYPE FLOAT USING base_amount::float;
+
+ALTER TABLE expense
+    ALTER COLUMN amount TYPE FLOAT USING amount::float,
+    ALTER COLUMN base_amount TYPE FLOAT USING base_amount::float;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE expense_history
+(
+    history_id SERIAL PRIMARY KEY,
+    expense_id INT       NOT NULL,
+    changed_by INT       NOT NULL,
+    changes    JSONB     NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (expense_id) REFERENCES expense (expense_id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_expense_history_expense_id ON expense_history (expense_id, changed_at);
+
+-- +goose Down
+DROP TABLE expense_history;
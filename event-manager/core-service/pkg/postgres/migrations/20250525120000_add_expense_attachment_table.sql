@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE expense_attachment
+(
+    attachment_id SERIAL PRIMARY KEY,
+    expense_id    INT          NOT NULL,
+    uploaded_by   INT          NOT NULL,
+    file_name     VARCHAR(255) NOT NULL,
+    content_type  VARCHAR(100) NOT NULL,
+    size          BIGINT       NOT NULL,
+    storage_key   VARCHAR(255) NOT NULL UNIQUE,
+    created_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (expense_id) REFERENCES expense (expense_id) ON DELETE CASCADE,
+    FOREIGN KEY (uploaded_by) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_expense_attachment_expense_id ON expense_attachment (expense_id);
+
+-- +goose Down
+DROP TABLE expense_attachment;
@@ -0,0 +1,66 @@
+-- +goose Up
+CREATE TABLE expense_category
+(
+    category_id     SERIAL PRIMARY KEY,
+    event_id        INT            NOT NULL,
+    name            VARCHAR(100)   NOT NULL,
+    budget          NUMERIC(14, 2),
+    budget_exceeded BOOLEAN        NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES events (event_id) ON DELETE CASCADE,
+    UNIQUE (event_id, name)
+);
+
+CREATE TABLE event_budget
+(
+    event_id        INT PRIMARY KEY,
+    total_budget    NUMERIC(14, 2),
+    budget_exceeded BOOLEAN   NOT NULL DEFAULT FALSE,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES events (event_id) ON DELETE CASCADE
+);
+
+CREATE TABLE recurring_expense
+(
+    recurring_id SERIAL PRIMARY KEY,
+    event_id     INT            NOT NULL,
+    created_by   INT            NOT NULL,
+    category_id  INT,
+    description  TEXT           NOT NULL,
+    amount       NUMERIC(14, 2) NOT NULL,
+    currency     VARCHAR(3)     NOT NULL,
+    split_method VARCHAR(20)    NOT NULL,
+    split        JSONB          NOT NULL,
+    frequency    VARCHAR(10)    NOT NULL,
+    next_date    DATE           NOT NULL,
+    end_date     DATE,
+    created_at   TIMESTAMP      NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES events (event_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (category_id) REFERENCES expense_category (category_id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_recurring_expense_event_id ON recurring_expense (event_id);
+CREATE INDEX idx_recurring_expense_next_date ON recurring_expense (next_date);
+
+ALTER TABLE expense
+    ADD COLUMN category_id     INT REFERENCES expense_category (category_id) ON DELETE SET NULL,
+    ADD COLUMN recurring_id    INT REFERENCES recurring_expense (recurring_id) ON DELETE SET NULL,
+    ADD COLUMN occurrence_date DATE;
+
+CREATE INDEX idx_expense_category_id ON expense (category_id);
+-- Повторный запуск планировщика не создает второй расход за ту же дату
+CREATE UNIQUE INDEX idx_expense_recurring_occurrence ON expense (recurring_id, occurrence_date);
+
+-- +goose Down
+DROP INDEX idx_expense_recurring_occurrence;
+DROP INDEX idx_expense_category_id;
+
+ALTER TABLE expense
+    DROP COLUMN occurrence_date,
+    DROP COLUMN recurring_id,
+    DROP COLUMN category_id;
+
+DROP TABLE recurring_expense;
+DROP TABLE event_budget;
+DROP TABLE expense_category;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Версия события для оптимистичной блокировки: каждое изменение увеличивает ее на единицу
+ALTER TABLE events ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE events ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+UPDATE events SET updated_at = created_at;
+
+-- +goose Down
+ALTER TABLE events DROP COLUMN updated_at;
+ALTER TABLE events DROP COLUMN version;
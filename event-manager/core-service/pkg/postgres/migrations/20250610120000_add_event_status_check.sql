@@ -0,0 +1,15 @@
+-- +goose Up
+-- События создавались с пустым статусом: считаем их запланированными
+UPDATE events SET status = 'planned'
+WHERE status NOT IN ('draft', 'planned', 'active', 'completed', 'cancelled', 'archived');
+
+ALTER TABLE events ADD CONSTRAINT events_status_check
+    CHECK (status IN ('draft', 'planned', 'active', 'completed', 'cancelled', 'archived'));
+
+-- Выборка событий, которые пора начать или завершить
+CREATE INDEX idx_events_status_dates ON events (status, start_date, end_date);
+
+-- +goose Down
+DROP INDEX idx_events_status_dates;
+
+ALTER TABLE events DROP CONSTRAINT events_status_check;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Конфигурация полнотекстового поиска для смешанных русских и английских текстов:
+-- кириллические слова приводятся к основе русским стеммером, латинские — английским
+CREATE TEXT SEARCH CONFIGURATION ru_en (COPY = russian);
+ALTER TEXT SEARCH CONFIGURATION ru_en
+    ALTER MAPPING FOR asciiword, asciihword, hword_asciipart WITH english_stem;
+ALTER TEXT SEARCH CONFIGURATION ru_en
+    ALTER MAPPING FOR word, hword, hword_part WITH russian_stem;
+
+-- Вес совпадения: название важнее описания, описание важнее места проведения
+ALTER TABLE events ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('ru_en'::regconfig, coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('ru_en'::regconfig, coalesce(description, '')), 'B') ||
+    setweight(to_tsvector('ru_en'::regconfig, coalesce(location, '')), 'C')
+) STORED;
+
+CREATE INDEX idx_events_search_vector ON events USING GIN (search_vector);
+
+-- Фильтры по организатору и участнику
+CREATE INDEX idx_events_organizer ON events (organizer_id);
+CREATE INDEX idx_event_participant_user ON event_participant (user_id);
+
+-- +goose Down
+DROP INDEX idx_event_participant_user;
+
+DROP INDEX idx_events_organizer;
+
+DROP INDEX idx_events_search_vector;
+
+ALTER TABLE events DROP COLUMN search_vector;
+
+DROP TEXT SEARCH CONFIGURATION ru_en;
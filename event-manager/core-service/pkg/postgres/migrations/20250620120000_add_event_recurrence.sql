@@ -0,0 +1,37 @@
+-- +goose Up
+-- Серия повторяющихся событий хранится одной записью с правилом RRULE, время первого
+-- повторения — start_date и end_date. series_end — окончание последнего повторения,
+-- NULL у бесконечной серии
+ALTER TABLE events ADD COLUMN recurrence_rule TEXT;
+ALTER TABLE events ADD COLUMN series_end TIMESTAMP;
+
+UPDATE events SET series_end = end_date;
+
+-- Отмененные и измененные повторения серии. occurrence_date — начало повторения по правилу
+CREATE TABLE event_exception
+(
+    event_id        INT       NOT NULL,
+    occurrence_date TIMESTAMP NOT NULL,
+    cancelled       BOOL      NOT NULL DEFAULT FALSE,
+    title           TEXT,
+    description     TEXT,
+    location        TEXT,
+    start_date      TIMESTAMP,
+    end_date        TIMESTAMP,
+    updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (event_id, occurrence_date),
+    FOREIGN KEY (event_id) REFERENCES events (event_id) ON DELETE CASCADE
+);
+
+-- Завершение событий по окончанию последнего повторения
+DROP INDEX idx_events_status_dates;
+CREATE INDEX idx_events_status_dates ON events (status, start_date, series_end);
+
+-- +goose Down
+DROP INDEX idx_events_status_dates;
+CREATE INDEX idx_events_status_dates ON events (status, start_date, end_date);
+
+DROP TABLE event_exception;
+
+ALTER TABLE events DROP COLUMN series_end;
+ALTER TABLE events DROP COLUMN recurrence_rule;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Срок задачи попадает в календарь как VTODO
+ALTER TABLE tasks ADD COLUMN due_date TIMESTAMP;
+
+CREATE INDEX idx_tasks_event_due_date ON tasks (event_id) WHERE due_date IS NOT NULL;
+
+-- Секретный токен ссылки на календарь пользователя, ссылка открывается без авторизации
+CREATE TABLE calendar_token
+(
+    user_id    INT PRIMARY KEY,
+    token      VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE calendar_token;
+
+DROP INDEX idx_tasks_event_due_date;
+
+ALTER TABLE tasks DROP COLUMN due_date;
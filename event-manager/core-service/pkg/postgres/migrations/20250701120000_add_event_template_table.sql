@@ -0,0 +1,18 @@
+-- +goose Up
+-- Шаблон события: снимок задач, ролей участников и бюджета, из которого создаются новые события
+CREATE TABLE event_template
+(
+    template_id     SERIAL PRIMARY KEY,
+    owner_id        INT          NOT NULL,
+    name            VARCHAR(100) NOT NULL,
+    source_event_id INT,
+    content         JSONB        NOT NULL,
+    created_at      TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (owner_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (source_event_id) REFERENCES events (event_id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_event_template_owner_id ON event_template (owner_id);
+
+-- +goose Down
+DROP TABLE event_template;
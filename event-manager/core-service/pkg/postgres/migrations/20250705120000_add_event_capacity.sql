@@ -0,0 +1,16 @@
+-- +goose Up
+-- Ограничение числа участников события, NULL — без ограничения
+ALTER TABLE events ADD COLUMN max_participants INT CHECK (max_participants > 0);
+
+-- Участник в листе ожидания, если waitlisted_at задан. Очередь упорядочена по времени
+-- постановки в лист ожидания
+ALTER TABLE event_participant ADD COLUMN waitlisted_at TIMESTAMP;
+
+CREATE INDEX idx_event_participant_waitlist ON event_participant (event_id, waitlisted_at, event_participant_id)
+    WHERE waitlisted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_event_participant_waitlist;
+
+ALTER TABLE event_participant DROP COLUMN waitlisted_at;
+ALTER TABLE events DROP COLUMN max_participants;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Приглашение участника, ожидающее ответа. Nonce входит в подписанную ссылку приглашения и меняется
+-- при повторной отправке, поэтому прежние ссылки перестают действовать. После ответа запись удаляется
+CREATE TABLE event_invitation
+(
+    event_participant_id INT PRIMARY KEY,
+    nonce                VARCHAR(32) NOT NULL,
+    expires_at           TIMESTAMP   NOT NULL,
+    sent_at              TIMESTAMP   NOT NULL,
+    FOREIGN KEY (event_participant_id) REFERENCES event_participant (event_participant_id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE event_invitation;
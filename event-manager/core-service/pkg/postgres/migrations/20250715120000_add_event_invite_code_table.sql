@@ -0,0 +1,24 @@
+-- +goose Up
+-- Код приглашения в событие. По коду в событие может вступить любой зарегистрированный пользователь
+-- с ролью role, пока код не отозван, не истек и не исчерпан. max_uses и expires_at NULL — без ограничения
+CREATE TABLE event_invite_code
+(
+    invite_code_id SERIAL PRIMARY KEY,
+    event_id       INT         NOT NULL,
+    code           VARCHAR(16) NOT NULL UNIQUE,
+    role           VARCHAR(15) NOT NULL,
+    max_uses       INT CHECK (max_uses > 0),
+    uses_count     INT         NOT NULL DEFAULT 0,
+    expires_at     TIMESTAMP,
+    created_by     INT         NOT NULL,
+    created_at     TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at   TIMESTAMP,
+    revoked_at     TIMESTAMP,
+    FOREIGN KEY (event_id) REFERENCES events (event_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_event_invite_code_event ON event_invite_code (event_id);
+
+-- +goose Down
+DROP TABLE event_invite_code;
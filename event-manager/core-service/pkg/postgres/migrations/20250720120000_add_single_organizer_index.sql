@@ -0,0 +1,6 @@
+-- +goose Up
+-- У события ровно один организатор: роль передается только вместе с событием
+CREATE UNIQUE INDEX idx_event_participant_organizer ON event_participant (event_id) WHERE role = 'organizer';
+
+-- +goose Down
+DROP INDEX idx_event_participant_organizer;
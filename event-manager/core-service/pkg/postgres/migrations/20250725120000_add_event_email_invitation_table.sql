@@ -0,0 +1,19 @@
+-- +goose Up
+-- Приглашение в событие по адресу, для которого еще нет пользователя. Зарегистрировавшись с этим адресом,
+-- пользователь получает обычное приглашение участника, после чего запись удаляется
+CREATE TABLE event_email_invitation
+(
+    email_invitation_id SERIAL PRIMARY KEY,
+    event_id            INT          NOT NULL,
+    email               VARCHAR(255) NOT NULL,
+    invited_by          INT          NOT NULL,
+    created_at          TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (event_id, email),
+    FOREIGN KEY (event_id) REFERENCES events (event_id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_event_email_invitation_email ON event_email_invitation (email);
+
+-- +goose Down
+DROP TABLE event_email_invitation;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Названия шаблонов пользователя не повторяются. Уже сохраненные дубликаты получают суффикс с ID шаблона
+UPDATE event_template t
+SET name = LEFT(t.name, 85) || ' (' || t.template_id || ')'
+WHERE EXISTS (SELECT 1
+              FROM event_template d
+              WHERE d.owner_id = t.owner_id
+                AND d.name = t.name
+                AND d.template_id < t.template_id);
+
+CREATE UNIQUE INDEX idx_event_template_owner_name ON event_template (owner_id, name);
+
+-- +goose Down
+DROP INDEX idx_event_template_owner_name;
@@ -0,0 +1,584 @@
+// Package rrule разбирает правила повторения RFC 5545 (RRULE) и вычисляет даты повторений.
+// Поддерживаются FREQ=DAILY, WEEKLY, MONTHLY и YEARLY с частями INTERVAL, COUNT, UNTIL,
+// BYDAY, BYMONTHDAY, BYMONTH и WKST
+package rrule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+type Frequency int
+
+const (
+	Daily Frequency = iota
+	Weekly
+	Monthly
+	Yearly
+)
+
+var frequencies = map[string]Frequency{
+	"DAILY":   Daily,
+	"WEEKLY":  Weekly,
+	"MONTHLY": Monthly,
+	"YEARLY":  Yearly,
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// horizonYears ограничивает перебор: повторения позже horizonYears лет от начала серии не вычисляются.
+// За 400 лет григорианский календарь проходит полный цикл, поэтому редкие правила вроде 29 февраля
+// успевают сработать, а перебор правила без повторений заканчивается за ограниченное время
+const horizonYears = 400
+
+// maxMonthDays наибольшее число дней в каждом месяце с учетом високосного февраля
+var maxMonthDays = map[time.Month]int{
+	time.January: 31, time.February: 29, time.March: 31, time.April: 30, time.May: 31, time.June: 30,
+	time.July: 31, time.August: 31, time.September: 30, time.October: 31, time.November: 30, time.December: 31,
+}
+
+// Weekday день недели из BYDAY. N — порядковый номер дня в месяце или году (отрицательный — с конца),
+// 0 — каждый такой день
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+// Rule разобранное правило повторения
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int        // 0 — без ограничения количества
+	Until      *time.Time // Последний допустимый момент начала, включительно
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+	WeekStart  time.Weekday
+}
+
+// Parse разбирает правило вида FREQ=WEEKLY;BYDAY=TU,TH;COUNT=10. Префикс RRULE: допускается
+func Parse(value string) (Rule, error) {
+	rule := Rule{Interval: 1, WeekStart: time.Monday}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return Rule{}, errors.WithMessage(ErrInvalidRule, "rule is empty")
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		val = strings.ToUpper(strings.TrimSpace(val))
+		if !ok || val == "" {
+			return Rule{}, errors.WithMessagef(ErrInvalidRule, "malformed part %q", part)
+		}
+		if seen[key] {
+			return Rule{}, errors.WithMessagef(ErrInvalidRule, "duplicate part %s", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			freq, ok := frequencies[val]
+			if !ok {
+				return Rule{}, errors.WithMessagef(ErrInvalidRule, "unsupported frequency %s", val)
+			}
+			rule.Freq = freq
+		case "INTERVAL":
+			rule.Interval, err = parsePositive(val)
+		case "COUNT":
+			rule.Count, err = parsePositive(val)
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(val)
+			rule.Until = &until
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(val)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseIntList(val, -31, 31)
+		case "BYMONTH":
+			var months []int
+			months, err = parseIntList(val, 1, 12)
+			for _, month := range months {
+				rule.ByMonth = append(rule.ByMonth, time.Month(month))
+			}
+		case "WKST":
+			day, ok := weekdays[val]
+			if !ok {
+				return Rule{}, errors.WithMessagef(ErrInvalidRule, "invalid week start %s", val)
+			}
+			rule.WeekStart = day
+		default:
+			return Rule{}, errors.WithMessagef(ErrInvalidRule, "unsupported part %s", key)
+		}
+		if err != nil {
+			return Rule{}, errors.WithMessagef(ErrInvalidRule, "%s: %s", key, err)
+		}
+	}
+
+	if !seen["FREQ"] {
+		return Rule{}, errors.WithMessage(ErrInvalidRule, "FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return Rule{}, errors.WithMessage(ErrInvalidRule, "COUNT and UNTIL cannot be used together")
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return Rule{}, errors.WithMessage(ErrInvalidRule, "BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	for _, day := range rule.ByDay {
+		if day.N != 0 && (rule.Freq == Daily || rule.Freq == Weekly) {
+			return Rule{}, errors.WithMessage(ErrInvalidRule, "numeric BYDAY is allowed only with FREQ=MONTHLY or YEARLY")
+		}
+		if (day.N > 5 || day.N < -5) && (rule.Freq == Monthly || len(rule.ByMonth) > 0) {
+			return Rule{}, errors.WithMessagef(ErrInvalidRule, "BYDAY %d%s does not occur in a month", day.N, weekdayName(day.Day))
+		}
+	}
+	if !rule.monthDaysReachable() {
+		return Rule{}, errors.WithMessage(ErrInvalidRule, "BYMONTHDAY never occurs in BYMONTH")
+	}
+
+	return rule, nil
+}
+
+// monthDaysReachable сообщает, что хотя бы один день BYMONTHDAY есть хотя бы в одном месяце BYMONTH
+func (r Rule) monthDaysReachable() bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+
+	months := r.ByMonth
+	if len(months) == 0 {
+		months = []time.Month{time.January}
+	}
+	for _, month := range months {
+		for _, day := range r.ByMonthDay {
+			if day <= maxMonthDays[month] && -day <= maxMonthDays[month] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String возвращает правило в каноническом виде
+func (r Rule) String() string {
+	parts := make([]string, 0, 8)
+	for name, freq := range frequencies {
+		if freq == r.Freq {
+			parts = append(parts, "FREQ="+name)
+		}
+	}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		months := make([]string, len(r.ByMonth))
+		for i, month := range r.ByMonth {
+			months[i] = strconv.Itoa(int(month))
+		}
+		parts = append(parts, "BYMONTH="+strings.Join(months, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = weekdayName(day.Day)
+			if day.N != 0 {
+				days[i] = strconv.Itoa(day.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayName(r.WeekStart))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// Infinite сообщает, что у правила нет ни COUNT, ни UNTIL
+func (r Rule) Infinite() bool {
+	return r.Count == 0 && r.Until == nil
+}
+
+// Between возвращает начала повторений серии, начинающейся в start, попадающие в [from, to),
+// но не больше limit
+func (r Rule) Between(start, from, to time.Time, limit int) []time.Time {
+	result := make([]time.Time, 0)
+	r.iterate(start, to, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			result = append(result, t)
+		}
+		return len(result) < limit
+	})
+
+	return result
+}
+
+// First возвращает начало первого повторения серии
+func (r Rule) First(start time.Time) (time.Time, bool) {
+	var first time.Time
+	var found bool
+	r.iterate(start, time.Time{}, func(t time.Time) bool {
+		first, found = t, true
+		return false
+	})
+
+	return first, found
+}
+
+// Last возвращает начало последнего повторения серии. Для бесконечного правила ok = false
+func (r Rule) Last(start time.Time) (time.Time, bool) {
+	if r.Infinite() {
+		return time.Time{}, false
+	}
+
+	var last time.Time
+	var found bool
+	r.iterate(start, time.Time{}, func(t time.Time) bool {
+		last, found = t, true
+		return true
+	})
+
+	return last, found
+}
+
+// Index возвращает порядковый номер повторения, начинающегося в occurrence, начиная с 0.
+// Если такого повторения в серии нет, ok = false
+func (r Rule) Index(start, occurrence time.Time) (int, bool) {
+	index, found := 0, false
+	r.iterate(start, occurrence.Add(time.Nanosecond), func(t time.Time) bool {
+		if t.Equal(occurrence) {
+			found = true
+			return false
+		}
+		if t.After(occurrence) {
+			return false
+		}
+		index++
+		return true
+	})
+
+	return index, found
+}
+
+// iterate передает в fn начала повторений по возрастанию, пока fn возвращает true
+// и не исчерпаны COUNT и UNTIL. Повторения раньше start не учитываются. Непустой horizon
+// останавливает перебор на периоде, начинающемся позже него, а без него перебор идет не дальше horizonYears лет
+func (r Rule) iterate(start, horizon time.Time, fn func(t time.Time) bool) {
+	interval := max(r.Interval, 1)
+	count := 0
+
+	limit := start.AddDate(horizonYears, 0, 0)
+	if !horizon.IsZero() && horizon.Before(limit) {
+		limit = horizon
+	}
+
+	for period := 0; ; period++ {
+		periodStart := r.periodStart(start, period*interval)
+		if r.pastUntil(periodStart) || periodStart.After(limit) {
+			return
+		}
+
+		days := r.periodDays(start, period*interval)
+
+		for _, day := range days {
+			t := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+			if t.Before(start) {
+				continue
+			}
+			if r.pastUntil(t) {
+				return
+			}
+
+			count++
+			if !fn(t) || (r.Count > 0 && count >= r.Count) {
+				return
+			}
+		}
+	}
+}
+
+func (r Rule) pastUntil(t time.Time) bool {
+	return r.Until != nil && t.After(*r.Until)
+}
+
+// periodStart возвращает первый день периода с номером n, считая от периода, содержащего start
+func (r Rule) periodStart(start time.Time, n int) time.Time {
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	switch r.Freq {
+	case Weekly:
+		offset := (int(day.Weekday()) - int(r.WeekStart) + 7) % 7
+		return day.AddDate(0, 0, n*7-offset)
+	case Monthly:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location()).AddDate(0, n, 0)
+	case Yearly:
+		return time.Date(day.Year()+n, time.January, 1, 0, 0, 0, 0, day.Location())
+	default:
+		return day.AddDate(0, 0, n)
+	}
+}
+
+// periodDays возвращает отсортированные дни повторений в периоде с номером n
+func (r Rule) periodDays(start time.Time, n int) []time.Time {
+	first := r.periodStart(start, n)
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		if r.matchMonth(first) && r.matchMonthDay(first) && r.matchWeekday(first) {
+			days = []time.Time{first}
+		}
+	case Weekly:
+		for i := 0; i < 7; i++ {
+			day := first.AddDate(0, 0, i)
+			if !r.matchMonth(day) {
+				continue
+			}
+			if len(r.ByDay) == 0 && day.Weekday() != start.Weekday() {
+				continue
+			}
+			if r.matchWeekday(day) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		if r.matchMonth(first) {
+			days = r.monthDays(start, first)
+		}
+	case Yearly:
+		days = r.yearDays(start, first)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// monthDays дни месяца, начинающегося в first, по BYMONTHDAY и BYDAY, а без них — день месяца start
+func (r Rule) monthDays(start, first time.Time) []time.Time {
+	last := first.AddDate(0, 1, -1)
+
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if start.Day() > last.Day() {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, start.Day()-1)}
+	}
+
+	var days []time.Time
+	if len(r.ByDay) > 0 {
+		days = weekdaysIn(first, last, r.ByDay)
+	} else {
+		days = make([]time.Time, 0, last.Day())
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			days = append(days, day)
+		}
+	}
+
+	matched := days[:0]
+	for _, day := range days {
+		if r.matchMonthDay(day) {
+			matched = append(matched, day)
+		}
+	}
+
+	return matched
+}
+
+// yearDays дни года, начинающегося в first. Без BYMONTH порядковые номера BYDAY считаются в пределах года
+func (r Rule) yearDays(start, first time.Time) []time.Time {
+	switch {
+	case len(r.ByMonth) > 0 || len(r.ByMonthDay) > 0:
+		var days []time.Time
+		for month := time.January; month <= time.December; month++ {
+			monthFirst := time.Date(first.Year(), month, 1, 0, 0, 0, 0, first.Location())
+			if !r.matchMonth(monthFirst) {
+				continue
+			}
+			days = append(days, r.monthDays(start, monthFirst)...)
+		}
+		return days
+	case len(r.ByDay) > 0:
+		return weekdaysIn(first, first.AddDate(1, 0, -1), r.ByDay)
+	default:
+		day := time.Date(first.Year(), start.Month(), start.Day(), 0, 0, 0, 0, first.Location())
+		if day.Month() != start.Month() {
+			// 29 февраля в невисокосный год пропускается
+			return nil
+		}
+		return []time.Time{day}
+	}
+}
+
+// weekdaysIn дни между first и last включительно, подходящие под BYDAY
+func weekdaysIn(first, last time.Time, byDay []Weekday) []time.Time {
+	byWeekday := make(map[time.Weekday][]time.Time, 7)
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		byWeekday[day.Weekday()] = append(byWeekday[day.Weekday()], day)
+	}
+
+	seen := make(map[time.Time]bool)
+	days := make([]time.Time, 0)
+	add := func(day time.Time) {
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	for _, spec := range byDay {
+		candidates := byWeekday[spec.Day]
+		switch {
+		case spec.N == 0:
+			for _, day := range candidates {
+				add(day)
+			}
+		case spec.N > 0 && spec.N <= len(candidates):
+			add(candidates[spec.N-1])
+		case spec.N < 0 && -spec.N <= len(candidates):
+			add(candidates[len(candidates)+spec.N])
+		}
+	}
+
+	return days
+}
+
+func (r Rule) matchMonth(day time.Time) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, month := range r.ByMonth {
+		if day.Month() == month {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) matchMonthDay(day time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay == day.Day() || daysInMonth+monthDay+1 == day.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchWeekday проверяет BYDAY без порядковых номеров, применяемый как фильтр
+func (r Rule) matchWeekday(day time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, spec := range r.ByDay {
+		if spec.Day == day.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%q is not a positive number", value)
+	}
+	return n, nil
+}
+
+func parseIntList(value string, low, high int) ([]int, error) {
+	items := strings.Split(value, ",")
+	result := make([]int, len(items))
+	for i, item := range items {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < low || n > high || n == 0 {
+			return nil, fmt.Errorf("%q is out of range", item)
+		}
+		result[i] = n
+	}
+	return result, nil
+}
+
+func parseByDay(value string) ([]Weekday, error) {
+	items := strings.Split(value, ",")
+	result := make([]Weekday, len(items))
+	for i, item := range items {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid day %q", item)
+		}
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q", item)
+		}
+
+		var n int
+		if prefix := item[:len(item)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid day %q", item)
+			}
+		}
+		result[i] = Weekday{Day: day, N: n}
+	}
+	return result, nil
+}
+
+// parseUntil разбирает UNTIL в UTC, локальном времени или как дату. Дата включается целиком
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102T150405", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func weekdayName(day time.Weekday) string {
+	for name, weekday := range weekdays {
+		if weekday == day {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package rrule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day, hour int) time.Time {
+	return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+}
+
+// Тест 1: Повторения по правилу начиная с даты первого повторения
+func TestBetween(t *testing.T) {
+	cases := []struct {
+		name  string
+		rule  string
+		start time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "каждый четверг, 3 раза",
+			rule:  "FREQ=WEEKLY;BYDAY=TH;COUNT=3",
+			start: date(2025, 7, 3, 19),
+			want:  []time.Time{date(2025, 7, 3, 19), date(2025, 7, 10, 19), date(2025, 7, 17, 19)},
+		},
+		{
+			name:  "вторник и четверг через неделю",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH;COUNT=4",
+			start: date(2025, 7, 1, 10),
+			want:  []time.Time{date(2025, 7, 1, 10), date(2025, 7, 3, 10), date(2025, 7, 15, 10), date(2025, 7, 17, 10)},
+		},
+		{
+			name:  "последняя пятница месяца до даты",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20250930",
+			start: date(2025, 7, 25, 18),
+			want:  []time.Time{date(2025, 7, 25, 18), date(2025, 8, 29, 18), date(2025, 9, 26, 18)},
+		},
+		{
+			name:  "31-е число пропускается в коротких месяцах",
+			rule:  "FREQ=MONTHLY;COUNT=3",
+			start: date(2025, 7, 31, 9),
+			want:  []time.Time{date(2025, 7, 31, 9), date(2025, 8, 31, 9), date(2025, 10, 31, 9)},
+		},
+		{
+			name:  "ежегодно в последний день февраля",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=-1;COUNT=2",
+			start: date(2027, 2, 28, 12),
+			want:  []time.Time{date(2027, 2, 28, 12), date(2028, 2, 29, 12)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rule, err := Parse(tc.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, rule.Between(tc.start, tc.start, tc.start.AddDate(5, 0, 0), 100))
+		})
+	}
+}
+
+// Тест 2: Окно и лимит ограничивают бесконечную серию
+func TestBetween_InfiniteWindow(t *testing.T) {
+	rule, err := Parse("RRULE:FREQ=DAILY;INTERVAL=3")
+	assert.NoError(t, err)
+
+	start := date(2025, 7, 1, 8)
+	got := rule.Between(start, date(2025, 7, 5, 0), date(2025, 7, 14, 0), 100)
+
+	assert.Equal(t, []time.Time{date(2025, 7, 7, 8), date(2025, 7, 10, 8), date(2025, 7, 13, 8)}, got)
+	assert.Len(t, rule.Between(start, start, start.AddDate(1, 0, 0), 10), 10)
+
+	_, ok := rule.Last(start)
+	assert.False(t, ok)
+}
+
+// Тест 3: Номер повторения и последнее повторение конечной серии
+func TestIndexAndLast(t *testing.T) {
+	rule, err := Parse("FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5")
+	assert.NoError(t, err)
+
+	start := date(2025, 7, 7, 18)
+	index, ok := rule.Index(start, date(2025, 7, 16, 18))
+	assert.True(t, ok)
+	assert.Equal(t, 3, index)
+
+	_, ok = rule.Index(start, date(2025, 7, 15, 18))
+	assert.False(t, ok)
+
+	last, ok := rule.Last(start)
+	assert.True(t, ok)
+	assert.Equal(t, date(2025, 7, 21, 18), last)
+}
+
+// Тест 4: Некорректные правила отклоняются, корректные приводятся к каноническому виду
+func TestParse(t *testing.T) {
+	for _, value := range []string{
+		"", "BYDAY=MO", "FREQ=HOURLY", "FREQ=WEEKLY;COUNT=2;UNTIL=20250101", "FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1", "FREQ=DAILY;BYSETPOS=1", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=31", "FREQ=MONTHLY;BYMONTH=4,6;BYMONTHDAY=-31", "FREQ=MONTHLY;BYDAY=6MO",
+	} {
+		_, err := Parse(value)
+		assert.ErrorIs(t, err, ErrInvalidRule, value)
+	}
+
+	rule, err := Parse("freq=monthly;byday=2tu;until=20251231T235959Z;interval=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "FREQ=MONTHLY;BYDAY=2TU;UNTIL=20251231T235959Z", rule.String())
+}
+
+// Тест 5: Перебор правила без повторений останавливается на горизонте
+func TestFirst_NeverMatches(t *testing.T) {
+	rule, err := Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29;BYDAY=1MO")
+	assert.NoError(t, err)
+
+	_, ok := rule.First(date(2025, 1, 1, 10))
+	assert.False(t, ok)
+
+	rule, err = Parse("FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29")
+	assert.NoError(t, err)
+
+	first, ok := rule.First(date(2025, 3, 1, 10))
+	assert.True(t, ok)
+	assert.Equal(t, date(2028, 2, 29, 10), first)
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+var ErrNotFound = errors.New("file not found")
+
+// Local хранит файлы в каталоге локальной файловой системы. Ключ — относительный путь внутри каталога
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, errors.WithMessagef(err, "create storage dir %s", root)
+	}
+
+	return &Local{
+		root: root,
+	}, nil
+}
+
+// Put сохраняет файл атомарно: данные пишутся во временный файл, который затем переименовывается
+func (s *Local) Put(ctx context.Context, key string, r io.Reader) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return errors.WithMessage(err, "create file dir")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return errors.WithMessage(err, "create temp file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return errors.WithMessage(err, "write file")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WithMessage(err, "close file")
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.WithMessage(err, "rename file")
+	}
+
+	return nil
+}
+
+func (s *Local) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.WithMessage(err, "open file")
+	}
+
+	return file, nil
+}
+
+// Delete удаляет файл. Отсутствующий файл ошибкой не считается
+func (s *Local) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithMessage(err, "delete file")
+	}
+
+	return nil
+}
+
+// path строит путь к файлу, не позволяя ключу выйти за пределы каталога хранилища
+func (s *Local) path(key string) string {
+	return filepath.Join(s.root, filepath.Clean("/"+key))
+}
@@ -0,0 +1,286 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HeaderStyle стиль жирного текста для строки заголовков
+const HeaderStyle = 1
+
+// firstCustomFormat первый id пользовательского числового формата в styles.xml
+const firstCustomFormat = 164
+
+// excelEpoch начало отсчета дат Excel с учетом ошибки 1900 года
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+type cellKind int
+
+const (
+	kindString cellKind = iota
+	kindNumber
+)
+
+// Cell ячейка строки листа
+type Cell struct {
+	kind  cellKind
+	text  string
+	style int
+}
+
+// String текстовая ячейка
+func String(s string) Cell {
+	return Cell{kind: kindString, text: s}
+}
+
+// Header текстовая ячейка строки заголовков
+func Header(s string) Cell {
+	return Cell{kind: kindString, text: s, style: HeaderStyle}
+}
+
+// Number числовая ячейка в формате style, зарегистрированном через NumberFormat
+func Number(v float64, style int) Cell {
+	return Cell{kind: kindNumber, text: strconv.FormatFloat(v, 'f', -1, 64), style: style}
+}
+
+// Time дата и время в формате style. Часовой пояс отбрасывается, в ячейку попадает местное время t
+func Time(t time.Time, style int) Cell {
+	local := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	days := local.Sub(excelEpoch).Hours() / 24
+	return Number(days, style)
+}
+
+// Writer записывает книгу XLSX потоком: строки листа сжимаются и отправляются в w по мере записи,
+// в памяти хранятся только названия листов и форматы. Листы пишутся по очереди
+type Writer struct {
+	zw      *zip.Writer
+	sheet   *bufio.Writer
+	sheets  []string
+	formats []string
+	row     int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		zw: zip.NewWriter(w),
+	}
+}
+
+// NumberFormat регистрирует числовой формат Excel, например "#,##0.00", и возвращает стиль для ячеек
+func (w *Writer) NumberFormat(code string) int {
+	for i, format := range w.formats {
+		if format == code {
+			return HeaderStyle + 1 + i
+		}
+	}
+
+	w.formats = append(w.formats, code)
+	return HeaderStyle + len(w.formats)
+}
+
+// AddSheet завершает текущий лист и начинает новый
+func (w *Writer) AddSheet(name string) error {
+	if err := w.closeSheet(); err != nil {
+		return err
+	}
+
+	w.sheets = append(w.sheets, name)
+	f, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return errors.WithMessage(err, "create sheet")
+	}
+
+	w.sheet = bufio.NewWriter(f)
+	w.row = 0
+	_, err = w.sheet.WriteString(xml.Header +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+func (w *Writer) WriteRow(cells ...Cell) error {
+	if w.sheet == nil {
+		return errors.New("no sheet to write row")
+	}
+
+	w.row++
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, `<row r="%d">`, w.row)
+	for i, cell := range cells {
+		ref := columnName(i) + strconv.Itoa(w.row)
+		style := ""
+		if cell.style != 0 {
+			style = fmt.Sprintf(` s="%d"`, cell.style)
+		}
+
+		switch cell.kind {
+		case kindNumber:
+			fmt.Fprintf(buf, `<c r="%s"%s><v>%s</v></c>`, ref, style, cell.text)
+		default:
+			fmt.Fprintf(buf, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			if err := xml.EscapeText(buf, []byte(cell.text)); err != nil {
+				return errors.WithMessage(err, "escape cell text")
+			}
+			buf.WriteString(`</t></is></c>`)
+		}
+	}
+	buf.WriteString(`</row>`)
+
+	_, err := w.sheet.WriteString(buf.String())
+	return err
+}
+
+// Close завершает лист и записывает описание книги. Writer, переданный в NewWriter, не закрывается
+func (w *Writer) Close() error {
+	if len(w.sheets) == 0 {
+		if err := w.AddSheet("Sheet1"); err != nil {
+			return err
+		}
+	}
+	if err := w.closeSheet(); err != nil {
+		return err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", w.workbook()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRels()},
+		{"xl/styles.xml", w.styles()},
+	}
+	for _, part := range parts {
+		f, err := w.zw.Create(part.name)
+		if err != nil {
+			return errors.WithMessagef(err, "create %s", part.name)
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return errors.WithMessagef(err, "write %s", part.name)
+		}
+	}
+
+	return w.zw.Close()
+}
+
+func (w *Writer) closeSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+
+	if _, err := w.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	err := w.sheet.Flush()
+	w.sheet = nil
+
+	return err
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func (w *Writer) contentTypes() string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+
+	return b.String()
+}
+
+func (w *Writer) workbook() string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range w.sheets {
+		fmt.Fprintf(b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(sheetName(name)), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+
+	return b.String()
+}
+
+func (w *Writer) workbookRels() string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+
+	return b.String()
+}
+
+// styles описывает стиль по умолчанию, жирный заголовок и зарегистрированные числовые форматы
+func (w *Writer) styles() string {
+	b := &strings.Builder{}
+	b.WriteString(xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(w.formats) > 0 {
+		fmt.Fprintf(b, `<numFmts count="%d">`, len(w.formats))
+		for i, format := range w.formats {
+			fmt.Fprintf(b, `<numFmt numFmtId="%d" formatCode="%s"/>`, firstCustomFormat+i, escape(format))
+		}
+		b.WriteString(`</numFmts>`)
+	}
+	b.WriteString(`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+	fmt.Fprintf(b, `<cellXfs count="%d">`, HeaderStyle+1+len(w.formats))
+	b.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>`)
+	for i := range w.formats {
+		fmt.Fprintf(b, `<xf numFmtId="%d" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`, firstCustomFormat+i)
+	}
+	b.WriteString(`</cellXfs></styleSheet>`)
+
+	return b.String()
+}
+
+// columnName переводит номер столбца с нуля в буквенное обозначение: 0 — A, 26 — AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+
+	return name
+}
+
+// sheetName убирает из названия листа недопустимые символы и обрезает его до 31 символа
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+
+	return name
+}
+
+func escape(s string) string {
+	b := &strings.Builder{}
+	_ = xml.EscapeText(b, []byte(s))
+	return b.String()
+}
@@ -4,13 +4,21 @@ import (
 	"fmt"
 	"github.com/PabloPerdolie/event-manager/notification-service/internal/domain"
 	"github.com/PabloPerdolie/event-manager/notification-service/internal/model"
+	"strings"
+	"time"
 )
 
 var SupportedEvents = map[string]bool{
-	"event_created":     true,
-	"task_assigned":     true,
-	"expense_added":     true,
-	"participant_added": true,
+	"event_created":             true,
+	"task_assigned":             true,
+	"expense_added":             true,
+	"participant_added":         true,
+	"budget_exceeded":           true,
+	"event_updated":             true,
+	"participant_invited":       true,
+	"participant_promoted":      true,
+	"participant_role_changed":  true,
+	"participant_email_invited": true,
 }
 
 func GenerateEmailContent(msg domain.NotificationMessage) (*model.EmailContent, error) {
@@ -48,6 +56,77 @@ func GenerateEmailContent(msg domain.NotificationMessage) (*model.EmailContent,
 		subject = "You added as a participant"
 		body = fmt.Sprintf("You added as a participant of event: '%s'.", eventName)
 
+	case "participant_invited":
+		eventName, ok := msg.Data["event_name"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+		acceptURL, ok := msg.Data["accept_url"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+		declineURL, ok := msg.Data["decline_url"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		subject = "You are invited to an event"
+		body = fmt.Sprintf("You are invited to event '%s'.", eventName)
+
+		if position, ok := msg.Data["waitlist_position"].(float64); ok {
+			body += fmt.Sprintf(" The event is full, after accepting you will be on the waitlist at position %.0f.", position)
+		}
+
+		body += fmt.Sprintf("\n\nAccept: %s\nDecline: %s", acceptURL, declineURL)
+
+		if expires, ok := msg.Data["expires_at"].(string); ok {
+			if date, err := time.Parse(time.RFC3339, expires); err == nil {
+				body += fmt.Sprintf("\n\nThe invitation expires on %s.", date.Format("2006-01-02 15:04"))
+			}
+		}
+
+	case "participant_promoted":
+		eventName, ok := msg.Data["event_name"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		subject = "A spot has opened up"
+		body = fmt.Sprintf("A spot has opened up in event '%s', you are now a participant.", eventName)
+
+	case "participant_role_changed":
+		eventName, ok := msg.Data["event_name"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+		role, ok := msg.Data["role"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		subject = "Your role has been changed"
+		body = fmt.Sprintf("Your role in event '%s' has been changed to %s.", eventName, role)
+
+		if role == "organizer" {
+			subject = "You are now the organizer"
+			body = fmt.Sprintf("Event '%s' has been transferred to you, you are now its organizer.", eventName)
+		}
+
+	case "participant_email_invited":
+		eventName, ok := msg.Data["event_name"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		claimToken, ok := msg.Data["claim_token"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		subject = "You are invited to an event"
+		body = fmt.Sprintf("You are invited to event '%s'. Sign up and claim the invitation "+
+			"with this code to answer it: %s", eventName, claimToken)
+
 	case "task_assigned":
 		taskName, ok := msg.Data["task_name"].(string)
 		if !ok {
@@ -80,6 +159,67 @@ func GenerateEmailContent(msg domain.NotificationMessage) (*model.EmailContent,
 			body += fmt.Sprintf(" Event: %s", eventName)
 		}
 
+	case "budget_exceeded":
+		eventName, ok := msg.Data["event_name"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		budget, ok := msg.Data["budget"].(float64)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		spent, ok := msg.Data["spent"].(float64)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		currency, _ := msg.Data["currency"].(string)
+		subject = "Budget Exceeded"
+
+		if category, _ := msg.Data["category"].(string); category != "" {
+			body = fmt.Sprintf("Expenses in category '%s' of event '%s' have exceeded the budget", category, eventName)
+		} else {
+			body = fmt.Sprintf("Expenses of event '%s' have exceeded the total budget", eventName)
+		}
+		body += fmt.Sprintf(": %.2f of %.2f %s spent.", spent, budget, currency)
+
+	case "event_updated":
+		eventName, ok := msg.Data["event_name"].(string)
+		if !ok {
+			return nil, model.ErrInvalidNotificationData
+		}
+
+		fields, _ := msg.Data["changed_fields"].([]any)
+		names := make([]string, 0, len(fields))
+		for _, field := range fields {
+			if name, ok := field.(string); ok {
+				names = append(names, strings.ReplaceAll(name, "_", " "))
+			}
+		}
+
+		subject = "Event Updated"
+		body = fmt.Sprintf("Event '%s' has been updated.", eventName)
+
+		// Изменение повторения серии: scope this — одного повторения, following — начиная с него
+		scope, _ := msg.Data["scope"].(string)
+		occurrence, _ := msg.Data["occurrence_date"].(string)
+		if date, err := time.Parse(time.RFC3339, occurrence); err == nil {
+			switch scope {
+			case "this":
+				body = fmt.Sprintf("Event '%s' on %s has been updated.", eventName, date.Format("2006-01-02 15:04"))
+			case "following":
+				body = fmt.Sprintf("Event '%s' has been updated starting from %s.", eventName, date.Format("2006-01-02 15:04"))
+			}
+		} else if scope == "all" {
+			body = fmt.Sprintf("All occurrences of event '%s' have been updated.", eventName)
+		}
+
+		if len(names) > 0 {
+			body += fmt.Sprintf(" Changed: %s.", strings.Join(names, ", "))
+		}
+
 	default:
 		return nil, model.ErrUnsupportedEventType
 	}